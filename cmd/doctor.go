@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shaharia-lab/echoy/internal/chat"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/llm"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+// doctorLLMTimeout bounds the LLM health check of the doctor command
+const doctorLLMTimeout = 30 * time.Second
+
+// DoctorCheck is the outcome of a single doctor check
+type DoctorCheck struct {
+	Name    string        `json:"name"`
+	OK      bool          `json:"ok"`
+	Kind    llm.ErrorKind `json:"kind,omitempty"`
+	Message string        `json:"message"`
+}
+
+// DoctorResult is the JSON output of the doctor command
+type DoctorResult struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []DoctorCheck `json:"checks"`
+}
+
+// NewDoctorCmd creates a command that checks whether Echoy is ready to use
+func NewDoctorCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that Echoy is configured and the LLM is usable",
+		Long:  `Check the configuration and send a minimal request to the configured LLM provider to confirm it is reachable and accepts the token.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer container.Logger.Flush()
+
+			result := DoctorResult{Checks: []DoctorCheck{checkConfiguration(container)}}
+			if result.Checks[0].OK {
+				result.Checks = append(result.Checks, checkLLM(cmd.Context(), container, output.JSON))
+			}
+
+			result.Healthy = true
+			for _, check := range result.Checks {
+				result.Healthy = result.Healthy && check.OK
+			}
+
+			text := func(t theme.Theme) {
+				for _, check := range result.Checks {
+					if check.OK {
+						t.Success().Println(fmt.Sprintf("✓ %s: %s", check.Name, check.Message))
+						continue
+					}
+					t.Error().Println(fmt.Sprintf("✗ %s: %s", check.Name, check.Message))
+				}
+			}
+
+			if !result.Healthy {
+				cmd.SilenceUsage = true
+				return output.Fail(result, errors.New("one or more checks failed"), text)
+			}
+
+			return output.Success(result, text)
+		},
+	}
+
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+
+	return cmd
+}
+
+func checkConfiguration(container *cli.Container) DoctorCheck {
+	check := DoctorCheck{Name: "Configuration"}
+
+	if !container.ConfigFromFile.IsInitialized() {
+		check.Message = "not configured yet, run 'echoy init'"
+		return check
+	}
+
+	if container.ConfigFromFile.LLM.RequiresToken() && container.ConfigFromFile.LLM.Token == "" {
+		check.Message = fmt.Sprintf("the LLM token could not be read from the %s", container.ConfigFromFile.LLM.ResolvedTokenSource())
+		return check
+	}
+
+	check.OK = true
+	check.Message = fmt.Sprintf("provider %s, model %s", container.ConfigFromFile.LLM.Provider, container.ConfigFromFile.LLM.Model)
+	return check
+}
+
+func checkLLM(ctx context.Context, container *cli.Container, quiet bool) DoctorCheck {
+	check := DoctorCheck{Name: "LLM"}
+
+	httpClient, err := llm.NewHTTPClient(container.ConfigFromFile.LLM, container.ConfigFromFile.HTTP, container.Config.Version.Version)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	llmService, err := llm.NewLLMService(container.ConfigFromFile.LLM, httpClient)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, doctorLLMTimeout)
+	defer cancel()
+
+	spinner := theme.NewTerminalSpinner(container.ThemeMgr.GetCurrentTheme())
+	if !quiet {
+		spinner.Start("Contacting the LLM provider")
+	}
+
+	err = chat.NewChatService(llmService, nil, container.Logger).HealthCheck(ctx)
+	spinner.Stop()
+
+	if err != nil {
+		check.Kind = llm.ClassifyError(err).Kind
+		check.Message = err.Error()
+		return check
+	}
+
+	check.OK = true
+	check.Message = "reachable and the token is accepted"
+	if !container.ConfigFromFile.LLM.RequiresToken() {
+		check.Message = "answering, but it is not a real model"
+	}
+	return check
+}
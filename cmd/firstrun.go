@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mattn/go-isatty"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/secret"
+	"github.com/spf13/cobra"
+)
+
+var errNotConfigured = errors.New("echoy is not configured yet, run 'echoy init' to get started")
+
+// firstRun offers the init wizard to commands that need a configuration
+type firstRun struct {
+	container *cli.Container
+	// isInteractive reports whether the user can answer prompts
+	isInteractive func() bool
+	// confirm asks the user a yes or no question
+	confirm func(message string) (bool, error)
+	// initialize runs the init wizard and returns the configuration it saved
+	initialize func() (config.Config, error)
+}
+
+func newFirstRun(container *cli.Container) *firstRun {
+	return &firstRun{
+		container:     container,
+		isInteractive: stdinIsTerminal,
+		confirm:       askConfirm,
+		initialize: func() (config.Config, error) {
+			if err := container.Initializer.Run(); err != nil {
+				return config.Config{}, err
+			}
+			return container.Initializer.Config, nil
+		},
+	}
+}
+
+// ensureConfigured guards commands annotated with cli.RequiresConfigAnnotation against
+// running with the blank configuration written on first start. In an interactive
+// terminal the user is offered the init wizard, otherwise a hint to run init is returned.
+func ensureConfigured(cm *cobra.Command, container *cli.Container) error {
+	return newFirstRun(container).ensure(cm)
+}
+
+func (f *firstRun) ensure(cm *cobra.Command) error {
+	if _, ok := cm.Annotations[cli.RequiresConfigAnnotation]; !ok {
+		return nil
+	}
+
+	container := f.container
+	if container.ConfigFromFile.IsInitialized() {
+		return nil
+	}
+
+	container.Logger.WithField("command", cm.Name()).Warn("configuration is not initialized")
+	cm.SilenceUsage = true
+
+	if !f.isInteractive() {
+		return errNotConfigured
+	}
+
+	container.ThemeMgr.GetCurrentTheme().Warning().Println("Echoy is not configured yet.")
+
+	runInit, err := f.confirm("Would you like to run the setup wizard now?")
+	if err != nil {
+		return err
+	}
+
+	if !runInit {
+		return errNotConfigured
+	}
+
+	initialized, err := f.initialize()
+	if err != nil {
+		container.Logger.WithField(logger.ErrorKey, err).Error("initialization before first run failed")
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+
+	token, err := secret.Resolve(initialized.LLM)
+	if err != nil {
+		return err
+	}
+	initialized.LLM.Token = token
+
+	container.ConfigFromFile = initialized
+	fmt.Fprintln(cm.OutOrStdout())
+
+	return nil
+}
+
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+func askConfirm(message string) (bool, error) {
+	answer := true
+	prompt := &survey.Confirm{
+		Message: message,
+		Default: true,
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return false, err
+	}
+
+	return answer, nil
+}
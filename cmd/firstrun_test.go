@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstRun_Ensure(t *testing.T) {
+	initializedConfig := config.Config{LLM: config.LLMConfig{Provider: "openai", Token: "sk-test"}}
+
+	tests := []struct {
+		name           string
+		annotated      bool
+		config         config.Config
+		interactive    bool
+		answer         bool
+		confirmErr     error
+		initErr        error
+		wantErr        error
+		wantErrText    string
+		wantInit       bool
+		wantConfigured bool
+	}{
+		{
+			name:   "command without annotation",
+			config: config.Config{},
+		},
+		{
+			name:           "already initialized",
+			annotated:      true,
+			config:         initializedConfig,
+			wantConfigured: true,
+		},
+		{
+			name:      "not interactive",
+			annotated: true,
+			wantErr:   errNotConfigured,
+		},
+		{
+			name:        "wizard declined",
+			annotated:   true,
+			interactive: true,
+			answer:      false,
+			wantErr:     errNotConfigured,
+		},
+		{
+			name:        "prompt fails",
+			annotated:   true,
+			interactive: true,
+			confirmErr:  errors.New("interrupt"),
+			wantErrText: "interrupt",
+		},
+		{
+			name:        "wizard fails",
+			annotated:   true,
+			interactive: true,
+			answer:      true,
+			initErr:     errors.New("no provider selected"),
+			wantErrText: "initialization failed: no provider selected",
+			wantInit:    true,
+		},
+		{
+			name:           "wizard accepted",
+			annotated:      true,
+			interactive:    true,
+			answer:         true,
+			wantInit:       true,
+			wantConfigured: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultTheme := theme.NewDefaultTheme()
+			defaultTheme.SetEnabled(false)
+
+			container := &cli.Container{
+				Logger:         logger.NewNoopLogger(),
+				ThemeMgr:       theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&bytes.Buffer{}),
+				ConfigFromFile: tt.config,
+			}
+
+			initCalled := false
+			f := newFirstRun(container)
+			f.isInteractive = func() bool { return tt.interactive }
+			f.confirm = func(message string) (bool, error) { return tt.answer, tt.confirmErr }
+			f.initialize = func() (config.Config, error) {
+				initCalled = true
+				if tt.initErr != nil {
+					return config.Config{}, tt.initErr
+				}
+				return initializedConfig, nil
+			}
+
+			cm := &cobra.Command{Use: "chat"}
+			cm.SetOut(&bytes.Buffer{})
+			if tt.annotated {
+				cm.Annotations = map[string]string{cli.RequiresConfigAnnotation: ""}
+			}
+
+			err := f.ensure(cm)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantErrText != "":
+				assert.EqualError(t, err, tt.wantErrText)
+			default:
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantInit, initCalled)
+			assert.Equal(t, tt.wantConfigured, container.ConfigFromFile.IsInitialized())
+		})
+	}
+}
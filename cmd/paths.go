@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// PathsResult is the JSON output of the paths command
+type PathsResult struct {
+	Paths  map[string]string `json:"paths"`
+	Socket string            `json:"socket"`
+}
+
+// NewPathsCmd creates a command that prints where Echoy keeps its files
+func NewPathsCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "Show where Echoy keeps its configuration, logs and data",
+		Long: `Show the resolved locations of the configuration file, logs, chat history database,
+caches and the daemon socket, including overrides from ECHOY_HOME, ECHOY_CONFIG_DIR,
+ECHOY_DATA_DIR, ECHOY_CACHE_DIR and ECHOY_LOGS_DIR.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result := PathsResult{
+				Paths:  make(map[string]string, len(container.Paths)),
+				Socket: container.SocketFilePath,
+			}
+			for pathType, path := range container.Paths {
+				result.Paths[string(pathType)] = path
+			}
+
+			return output.Success(result, func(t theme.Theme) {
+				names := make([]string, 0, len(result.Paths))
+				for name := range result.Paths {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+
+				var table strings.Builder
+				w := tabwriter.NewWriter(&table, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(w, "NAME\tPATH")
+				for _, name := range names {
+					fmt.Fprintf(w, "%s\t%s\n", name, result.Paths[name])
+				}
+				fmt.Fprintf(w, "%s\t%s\n", "socket", result.Socket)
+				w.Flush()
+
+				lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+				t.Primary().Println(lines[0])
+				for _, line := range lines[1:] {
+					t.Subtle().Println(line)
+				}
+			})
+		},
+	}
+
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+
+	return cmd
+}
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/cli"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
-	"github.com/shaharia-lab/telemetry-collector"
 	"github.com/spf13/cobra"
 )
 
 // NewRootCmd creates and returns the root command
 func NewRootCmd(container *cli.Container) *cobra.Command {
+	cancelTimeout := func() {}
+
 	rootCmd := &cobra.Command{
 		Version: container.Config.Version.VersionText(),
 		Use:     "echoy",
@@ -18,26 +19,32 @@ func NewRootCmd(container *cli.Container) *cobra.Command {
             
             A smart CLI assistant that transforms your queries into insightful 
             responses, creating a true dialogue between you and technology.`,
+		PersistentPreRunE: func(cm *cobra.Command, args []string) error {
+			cancel, err := cli.ApplyTimeout(cm)
+			if err != nil {
+				return err
+			}
+			cancelTimeout = cancel
+
+			return ensureConfigured(cm, container)
+		},
+		PersistentPostRun: func(cm *cobra.Command, args []string) {
+			cancelTimeout()
+		},
 		RunE: func(cm *cobra.Command, args []string) error {
 			themeManager := container.ThemeMgr
 			themeManager.DisplayBanner(fmt.Sprintf("Welcome to %s", container.Config.Name), 40, "Your AI assistant for the CLI")
 			fmt.Println("")
 			themeManager.GetCurrentTheme().Warning().Println("Please run 'echoy init' to set up your assistant.")
 
-			if container.ConfigFromFile.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					cm.Context(),
-					container.Config,
-					"cmd.root.execute",
-					telemetry.SeverityInfo,
-					"Root command executed",
-					nil,
-				)
-			}
+			container.Telemetry.Add(telemetryEvent.RootCommandExecuted())
 
 			return nil
 		},
 	}
 
+	cli.AddVerbosityFlags(rootCmd.PersistentFlags())
+	cli.AddTimeoutFlag(rootCmd.PersistentFlags())
+
 	return rootCmd
 }
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/daemon"
+	"github.com/shaharia-lab/echoy/internal/httpx"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/shaharia-lab/echoy/internal/webserver"
+	"github.com/spf13/cobra"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// selftestRequestTimeout bounds the daemon commands and the health endpoints
+	selftestRequestTimeout = 5 * time.Second
+	// selftestStartTimeout bounds starting the web server, which may download the web UI.
+	// The daemon reports the progress meanwhile, each line within webserverStartReadTimeout.
+	selftestStartTimeout = 2 * time.Minute
+	// selftestChatTimeout bounds the chat request, answered by the LLM
+	selftestChatTimeout = time.Minute
+)
+
+// selftestQuestion is the chat request of the selftest, short to answer
+const selftestQuestion = "Reply with the single word OK."
+
+// SelftestStep is the outcome of a single step of the selftest command
+type SelftestStep struct {
+	Name string `json:"name"`
+	OK   bool   `json:"ok"`
+	// Skipped steps weren't run because a previous step failed
+	Skipped    bool   `json:"skipped,omitempty"`
+	Message    string `json:"message"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SelftestResult is the JSON output of the selftest command
+type SelftestResult struct {
+	Passed bool           `json:"passed"`
+	Steps  []SelftestStep `json:"steps"`
+}
+
+// NewSelftestCmd creates a command that checks the running daemon and web server end to end
+func NewSelftestCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Check that the running daemon and web server answer end to end",
+		Long: `Check the live system rather than the configuration, like 'echoy doctor' does: ping
+the daemon, start the web server through it unless it is running, call its /ping and
+/healthz endpoints and send a short chat request to the configured LLM. The chat is
+deleted from the chat history afterwards.
+
+The daemon isn't started, start it first with 'echoy start'. Every step is reported with
+its duration, the steps after a failed one are skipped, and the command exits with a
+non-zero status if any step fails.`,
+		Annotations: map[string]string{
+			cli.RequiresConfigAnnotation: "true",
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer container.Logger.Flush()
+
+			client := daemon.NewClient(&daemon.UnixSocketProvider{
+				SocketPath: container.SocketFilePath,
+				Timeout:    500 * time.Millisecond,
+			}, 2*time.Second, 5*time.Second)
+			if !output.JSON {
+				client.OnProgress = func(message string) {
+					container.ThemeMgr.GetCurrentTheme().Subtle().Println(message + "...")
+				}
+			}
+
+			httpClient, err := httpx.NewClient(httpx.Options{Version: container.Config.Version.Version, Timeout: -1})
+			if err != nil {
+				return fmt.Errorf("failed to create the HTTP client: %w", err)
+			}
+
+			s := &selftest{
+				ctx:        cmd.Context(),
+				client:     client,
+				httpClient: httpClient,
+				apiPort:    container.ConfigFromFile.WebServer.ResolvedAPIPort(),
+			}
+			if s.ctx == nil {
+				s.ctx = context.Background()
+			}
+			s.runAll()
+
+			result := SelftestResult{Passed: true, Steps: s.steps}
+			for _, step := range result.Steps {
+				result.Passed = result.Passed && step.OK
+			}
+
+			text := func(t theme.Theme) {
+				for _, step := range result.Steps {
+					switch {
+					case step.Skipped:
+						t.Subtle().Println(fmt.Sprintf("- %s: %s", step.Name, step.Message))
+					case step.OK:
+						t.Success().Println(fmt.Sprintf("✓ %s: %s (%dms)", step.Name, step.Message, step.DurationMs))
+					default:
+						t.Error().Println(fmt.Sprintf("✗ %s: %s (%dms)", step.Name, step.Message, step.DurationMs))
+					}
+				}
+			}
+
+			if !result.Passed {
+				cmd.SilenceUsage = true
+				return output.Fail(result, errors.New("one or more selftest steps failed"), text)
+			}
+
+			return output.Success(result, text)
+		},
+	}
+
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+
+	return cmd
+}
+
+// selftest runs the steps of the selftest command one after the other
+type selftest struct {
+	ctx        context.Context
+	client     *daemon.Client
+	httpClient *http.Client
+	apiPort    string
+	baseURL    string
+	steps      []SelftestStep
+	failed     bool
+}
+
+func (s *selftest) runAll() {
+	s.run("Daemon", s.pingDaemon)
+	s.run("Web server", s.startWebServer)
+	s.run("Ping", s.ping)
+	s.run("Health", s.health)
+	s.run("Chat", s.chat)
+}
+
+// run times the step name, done by fn, unless a previous step failed
+func (s *selftest) run(name string, fn func() (string, error)) {
+	if s.failed {
+		s.steps = append(s.steps, SelftestStep{Name: name, Skipped: true, Message: "skipped after a failed step"})
+		return
+	}
+
+	started := time.Now()
+	message, err := fn()
+	step := SelftestStep{Name: name, OK: err == nil, Message: message, DurationMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		step.Message = err.Error()
+		s.failed = true
+	}
+
+	s.steps = append(s.steps, step)
+}
+
+func (s *selftest) pingDaemon() (string, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, selftestRequestTimeout)
+	defer cancel()
+
+	if running, status := s.client.IsRunning(ctx); !running {
+		return "", fmt.Errorf("not running (%s), start it with 'echoy start'", status)
+	}
+
+	return "running", nil
+}
+
+// startWebServer starts the web server through the daemon unless it is running, and
+// resolves the URL its endpoints are called with
+func (s *selftest) startWebServer() (string, error) {
+	baseURL, err := selftestBaseURL(s.apiPort)
+	if err != nil {
+		return "", err
+	}
+	s.baseURL = baseURL
+
+	ctx, cancel := context.WithTimeout(s.ctx, selftestRequestTimeout)
+	defer cancel()
+
+	status, err := daemon.ExecuteService(ctx, s.client, "webserver", "status")
+	if err != nil {
+		return "", fmt.Errorf("failed to get its status: %w", err)
+	}
+	if status.Running {
+		return "already " + status.Status, nil
+	}
+
+	ctx, cancel = context.WithTimeout(s.ctx, selftestStartTimeout)
+	defer cancel()
+
+	startClient := *s.client
+	startClient.ReadTimeout = webserverStartReadTimeout
+	started, err := daemon.ExecuteService(ctx, &startClient, "webserver", "start")
+	if err != nil {
+		return "", fmt.Errorf("failed to start: %w", err)
+	}
+
+	return "started, " + started.Status, nil
+}
+
+func (s *selftest) ping() (string, error) {
+	status, body, err := s.request(selftestRequestTimeout, http.MethodGet, "/ping", nil)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK || body != "pong" {
+		return "", fmt.Errorf("unexpected response %d: %s", status, body)
+	}
+
+	return "pong", nil
+}
+
+func (s *selftest) health() (string, error) {
+	status, body, err := s.request(selftestRequestTimeout, http.MethodGet, "/healthz", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if status != http.StatusOK || json.Unmarshal([]byte(body), &health) != nil || health.Status != "ok" {
+		return "", fmt.Errorf("unexpected response %d: %s", status, body)
+	}
+
+	return health.Status, nil
+}
+
+// chat asks the LLM selftestQuestion and deletes the chat from the chat history afterwards
+func (s *selftest) chat() (string, error) {
+	stream := false
+	request, err := json.Marshal(types.ChatRequest{Question: selftestQuestion, Stream: &stream})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the chat request: %w", err)
+	}
+
+	status, body, err := s.request(selftestChatTimeout, http.MethodPost, "/api/v1/chats", request)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("unexpected response %d: %s", status, body)
+	}
+
+	var response types.ChatResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		return "", fmt.Errorf("unexpected response: %s", body)
+	}
+	if strings.TrimSpace(response.Answer) == "" {
+		return "", errors.New("the answer is empty")
+	}
+
+	status, body, err = s.request(selftestRequestTimeout, http.MethodDelete, "/api/v1/chats/"+response.ChatUUID.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("answered, but failed to delete the chat %s: %w", response.ChatUUID, err)
+	}
+	if status != http.StatusNoContent {
+		return "", fmt.Errorf("answered, but failed to delete the chat %s: unexpected response %d: %s", response.ChatUUID, status, body)
+	}
+
+	return fmt.Sprintf("answered in %d output tokens", response.OutputToken), nil
+}
+
+// request calls path on the web server and returns the status and the trimmed body
+func (s *selftest) request(timeout time.Duration, method, path string, body []byte) (int, string, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read the response: %w", err)
+	}
+
+	return resp.StatusCode, strings.TrimSpace(string(payload)), nil
+}
+
+// selftestBaseURL returns the URL of the web server listening on apiPort, the
+// webserver.api_port setting. A server listening on all interfaces is called on localhost.
+func selftestBaseURL(apiPort string) (string, error) {
+	addr, err := webserver.ListenAddress(apiPort)
+	if err != nil {
+		return "", fmt.Errorf("webserver.api_port: %w", err)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if port == "0" {
+		return "", errors.New("webserver.api_port is 0, the port the web server listens on can't be known")
+	}
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port), nil
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/shaharia-lab/echoy/internal/config"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
-	"github.com/shaharia-lab/telemetry-collector"
+	"github.com/shaharia-lab/echoy/internal/update"
 	"os"
 	"strings"
 
@@ -14,23 +14,14 @@ import (
 )
 
 // NewUpdateCmd creates a new update command
-func NewUpdateCmd(config config.Config, appCfg *config.AppConfig, themeManager *theme.Manager) *cobra.Command {
+func NewUpdateCmd(events *telemetryEvent.Batch, appCfg *config.AppConfig, themeManager *theme.Manager) *cobra.Command {
 	updateCmd := &cobra.Command{
 		Version: appCfg.Version.VersionText(),
 		Use:     "update",
 		Short:   "Check for updates and update the CLI",
-		Long:    "Check for updates and if a new version is available, download and install it",
+		Long:    "Check for updates and if a new version is available, download and install it. The release is only installed if its minisign signature matches the public key built into this binary.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if config.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					cmd.Context(),
-					appCfg,
-					"cmd.update",
-					telemetry.SeverityInfo,
-					"Start updating the CLI",
-					nil,
-				)
-			}
+			events.Add(telemetryEvent.UpdateStarted())
 
 			return runUpdate(themeManager.GetCurrentTheme(), appCfg.Repository, appCfg.Version.Version)
 		},
@@ -48,8 +39,19 @@ func runUpdate(theme theme.Theme, repository config.Repository, currentAppVersio
 		),
 	)
 
+	// Releases are only installed if they are signed with the key built into this binary
+	validator, err := update.NewMinisignValidator(update.PublicKey)
+	if err != nil {
+		return fmt.Errorf("refusing to update, releases can't be verified: %w", err)
+	}
+
+	updater, err := selfupdate.NewUpdater(selfupdate.Config{Validator: validator})
+	if err != nil {
+		return fmt.Errorf("error creating updater: %s", err)
+	}
+
 	// Check for the latest version
-	latest, found, err := selfupdate.DetectLatest(fmt.Sprintf("%s/%s", repository.Owner, repository.Repo))
+	latest, found, err := updater.DetectLatest(fmt.Sprintf("%s/%s", repository.Owner, repository.Repo))
 	if err != nil {
 		return fmt.Errorf("error detecting version: %s", err)
 	}
@@ -88,9 +90,9 @@ func runUpdate(theme theme.Theme, repository config.Repository, currentAppVersio
 		return fmt.Errorf("could not locate executable path: %s", err)
 	}
 
-	// Update the binary
-	if err := selfupdate.UpdateTo(latest.AssetURL, exe); err != nil {
-		return fmt.Errorf("error updating binary: %s", err)
+	// Update the binary, after verifying its signature
+	if err := updater.UpdateTo(latest, exe); err != nil {
+		return fmt.Errorf("error updating binary, the running version was kept: %s", err)
 	}
 
 	fmt.Printf("Successfully updated to version %s\n", latest.Version)
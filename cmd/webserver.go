@@ -5,43 +5,84 @@ import (
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/cli"
 	"github.com/shaharia-lab/echoy/internal/daemon"
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/shaharia-lab/echoy/internal/webserver"
 	"github.com/spf13/cobra"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
+// webserverStartReadTimeout is how long `webserver start` waits for the next progress line
+// or the response of the daemon
+const webserverStartReadTimeout = 30 * time.Second
+
+// WebserverResult is the JSON output of the webserver command
+type WebserverResult struct {
+	Action  string `json:"action"`
+	Success bool   `json:"success"`
+	Running bool   `json:"running"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
 // NewWebserverCmd creates a command to manage the webserver through the daemon
 func NewWebserverCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+
 	cmd := &cobra.Command{
-		Use:   "webserver [start|stop]",
+		Use:   "webserver [start|stop|status]",
 		Short: "Manage the Echoy web server",
-		Long:  `Start or stop the Echoy web server through the daemon.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Start, stop or show the status of the Echoy web server through the daemon.
+
+Use 'echoy webserver run' to run the web server in the foreground without the daemon.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer container.Logger.Flush()
 
 			subcommand := strings.ToLower(args[0])
-			if subcommand != "start" && subcommand != "stop" {
+			if subcommand != "start" && subcommand != "stop" && subcommand != "status" {
 				container.Logger.WithFields(map[string]interface{}{
 					logger.ErrorKey: fmt.Errorf("invalid subcommand: %s", subcommand),
 					"command":       "webserver",
 					"subcommand":    subcommand,
 				}).Error("invalid subcommand")
 
-				return fmt.Errorf("invalid subcommand: %s (must be 'start' or 'stop')", subcommand)
+				return fmt.Errorf("invalid subcommand: %s (must be 'start', 'stop' or 'status')", subcommand)
 			}
 
 			provider := &daemon.UnixSocketProvider{
 				SocketPath: container.SocketFilePath,
 				Timeout:    500 * time.Millisecond,
 			}
-			client := daemon.NewClient(provider, 2*time.Second, 5*time.Second)
+			// Starting may download the web UI. Its progress renews the read timeout, which only
+			// has to cover the longest step, e.g. looking up the release on GitHub.
+			readTimeout := 2 * time.Second
+			if subcommand == "start" {
+				readTimeout = webserverStartReadTimeout
+			}
+			client := daemon.NewClient(provider, readTimeout, 5*time.Second)
+			if !output.JSON {
+				client.OnProgress = func(message string) {
+					container.ThemeMgr.GetCurrentTheme().Subtle().Println(message + "...")
+				}
+			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+			// The daemon runs the command without its exec timeout, so a start is only bounded
+			// by the read timeout of the client
+			ctx := cmd.Context()
+			if subcommand != "start" {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, 5*time.Second)
+				defer cancel()
+			}
 
-			response, err := client.Execute(ctx, "webserver", []string{subcommand})
+			serviceResult, err := daemon.ExecuteService(ctx, client, "webserver", subcommand)
 			if err != nil {
 				if isConnectionError(err) {
 					msg := "Daemon is not running. Please start the daemon first with 'echoy start'"
@@ -52,8 +93,9 @@ func NewWebserverCmd(container *cli.Container) *cobra.Command {
 						"subcommand":    subcommand,
 					}).Error("webserver command failed because the daemon is not running")
 
-					container.ThemeMgr.GetCurrentTheme().Error().Println(msg)
-					return fmt.Errorf("daemon is not running")
+					return output.Fail(WebserverResult{Action: subcommand, Error: "daemon is not running"}, fmt.Errorf("daemon is not running"), func(t theme.Theme) {
+						t.Error().Println(msg)
+					})
 				}
 
 				container.Logger.WithFields(map[string]interface{}{
@@ -62,32 +104,122 @@ func NewWebserverCmd(container *cli.Container) *cobra.Command {
 					"subcommand":    subcommand,
 				}).Error("failed to execute webserver command")
 
-				container.ThemeMgr.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to %s webserver: %v", subcommand, err))
-				return fmt.Errorf("failed to %s webserver: %w", subcommand, err)
-			}
-
-			response = strings.TrimSpace(response)
-			if strings.HasPrefix(response, "OK: ") {
-				response = strings.TrimPrefix(response, "OK: ")
+				return output.Fail(WebserverResult{Action: subcommand, Error: err.Error()}, fmt.Errorf("failed to %s webserver: %w", subcommand, err), func(t theme.Theme) {
+					t.Error().Println(fmt.Sprintf("Failed to %s webserver: %v", subcommand, err))
+				})
 			}
 
 			container.Logger.WithFields(map[string]interface{}{
 				"command":    "webserver",
 				"subcommand": subcommand,
-				"response":   response,
+				"running":    serviceResult.Running,
+				"status":     serviceResult.Status,
 			}).Info("Webserver command executed")
 
-			container.ThemeMgr.GetCurrentTheme().Success().Println(response)
-			return nil
+			result := WebserverResult{
+				Action:  subcommand,
+				Success: true,
+				Running: serviceResult.Running,
+				Status:  serviceResult.Status,
+				Message: serviceResult.Message,
+			}
+			return output.Success(result, func(t theme.Theme) {
+				t.Success().Println(result.Message)
+			})
 		},
 	}
 
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+	cmd.AddCommand(newWebserverRunCmd(container))
+
 	cmd.Example = "  echoy webserver start  # Start the web server\n" +
-		"  echoy webserver stop   # Stop the web server"
+		"  echoy webserver stop   # Stop the web server\n" +
+		"  echoy webserver status # Show whether the web server is running\n" +
+		"  echoy webserver run    # Run the web server in the foreground, without the daemon\n" +
+		"  echoy webserver start --json  # Print the result as JSON"
 
 	return cmd
 }
 
+func newWebserverRunCmd(container *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run",
+		Short: "Run the web server in the foreground, without the daemon",
+		Long: `Run the web server in the foreground until it is interrupted with SIGINT or SIGTERM.
+Unlike 'echoy webserver start' it doesn't need the daemon, which suits containers and
+external process supervisors that only need the HTTP API. The idle shutdown of the
+web server doesn't apply, only the daemon can start it again.`,
+		Annotations: map[string]string{
+			cli.RequiresConfigAnnotation: "true",
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer container.Logger.Flush()
+
+			t := container.ThemeMgr.GetCurrentTheme()
+
+			server, err := webserver.BuildWebserver(container.ConfigFromFile, container.Config, container.ThemeMgr, container.Paths[filesystem.CacheWebuiBuild], container.ProcessLogConfig("webserver.log"), container.Paths[filesystem.ChatHistoryDB])
+			if err != nil {
+				container.Logger.WithFields(map[string]interface{}{
+					logger.ErrorKey: err,
+					"command":       "webserver",
+					"subcommand":    "run",
+				}).Error("failed to build web server")
+
+				return fmt.Errorf("failed to build web server: %w", err)
+			}
+			server.IdleShutdown = 0
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			stopPruner, err := history.StartPruner(ctx, container.ConfigFromFile.Chat, container.Paths[filesystem.ChatHistoryDB], container.Logger)
+			if err != nil {
+				container.Logger.WithField(logger.ErrorKey, err).Error("Failed to open chat history storage for pruning")
+				return err
+			}
+			defer stopPruner()
+
+			if err := server.Start(cmd.Context()); err != nil {
+				container.Logger.WithFields(map[string]interface{}{
+					logger.ErrorKey: err,
+					"command":       "webserver",
+					"subcommand":    "run",
+				}).Error("failed to start web server")
+
+				t.Error().Println(fmt.Sprintf("Failed to start web server: %v", err))
+				return fmt.Errorf("failed to start web server: %w", err)
+			}
+
+			container.Logger.WithFields(map[string]interface{}{
+				"command":    "webserver",
+				"subcommand": "run",
+				"status":     server.Status(),
+			}).Info("Web server started in the foreground")
+			t.Success().Println(fmt.Sprintf("Web server %s, press Ctrl+C to stop", server.Status()))
+
+			var exitErr error
+			select {
+			case <-ctx.Done():
+			case exitErr = <-server.Exited():
+				container.Logger.WithField(logger.ErrorKey, exitErr).Error("Web server exited")
+			}
+
+			t.Info().Println("Shutting down web server...")
+			if err := server.Stop(context.Background()); err != nil {
+				container.Logger.WithField(logger.ErrorKey, err).Error("Failed to stop web server")
+				return fmt.Errorf("failed to stop web server: %w", err)
+			}
+			if exitErr != nil {
+				return fmt.Errorf("web server exited: %w", exitErr)
+			}
+
+			t.Success().Println("Web server stopped.")
+			return nil
+		},
+	}
+}
+
 // isConnectionError checks if the error is related to connection issues
 func isConnectionError(err error) bool {
 	errStr := err.Error()
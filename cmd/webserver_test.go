@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/daemon"
+	"github.com/shaharia-lab/echoy/internal/daemon/daemontest"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebserverCmd_JSON(t *testing.T) {
+	newContainer := func(socketPath string) *cli.Container {
+		defaultTheme := theme.NewDefaultTheme()
+		defaultTheme.SetEnabled(false)
+
+		return &cli.Container{
+			Logger:         logger.NewNoopLogger(),
+			ThemeMgr:       theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&bytes.Buffer{}),
+			SocketFilePath: socketPath,
+		}
+	}
+
+	run := func(t *testing.T, container *cli.Container, args ...string) (WebserverResult, error) {
+		t.Helper()
+
+		cmd := NewWebserverCmd(container)
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append(args, "--json"))
+
+		err := cmd.ExecuteContext(context.Background())
+
+		var result WebserverResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result), "the output should be JSON: %s", stdout.String())
+		return result, err
+	}
+
+	t.Run("status", func(t *testing.T) {
+		client, cleanup := daemontest.StartTestDaemon(t, daemontest.WithCommand("WEBSERVER", func(ctx context.Context, args []string) (string, error) {
+			payload, err := json.Marshal(daemon.ServiceResult{Service: "webserver", Action: args[0], Running: true, Status: "running on :8080", Message: "webserver is running on :8080"})
+			return string(payload), err
+		}))
+		defer cleanup()
+
+		result, err := run(t, newContainer(client.Provider.(*daemon.UnixSocketProvider).SocketPath), "status")
+		require.NoError(t, err)
+		assert.Equal(t, WebserverResult{
+			Action:  "status",
+			Success: true,
+			Running: true,
+			Status:  "running on :8080",
+			Message: "webserver is running on :8080",
+		}, result)
+	})
+
+	t.Run("daemon not running", func(t *testing.T) {
+		result, err := run(t, newContainer(t.TempDir()+"/echoy.sock"), "start")
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, WebserverResult{Action: "start", Error: "daemon is not running"}, result)
+	})
+}
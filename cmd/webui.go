@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/shaharia-lab/echoy/internal/webui"
+	"github.com/spf13/cobra"
+	"net/http"
+)
+
+// WebUIClearResult is the JSON output of the webui clear command
+type WebUIClearResult struct {
+	Directory string `json:"directory"`
+	webui.ClearResult
+}
+
+// NewWebUICmd creates a command to manage the cached web UI
+func NewWebUICmd(container *cli.Container) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webui",
+		Short: "Manage the cached web UI",
+		Long:  `Manage the web UI assets Echoy downloads from GitHub and caches for the web server.`,
+	}
+
+	cmd.AddCommand(newWebUIClearCmd(container))
+
+	return cmd
+}
+
+func newWebUIClearCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the cached web UI so it is downloaded again",
+		Long: `Remove the downloaded web UI and the marker of its release from the cache, e.g. after
+a corrupt download. The next 'echoy webserver start' downloads it again. Stop the web
+server first, it serves the files from the cache.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defer container.Logger.Flush()
+
+			directory := container.Paths[filesystem.CacheWebuiBuild]
+			cleared, err := webui.NewFrontendGitHubReleaseDownloader(directory, http.DefaultClient, container.Logger).Clear()
+			result := WebUIClearResult{Directory: directory, ClearResult: cleared}
+			if err != nil {
+				container.Logger.WithFields(map[string]interface{}{
+					logger.ErrorKey: err,
+					"directory":     directory,
+				}).Error("failed to clear the web UI cache")
+
+				return output.Fail(result, fmt.Errorf("failed to clear the web UI cache: %w", err), func(t theme.Theme) {
+					t.Error().Println(fmt.Sprintf("Failed to clear the web UI cache: %v", err))
+				})
+			}
+
+			container.Logger.WithFields(map[string]interface{}{
+				"directory": directory,
+				"removed":   len(result.Removed),
+				"bytes":     result.Bytes,
+			}).Info("Web UI cache cleared")
+
+			return output.Success(result, func(t theme.Theme) {
+				if len(result.Removed) == 0 {
+					t.Info().Println(fmt.Sprintf("The web UI cache in %s is already empty", directory))
+					return
+				}
+
+				for _, path := range result.Removed {
+					t.Subtle().Println("Removed " + path)
+				}
+				t.Success().Println(fmt.Sprintf("Cleared %s from the web UI cache, it is downloaded again on the next start", formatBytes(result.Bytes)))
+			})
+		},
+	}
+
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+
+	return cmd
+}
+
+// formatBytes formats n bytes in the largest binary unit that keeps it at one or more
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,159 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/llm"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"golang.org/x/term"
+)
+
+// errEmptyPrompt is returned when a one-shot chat has nothing to ask
+var errEmptyPrompt = errors.New("the prompt is empty")
+
+// errPipedInputTooLarge is returned when the piped input doesn't fit into the model's context window
+var errPipedInputTooLarge = errors.New("the piped input is more than the model can take")
+
+// bytesPerToken is a rough estimate of how many bytes of text make up a token
+const bytesPerToken = 4
+
+// Delimiters around piped input that is sent together with an instruction
+const (
+	pipedInputStart = "--- Input ---"
+	pipedInputEnd   = "--- End of input ---"
+)
+
+// promptInput describes where a one-shot prompt may come from
+type promptInput struct {
+	// flag is the value of --prompt
+	flag string
+	// args are the positional arguments, joined with spaces
+	args []string
+	// stdin is read if it isn't a terminal. With a flag or arguments as the instruction it
+	// is appended as context, otherwise it is the prompt itself.
+	stdin           io.Reader
+	stdinIsTerminal bool
+	// maxTokens is the estimated number of tokens the prompt may take, 0 means no limit
+	maxTokens int
+}
+
+// oneShot reports whether the input asks for a single answer instead of a session
+func (in promptInput) oneShot() bool {
+	return in.flag != "" || len(in.args) > 0 || !in.stdinIsTerminal
+}
+
+// read returns the prompt: the instruction from the flag or else the arguments, followed
+// by the piped input between delimiters
+func (in promptInput) read() (string, error) {
+	instruction := in.flag
+	if instruction == "" {
+		instruction = strings.Join(in.args, " ")
+	}
+	instruction = strings.TrimSpace(instruction)
+
+	var piped string
+	if !in.stdinIsTerminal && in.stdin != nil {
+		var err error
+		if piped, err = in.readStdin(len(instruction)); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case instruction == "" && piped == "":
+		return "", errEmptyPrompt
+	case piped == "":
+		return instruction, nil
+	case instruction == "":
+		return piped, nil
+	default:
+		return fmt.Sprintf("%s\n\n%s\n%s\n%s", instruction, pipedInputStart, piped, pipedInputEnd), nil
+	}
+}
+
+// readStdin reads the piped input, failing once it would exceed the token budget together
+// with the instruction of instructionBytes bytes
+func (in promptInput) readStdin(instructionBytes int) (string, error) {
+	reader := in.stdin
+	maxBytes := int64(in.maxTokens*bytesPerToken - instructionBytes)
+	if in.maxTokens > 0 {
+		if maxBytes < 0 {
+			maxBytes = 0
+		}
+		reader = io.LimitReader(in.stdin, maxBytes+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the prompt from stdin: %w", err)
+	}
+
+	if in.maxTokens > 0 && int64(len(data)) > maxBytes {
+		return "", fmt.Errorf(
+			"%w: about %d tokens fit into the prompt, try piping less, e.g. with head or tail",
+			errPipedInputTooLarge, maxBytes/bytesPerToken,
+		)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// promptBudget estimates how many tokens of the model's context window are left for the
+// prompt once the answer's max_tokens are reserved. It is 0, no limit, if the context
+// window of the model isn't known; the provider rejects prompts that are too long anyway.
+// If max_tokens doesn't leave any room, the prompt is only bounded by the context window.
+func promptBudget(cfg config.LLMConfig) int {
+	contextWindow, ok := llm.ContextWindow(cfg.Provider, cfg.Model)
+	if !ok {
+		return 0
+	}
+
+	budget := contextWindow - int(cfg.MaxTokens)
+	if budget < 1 {
+		return contextWindow
+	}
+
+	return budget
+}
+
+// stdinPromptInput returns the promptInput for the process's stdin
+func stdinPromptInput(flag string, args []string, cfg config.LLMConfig) promptInput {
+	return promptInput{
+		flag:            flag,
+		args:            args,
+		stdin:           os.Stdin,
+		stdinIsTerminal: term.IsTerminal(int(os.Stdin.Fd())),
+		maxTokens:       promptBudget(cfg),
+	}
+}
+
+// AskResult is the JSON output of a one-shot chat
+type AskResult struct {
+	types.ChatResponse
+	Error string `json:"error,omitempty"`
+}
+
+// ask sends prompt as a new chat and prints the answer, as plain text so it can be piped,
+// or with --json together with the token counts
+func ask(ctx context.Context, chatService Service, prompt string, output *cli.Output, stdout io.Writer) error {
+	response, err := chatService.Chat(ctx, uuid.Nil, prompt)
+	if err != nil {
+		return output.Fail(AskResult{Error: err.Error()}, fmt.Errorf("failed to get an answer: %w", err), func(theme.Theme) {})
+	}
+
+	return output.Success(AskResult{ChatResponse: response}, func(t theme.Theme) {
+		fmt.Fprintln(stdout, strings.TrimRight(response.Answer, "\n"))
+		if response.Warning != "" {
+			fmt.Fprintln(os.Stderr, "Warning: "+response.Warning)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	chatMock "github.com/shaharia-lab/echoy/internal/chat/mocks"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       promptInput
+		wantOneShot bool
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "interactive",
+			input:       promptInput{stdinIsTerminal: true},
+			wantOneShot: false,
+			wantErr:     errEmptyPrompt,
+		},
+		{
+			name:        "flag wins over arguments",
+			input:       promptInput{flag: "from flag", args: []string{"from", "args"}, stdinIsTerminal: true},
+			wantOneShot: true,
+			want:        "from flag",
+		},
+		{
+			name:        "piped stdin is context for the instruction",
+			input:       promptInput{args: []string{"what's", "wrong?"}, stdin: strings.NewReader("panic: nil map\n")},
+			wantOneShot: true,
+			want:        "what's wrong?\n\n--- Input ---\npanic: nil map\n--- End of input ---",
+		},
+		{
+			name:        "piped stdin within the budget",
+			input:       promptInput{flag: "sum", stdin: strings.NewReader("1 2 3"), maxTokens: 2},
+			wantOneShot: true,
+			want:        "sum\n\n--- Input ---\n1 2 3\n--- End of input ---",
+		},
+		{
+			name:        "piped stdin over the budget",
+			input:       promptInput{flag: "sum", stdin: strings.NewReader("1 2 3 4 5 6"), maxTokens: 2},
+			wantOneShot: true,
+			wantErr:     errPipedInputTooLarge,
+		},
+		{
+			name:        "arguments are joined",
+			input:       promptInput{args: []string{"what", "is", "Go?"}, stdinIsTerminal: true},
+			wantOneShot: true,
+			want:        "what is Go?",
+		},
+		{
+			name:        "piped stdin",
+			input:       promptInput{stdin: strings.NewReader("  summarize this\n")},
+			wantOneShot: true,
+			want:        "summarize this",
+		},
+		{
+			name:        "empty stdin",
+			input:       promptInput{stdin: strings.NewReader("\n")},
+			wantOneShot: true,
+			wantErr:     errEmptyPrompt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantOneShot, tt.input.oneShot())
+
+			got, err := tt.input.read()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func newTestOutput(t *testing.T, jsonOutput bool) (*cli.Output, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	themeMgr := theme.NewManager(theme.NewDefaultTheme(), &config.AppConfig{}, nil).SetOutput(&buf)
+	output := cli.NewOutput(&cobra.Command{}, themeMgr).SetWriter(&buf)
+	output.JSON = jsonOutput
+
+	return output, &buf
+}
+
+func TestPromptBudget(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LLMConfig
+		want int
+	}{
+		{
+			name: "reserves max_tokens for the answer",
+			cfg:  config.LLMConfig{Provider: config.EchoProvider, Model: "echo", MaxTokens: 1000},
+			want: 8192 - 1000,
+		},
+		{
+			name: "unknown context window is no limit",
+			cfg:  config.LLMConfig{Provider: "openai", Model: "my-fine-tuned-model", MaxTokens: 1000},
+			want: 0,
+		},
+		{
+			name: "max_tokens of the whole context window",
+			cfg:  config.LLMConfig{Provider: config.EchoProvider, Model: "echo", MaxTokens: 8192},
+			want: 8192,
+		},
+		{
+			name: "max_tokens beyond the context window",
+			cfg:  config.LLMConfig{Provider: config.EchoProvider, Model: "echo", MaxTokens: 100000},
+			want: 8192,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, promptBudget(tt.cfg))
+		})
+	}
+}
+
+func TestAsk(t *testing.T) {
+	chatUUID := uuid.New()
+	response := types.ChatResponse{ChatUUID: chatUUID, Answer: "Go is a programming language.\n", InputToken: 5, OutputToken: 7}
+
+	t.Run("text", func(t *testing.T) {
+		chatService := chatMock.NewMockService(t)
+		chatService.EXPECT().Chat(context.Background(), uuid.Nil, "what is Go?").Return(response, nil)
+
+		output, _ := newTestOutput(t, false)
+		var stdout bytes.Buffer
+
+		require.NoError(t, ask(context.Background(), chatService, "what is Go?", output, &stdout))
+		assert.Equal(t, "Go is a programming language.\n", stdout.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		chatService := chatMock.NewMockService(t)
+		chatService.EXPECT().Chat(context.Background(), uuid.Nil, "what is Go?").Return(response, nil)
+
+		output, buf := newTestOutput(t, true)
+		var stdout bytes.Buffer
+
+		require.NoError(t, ask(context.Background(), chatService, "what is Go?", output, &stdout))
+		assert.Empty(t, stdout.String())
+
+		var result AskResult
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+		assert.Equal(t, chatUUID, result.ChatUUID)
+		assert.Equal(t, 5, result.InputToken)
+		assert.Equal(t, 7, result.OutputToken)
+	})
+
+	t.Run("json error", func(t *testing.T) {
+		chatService := chatMock.NewMockService(t)
+		chatService.EXPECT().Chat(context.Background(), uuid.Nil, "what is Go?").Return(types.ChatResponse{}, errors.New("rate limited"))
+
+		output, buf := newTestOutput(t, true)
+
+		err := ask(context.Background(), chatService, "what is Go?", output, &bytes.Buffer{})
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Contains(t, buf.String(), `"error": "rate limited"`)
+	})
+}
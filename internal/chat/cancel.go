@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrGenerationCancelled ends an answer stopped with Cancel
+var ErrGenerationCancelled = errors.New("the answer was cancelled")
+
+// ErrNotGenerating is returned by Cancel when no answer is being generated in the chat
+var ErrNotGenerating = errors.New("no answer is being generated")
+
+// generation is an answer being generated, registered so Cancel can stop it
+type generation struct {
+	cancel context.CancelCauseFunc
+}
+
+// generations holds the answers being generated, keyed by chat session
+type generations struct {
+	mu      sync.Mutex
+	running map[uuid.UUID]*generation
+}
+
+// Cancel stops the answer being generated in the chat sessionID. A streamed answer ends
+// with an ErrGenerationCancelled error, and the part of it that was generated is saved
+// like that of any interrupted stream. It returns ErrNotGenerating if there is no answer
+// to stop.
+func (s *ServiceImpl) Cancel(sessionID uuid.UUID) error {
+	s.generations.mu.Lock()
+	g, ok := s.generations.running[sessionID]
+	s.generations.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w in chat %s", ErrNotGenerating, sessionID)
+	}
+
+	g.cancel(ErrGenerationCancelled)
+	return nil
+}
+
+// trackGeneration derives the context of an answer in sessionID from ctx and registers it
+// for Cancel. The returned function must be called once the answer is complete. Answers
+// outside of a chat can't be cancelled.
+func (s *ServiceImpl) trackGeneration(ctx context.Context, sessionID uuid.UUID) (context.Context, func()) {
+	generationCtx, cancel := context.WithCancelCause(ctx)
+	if sessionID == uuid.Nil {
+		return generationCtx, func() { cancel(nil) }
+	}
+
+	g := &generation{cancel: cancel}
+
+	s.generations.mu.Lock()
+	if s.generations.running == nil {
+		s.generations.running = make(map[uuid.UUID]*generation)
+	}
+	// A newer answer in the same chat takes over, the older one can't be cancelled anymore
+	s.generations.running[sessionID] = g
+	s.generations.mu.Unlock()
+
+	return generationCtx, func() {
+		s.generations.mu.Lock()
+		if s.generations.running[sessionID] == g {
+			delete(s.generations.running, sessionID)
+		}
+		s.generations.mu.Unlock()
+
+		cancel(nil)
+	}
+}
+
+// cancelledError returns ErrGenerationCancelled if the answer generated with ctx was
+// stopped by Cancel, and err otherwise
+func cancelledError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrGenerationCancelled) {
+		return ErrGenerationCancelled
+	}
+
+	return err
+}
@@ -2,56 +2,369 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/shaharia-lab/echoy/internal/api"
 	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/echoy/internal/llm"
+	"github.com/shaharia-lab/echoy/internal/logger"
 	"github.com/shaharia-lab/goai"
+	"strings"
 	"time"
+	"unicode"
 )
 
+// searchSnippetContext is the number of characters shown around a search match
+const searchSnippetContext = 40
+
 // HistoryService defines operations for chat history management
 type HistoryService interface {
 	CreateChat(ctx context.Context) (*goai.ChatHistory, error)
 	AddMessage(ctx context.Context, uuid uuid.UUID, message goai.ChatHistoryMessage) error
+	AddPartialMessage(ctx context.Context, uuid uuid.UUID, message goai.ChatHistoryMessage) error
 	GetChat(ctx context.Context, uuid uuid.UUID) (*goai.ChatHistory, error)
+	GetChatDetails(ctx context.Context, uuid uuid.UUID) (*history.Chat, error)
 	ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error)
+	SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error)
+	ListChats(ctx context.Context) ([]history.Chat, error)
+	SetChatTitle(ctx context.Context, uuid uuid.UUID, title string) error
+	ImportChat(ctx context.Context, chat history.Chat) error
+	DeleteChat(ctx context.Context, uuid uuid.UUID) error
 }
 
 // Service provides chat functionality using the LLM
 type Service interface {
 	Chat(ctx context.Context, sessionID uuid.UUID, message string) (types.ChatResponse, error)
+	Continue(ctx context.Context, sessionID uuid.UUID) (types.ChatResponse, error)
+	Cancel(sessionID uuid.UUID) error
 	ChatStreaming(ctx context.Context, sessionID uuid.UUID, message string) (<-chan goai.StreamingLLMResponse, error)
-	GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error)
+	CreateChat(ctx context.Context) (uuid.UUID, error)
+	ForkChat(ctx context.Context, sourceID uuid.UUID, uptoMessageIndex int) (uuid.UUID, error)
+	GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*history.Chat, error)
 	GetListChatHistories(ctx context.Context) (types.ChatHistoryList, error)
+	RenameChat(ctx context.Context, chatUUID uuid.UUID, title string) error
+	DeleteChat(ctx context.Context, chatUUID uuid.UUID) error
+	SearchChats(ctx context.Context, query string) (types.ChatSearchResults, error)
+	ExportChats(ctx context.Context) (types.ChatExport, error)
+	ImportChats(ctx context.Context, export types.ChatExport, onConflict types.ImportConflictStrategy) (types.ChatImportResult, error)
+	HealthCheck(ctx context.Context) error
 }
 
+// historyWarning is reported to the caller when the answer could not be saved to the chat history
+const historyWarning = "the conversation could not be saved to chat history"
+
+// ErrHistoryNotSaved is the last response of a streamed answer that was delivered in full
+// but could not be saved to the chat history, after its Done response
+var ErrHistoryNotSaved = errors.New(historyWarning)
+
+// continuePrompt asks the model to carry on with the last answer of a chat
+const continuePrompt = "Continue your last answer exactly where it stopped, without repeating what you already wrote."
+
+// ErrNothingToContinue is returned by Continue when the chat doesn't end with an answer
+var ErrNothingToContinue = errors.New("the chat has no answer to continue")
+
+// ErrInvalidMessageIndex is returned by ForkChat for an index past the last message
+var ErrInvalidMessageIndex = errors.New("the chat has no message at this index")
+
+// ErrEmptyResponse is returned when the LLM answers with no content, even when asked again
+var ErrEmptyResponse = errors.New("the model returned no content")
+
+// emptyResponseRetries is how many more times an empty answer is asked for again
+const emptyResponseRetries = 1
+
+// ErrLLMTimeout is returned when the LLM provider doesn't answer within the request timeout
+var ErrLLMTimeout = errors.New("the LLM provider did not answer in time")
+
 // ServiceImpl implements the ChatService interface
 type ServiceImpl struct {
 	llmService     llm.Service
 	historyService HistoryService
+	logger         logger.Logger
+	requestTimeout time.Duration
+	maxTokens      int64
+	provider       string
+	model          string
+	logContent     bool
+	generations    generations
+	turns          turns
 }
 
 // NewChatService creates a new chat service
-func NewChatService(llmService llm.Service, historyService HistoryService) *ServiceImpl {
+func NewChatService(llmService llm.Service, historyService HistoryService, log logger.Logger) *ServiceImpl {
 	return &ServiceImpl{
 		llmService:     llmService,
 		historyService: historyService,
+		logger:         log,
 	}
 }
 
-// Chat provides non-streaming chat functionality
+// SetRequestTimeout bounds how long the LLM may take to answer, or for a streamed answer
+// to start. Zero, the default, means no timeout besides the caller's context.
+func (s *ServiceImpl) SetRequestTimeout(timeout time.Duration) *ServiceImpl {
+	s.requestTimeout = timeout
+	return s
+}
+
+// SetMaxTokens sets the maximum length of an answer the LLM is configured with, answers
+// using all of it are reported with types.FinishReasonLength
+func (s *ServiceImpl) SetMaxTokens(maxTokens int64) *ServiceImpl {
+	s.maxTokens = maxTokens
+	return s
+}
+
+// finishReason tells why an answer of outputTokens ended, given the maximum length of
+// answers. Zero means no known maximum. It is empty, unknown, if the provider didn't count
+// the tokens, e.g. in the streams of some providers: the answer may have been cut off.
+func finishReason(outputTokens int, maxTokens int64) string {
+	if outputTokens <= 0 {
+		return ""
+	}
+
+	if maxTokens > 0 && int64(outputTokens) >= maxTokens {
+		return types.FinishReasonLength
+	}
+
+	return types.FinishReasonStop
+}
+
+// timeoutError turns the error of an LLM call that was cut off by the request timeout
+// into one wrapping ErrLLMTimeout. Cancellations by the caller are returned unchanged.
+func (s *ServiceImpl) timeoutError(parent, call context.Context, err error) error {
+	if parent.Err() == nil && errors.Is(context.Cause(call), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s, raise llm.request_timeout if the model needs longer", ErrLLMTimeout, s.requestTimeout)
+	}
+
+	return err
+}
+
+// HealthCheck reports whether the configured LLM can be used, i.e. it is reachable and
+// accepts the token. LLM failures are returned as a *llm.ProviderError.
+func (s *ServiceImpl) HealthCheck(ctx context.Context) error {
+	if s.llmService == nil {
+		return errors.New("no LLM service configured")
+	}
+
+	if err := s.llmService.Ping(ctx); err != nil {
+		s.logger.WithField(logger.ErrorKey, err).Warn("LLM health check failed")
+		return err
+	}
+
+	return nil
+}
+
+// Chat provides non-streaming chat functionality. Failing to persist the conversation
+// doesn't fail the chat, it is logged and reported through ChatResponse.Warning. An empty
+// answer is asked for again once, then fails with ErrEmptyResponse without being saved.
+// Turns in the same chat run one after the other.
 func (s *ServiceImpl) Chat(ctx context.Context, sessionID uuid.UUID, message string) (types.ChatResponse, error) {
 	userMessage := goai.LLMMessage{
 		Role: goai.UserRole,
 		Text: message,
 	}
 
+	endTurn, err := s.lockTurn(ctx, sessionID)
+	if err != nil {
+		return types.ChatResponse{}, err
+	}
+	defer endTurn()
+
+	sessionID, historyErr := s.saveUserMessage(ctx, sessionID, userMessage)
+
+	generationCtx, done := s.trackGeneration(ctx, sessionID)
+	llmResponse, err := s.generate(generationCtx, []goai.LLMMessage{userMessage})
+	done()
+	if err != nil {
+		return types.ChatResponse{}, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	if historyErr == nil {
+		historyErr = s.saveAssistantMessage(ctx, sessionID, llmResponse.Text)
+	}
+
+	chatResponse := types.ChatResponse{
+		ChatUUID:     sessionID,
+		Answer:       llmResponse.Text,
+		InputToken:   llmResponse.TotalInputToken,
+		OutputToken:  llmResponse.TotalOutputToken,
+		FinishReason: finishReason(llmResponse.TotalOutputToken, s.maxTokens),
+	}
+	if historyErr != nil {
+		chatResponse.Warning = historyWarning
+	}
+
+	return chatResponse, nil
+}
+
+// Continue asks the LLM to carry on with the last answer of the chat sessionID, e.g. one
+// cut off by the maximum length. The model gets the whole conversation and the
+// continuation is stored as another answer. Failing to store it is reported through
+// ChatResponse.Warning like for Chat.
+func (s *ServiceImpl) Continue(ctx context.Context, sessionID uuid.UUID) (types.ChatResponse, error) {
+	endTurn, err := s.lockTurn(ctx, sessionID)
+	if err != nil {
+		return types.ChatResponse{}, err
+	}
+	defer endTurn()
+
+	chatHistory, err := s.historyService.GetChat(ctx, sessionID)
+	if err != nil {
+		return types.ChatResponse{}, fmt.Errorf("failed to load chat: %w", err)
+	}
+
+	if len(chatHistory.Messages) == 0 || chatHistory.Messages[len(chatHistory.Messages)-1].Role != goai.AssistantRole {
+		return types.ChatResponse{}, ErrNothingToContinue
+	}
+
+	messages := make([]goai.LLMMessage, 0, len(chatHistory.Messages)+1)
+	for _, message := range chatHistory.Messages {
+		messages = append(messages, message.LLMMessage)
+	}
+	messages = append(messages, goai.LLMMessage{Role: goai.UserRole, Text: continuePrompt})
+
+	generationCtx, done := s.trackGeneration(ctx, sessionID)
+	llmResponse, err := s.generate(generationCtx, messages)
+	done()
+	if err != nil {
+		return types.ChatResponse{}, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	chatResponse := types.ChatResponse{
+		ChatUUID:     sessionID,
+		Answer:       llmResponse.Text,
+		InputToken:   llmResponse.TotalInputToken,
+		OutputToken:  llmResponse.TotalOutputToken,
+		FinishReason: finishReason(llmResponse.TotalOutputToken, s.maxTokens),
+	}
+	if err := s.saveAssistantMessage(ctx, sessionID, llmResponse.Text); err != nil {
+		chatResponse.Warning = historyWarning
+	}
+
+	return chatResponse, nil
+}
+
+// generate calls the LLM, asking again if the answer is empty. It fails with
+// ErrEmptyResponse if the answer stays empty.
+func (s *ServiceImpl) generate(ctx context.Context, messages []goai.LLMMessage) (goai.LLMResponse, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := s.generateOnce(ctx, messages)
+		if err != nil || !isEmptyAnswer(response.Text) {
+			return response, err
+		}
+
+		if attempt == emptyResponseRetries {
+			return goai.LLMResponse{}, ErrEmptyResponse
+		}
+
+		s.logger.Warn("the LLM returned an empty answer, asking again")
+	}
+}
+
+// isEmptyAnswer reports whether answer has no content besides whitespace
+func isEmptyAnswer(answer string) bool {
+	return strings.TrimSpace(answer) == ""
+}
+
+// generateOnce calls the LLM, bounded by the request timeout, and logs the call
+func (s *ServiceImpl) generateOnce(ctx context.Context, messages []goai.LLMMessage) (goai.LLMResponse, error) {
+	call := llmCall{messages: messages, started: time.Now()}
+
+	generateCtx := ctx
+	if s.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		generateCtx, cancel = context.WithTimeout(ctx, s.requestTimeout)
+		defer cancel()
+	}
+
+	response, err := s.llmService.Generate(generateCtx, messages)
+	if err != nil {
+		call.err = cancelledError(ctx, s.timeoutError(ctx, generateCtx, err))
+		s.logLLMCall(call)
+		return goai.LLMResponse{}, call.err
+	}
+
+	call.answer = response.Text
+	call.inputTokens = response.TotalInputToken
+	call.outputTokens = response.TotalOutputToken
+	call.finishReason = finishReason(response.TotalOutputToken, s.maxTokens)
+	s.logLLMCall(call)
+
+	return response, nil
+}
+
+// generateStream starts a streamed answer and logs the call once the stream ends
+func (s *ServiceImpl) generateStream(ctx context.Context, messages []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error) {
+	call := llmCall{messages: messages, started: time.Now(), streamed: true}
+
+	sourceChan, err := s.startStream(ctx, messages)
+	if err != nil {
+		call.err = err
+		s.logLLMCall(call)
+		return nil, err
+	}
+
+	return s.logStream(ctx, call, sourceChan), nil
+}
+
+// startStream starts a streamed answer. The request timeout only applies until the
+// first response arrives, so long answers aren't cut off once they are flowing.
+func (s *ServiceImpl) startStream(ctx context.Context, messages []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error) {
+	if s.requestTimeout <= 0 {
+		return s.llmService.GenerateStream(ctx, messages)
+	}
+
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(s.requestTimeout, func() { cancel(context.DeadlineExceeded) })
+
+	sourceChan, err := s.llmService.GenerateStream(streamCtx, messages)
+	if err != nil {
+		timer.Stop()
+		cancel(nil)
+		return nil, s.timeoutError(ctx, streamCtx, err)
+	}
+
+	responseChan := make(chan goai.StreamingLLMResponse)
+	logger.SafeGo(func() {
+		defer cancel(nil)
+		defer close(responseChan)
+
+		started := false
+		for response := range sourceChan {
+			if !started {
+				timer.Stop()
+				started = true
+			}
+			if response.Error != nil {
+				response.Error = s.timeoutError(ctx, streamCtx, response.Error)
+			}
+
+			select {
+			case responseChan <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if !started && ctx.Err() == nil && errors.Is(context.Cause(streamCtx), context.DeadlineExceeded) {
+			select {
+			case responseChan <- goai.StreamingLLMResponse{Error: s.timeoutError(ctx, streamCtx, context.DeadlineExceeded), Done: true}:
+			case <-ctx.Done():
+			}
+		}
+	}, s.logger.WithField("goroutine", "generate_stream"))
+
+	return responseChan, nil
+}
+
+// saveUserMessage stores the user's message, creating the chat first if sessionID is nil.
+// Errors are logged and returned so the caller can continue without history.
+func (s *ServiceImpl) saveUserMessage(ctx context.Context, sessionID uuid.UUID, userMessage goai.LLMMessage) (uuid.UUID, error) {
 	if sessionID == uuid.Nil {
 		chatHistory, err := s.historyService.CreateChat(ctx)
 		if err != nil {
-			return types.ChatResponse{}, fmt.Errorf("failed to create chat session: %w", err)
+			s.logger.WithField(logger.ErrorKey, err).Error("failed to create chat session, continuing without history")
+			return uuid.Nil, fmt.Errorf("failed to create chat session: %w", err)
 		}
 
 		sessionID = chatHistory.UUID
@@ -61,36 +374,49 @@ func (s *ServiceImpl) Chat(ctx context.Context, sessionID uuid.UUID, message str
 		LLMMessage:  userMessage,
 		GeneratedAt: time.Now().UTC(),
 	}); err != nil {
-		return types.ChatResponse{}, fmt.Errorf("failed to add message to chat history: %w", err)
+		s.logger.WithFields(map[string]interface{}{
+			logger.ErrorKey: err,
+			"chat_uuid":     sessionID.String(),
+		}).Error("failed to add message to chat history, continuing without history")
+		return sessionID, fmt.Errorf("failed to add message to chat history: %w", err)
 	}
 
-	llmResponse, err := s.llmService.Generate(ctx, []goai.LLMMessage{userMessage})
-	if err != nil {
-		return types.ChatResponse{}, fmt.Errorf("failed to generate response: %w", err)
-	}
+	return sessionID, nil
+}
+
+// saveAssistantMessage stores the assistant's answer, logging failures
+func (s *ServiceImpl) saveAssistantMessage(ctx context.Context, sessionID uuid.UUID, answer string) error {
+	return s.addAssistantMessage(ctx, sessionID, answer, s.historyService.AddMessage)
+}
 
-	err = s.historyService.AddMessage(ctx, sessionID, goai.ChatHistoryMessage{
+// savePartialAssistantMessage stores the part of an answer that was delivered before its
+// stream ended, flagged as partial, logging failures
+func (s *ServiceImpl) savePartialAssistantMessage(ctx context.Context, sessionID uuid.UUID, answer string) error {
+	return s.addAssistantMessage(ctx, sessionID, answer, s.historyService.AddPartialMessage)
+}
+
+func (s *ServiceImpl) addAssistantMessage(ctx context.Context, sessionID uuid.UUID, answer string, add func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error) error {
+	err := add(ctx, sessionID, goai.ChatHistoryMessage{
 		LLMMessage: goai.LLMMessage{
 			Role: goai.AssistantRole,
-			Text: llmResponse.Text,
+			Text: answer,
 		},
 		GeneratedAt: time.Now().UTC(),
 	})
 	if err != nil {
-		return types.ChatResponse{}, fmt.Errorf("failed to add response to chat history: %w", err)
+		s.logger.WithFields(map[string]interface{}{
+			logger.ErrorKey: err,
+			"chat_uuid":     sessionID.String(),
+		}).Error("failed to add response to chat history")
+		return fmt.Errorf("failed to add response to chat history: %w", err)
 	}
 
-	return types.ChatResponse{
-		ChatUUID:    sessionID,
-		Answer:      llmResponse.Text,
-		InputToken:  llmResponse.TotalInputToken,
-		OutputToken: llmResponse.TotalOutputToken,
-	}, nil
+	return nil
 }
 
-// GetChatHistory retrieves chat history for a given chat session
-func (s *ServiceImpl) GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error) {
-	chatHistory, err := s.historyService.GetChat(ctx, chatUUID)
+// GetChatHistory retrieves chat history for a given chat session, with its metadata
+func (s *ServiceImpl) GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*history.Chat, error) {
+	chatHistory, err := s.historyService.GetChatDetails(ctx, chatUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list chat histories: %w", err)
 	}
@@ -100,13 +426,18 @@ func (s *ServiceImpl) GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*
 
 // GetListChatHistories retrieves all chat histories
 func (s *ServiceImpl) GetListChatHistories(ctx context.Context) (types.ChatHistoryList, error) {
-	chatHistories, err := s.historyService.ListChatHistories(ctx)
+	chatHistories, err := s.historyService.ListChats(ctx)
 	if err != nil {
 		return types.ChatHistoryList{}, fmt.Errorf("failed to list chat histories: %w", err)
 	}
 
+	chats := make([]types.Chat, 0, len(chatHistories))
+	for _, chatHistory := range chatHistories {
+		chats = append(chats, types.NewChat(chatHistory))
+	}
+
 	return types.ChatHistoryList{
-		Chats: chatHistories,
+		Chats: chats,
 		Pagination: api.Pagination{
 			Page:    1,
 			PerPage: len(chatHistories),
@@ -115,72 +446,313 @@ func (s *ServiceImpl) GetListChatHistories(ctx context.Context) (types.ChatHisto
 	}, nil
 }
 
-// ChatStreaming provides streaming chat functionality
+// CreateChat starts an empty chat and returns its UUID, so it can be shown before the
+// first message is sent
+func (s *ServiceImpl) CreateChat(ctx context.Context) (uuid.UUID, error) {
+	chatHistory, err := s.historyService.CreateChat(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create chat: %w", err)
+	}
+
+	return chatHistory.UUID, nil
+}
+
+// ForkChat starts a new chat with a copy of the messages of the chat sourceID, from the
+// first one up to the one at uptoMessageIndex included, e.g. to try another question at
+// that point of the conversation. A negative index copies all of them. It returns the
+// UUID of the new chat, or ErrInvalidMessageIndex if the source has no message at
+// uptoMessageIndex. The source is left unchanged.
+func (s *ServiceImpl) ForkChat(ctx context.Context, sourceID uuid.UUID, uptoMessageIndex int) (uuid.UUID, error) {
+	source, err := s.historyService.GetChatDetails(ctx, sourceID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to load chat: %w", err)
+	}
+
+	if uptoMessageIndex >= len(source.Messages) {
+		return uuid.Nil, fmt.Errorf("%w: index %d of a chat with %d messages", ErrInvalidMessageIndex, uptoMessageIndex, len(source.Messages))
+	}
+
+	messages := source.Messages
+	if uptoMessageIndex >= 0 {
+		messages = messages[:uptoMessageIndex+1]
+	}
+
+	fork := history.Chat{ChatHistory: goai.ChatHistory{
+		UUID:      uuid.New(),
+		Messages:  append([]goai.ChatHistoryMessage{}, messages...),
+		CreatedAt: time.Now().UTC(),
+	}}
+	for _, index := range source.PartialMessages {
+		if index < len(messages) {
+			fork.PartialMessages = append(fork.PartialMessages, index)
+		}
+	}
+	if err := s.historyService.ImportChat(ctx, fork); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to fork chat: %w", err)
+	}
+
+	return fork.UUID, nil
+}
+
+// RenameChat sets the title of a chat
+func (s *ServiceImpl) RenameChat(ctx context.Context, chatUUID uuid.UUID, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("chat title must not be empty")
+	}
+
+	if err := s.historyService.SetChatTitle(ctx, chatUUID, title); err != nil {
+		return fmt.Errorf("failed to rename chat: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteChat removes a chat and its messages from the chat history
+func (s *ServiceImpl) DeleteChat(ctx context.Context, chatUUID uuid.UUID) error {
+	if err := s.historyService.DeleteChat(ctx, chatUUID); err != nil {
+		return fmt.Errorf("failed to delete chat: %w", err)
+	}
+
+	return nil
+}
+
+// SearchChats finds the chats containing query in any of their messages
+func (s *ServiceImpl) SearchChats(ctx context.Context, query string) (types.ChatSearchResults, error) {
+	chatHistories, err := s.historyService.SearchChats(ctx, query)
+	if err != nil {
+		return types.ChatSearchResults{}, fmt.Errorf("failed to search chat histories: %w", err)
+	}
+
+	results := make([]types.ChatSearchResult, 0, len(chatHistories))
+	for _, chatHistory := range chatHistories {
+		results = append(results, types.ChatSearchResult{
+			Chat:    types.NewChat(history.Chat{ChatHistory: chatHistory}),
+			Snippet: searchSnippet(chatHistory, query),
+		})
+	}
+
+	return types.ChatSearchResults{
+		Query:   query,
+		Results: results,
+		Pagination: api.Pagination{
+			Page:    1,
+			PerPage: len(results),
+			Total:   len(results),
+		},
+	}, nil
+}
+
+// ExportChats collects all stored chats into a portable export
+func (s *ServiceImpl) ExportChats(ctx context.Context) (types.ChatExport, error) {
+	chatHistories, err := s.historyService.ListChats(ctx)
+	if err != nil {
+		return types.ChatExport{}, fmt.Errorf("failed to list chat histories: %w", err)
+	}
+
+	return types.ChatExport{
+		Version:    types.ChatExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Chats:      chatHistories,
+	}, nil
+}
+
+// ImportChats restores the chats of an export. Chats whose UUID is already stored are
+// skipped or imported under a new UUID depending on onConflict.
+func (s *ServiceImpl) ImportChats(ctx context.Context, export types.ChatExport, onConflict types.ImportConflictStrategy) (types.ChatImportResult, error) {
+	var result types.ChatImportResult
+
+	if export.Version > types.ChatExportVersion {
+		return result, fmt.Errorf("unsupported chat export version %d", export.Version)
+	}
+
+	if onConflict != types.ImportConflictSkip && onConflict != types.ImportConflictRemap {
+		return result, fmt.Errorf("unknown import conflict strategy %q", onConflict)
+	}
+
+	for _, chatHistory := range export.Chats {
+		err := s.historyService.ImportChat(ctx, chatHistory)
+		if errors.Is(err, history.ErrChatExists) {
+			if onConflict == types.ImportConflictSkip {
+				result.Skipped++
+				continue
+			}
+
+			chatHistory.UUID = uuid.New()
+			if err = s.historyService.ImportChat(ctx, chatHistory); err == nil {
+				result.Remapped++
+				continue
+			}
+		}
+
+		if err != nil {
+			return result, fmt.Errorf("failed to import chat %s: %w", chatHistory.UUID, err)
+		}
+
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// searchSnippet returns an excerpt of the first message containing query, with
+// searchSnippetContext characters of surrounding text on each side
+func searchSnippet(chatHistory goai.ChatHistory, query string) string {
+	needle := lowerRunes(query)
+
+	for _, message := range chatHistory.Messages {
+		text := []rune(message.Text)
+		index := runeIndex(lowerRunes(message.Text), needle)
+		if index < 0 {
+			continue
+		}
+
+		start := max(index-searchSnippetContext, 0)
+		end := min(index+len(needle)+searchSnippetContext, len(text))
+
+		snippet := strings.Join(strings.Fields(string(text[start:end])), " ")
+		if start > 0 {
+			snippet = "..." + snippet
+		}
+		if end < len(text) {
+			snippet += "..."
+		}
+
+		return snippet
+	}
+
+	return ""
+}
+
+// lowerRunes lower-cases s rune by rune, keeping indexes aligned with []rune(s)
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+
+	return runes
+}
+
+// runeIndex is strings.Index for rune slices, so the result can be used to slice the original text
+func runeIndex(haystack, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ChatStreaming provides streaming chat functionality. As with Chat, failing to persist
+// the conversation doesn't interrupt the stream: a complete answer is then followed by an
+// ErrHistoryNotSaved response, like the warning of Chat. If the stream ends early,
+// because ctx is cancelled or the provider stops without finishing, the part of the answer
+// that was delivered is saved as a partial message. An answer stopped with Cancel
+// ends with an ErrGenerationCancelled error, and one that finishes empty with an
+// ErrEmptyResponse error, without being saved.
+//
+// The turn only ends once the answer is saved, which may be after its last response was
+// read. A next turn in the same chat waits for it, so the history keeps the order of the
+// conversation.
 func (s *ServiceImpl) ChatStreaming(ctx context.Context, sessionID uuid.UUID, message string) (<-chan goai.StreamingLLMResponse, error) {
 	userMessage := goai.LLMMessage{
 		Role: goai.UserRole,
 		Text: message,
 	}
 
-	if sessionID == uuid.Nil {
-		chatHistory, err := s.historyService.CreateChat(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create chat session: %w", err)
-		}
-		sessionID = chatHistory.UUID
+	endTurn, err := s.lockTurn(ctx, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.historyService.AddMessage(ctx, sessionID, goai.ChatHistoryMessage{
-		LLMMessage:  userMessage,
-		GeneratedAt: time.Now().UTC(),
-	}); err != nil {
-		return nil, fmt.Errorf("failed to add message to chat history: %w", err)
-	}
+	sessionID, historyErr := s.saveUserMessage(ctx, sessionID, userMessage)
 
-	sourceChan, err := s.llmService.GenerateStream(ctx, []goai.LLMMessage{userMessage})
+	generationCtx, done := s.trackGeneration(ctx, sessionID)
+	sourceChan, err := s.generateStream(generationCtx, []goai.LLMMessage{userMessage})
 	if err != nil {
+		done()
+		endTurn()
 		return nil, fmt.Errorf("failed to generate streaming response: %w", err)
 	}
 
 	// Create a new channel to broadcast responses
 	resultChan := make(chan goai.StreamingLLMResponse)
 
-	go func() {
+	logger.SafeGo(func() {
+		defer endTurn()
 		defer close(resultChan)
+		defer done()
+
+		var completeResponse strings.Builder
+		saved := false
+		// complete is set once the Done response of a whole answer is forwarded
+		complete := false
+
+		// An interrupted stream, by the client going away or the provider failing, still
+		// keeps what was sent so far, flagged as partial
+		defer func() {
+			if saved || historyErr != nil || completeResponse.Len() == 0 {
+				return
+			}
 
-		var completeResponse string
+			s.logger.WithField("chat_uuid", sessionID.String()).Warn("stream ended before the answer was complete, saving the partial answer")
+			_ = s.savePartialAssistantMessage(context.WithoutCancel(ctx), sessionID, completeResponse.String())
+		}()
 
+	forward:
 		for streamingResp := range sourceChan {
+			if streamingResp.Done && streamingResp.Error == nil && isEmptyAnswer(completeResponse.String()+streamingResp.Text) {
+				streamingResp.Error = ErrEmptyResponse
+				saved = true
+			}
+
 			// Forward each response to our result channel
 			select {
 			case resultChan <- streamingResp:
 				// Message forwarded
-			case <-ctx.Done():
-				return
+			case <-generationCtx.Done():
+				break forward
 			}
 
 			// Process for history
 			if streamingResp.Error != nil {
+				s.logStreamError(sessionID, streamingResp.Error)
 				continue
 			}
 
-			completeResponse += streamingResp.Text
+			completeResponse.WriteString(streamingResp.Text)
 
 			if streamingResp.Done {
+				complete = true
+			}
+			if streamingResp.Done && historyErr == nil && !saved {
 				// Save complete response to history
-				err := s.historyService.AddMessage(ctx, sessionID, goai.ChatHistoryMessage{
-					LLMMessage: goai.LLMMessage{
-						Role: goai.AssistantRole,
-						Text: completeResponse,
-					},
-					GeneratedAt: time.Now().UTC(),
-				})
-				if err != nil {
-					fmt.Printf("Failed to save complete streaming response: %v\n", err)
-				}
+				historyErr = s.saveAssistantMessage(ctx, sessionID, completeResponse.String())
+				saved = true
+			}
+		}
+
+		if complete && historyErr != nil {
+			select {
+			case resultChan <- goai.StreamingLLMResponse{Error: ErrHistoryNotSaved, Done: true}:
+			case <-ctx.Done():
+			}
+		}
+
+		// The caller still reads the stream, tell it why it ended early
+		if !saved && cancelledError(generationCtx, nil) != nil {
+			select {
+			case resultChan <- goai.StreamingLLMResponse{Error: ErrGenerationCancelled, Done: true}:
+			case <-ctx.Done():
 			}
 		}
-	}()
+	}, s.logger.WithFields(logger.Fields{"goroutine": "chat_streaming", "session_id": sessionID}))
 
 	return resultChan, nil
 }
@@ -191,6 +763,7 @@ func (s *ServiceImpl) processStreamingResponse(ctx context.Context, sessionID uu
 
 	for streamingResp := range responseChan {
 		if streamingResp.Error != nil {
+			s.logStreamError(sessionID, streamingResp.Error)
 			continue
 		}
 
@@ -201,14 +774,13 @@ func (s *ServiceImpl) processStreamingResponse(ctx context.Context, sessionID uu
 		}
 	}
 
-	err := s.historyService.AddMessage(ctx, sessionID, goai.ChatHistoryMessage{
-		LLMMessage: goai.LLMMessage{
-			Role: goai.AssistantRole,
-			Text: completeResponse,
-		},
-		GeneratedAt: time.Now().UTC(),
-	})
-	if err != nil {
-		fmt.Printf("Failed to save complete streaming response: %v\n", err)
-	}
+	_ = s.saveAssistantMessage(ctx, sessionID, completeResponse)
+}
+
+// logStreamError records an error chunk received from the LLM stream
+func (s *ServiceImpl) logStreamError(sessionID uuid.UUID, err error) {
+	s.logger.WithFields(map[string]interface{}{
+		logger.ErrorKey: err,
+		"chat_uuid":     sessionID.String(),
+	}).Warn("error in streaming response")
 }
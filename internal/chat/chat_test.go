@@ -3,12 +3,21 @@ package chat
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/shaharia-lab/echoy/internal/chat/mocks"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/history"
+	"github.com/shaharia-lab/echoy/internal/llm"
 	mocks2 "github.com/shaharia-lab/echoy/internal/llm/mocks"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	loggerMocks "github.com/shaharia-lab/echoy/internal/logger/mocks"
 	"github.com/shaharia-lab/goai"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -23,20 +32,30 @@ func TestServiceImpl_Chat(t *testing.T) {
 		mockAddUserError      error
 		mockAddAssistantError error
 		expectedError         bool
+		expectedWarning       bool
+		expectedFinishReason  string
 	}{
 		{
-			name:            "successful chat",
+			name:                 "successful chat",
+			sessionID:            uuid.New(),
+			userMessage:          "Hello, how are you?",
+			mockLLMResponse:      goai.LLMResponse{Text: "I'm doing well, thank you!", TotalOutputToken: 7},
+			mockLLMError:         nil,
+			expectedFinishReason: types.FinishReasonStop,
+		},
+		{
+			name:            "no token count leaves the finish reason out",
 			sessionID:       uuid.New(),
-			userMessage:     "Hello, how are you?",
-			mockLLMResponse: goai.LLMResponse{Text: "I'm doing well, thank you!"},
-			mockLLMError:    nil,
+			userMessage:     "Hello",
+			mockLLMResponse: goai.LLMResponse{Text: "Hi"},
 		},
 		{
-			name:             "error adding user message",
+			name:             "error adding user message still answers",
 			sessionID:        uuid.New(),
 			userMessage:      "Hello",
+			mockLLMResponse:  goai.LLMResponse{Text: "Response"},
 			mockAddUserError: errors.New("failed to add user message"),
-			expectedError:    true,
+			expectedWarning:  true,
 		},
 		{
 			name:          "error generating LLM response",
@@ -46,12 +65,19 @@ func TestServiceImpl_Chat(t *testing.T) {
 			expectedError: true,
 		},
 		{
-			name:                  "error adding assistant response",
+			name:                  "error adding assistant response still answers",
 			sessionID:             uuid.New(),
 			userMessage:           "Hello",
 			mockLLMResponse:       goai.LLMResponse{Text: "Response"},
 			mockAddAssistantError: errors.New("failed to add assistant message"),
-			expectedError:         true,
+			expectedWarning:       true,
+		},
+		{
+			name:                 "answer cut off at the maximum tokens",
+			sessionID:            uuid.New(),
+			userMessage:          "Tell me everything",
+			mockLLMResponse:      goai.LLMResponse{Text: "Once upon", TotalOutputToken: 100},
+			expectedFinishReason: types.FinishReasonLength,
 		},
 	}
 
@@ -60,31 +86,25 @@ func TestServiceImpl_Chat(t *testing.T) {
 			mockHistoryService := new(mocks.MockHistoryService)
 			mockLLMService := new(mocks2.MockService)
 
-			chatService := NewChatService(mockLLMService, mockHistoryService)
+			chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()).SetMaxTokens(100)
 
 			ctx := context.Background()
 
-			if tc.mockAddUserError != nil {
-				mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
-					return msg.Role == goai.UserRole && msg.Text == tc.userMessage
-				})).Return(tc.mockAddUserError)
-			} else {
-				mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
-					return msg.Role == goai.UserRole && msg.Text == tc.userMessage
-				})).Return(nil)
+			mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+				return msg.Role == goai.UserRole && msg.Text == tc.userMessage
+			})).Return(tc.mockAddUserError)
 
-				expectedLLMMessage := []goai.LLMMessage{{
-					Role: goai.UserRole,
-					Text: tc.userMessage,
-				}}
+			expectedLLMMessage := []goai.LLMMessage{{
+				Role: goai.UserRole,
+				Text: tc.userMessage,
+			}}
 
-				mockLLMService.On("Generate", ctx, expectedLLMMessage).Return(tc.mockLLMResponse, tc.mockLLMError)
+			mockLLMService.On("Generate", mock.Anything, expectedLLMMessage).Return(tc.mockLLMResponse, tc.mockLLMError)
 
-				if tc.mockLLMError == nil {
-					mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
-						return msg.Role == goai.AssistantRole && msg.Text == tc.mockLLMResponse.Text
-					})).Return(tc.mockAddAssistantError)
-				}
+			if tc.mockLLMError == nil && tc.mockAddUserError == nil {
+				mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+					return msg.Role == goai.AssistantRole && msg.Text == tc.mockLLMResponse.Text
+				})).Return(tc.mockAddAssistantError)
 			}
 
 			response, err := chatService.Chat(ctx, tc.sessionID, tc.userMessage)
@@ -94,6 +114,9 @@ func TestServiceImpl_Chat(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.mockLLMResponse.Text, response.Answer)
+				assert.Equal(t, tc.expectedWarning, response.Warning != "")
+
+				assert.Equal(t, tc.expectedFinishReason, response.FinishReason)
 			}
 
 			mockHistoryService.AssertExpectations(t)
@@ -102,6 +125,365 @@ func TestServiceImpl_Chat(t *testing.T) {
 	}
 }
 
+func TestServiceImpl_Chat_CreateChatFails(t *testing.T) {
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLLMService := new(mocks2.MockService)
+	chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+	ctx := context.Background()
+	mockHistoryService.On("CreateChat", ctx).Return(nil, errors.New("database is locked"))
+	mockLLMService.On("Generate", mock.Anything, mock.Anything).Return(goai.LLMResponse{Text: "Answer"}, nil)
+
+	response, err := chatService.Chat(ctx, uuid.Nil, "Hello")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Answer", response.Answer)
+	assert.Equal(t, uuid.Nil, response.ChatUUID)
+	assert.NotEmpty(t, response.Warning)
+	mockHistoryService.AssertExpectations(t)
+	mockLLMService.AssertExpectations(t)
+}
+
+func TestServiceImpl_Chat_RequestTimeout(t *testing.T) {
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLLMService := new(mocks2.MockService)
+	chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()).SetRequestTimeout(10 * time.Millisecond)
+
+	sessionID := uuid.New()
+	mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.Anything).Return(nil)
+	mockLLMService.On("Generate", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ []goai.LLMMessage) (goai.LLMResponse, error) {
+			<-ctx.Done()
+			return goai.LLMResponse{}, fmt.Errorf("request failed: %v", ctx.Err())
+		})
+
+	_, err := chatService.Chat(context.Background(), sessionID, "Hello")
+
+	assert.ErrorIs(t, err, ErrLLMTimeout)
+	assert.Contains(t, err.Error(), "10ms")
+}
+
+func TestServiceImpl_Chat_EmptyResponse(t *testing.T) {
+	t.Run("asks again once", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+		sessionID := uuid.New()
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+			return msg.Role == goai.UserRole
+		})).Return(nil).Once()
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+			return msg.Role == goai.AssistantRole && msg.Text == "Answer"
+		})).Return(nil).Once()
+		mockLLMService.On("Generate", mock.Anything, mock.Anything).Return(goai.LLMResponse{Text: " \n"}, nil).Once()
+		mockLLMService.On("Generate", mock.Anything, mock.Anything).Return(goai.LLMResponse{Text: "Answer"}, nil).Once()
+
+		response, err := chatService.Chat(context.Background(), sessionID, "Hello")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Answer", response.Answer)
+		mockHistoryService.AssertExpectations(t)
+		mockLLMService.AssertExpectations(t)
+	})
+
+	t.Run("fails without saving the answer", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+		sessionID := uuid.New()
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+			return msg.Role == goai.UserRole
+		})).Return(nil).Once()
+		mockLLMService.On("Generate", mock.Anything, mock.Anything).Return(goai.LLMResponse{Text: ""}, nil).Twice()
+
+		_, err := chatService.Chat(context.Background(), sessionID, "Hello")
+
+		assert.ErrorIs(t, err, ErrEmptyResponse)
+		mockHistoryService.AssertExpectations(t)
+		mockLLMService.AssertExpectations(t)
+	})
+}
+
+func TestServiceImpl_ChatStreaming_RequestTimeout(t *testing.T) {
+	t.Run("no response in time", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()).SetRequestTimeout(10 * time.Millisecond)
+
+		sessionID := uuid.New()
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.Anything).Return(nil)
+		mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, _ []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error) {
+				source := make(chan goai.StreamingLLMResponse)
+				go func() {
+					<-ctx.Done()
+					close(source)
+				}()
+				return source, nil
+			})
+
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, "Hello")
+		assert.NoError(t, err)
+
+		var last goai.StreamingLLMResponse
+		for response := range responses {
+			last = response
+		}
+		assert.ErrorIs(t, last.Error, ErrLLMTimeout)
+	})
+
+	t.Run("long answers are not cut off", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()).SetRequestTimeout(10 * time.Millisecond)
+
+		sessionID := uuid.New()
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.Anything).Return(nil)
+		mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, _ []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error) {
+				source := make(chan goai.StreamingLLMResponse)
+				go func() {
+					defer close(source)
+					source <- goai.StreamingLLMResponse{Text: "Hello"}
+					time.Sleep(30 * time.Millisecond)
+					if ctx.Err() != nil {
+						source <- goai.StreamingLLMResponse{Error: ctx.Err(), Done: true}
+						return
+					}
+					source <- goai.StreamingLLMResponse{Text: " there", Done: true}
+				}()
+				return source, nil
+			})
+
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, "Hello")
+		assert.NoError(t, err)
+
+		var text strings.Builder
+		for response := range responses {
+			assert.NoError(t, response.Error)
+			text.WriteString(response.Text)
+		}
+		assert.Equal(t, "Hello there", text.String())
+	})
+}
+
+// savedAnswer is an assistant message passed to the history service
+type savedAnswer struct {
+	text    string
+	partial bool
+}
+
+func TestServiceImpl_ChatStreaming_SavesPartialAnswer(t *testing.T) {
+	setup := func(t *testing.T, source chan goai.StreamingLLMResponse) (*ServiceImpl, uuid.UUID, chan savedAnswer) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+
+		sessionID := uuid.New()
+		saved := make(chan savedAnswer, 1)
+		mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+			return msg.Role == goai.UserRole
+		})).Return(nil)
+		for method, partial := range map[string]bool{"AddMessage": false, "AddPartialMessage": true} {
+			mockHistoryService.On(method, mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+				return msg.Role == goai.AssistantRole
+			})).Run(func(args mock.Arguments) {
+				assert.NoError(t, args.Get(0).(context.Context).Err(), "the partial answer should be saved with a live context")
+				saved <- savedAnswer{text: args.Get(2).(goai.ChatHistoryMessage).Text, partial: partial}
+			}).Return(nil).Maybe()
+		}
+		mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).Return((<-chan goai.StreamingLLMResponse)(source), nil)
+
+		return NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()), sessionID, saved
+	}
+
+	t.Run("client disconnects", func(t *testing.T) {
+		source := make(chan goai.StreamingLLMResponse)
+		chatService, sessionID, saved := setup(t, source)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		responses, err := chatService.ChatStreaming(ctx, sessionID, "Hello")
+		assert.NoError(t, err)
+
+		source <- goai.StreamingLLMResponse{Text: "Hello"}
+		<-responses
+		source <- goai.StreamingLLMResponse{Text: ", world"}
+		<-responses
+		cancel()
+		// The provider stops streaming once the request is cancelled
+		close(source)
+
+		select {
+		case answer := <-saved:
+			assert.Equal(t, savedAnswer{text: "Hello, world", partial: true}, answer, "the marker shouldn't be part of the text")
+		case <-time.After(time.Second):
+			t.Fatal("partial answer was not saved")
+		}
+	})
+
+	t.Run("stream ends without done", func(t *testing.T) {
+		source := make(chan goai.StreamingLLMResponse, 2)
+		chatService, sessionID, saved := setup(t, source)
+
+		source <- goai.StreamingLLMResponse{Text: "Hello"}
+		source <- goai.StreamingLLMResponse{Error: errors.New("connection reset"), Done: true}
+		close(source)
+
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, "Hello")
+		assert.NoError(t, err)
+		for range responses {
+		}
+
+		select {
+		case answer := <-saved:
+			assert.Equal(t, savedAnswer{text: "Hello", partial: true}, answer)
+		case <-time.After(time.Second):
+			t.Fatal("partial answer was not saved")
+		}
+	})
+
+	t.Run("empty answer is not saved", func(t *testing.T) {
+		source := make(chan goai.StreamingLLMResponse, 2)
+		chatService, sessionID, saved := setup(t, source)
+
+		source <- goai.StreamingLLMResponse{Text: " "}
+		source <- goai.StreamingLLMResponse{Done: true}
+		close(source)
+
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, "Hello")
+		assert.NoError(t, err)
+		var last goai.StreamingLLMResponse
+		for response := range responses {
+			last = response
+		}
+
+		assert.ErrorIs(t, last.Error, ErrEmptyResponse)
+		select {
+		case answer := <-saved:
+			t.Fatalf("empty answer saved as %q", answer.text)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+
+	t.Run("complete answer is saved once", func(t *testing.T) {
+		source := make(chan goai.StreamingLLMResponse, 1)
+		chatService, sessionID, saved := setup(t, source)
+
+		source <- goai.StreamingLLMResponse{Text: "Hello", Done: true}
+		close(source)
+
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, "Hello")
+		assert.NoError(t, err)
+		for range responses {
+		}
+
+		assert.Equal(t, savedAnswer{text: "Hello"}, <-saved)
+		select {
+		case answer := <-saved:
+			t.Fatalf("answer saved twice, second time as %q", answer.text)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+}
+
+func TestServiceImpl_ChatStreaming_TurnOrder(t *testing.T) {
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLLMService := new(mocks2.MockService)
+	chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+	sessionID := uuid.New()
+	var mu sync.Mutex
+	var saved []string
+	mockHistoryService.On("AddMessage", mock.Anything, sessionID, mock.Anything).Run(func(args mock.Arguments) {
+		msg := args.Get(2).(goai.ChatHistoryMessage)
+		if msg.Role == goai.AssistantRole {
+			// A slow write, the caller has read the answer and moved on by now
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		mu.Lock()
+		saved = append(saved, msg.Text)
+		mu.Unlock()
+	}).Return(nil)
+
+	for _, answer := range []string{"First answer", "Second answer"} {
+		source := make(chan goai.StreamingLLMResponse, 1)
+		source <- goai.StreamingLLMResponse{Text: answer, Done: true}
+		close(source)
+		mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).Return((<-chan goai.StreamingLLMResponse)(source), nil).Once()
+	}
+
+	// Each turn stops reading at the done response, and the next one starts right away
+	for _, question := range []string{"First question", "Second question"} {
+		responses, err := chatService.ChatStreaming(context.Background(), sessionID, question)
+		require.NoError(t, err)
+		for response := range responses {
+			if response.Done {
+				break
+			}
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(saved) == 4
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"First question", "First answer", "Second question", "Second answer"}, saved)
+}
+
+func TestServiceImpl_ChatStreaming_HistoryWarning(t *testing.T) {
+	testCases := []struct {
+		name          string
+		userSaveErr   error
+		answerSaveErr error
+	}{
+		{name: "question not saved", userSaveErr: errors.New("disk full")},
+		{name: "answer not saved", answerSaveErr: errors.New("disk full")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockHistoryService := new(mocks.MockHistoryService)
+			mockLLMService := new(mocks2.MockService)
+			chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+			ctx := context.Background()
+			sessionID := uuid.New()
+			mockHistoryService.On("AddMessage", ctx, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+				return msg.Role == goai.UserRole
+			})).Return(tc.userSaveErr)
+			if tc.userSaveErr == nil {
+				mockHistoryService.On("AddMessage", ctx, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+					return msg.Role == goai.AssistantRole && msg.Text == "Hi"
+				})).Return(tc.answerSaveErr)
+			}
+
+			stream := make(chan goai.StreamingLLMResponse, 1)
+			stream <- goai.StreamingLLMResponse{Text: "Hi", Done: true}
+			close(stream)
+			mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).Return((<-chan goai.StreamingLLMResponse)(stream), nil)
+
+			resultChan, err := chatService.ChatStreaming(ctx, sessionID, "Hello")
+			require.NoError(t, err)
+
+			var responses []goai.StreamingLLMResponse
+			for resp := range resultChan {
+				responses = append(responses, resp)
+			}
+
+			// The whole answer comes first, the warning after it
+			require.Len(t, responses, 2)
+			assert.Equal(t, goai.StreamingLLMResponse{Text: "Hi", Done: true}, responses[0])
+			assert.ErrorIs(t, responses[1].Error, ErrHistoryNotSaved)
+			mockHistoryService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestServiceImpl_ChatStreaming(t *testing.T) {
 	testCases := []struct {
 		name             string
@@ -118,11 +500,10 @@ func TestServiceImpl_ChatStreaming(t *testing.T) {
 			mockStreamError: nil,
 		},
 		{
-			name:             "error adding user message",
+			name:             "error adding user message still streams",
 			sessionID:        uuid.New(),
 			userMessage:      "Hello",
 			mockAddUserError: errors.New("failed to add user message"),
-			expectedError:    true,
 		},
 		{
 			name:            "error generating streaming response",
@@ -137,13 +518,19 @@ func TestServiceImpl_ChatStreaming(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockHistoryService := new(mocks.MockHistoryService)
 			mockLLMService := new(mocks2.MockService)
-			chatService := NewChatService(mockLLMService, mockHistoryService)
+			chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
 
 			ctx := context.Background()
 			if tc.mockAddUserError != nil {
 				mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
 					return msg.Role == goai.UserRole && msg.Text == tc.userMessage
 				})).Return(tc.mockAddUserError)
+
+				// The answer is still streamed, it is just not saved
+				mockRespChan := make(chan goai.StreamingLLMResponse, 1)
+				mockRespChan <- goai.StreamingLLMResponse{Text: "Hi", Done: true}
+				close(mockRespChan)
+				mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).Return((<-chan goai.StreamingLLMResponse)(mockRespChan), nil)
 			} else {
 				mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
 					return msg.Role == goai.UserRole && msg.Text == tc.userMessage
@@ -156,11 +543,11 @@ func TestServiceImpl_ChatStreaming(t *testing.T) {
 
 				if tc.mockStreamError != nil {
 
-					mockLLMService.On("GenerateStream", ctx, expectedLLMMessage).Return(nil, tc.mockStreamError)
+					mockLLMService.On("GenerateStream", mock.Anything, expectedLLMMessage).Return(nil, tc.mockStreamError)
 				} else {
 
 					mockRespChan := make(chan goai.StreamingLLMResponse)
-					mockLLMService.On("GenerateStream", ctx, expectedLLMMessage).Return((<-chan goai.StreamingLLMResponse)(mockRespChan), nil)
+					mockLLMService.On("GenerateStream", mock.Anything, expectedLLMMessage).Return((<-chan goai.StreamingLLMResponse)(mockRespChan), nil)
 					mockHistoryService.On("AddMessage", ctx, tc.sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
 						return msg.Role == goai.AssistantRole && msg.Text == "Hello, world! How can I help?"
 					})).Return(nil)
@@ -222,6 +609,10 @@ func TestServiceImpl_ChatStreaming(t *testing.T) {
 			if tc.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, resultChan)
+			} else {
+				assert.NoError(t, err)
+				for range resultChan {
+				}
 			}
 
 			mockHistoryService.AssertExpectations(t)
@@ -234,7 +625,7 @@ func TestProcessStreamingResponse(t *testing.T) {
 	t.Run("successful processing", func(t *testing.T) {
 		mockHistoryService := new(mocks.MockHistoryService)
 		mockLLMService := new(mocks2.MockService)
-		chatService := NewChatService(mockLLMService, mockHistoryService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
 
 		ctx := context.Background()
 		sessionID := uuid.New()
@@ -260,7 +651,7 @@ func TestProcessStreamingResponse(t *testing.T) {
 	t.Run("processing with errors", func(t *testing.T) {
 		mockHistoryService := new(mocks.MockHistoryService)
 		mockLLMService := new(mocks2.MockService)
-		chatService := NewChatService(mockLLMService, mockHistoryService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
 
 		ctx := context.Background()
 		sessionID := uuid.New()
@@ -286,7 +677,7 @@ func TestProcessStreamingResponse(t *testing.T) {
 	t.Run("error saving to history", func(t *testing.T) {
 		mockHistoryService := new(mocks.MockHistoryService)
 		mockLLMService := new(mocks2.MockService)
-		chatService := NewChatService(mockLLMService, mockHistoryService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
 
 		ctx := context.Background()
 		sessionID := uuid.New()
@@ -308,3 +699,451 @@ func TestProcessStreamingResponse(t *testing.T) {
 		mockHistoryService.AssertExpectations(t)
 	})
 }
+
+func TestServiceImpl_SearchChats(t *testing.T) {
+	t.Run("returns matches with snippets", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+		ctx := context.Background()
+		chatHistory := goai.ChatHistory{
+			UUID: uuid.New(),
+			Messages: []goai.ChatHistoryMessage{
+				{LLMMessage: goai.LLMMessage{Role: goai.UserRole, Text: "Tell me about Go"}},
+				{LLMMessage: goai.LLMMessage{Role: goai.AssistantRole, Text: "Goroutines are cheap"}},
+			},
+		}
+		mockHistoryService.On("SearchChats", ctx, "goroutines").Return([]goai.ChatHistory{chatHistory}, nil)
+
+		results, err := chatService.SearchChats(ctx, "goroutines")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "goroutines", results.Query)
+		assert.Equal(t, 1, results.Total)
+		if assert.Len(t, results.Results, 1) {
+			assert.Equal(t, chatHistory.UUID, results.Results[0].Chat.UUID)
+			assert.Equal(t, "Goroutines are cheap", results.Results[0].Snippet)
+		}
+		mockHistoryService.AssertExpectations(t)
+	})
+
+	t.Run("history error", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+		ctx := context.Background()
+		mockHistoryService.On("SearchChats", ctx, "go").Return(nil, errors.New("database is locked"))
+
+		_, err := chatService.SearchChats(ctx, "go")
+
+		assert.ErrorContains(t, err, "database is locked")
+		mockHistoryService.AssertExpectations(t)
+	})
+}
+
+func TestSearchSnippet(t *testing.T) {
+	long := strings.Repeat("a", 60) + " needle " + strings.Repeat("b", 60)
+
+	testCases := []struct {
+		name     string
+		messages []string
+		query    string
+		expected string
+	}{
+		{name: "short message", messages: []string{"find the needle here"}, query: "NEEDLE", expected: "find the needle here"},
+		{name: "first matching message", messages: []string{"nothing", "needle one", "needle two"}, query: "needle", expected: "needle one"},
+		{name: "truncated on both sides", messages: []string{long}, query: "needle", expected: "..." + strings.Repeat("a", 39) + " needle " + strings.Repeat("b", 39) + "..."},
+		{name: "whitespace collapsed", messages: []string{"line one\n\nneedle"}, query: "needle", expected: "line one needle"},
+		{name: "multi-byte match", messages: []string{"Die Größe und Gewicht"}, query: "größe", expected: "Die Größe und Gewicht"},
+		{name: "no match", messages: []string{"haystack"}, query: "needle", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chatHistory := goai.ChatHistory{}
+			for _, text := range tc.messages {
+				chatHistory.Messages = append(chatHistory.Messages, goai.ChatHistoryMessage{LLMMessage: goai.LLMMessage{Text: text}})
+			}
+
+			assert.Equal(t, tc.expected, searchSnippet(chatHistory, tc.query))
+		})
+	}
+}
+
+func TestServiceImpl_ExportChats(t *testing.T) {
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLLMService := new(mocks2.MockService)
+	chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+	ctx := context.Background()
+	chats := []history.Chat{{ChatHistory: goai.ChatHistory{UUID: uuid.New()}, Title: "first"}, {ChatHistory: goai.ChatHistory{UUID: uuid.New()}}}
+	mockHistoryService.On("ListChats", ctx).Return(chats, nil)
+
+	export, err := chatService.ExportChats(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, types.ChatExportVersion, export.Version)
+	assert.False(t, export.ExportedAt.IsZero())
+	assert.Equal(t, chats, export.Chats)
+	mockHistoryService.AssertExpectations(t)
+}
+
+func TestServiceImpl_ImportChats(t *testing.T) {
+	existing := history.Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New()}, Title: "existing"}
+	fresh := history.Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New()}}
+	export := types.ChatExport{Version: types.ChatExportVersion, Chats: []history.Chat{existing, fresh}}
+	conflictErr := fmt.Errorf("failed to import chat %s: %w", existing.UUID, history.ErrChatExists)
+
+	t.Run("skip existing chats", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("ImportChat", ctx, existing).Return(conflictErr).Once()
+		mockHistoryService.On("ImportChat", ctx, fresh).Return(nil).Once()
+
+		result, err := chatService.ImportChats(ctx, export, types.ImportConflictSkip)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.ChatImportResult{Imported: 1, Skipped: 1}, result)
+		mockHistoryService.AssertExpectations(t)
+	})
+
+	t.Run("remap existing chats", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("ImportChat", ctx, existing).Return(conflictErr).Once()
+		mockHistoryService.On("ImportChat", ctx, mock.MatchedBy(func(chat history.Chat) bool {
+			return chat.UUID != existing.UUID && chat.UUID != fresh.UUID && chat.Title == existing.Title
+		})).Return(nil).Once()
+		mockHistoryService.On("ImportChat", ctx, fresh).Return(nil).Once()
+
+		result, err := chatService.ImportChats(ctx, export, types.ImportConflictRemap)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.ChatImportResult{Imported: 1, Remapped: 1}, result)
+		mockHistoryService.AssertExpectations(t)
+	})
+
+	t.Run("storage error aborts import", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("ImportChat", ctx, existing).Return(errors.New("disk full")).Once()
+
+		_, err := chatService.ImportChats(ctx, export, types.ImportConflictSkip)
+
+		assert.ErrorContains(t, err, "disk full")
+		mockHistoryService.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown strategy and newer versions", func(t *testing.T) {
+		chatService := NewChatService(new(mocks2.MockService), new(mocks.MockHistoryService), logger.NewNoopLogger())
+		ctx := context.Background()
+
+		_, err := chatService.ImportChats(ctx, export, "overwrite")
+		assert.ErrorContains(t, err, "unknown import conflict strategy")
+
+		_, err = chatService.ImportChats(ctx, types.ChatExport{Version: types.ChatExportVersion + 1}, types.ImportConflictSkip)
+		assert.ErrorContains(t, err, "unsupported chat export version")
+	})
+}
+
+func TestServiceImpl_CreateChat(t *testing.T) {
+	ctx := context.Background()
+	chatUUID := uuid.New()
+
+	mockHistoryService := new(mocks.MockHistoryService)
+	chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+
+	mockHistoryService.On("CreateChat", ctx).Return(&goai.ChatHistory{UUID: chatUUID}, nil).Once()
+	got, err := chatService.CreateChat(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, chatUUID, got)
+
+	mockHistoryService.On("CreateChat", ctx).Return(nil, errors.New("disk full")).Once()
+	_, err = chatService.CreateChat(ctx)
+	assert.ErrorContains(t, err, "failed to create chat")
+
+	mockHistoryService.AssertExpectations(t)
+}
+
+func TestServiceImpl_Continue(t *testing.T) {
+	chatUUID := uuid.New()
+	conversation := []goai.ChatHistoryMessage{
+		{LLMMessage: goai.LLMMessage{Role: goai.UserRole, Text: "Tell me a story"}},
+		{LLMMessage: goai.LLMMessage{Role: goai.AssistantRole, Text: "Once upon a"}},
+	}
+
+	t.Run("sends the whole conversation and stores the continuation", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger()).SetMaxTokens(3)
+		ctx := context.Background()
+
+		mockHistoryService.On("GetChat", ctx, chatUUID).Return(&goai.ChatHistory{UUID: chatUUID, Messages: conversation}, nil)
+		mockLLMService.On("Generate", mock.Anything, []goai.LLMMessage{
+			conversation[0].LLMMessage,
+			conversation[1].LLMMessage,
+			{Role: goai.UserRole, Text: continuePrompt},
+		}).Return(goai.LLMResponse{Text: " time", TotalOutputToken: 1}, nil)
+		mockHistoryService.On("AddMessage", ctx, chatUUID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+			return msg.Role == goai.AssistantRole && msg.Text == " time"
+		})).Return(nil)
+
+		response, err := chatService.Continue(ctx, chatUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, types.ChatResponse{ChatUUID: chatUUID, Answer: " time", OutputToken: 1, FinishReason: types.FinishReasonStop}, response)
+		mockHistoryService.AssertExpectations(t)
+		mockLLMService.AssertExpectations(t)
+	})
+
+	t.Run("reports failures to store the continuation", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		mockLLMService := new(mocks2.MockService)
+		chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("GetChat", ctx, chatUUID).Return(&goai.ChatHistory{UUID: chatUUID, Messages: conversation}, nil)
+		mockLLMService.On("Generate", mock.Anything, mock.Anything).Return(goai.LLMResponse{Text: " time"}, nil)
+		mockHistoryService.On("AddMessage", ctx, chatUUID, mock.Anything).Return(errors.New("disk full"))
+
+		response, err := chatService.Continue(ctx, chatUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, historyWarning, response.Warning)
+	})
+
+	t.Run("requires an answer to continue", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("GetChat", ctx, chatUUID).Return(&goai.ChatHistory{UUID: chatUUID, Messages: conversation[:1]}, nil)
+
+		_, err := chatService.Continue(ctx, chatUUID)
+		assert.ErrorIs(t, err, ErrNothingToContinue)
+	})
+
+	t.Run("keeps not found errors", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("GetChat", ctx, chatUUID).Return(nil, fmt.Errorf("%w: %s", history.ErrChatNotFound, chatUUID))
+
+		_, err := chatService.Continue(ctx, chatUUID)
+		assert.ErrorIs(t, err, history.ErrChatNotFound)
+	})
+}
+
+func TestServiceImpl_ForkChat(t *testing.T) {
+	ctx := context.Background()
+	storage := history.NewMemoryStorage()
+	chatService := NewChatService(new(mocks2.MockService), storage, logger.NewNoopLogger())
+
+	source, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	for _, text := range []string{"Tell me a story", "Once upon a time", "Make it shorter", "Once."} {
+		role := goai.UserRole
+		if text == "Once upon a time" || text == "Once." {
+			role = goai.AssistantRole
+		}
+		require.NoError(t, storage.AddMessage(ctx, source.UUID, goai.ChatHistoryMessage{LLMMessage: goai.LLMMessage{Role: role, Text: text}}))
+	}
+	require.NoError(t, storage.AddPartialMessage(ctx, source.UUID, goai.ChatHistoryMessage{LLMMessage: goai.LLMMessage{Role: goai.AssistantRole, Text: "The end"}}))
+
+	t.Run("copies the messages up to the index", func(t *testing.T) {
+		forkID, err := chatService.ForkChat(ctx, source.UUID, 1)
+		require.NoError(t, err)
+		assert.NotEqual(t, source.UUID, forkID)
+
+		fork, err := storage.GetChat(ctx, forkID)
+		require.NoError(t, err)
+		require.Len(t, fork.Messages, 2)
+		assert.Equal(t, "Once upon a time", fork.Messages[1].Text)
+
+		require.NoError(t, storage.AddMessage(ctx, forkID, goai.ChatHistoryMessage{LLMMessage: goai.LLMMessage{Role: goai.UserRole, Text: "Make it longer"}}))
+		unchanged, err := storage.GetChat(ctx, source.UUID)
+		require.NoError(t, err)
+		assert.Len(t, unchanged.Messages, 5, "the source should be left unchanged")
+
+		details, err := storage.GetChatDetails(ctx, forkID)
+		require.NoError(t, err)
+		assert.Empty(t, details.PartialMessages)
+	})
+
+	t.Run("copies all the messages for a negative index", func(t *testing.T) {
+		forkID, err := chatService.ForkChat(ctx, source.UUID, -1)
+		require.NoError(t, err)
+
+		fork, err := storage.GetChat(ctx, forkID)
+		require.NoError(t, err)
+		assert.Len(t, fork.Messages, 5)
+
+		details, err := storage.GetChatDetails(ctx, forkID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{4}, details.PartialMessages, "the fork should keep the interrupted answer flagged")
+	})
+
+	t.Run("rejects an index past the last message", func(t *testing.T) {
+		_, err := chatService.ForkChat(ctx, source.UUID, 5)
+		assert.ErrorIs(t, err, ErrInvalidMessageIndex)
+	})
+
+	t.Run("unknown chat", func(t *testing.T) {
+		_, err := chatService.ForkChat(ctx, uuid.New(), -1)
+		assert.ErrorIs(t, err, history.ErrChatNotFound)
+	})
+}
+
+func TestServiceImpl_Cancel(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLLMService := new(mocks2.MockService)
+	chatService := NewChatService(mockLLMService, mockHistoryService, logger.NewNoopLogger())
+
+	assert.ErrorIs(t, chatService.Cancel(sessionID), ErrNotGenerating)
+
+	// The provider sends a word, then waits until the generation is cancelled
+	mockLLMService.On("GenerateStream", mock.Anything, mock.Anything).Return(func(ctx context.Context, _ []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error) {
+		source := make(chan goai.StreamingLLMResponse)
+		go func() {
+			defer close(source)
+			source <- goai.StreamingLLMResponse{Text: "Once"}
+			<-ctx.Done()
+		}()
+		return source, nil
+	})
+	mockHistoryService.On("AddMessage", ctx, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+		return msg.Role == goai.UserRole
+	})).Return(nil)
+	saved := make(chan string, 1)
+	mockHistoryService.On("AddPartialMessage", mock.Anything, sessionID, mock.MatchedBy(func(msg goai.ChatHistoryMessage) bool {
+		return msg.Role == goai.AssistantRole
+	})).Run(func(args mock.Arguments) {
+		saved <- args.Get(2).(goai.ChatHistoryMessage).Text
+	}).Return(nil)
+
+	stream, err := chatService.ChatStreaming(ctx, sessionID, "Tell me a story")
+	assert.NoError(t, err)
+	assert.Equal(t, "Once", (<-stream).Text)
+
+	assert.NoError(t, chatService.Cancel(sessionID))
+
+	var last goai.StreamingLLMResponse
+	for response := range stream {
+		last = response
+	}
+	assert.ErrorIs(t, last.Error, ErrGenerationCancelled)
+	assert.True(t, last.Done)
+	assert.Equal(t, "Once", <-saved, "the cancelled answer should be kept as partial")
+
+	assert.ErrorIs(t, chatService.Cancel(sessionID), ErrNotGenerating, "a finished answer can't be cancelled")
+}
+
+func TestServiceImpl_RenameChat(t *testing.T) {
+	chatUUID := uuid.New()
+
+	t.Run("trims and stores the title", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("SetChatTitle", ctx, chatUUID, "New title").Return(nil)
+
+		assert.NoError(t, chatService.RenameChat(ctx, chatUUID, "  New title "))
+		mockHistoryService.AssertExpectations(t)
+	})
+
+	t.Run("rejects empty titles", func(t *testing.T) {
+		chatService := NewChatService(new(mocks2.MockService), new(mocks.MockHistoryService), logger.NewNoopLogger())
+
+		assert.Error(t, chatService.RenameChat(context.Background(), chatUUID, "   "))
+	})
+
+	t.Run("keeps not found errors", func(t *testing.T) {
+		mockHistoryService := new(mocks.MockHistoryService)
+		chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+		ctx := context.Background()
+
+		mockHistoryService.On("SetChatTitle", ctx, chatUUID, "title").Return(fmt.Errorf("%w: %s", history.ErrChatNotFound, chatUUID))
+
+		err := chatService.RenameChat(ctx, chatUUID, "title")
+		assert.ErrorIs(t, err, history.ErrChatNotFound)
+	})
+}
+
+func TestServiceImpl_DeleteChat(t *testing.T) {
+	chatUUID := uuid.New()
+	mockHistoryService := new(mocks.MockHistoryService)
+	chatService := NewChatService(new(mocks2.MockService), mockHistoryService, logger.NewNoopLogger())
+	ctx := context.Background()
+
+	mockHistoryService.On("DeleteChat", ctx, chatUUID).Return(fmt.Errorf("%w: %s", history.ErrChatNotFound, chatUUID)).Once()
+	assert.ErrorIs(t, chatService.DeleteChat(ctx, chatUUID), history.ErrChatNotFound, "not found errors should be kept")
+
+	mockHistoryService.On("DeleteChat", ctx, chatUUID).Return(nil).Once()
+	assert.NoError(t, chatService.DeleteChat(ctx, chatUUID))
+	mockHistoryService.AssertExpectations(t)
+}
+
+func TestProcessStreamingResponse_LogsHistoryFailure(t *testing.T) {
+	mockHistoryService := new(mocks.MockHistoryService)
+	mockLogger := loggerMocks.NewMockLogger(t)
+	chatService := NewChatService(new(mocks2.MockService), mockHistoryService, mockLogger)
+
+	ctx := context.Background()
+	sessionID := uuid.New()
+	saveErr := errors.New("failed to save to history")
+
+	mockHistoryService.On("AddMessage", ctx, sessionID, mock.Anything).Return(saveErr)
+	mockLogger.EXPECT().WithFields(mock.MatchedBy(func(fields logger.Fields) bool {
+		return fields[logger.ErrorKey] == saveErr && fields["chat_uuid"] == sessionID.String()
+	})).Return(mockLogger).Once()
+	mockLogger.EXPECT().Error("failed to add response to chat history").Return().Once()
+
+	respChan := make(chan goai.StreamingLLMResponse, 1)
+	respChan <- goai.StreamingLLMResponse{Text: "Hello", Done: true}
+	close(respChan)
+
+	chatService.processStreamingResponse(ctx, sessionID, respChan)
+
+	mockHistoryService.AssertExpectations(t)
+}
+
+func TestServiceImpl_HealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("healthy", func(t *testing.T) {
+		mockLLMService := new(mocks2.MockService)
+		mockLLMService.On("Ping", ctx).Return(nil)
+
+		chatService := NewChatService(mockLLMService, new(mocks.MockHistoryService), logger.NewNoopLogger())
+		assert.NoError(t, chatService.HealthCheck(ctx))
+		mockLLMService.AssertExpectations(t)
+	})
+
+	t.Run("keeps the classified error", func(t *testing.T) {
+		pingErr := &llm.ProviderError{Kind: llm.ErrorKindAuth, Err: errors.New("invalid x-api-key")}
+		mockLLMService := new(mocks2.MockService)
+		mockLLMService.On("Ping", ctx).Return(pingErr)
+
+		chatService := NewChatService(mockLLMService, new(mocks.MockHistoryService), logger.NewNoopLogger())
+
+		var providerErr *llm.ProviderError
+		err := chatService.HealthCheck(ctx)
+		assert.True(t, errors.As(err, &providerErr))
+		assert.Equal(t, llm.ErrorKindAuth, providerErr.Kind)
+	})
+
+	t.Run("no LLM service", func(t *testing.T) {
+		chatService := NewChatService(nil, new(mocks.MockHistoryService), logger.NewNoopLogger())
+		assert.Error(t, chatService.HealthCheck(ctx))
+	})
+}
@@ -1,53 +1,104 @@
 package chat
 
 import (
-	"context"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/cli"
 	"github.com/shaharia-lab/echoy/internal/llm"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
-	"github.com/shaharia-lab/goai"
-	"github.com/shaharia-lab/telemetry-collector"
 	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 // NewChatCmd creates a new chat command
 func NewChatCmd(container *cli.Container) *cobra.Command {
+	var output *cli.Output
+	var prompt string
+
 	cmd := &cobra.Command{
 		Version: container.Config.Version.VersionText(),
-		Use:     "chat",
-		Short:   "Start an interactive chat session",
-		Long:    `Begin an interactive chat session with Echoy. Each session is uniquely identified.`,
+		Use:     "chat [prompt]",
+		Aliases: []string{"ask"},
+		Short:   "Start an interactive chat session or ask a single question",
+		Long: `Begin an interactive chat session with Echoy. Each session is uniquely identified.
+
+Given a prompt, with --prompt, as arguments or piped to stdin, the answer is printed to
+stdout and the command exits, e.g. echoy ask "summarize this" or echoy chat < question.txt.
+Piped input together with a prompt is sent as context for it, e.g.
+cat error.log | echoy ask "what's wrong here?". Use --json to get the answer together
+with its token counts.`,
+		Annotations: map[string]string{
+			cli.RequiresConfigAnnotation: "true",
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			llmService, err := llm.NewLLMService(container.ConfigFromFile.LLM)
+			input := stdinPromptInput(prompt, args, container.ConfigFromFile.LLM)
+
+			var question string
+			if input.oneShot() {
+				var err error
+				if question, err = input.read(); err != nil {
+					return err
+				}
+			}
+
+			httpClient, err := llm.NewHTTPClient(container.ConfigFromFile.LLM, container.ConfigFromFile.HTTP, container.Config.Version.Version)
+			if err != nil {
+				container.Logger.WithField(logger.ErrorKey, err).Error("error creating the HTTP client of the LLM provider")
+				return fmt.Errorf("error creating the HTTP client of the LLM provider: %w", err)
+			}
+
+			llmService, err := llm.NewLLMService(container.ConfigFromFile.LLM, httpClient)
 			if err != nil {
 				container.Logger.WithField(logger.ErrorKey, err).Error("error initializing LLM service")
 				return fmt.Errorf("error initializing LLM service: %w", err)
 			}
 
-			chatHistoryService := goai.NewInMemoryChatHistoryStorage()
-			chatService := NewChatService(llmService, chatHistoryService)
+			chatHistoryService, err := container.OpenHistoryStorage()
+			if err != nil {
+				container.Logger.WithField(logger.ErrorKey, err).Error("error opening chat history storage")
+				return fmt.Errorf("error opening chat history storage: %w", err)
+			}
+			defer chatHistoryService.Close()
+
+			chatService := NewChatService(llmService, chatHistoryService, container.Logger).
+				SetRequestTimeout(container.ConfigFromFile.LLM.ResolvedRequestTimeout()).
+				SetMaxTokens(container.ConfigFromFile.LLM.MaxTokens).
+				SetModel(container.ConfigFromFile.LLM.Provider, container.ConfigFromFile.LLM.Model).
+				SetLogContent(container.ConfigFromFile.LLM.LogContent)
+
+			ctx := cmd.Context()
+			if input.oneShot() {
+				container.Telemetry.Add(telemetryEvent.QuestionAsked())
+			} else {
+				container.Telemetry.Add(telemetryEvent.ChatStarted())
+			}
+
+			if input.oneShot() {
+				return ask(ctx, chatService, question, output, os.Stdout)
+			}
+
 			chatSession, err := NewChatSession(&container.ConfigFromFile, container.ThemeMgr.GetCurrentTheme(), chatService, chatHistoryService)
 			if err != nil {
 				container.Logger.WithField(logger.ErrorKey, err).Error("error creating chat session")
 				return fmt.Errorf("error creating chat session: %w", err)
 			}
 
-			ctx := context.Background()
-			if container.ConfigFromFile.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					ctx,
-					container.Config,
-					"cmd.chat",
-					telemetry.SeverityInfo, "Starting chat session",
-					nil,
-				)
+			if !container.ConfigFromFile.LLM.RequiresToken() {
+				container.ThemeMgr.GetCurrentTheme().Warning().Println(fmt.Sprintf("Using the %s provider, answers don't come from a real model", container.ConfigFromFile.LLM.Provider))
 			}
 
+			// Without this, writing to stdout piped into a program that has exited, such as
+			// head, kills Echoy. Ignored, the write fails and the session ends cleanly.
+			signal.Ignore(syscall.SIGPIPE)
+
 			return chatSession.Start(ctx)
 		},
 	}
 
+	cmd.Flags().StringVarP(&prompt, "prompt", "p", "", "ask a single question and print the answer instead of starting a session")
+	output = cli.NewOutput(cmd, container.ThemeMgr)
+
 	return cmd
 }
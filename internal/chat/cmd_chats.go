@@ -0,0 +1,168 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/history"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// NewChatsCmd creates the command group for managing stored chat histories
+func NewChatsCmd(container *cli.Container) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chats",
+		Short: "Manage stored chat histories",
+		Long:  `Export and import the chat histories stored by Echoy, e.g. for backups or moving to another machine.`,
+	}
+
+	cmd.AddCommand(newChatsExportCmd(container), newChatsImportCmd(container), newChatsRepairCmd(container))
+
+	return cmd
+}
+
+func newChatsExportCmd(container *cli.Container) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export <file>",
+		Short: "Export all chat histories to a JSON file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withHistoryChatService(container, func(chatService *ServiceImpl) error {
+				export, err := chatService.ExportChats(cmd.Context())
+				if err != nil {
+					container.Logger.WithField(logger.ErrorKey, err).Error("error exporting chats")
+					return fmt.Errorf("error exporting chats: %w", err)
+				}
+
+				data, err := json.MarshalIndent(export, "", "  ")
+				if err != nil {
+					return fmt.Errorf("error encoding chat export: %w", err)
+				}
+
+				if err := os.WriteFile(args[0], data, 0600); err != nil {
+					container.Logger.WithField(logger.ErrorKey, err).Error("error writing chat export")
+					return fmt.Errorf("error writing chat export: %w", err)
+				}
+
+				container.ThemeMgr.GetCurrentTheme().Success().Println(fmt.Sprintf("Exported %d chat(s) to %s", len(export.Chats), args[0]))
+				return nil
+			})
+		},
+	}
+}
+
+func newChatsImportCmd(container *cli.Container) *cobra.Command {
+	var onConflict string
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import chat histories from a JSON export",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("error reading chat export: %w", err)
+			}
+
+			var export types.ChatExport
+			if err := json.Unmarshal(data, &export); err != nil {
+				return fmt.Errorf("error decoding chat export %s: %w", args[0], err)
+			}
+
+			return withHistoryChatService(container, func(chatService *ServiceImpl) error {
+				result, err := chatService.ImportChats(cmd.Context(), export, types.ImportConflictStrategy(onConflict))
+				if err != nil {
+					container.Logger.WithField(logger.ErrorKey, err).Error("error importing chats")
+					return fmt.Errorf("error importing chats: %w", err)
+				}
+
+				container.Logger.WithFields(map[string]interface{}{
+					"imported": result.Imported,
+					"skipped":  result.Skipped,
+					"remapped": result.Remapped,
+				}).Info("chats imported")
+
+				container.ThemeMgr.GetCurrentTheme().Success().Println(fmt.Sprintf(
+					"Imported %d chat(s), %d skipped, %d imported under a new ID",
+					result.Imported, result.Skipped, result.Remapped,
+				))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(types.ImportConflictSkip), "what to do with chats that already exist: skip or remap")
+
+	return cmd
+}
+
+func newChatsRepairCmd(container *cli.Container) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Check the chat history database and recreate it if it is corrupt",
+		Long: `Run a thorough integrity check of the chat history database. A corrupt database is
+moved aside and replaced by an empty one. Use --force to recreate it even if the check passes.
+The daemon must be stopped first, as it keeps the database open.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t := container.ThemeMgr.GetCurrentTheme()
+			dbPath := container.Paths[filesystem.ChatHistoryDB]
+
+			err := history.CheckIntegrity(dbPath, true)
+			switch {
+			case err == nil && !force:
+				t.Success().Println("The chat history database is healthy")
+				return nil
+			case err != nil && !errors.Is(err, history.ErrCorrupt):
+				container.Logger.WithField(logger.ErrorKey, err).Error("error checking chat history database")
+				return fmt.Errorf("error checking chat history database: %w (stop the daemon with 'echoy stop' if it is running)", err)
+			case err != nil:
+				t.Warning().Println(err.Error())
+			}
+
+			// The daemon would keep writing to the replaced file, so its chats would be lost
+			if container.DaemonRunning() {
+				return errors.New("the daemon has the chat history database open, stop it with 'echoy stop' before repairing it")
+			}
+
+			backupPath, err := history.Recreate(dbPath)
+			if err != nil {
+				container.Logger.WithField(logger.ErrorKey, err).Error("error recreating chat history database")
+				return fmt.Errorf("error recreating chat history database: %w", err)
+			}
+
+			container.Logger.WithFields(map[string]interface{}{
+				"db_file":     dbPath,
+				"backup_file": backupPath,
+			}).Info("chat history database recreated")
+
+			t.Success().Println(fmt.Sprintf("Created an empty chat history database, the previous one was moved to %s", backupPath))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "recreate the database even if it passes the check")
+
+	return cmd
+}
+
+// withHistoryChatService opens the chat history storage and runs fn with a chat service
+// backed by it. The service has no LLM, so only history operations may be used.
+func withHistoryChatService(container *cli.Container, fn func(chatService *ServiceImpl) error) error {
+	historyStorage, err := container.OpenHistoryStorage()
+	if err != nil {
+		container.Logger.WithField(logger.ErrorKey, err).Error("error opening chat history storage")
+		return fmt.Errorf("error opening chat history storage: %w", err)
+	}
+	defer historyStorage.Close()
+
+	return fn(NewChatService(nil, historyStorage, container.Logger))
+}
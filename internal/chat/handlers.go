@@ -1,32 +1,62 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/history"
+	"github.com/shaharia-lab/echoy/internal/llm"
 	"github.com/shaharia-lab/goai"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// streamChunkWriteTimeout bounds how long writing a single SSE chunk may take. It replaces
+// the server-wide write timeout for streams, which would otherwise cut long generations short.
+const streamChunkWriteTimeout = 30 * time.Second
+
 type ChatHandler struct {
 	ChatService Service
+	// DefaultStreaming makes HandleChatRequest stream the answer unless the client asks for JSON
+	DefaultStreaming bool
+	// MaxTokens is the maximum length of an answer, streamed answers using all of it are
+	// reported with types.FinishReasonLength. Zero means no known maximum.
+	MaxTokens int64
+	// Location is the timezone of the *_local fields of the chats in responses, they are
+	// left out when it is nil
+	Location *time.Location
+
+	streams   *streamRegistry
+	readiness readinessCache
 }
 
 func NewChatHandler(chatService Service) *ChatHandler {
 	return &ChatHandler{
 		ChatService: chatService,
+		streams:     newStreamRegistry(),
 	}
 }
 
-// HandleChatRequest handles incoming chat requests
+// HandleChatRequest handles incoming chat requests. It answers with a single JSON response
+// or streams the answer as server-sent events, as negotiated by wantsStream. An empty answer
+// from the model is reported with 502.
 func (h *ChatHandler) HandleChatRequest() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req types.ChatRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		req, ok := decodeChatRequest(w, r)
+		if !ok {
+			return
+		}
+
+		if h.wantsStream(r, req) {
+			h.streamChat(w, r, req)
 			return
 		}
 
@@ -38,6 +68,10 @@ func (h *ChatHandler) HandleChatRequest() http.HandlerFunc {
 		}
 
 		chatResponse, err := h.ChatService.Chat(ctx, chatSessionID, req.Question)
+		if errors.Is(err, ErrEmptyResponse) {
+			http.Error(w, `{"error": "The model returned no content"}`, http.StatusBadGateway)
+			return
+		}
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed to get chat response: %v", err), http.StatusInternalServerError)
 			return
@@ -51,82 +85,270 @@ func (h *ChatHandler) HandleChatRequest() http.HandlerFunc {
 	}
 }
 
-// HandleChatStreamRequest handles streaming chat requests
+// wantsStream decides whether HandleChatRequest streams the answer. Clients choose with
+// the stream field of the request, or else with the Accept header, text/event-stream for
+// a stream and application/json for a single JSON response. Without either,
+// DefaultStreaming applies.
+func (h *ChatHandler) wantsStream(r *http.Request, req types.ChatRequest) bool {
+	if req.Stream != nil {
+		return *req.Stream
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/event-stream"):
+		return true
+	case strings.Contains(accept, "application/json"):
+		return false
+	default:
+		return h.DefaultStreaming
+	}
+}
+
+// HandleChatStreamRequest handles streaming chat requests. It always streams, a request
+// with stream set to false is rejected in favour of HandleChatRequest.
 func (h *ChatHandler) HandleChatStreamRequest() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req types.ChatRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		req, ok := decodeChatRequest(w, r)
+		if !ok {
 			return
 		}
 
-		ctx := r.Context()
-
-		var chatSessionID uuid.UUID
-		if req.ChatUUID != uuid.Nil {
-			chatSessionID = req.ChatUUID
+		if req.Stream != nil && !*req.Stream {
+			writeJSON(w, http.StatusUnprocessableEntity, types.ValidationErrorResponse{
+				Error:  "invalid chat request",
+				Fields: []types.FieldError{{Field: "stream", Message: "must be true or omitted, use POST /api/v1/chats for a JSON response"}},
+			})
+			return
 		}
 
-		// Set proper headers for SSE
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("X-Accel-Buffering", "no")
-		w.Header().Set("Transfer-Encoding", "chunked")
+		h.streamChat(w, r, req)
+	}
+}
 
-		if chatSessionID != uuid.Nil {
-			w.Header().Set("X-MKit-Chat-UUID", chatSessionID.String())
-		}
+// streamChat answers req as server-sent events. Events carry incrementing IDs. Answers
+// in an existing chat are buffered and generated independently of the request, so a
+// client that loses the connection can send the request again with the Last-Event-ID
+// header and gets the rest of the answer instead of a new one.
+func (h *ChatHandler) streamChat(w http.ResponseWriter, r *http.Request, req types.ChatRequest) {
+	ctx := r.Context()
+
+	var chatSessionID uuid.UUID
+	if req.ChatUUID != uuid.Nil {
+		chatSessionID = req.ChatUUID
+	}
+
+	lastEventID, resuming, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, `{"error": "Invalid Last-Event-ID"}`, http.StatusBadRequest)
+		return
+	}
+	if resuming && chatSessionID == uuid.Nil {
+		http.Error(w, `{"error": "Resuming a stream requires the chat UUID"}`, http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
 
-		flusher, ok := w.(http.Flusher)
+	var buffer *streamBuffer
+	switch {
+	case resuming:
+		buffer, ok = h.streams.get(chatSessionID)
 		if !ok {
-			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			http.Error(w, `{"error": "No answer to resume, it may have finished already"}`, http.StatusNotFound)
 			return
 		}
 
-		streamChan, err := h.ChatService.ChatStreaming(ctx, chatSessionID, req.Question)
+	default:
+		// Without a chat UUID the answer can't be resumed, so it ends with the request
+		generationCtx := ctx
+		if chatSessionID != uuid.Nil {
+			generationCtx = context.WithoutCancel(ctx)
+		}
+		generationCtx, cancel := withStreamLimit(generationCtx, ctx)
+
+		streamChan, err := h.ChatService.ChatStreaming(generationCtx, chatSessionID, req.Question)
 		if err != nil {
+			cancel()
 			http.Error(w, fmt.Sprintf("failed to get chat stream: %v", err), http.StatusInternalServerError)
 			return
 		}
 
-		// Initial response to establish the connection
-		fmt.Fprintf(w, "data: %s\n\n", "{\"content\":\"\",\"done\":false}")
-		flusher.Flush()
+		if chatSessionID == uuid.Nil {
+			buffer = newStreamBuffer()
+		} else {
+			buffer = h.streams.start(chatSessionID)
+		}
+		go func() {
+			defer cancel()
+			bufferStream(generationCtx, streamChan, buffer, h.MaxTokens)
+			if chatSessionID != uuid.Nil {
+				h.streams.expire(chatSessionID, buffer)
+			}
+		}()
+	}
 
-		for streamResp := range streamChan {
-			if streamResp.Error != nil {
-				// Send error in SSE format
-				errMsg := fmt.Sprintf("{\"error\":\"%s\"}", streamResp.Error.Error())
-				fmt.Fprintf(w, "data: %s\n\n", errMsg)
-				flusher.Flush()
+	// Set proper headers for SSE
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Transfer-Encoding", "chunked")
+
+	if chatSessionID != uuid.Nil {
+		w.Header().Set("X-MKit-Chat-UUID", chatSessionID.String())
+	}
+
+	// Initial response to establish the connection. It has no ID, so it doesn't move the
+	// client's Last-Event-ID.
+	extendWriteDeadline(w)
+	fmt.Fprintf(w, "data: %s\n\n", "{\"content\":\"\",\"done\":false}")
+	flusher.Flush()
+
+	for {
+		events, done, err := buffer.next(ctx, lastEventID)
+		if err != nil {
+			return
+		}
+
+		for _, event := range events {
+			if err := writeStreamEvent(w, flusher, event); err != nil {
+				log.Printf("error writing stream chunk: %v", err)
 				return
 			}
+			lastEventID = event.ID
+		}
 
-			if err := writeStreamChunk(w, flusher, streamResp); err != nil {
-				log.Printf("error writing stream chunk: %v", err)
+		if done {
+			return
+		}
+	}
+}
+
+// parseLastEventID returns the ID in the Last-Event-ID header and whether it is set
+func parseLastEventID(r *http.Request) (int, bool, error) {
+	value := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return 0, false, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+
+	return id, true, nil
+}
+
+// bufferStream records the answer of streamChan in buffer. An error ends the answer, and
+// so does reaching the maximum stream duration, with a terminal event saying so. The rest
+// of the channel is only drained. The done event carries the finish reason, derived from
+// the tokens counted in the chunks and maxTokens; it is left out if none were counted.
+func bufferStream(ctx context.Context, streamChan <-chan goai.StreamingLLMResponse, buffer *streamBuffer, maxTokens int64) {
+	defer buffer.finish()
+
+	var outputTokens int
+
+	for {
+		select {
+		case streamResp, ok := <-streamChan:
+			if !ok {
 				return
 			}
+
+			// Only follows a complete answer, the stream ends with it
+			if errors.Is(streamResp.Error, ErrHistoryNotSaved) {
+				buffer.add(warningEventData(streamResp.Error.Error()))
+				continue
+			}
+
+			if streamResp.Error != nil {
+				reason := ""
+				if errors.Is(streamResp.Error, ErrGenerationCancelled) {
+					reason = types.FinishReasonCancelled
+				}
+				buffer.add(terminalEventData(streamResp.Error, reason))
+				buffer.finish()
+				for range streamChan {
+				}
+				return
+			}
+
+			outputTokens += streamResp.TokenCount
+			reason := ""
+			if streamResp.Done {
+				reason = finishReason(outputTokens, maxTokens)
+			}
+
+			chunkData, err := streamChunkData(streamResp, reason)
+			if err != nil {
+				log.Printf("error encoding stream chunk: %v", err)
+				continue
+			}
+			buffer.add(chunkData)
+
+		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), errStreamDurationExceeded) {
+				buffer.add(terminalEventData(errStreamDurationExceeded, types.FinishReasonMaxDuration))
+			}
+			buffer.finish()
+			for range streamChan {
+			}
+			return
 		}
 	}
 }
 
-func writeStreamChunk(w http.ResponseWriter, flusher http.Flusher, streamResp goai.StreamingLLMResponse) error {
+// terminalEventData encodes the last event of an answer ended early by err. finishReason
+// is left out if it is empty.
+func terminalEventData(err error, finishReason string) string {
+	terminal, _ := json.Marshal(struct {
+		Error        string `json:"error"`
+		Done         bool   `json:"done"`
+		FinishReason string `json:"finish_reason,omitempty"`
+	}{Error: err.Error(), Done: true, FinishReason: finishReason})
+
+	return string(terminal)
+}
+
+// warningEventData encodes the event reporting a problem after the answer was complete
+func warningEventData(warning string) string {
+	data, _ := json.Marshal(struct {
+		Warning string `json:"warning"`
+	}{Warning: warning})
+
+	return string(data)
+}
+
+// streamChunkData encodes a chunk of a streamed answer. finishReason is only set for the
+// last one.
+func streamChunkData(streamResp goai.StreamingLLMResponse, finishReason string) (string, error) {
 	response := struct {
-		Content string `json:"content"`
-		MetaKey string `json:"meta_key,omitempty"`
-		Done    bool   `json:"done,omitempty"`
+		Content      string `json:"content"`
+		MetaKey      string `json:"meta_key,omitempty"`
+		Done         bool   `json:"done,omitempty"`
+		FinishReason string `json:"finish_reason,omitempty"`
 	}{
-		Content: streamResp.Text,
-		Done:    streamResp.Done,
+		Content:      streamResp.Text,
+		Done:         streamResp.Done,
+		FinishReason: finishReason,
 	}
 
 	chunkData, err := json.Marshal(response)
 	if err != nil {
-		return fmt.Errorf("failed to marshal stream chunk: %w", err)
+		return "", fmt.Errorf("failed to marshal stream chunk: %w", err)
 	}
 
-	if _, err := fmt.Fprintf(w, "data: %s\n\n", chunkData); err != nil {
+	return string(chunkData), nil
+}
+
+func writeStreamEvent(w http.ResponseWriter, flusher http.Flusher, event streamEvent) error {
+	extendWriteDeadline(w)
+	if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.ID, event.Data); err != nil {
 		return fmt.Errorf("error writing response: %w", err)
 	}
 
@@ -134,6 +356,39 @@ func writeStreamChunk(w http.ResponseWriter, flusher http.Flusher, streamResp go
 	return nil
 }
 
+// extendWriteDeadline pushes the connection's write deadline forward by streamChunkWriteTimeout
+func extendWriteDeadline(w http.ResponseWriter) {
+	err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(streamChunkWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("failed to extend stream write deadline: %v", err)
+	}
+}
+
+// readinessTimeout bounds the LLM health check behind the readiness endpoint
+const readinessTimeout = 10 * time.Second
+
+// ReadinessResponse is the body of the readiness endpoint
+type ReadinessResponse struct {
+	Status string        `json:"status"`
+	Kind   llm.ErrorKind `json:"kind,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// HandleReadinessRequest reports whether the configured LLM is usable. It responds with
+// 503 and the classified error if the health check fails. The result is reused for
+// readinessCacheTTL, so frequent probes don't each make a billed completion.
+func (h *ChatHandler) HandleReadinessRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, status := h.readiness.get(r.Context(), h.ChatService.HealthCheck)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("failed to encode readiness response: %v", err)
+		}
+	}
+}
+
 func (h *ChatHandler) HandleChatHistoryRequest() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -143,6 +398,9 @@ func (h *ChatHandler) HandleChatHistoryRequest() http.HandlerFunc {
 			http.Error(w, fmt.Sprintf("failed to get chat history: %v", err), http.StatusInternalServerError)
 			return
 		}
+		for i := range chatHistories.Chats {
+			chatHistories.Chats[i].Localize(h.Location)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(chatHistories); err != nil {
@@ -152,6 +410,227 @@ func (h *ChatHandler) HandleChatHistoryRequest() http.HandlerFunc {
 	}
 }
 
+// HandleChatSearchRequest handles requests to search chat histories by message content
+func (h *ChatHandler) HandleChatSearchRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			http.Error(w, `{"error": "Search query is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		searchResults, err := h.ChatService.SearchChats(r.Context(), query)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to search chats: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for i := range searchResults.Results {
+			searchResults.Results[i].Chat.Localize(h.Location)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(searchResults); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// HandleNewChatRequest handles requests to create an empty chat. It answers 201 with the
+// UUID of the chat, which the first message is then sent with.
+func (h *ChatHandler) HandleNewChatRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatUUID, err := h.ChatService.CreateChat(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to create chat: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, types.NewChatResponse{ChatUUID: chatUUID})
+	}
+}
+
+// HandleChatRenameRequest handles requests to change the title of a chat
+func (h *ChatHandler) HandleChatRenameRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parsedChatUUID, err := uuid.Parse(chi.URLParam(r, "chatId"))
+		if err != nil {
+			http.Error(w, `{"error": "Invalid chat ID"}`, http.StatusBadRequest)
+			return
+		}
+
+		var req types.ChatRenameRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if strings.TrimSpace(req.Title) == "" {
+			http.Error(w, `{"error": "Title is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := h.ChatService.RenameChat(r.Context(), parsedChatUUID, req.Title); err != nil {
+			if errors.Is(err, history.ErrChatNotFound) {
+				http.Error(w, `{"error": "Chat not found"}`, http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("failed to rename chat: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleChatDeleteRequest handles requests to delete a chat. It answers with 204, or 404 if
+// there is no such chat.
+func (h *ChatHandler) HandleChatDeleteRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parsedChatUUID, err := uuid.Parse(chi.URLParam(r, "chatId"))
+		if err != nil {
+			http.Error(w, `{"error": "Invalid chat ID"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := h.ChatService.DeleteChat(r.Context(), parsedChatUUID); err != nil {
+			if errors.Is(err, history.ErrChatNotFound) {
+				http.Error(w, `{"error": "Chat not found"}`, http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("failed to delete chat: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleChatContinueRequest handles requests to continue the last answer of a chat, e.g.
+// one that ended with finish_reason "length". It answers with the continuation like
+// HandleChatRequest, or 409 if the chat doesn't end with an answer and 502 if the
+// continuation is empty.
+func (h *ChatHandler) HandleChatContinueRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parsedChatUUID, err := uuid.Parse(chi.URLParam(r, "chatId"))
+		if err != nil {
+			http.Error(w, `{"error": "Invalid chat ID"}`, http.StatusBadRequest)
+			return
+		}
+
+		chatResponse, err := h.ChatService.Continue(r.Context(), parsedChatUUID)
+		if err != nil {
+			switch {
+			case errors.Is(err, history.ErrChatNotFound):
+				http.Error(w, `{"error": "Chat not found"}`, http.StatusNotFound)
+			case errors.Is(err, ErrNothingToContinue):
+				http.Error(w, `{"error": "The chat has no answer to continue"}`, http.StatusConflict)
+			case errors.Is(err, ErrEmptyResponse):
+				http.Error(w, `{"error": "The model returned no content"}`, http.StatusBadGateway)
+			default:
+				http.Error(w, fmt.Sprintf("failed to continue chat: %v", err), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, chatResponse)
+	}
+}
+
+// HandleChatForkRequest handles requests to start a new chat from the messages of another,
+// up to the upto_message_index of the optional body. It answers 201 with the UUID of the
+// new chat like HandleNewChatRequest, or 422 if the chat has no message at the index.
+func (h *ChatHandler) HandleChatForkRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parsedChatUUID, err := uuid.Parse(chi.URLParam(r, "chatId"))
+		if err != nil {
+			http.Error(w, `{"error": "Invalid chat ID"}`, http.StatusBadRequest)
+			return
+		}
+
+		var req types.ChatForkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		uptoMessageIndex := -1
+		if req.UptoMessageIndex != nil {
+			if *req.UptoMessageIndex < 0 {
+				writeJSON(w, http.StatusUnprocessableEntity, types.ValidationErrorResponse{
+					Error:  "invalid fork request",
+					Fields: []types.FieldError{{Field: "upto_message_index", Message: "must not be negative"}},
+				})
+				return
+			}
+			uptoMessageIndex = *req.UptoMessageIndex
+		}
+
+		forkUUID, err := h.ChatService.ForkChat(r.Context(), parsedChatUUID, uptoMessageIndex)
+		if err != nil {
+			switch {
+			case errors.Is(err, history.ErrChatNotFound):
+				http.Error(w, `{"error": "Chat not found"}`, http.StatusNotFound)
+			case errors.Is(err, ErrInvalidMessageIndex):
+				writeJSON(w, http.StatusUnprocessableEntity, types.ValidationErrorResponse{
+					Error:  "invalid fork request",
+					Fields: []types.FieldError{{Field: "upto_message_index", Message: "the chat has no message at this index"}},
+				})
+			default:
+				http.Error(w, fmt.Sprintf("failed to fork chat: %v", err), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, types.NewChatResponse{ChatUUID: forkUUID})
+	}
+}
+
+// HandleChatCancelRequest handles requests to stop the answer being generated in a chat,
+// for a "stop generating" button. It answers 204 once the generation is cancelled, or 404
+// if nothing is being generated.
+func (h *ChatHandler) HandleChatCancelRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parsedChatUUID, err := uuid.Parse(chi.URLParam(r, "chatId"))
+		if err != nil {
+			http.Error(w, `{"error": "Invalid chat ID"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := h.ChatService.Cancel(parsedChatUUID); err != nil {
+			if errors.Is(err, ErrNotGenerating) {
+				http.Error(w, `{"error": "No answer is being generated in this chat"}`, http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("failed to cancel chat: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleChatExportRequest handles requests to download all chat histories as a JSON export
+func (h *ChatHandler) HandleChatExportRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		export, err := h.ChatService.ExportChats(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to export chats: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="echoy-chats-%s.json"`, export.ExportedAt.Format("20060102-150405")))
+		if err := json.NewEncoder(w).Encode(export); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
 // HandleChatByIDRequest handles requests to get a chat by its ID
 func (h *ChatHandler) HandleChatByIDRequest() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -176,8 +655,11 @@ func (h *ChatHandler) HandleChatByIDRequest() http.HandlerFunc {
 			return
 		}
 
+		chat := types.NewChat(*chatHistory)
+		chat.Localize(h.Location)
+
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(chatHistory); err != nil {
+		if err := json.NewEncoder(w).Encode(chat); err != nil {
 			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,489 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	chatMock "github.com/shaharia-lab/echoy/internal/chat/mocks"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/history"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChatHandler_HandleChatRequest_Negotiation(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		accept           string
+		defaultStreaming bool
+		wantStream       bool
+	}{
+		{name: "default buffered", accept: "", defaultStreaming: false, wantStream: false},
+		{name: "default streaming", accept: "*/*", defaultStreaming: true, wantStream: true},
+		{name: "client asks for JSON", accept: "application/json, text/plain, */*", defaultStreaming: true, wantStream: false},
+		{name: "client asks for a stream", accept: "text/event-stream", defaultStreaming: false, wantStream: true},
+		{name: "stream field wins over Accept", body: `{"question":"Hello","stream":true}`, accept: "application/json", wantStream: true},
+		{name: "stream field false wins over default", body: `{"question":"Hello","stream":false}`, defaultStreaming: true, wantStream: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatService := chatMock.NewMockService(t)
+			if tt.wantStream {
+				stream := make(chan goai.StreamingLLMResponse, 1)
+				stream <- goai.StreamingLLMResponse{Text: "Hi", Done: true}
+				close(stream)
+				chatService.EXPECT().ChatStreaming(mock.Anything, uuid.Nil, "Hello").Return(stream, nil)
+			} else {
+				chatService.EXPECT().Chat(mock.Anything, uuid.Nil, "Hello").Return(types.ChatResponse{Answer: "Hi"}, nil)
+			}
+
+			handler := NewChatHandler(chatService)
+			handler.DefaultStreaming = tt.defaultStreaming
+
+			body := tt.body
+			if body == "" {
+				body = `{"question":"Hello"}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/chats", strings.NewReader(body))
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.HandleChatRequest()(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			if tt.wantStream {
+				assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+			} else {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+			assert.Contains(t, rec.Body.String(), "Hi")
+		})
+	}
+}
+
+func TestChatHandler_StreamResume(t *testing.T) {
+	chatUUID := uuid.New()
+	body := `{"question":"Hello","chat_uuid":"` + chatUUID.String() + `"}`
+
+	stream := make(chan goai.StreamingLLMResponse)
+	chatService := chatMock.NewMockService(t)
+	chatService.EXPECT().ChatStreaming(mock.Anything, chatUUID, "Hello").Return(stream, nil).Once()
+
+	handler := NewChatHandler(chatService)
+	request := func(ctx context.Context, lastEventID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chats/stream", strings.NewReader(body)).WithContext(ctx)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		rec := httptest.NewRecorder()
+		handler.HandleChatStreamRequest()(rec, req)
+		return rec
+	}
+
+	// The client is gone before the first chunk, the answer is generated anyway
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := request(gone, "")
+	assert.Equal(t, chatUUID.String(), rec.Header().Get("X-MKit-Chat-UUID"))
+	assert.NotContains(t, rec.Body.String(), "id:")
+
+	stream <- goai.StreamingLLMResponse{Text: "Hi", TokenCount: 1}
+	stream <- goai.StreamingLLMResponse{Text: " there", TokenCount: 1}
+	stream <- goai.StreamingLLMResponse{Done: true}
+	close(stream)
+
+	rec = request(context.Background(), "0")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "id: 1\ndata: {\"content\":\"Hi\"}\n\n")
+	assert.Contains(t, rec.Body.String(), "id: 3\ndata: {\"content\":\"\",\"done\":true,\"finish_reason\":\"stop\"}\n\n")
+
+	rec = request(context.Background(), "1")
+	assert.NotContains(t, rec.Body.String(), "id: 1\n", "events the client already has should not be sent again")
+	assert.Contains(t, rec.Body.String(), "id: 2\ndata: {\"content\":\" there\"}\n\n")
+	assert.Contains(t, rec.Body.String(), "id: 3\n")
+}
+
+func TestChatHandler_StreamResume_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		lastEventID string
+		wantStatus  int
+	}{
+		{name: "unknown stream", body: `{"question":"Hello","chat_uuid":"` + uuid.NewString() + `"}`, lastEventID: "3", wantStatus: http.StatusNotFound},
+		{name: "without chat UUID", body: `{"question":"Hello"}`, lastEventID: "3", wantStatus: http.StatusBadRequest},
+		{name: "invalid ID", body: `{"question":"Hello","chat_uuid":"` + uuid.NewString() + `"}`, lastEventID: "abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewChatHandler(chatMock.NewMockService(t))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/chats/stream", strings.NewReader(tt.body))
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+			rec := httptest.NewRecorder()
+
+			handler.HandleChatStreamRequest()(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestChatHandler_HandleNewChatRequest(t *testing.T) {
+	chatUUID := uuid.New()
+	chatService := chatMock.NewMockService(t)
+	chatService.EXPECT().CreateChat(mock.Anything).Return(chatUUID, nil).Once()
+
+	rec := httptest.NewRecorder()
+	NewChatHandler(chatService).HandleNewChatRequest()(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/new", nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"chat_uuid":"`+chatUUID.String()+`"}`, rec.Body.String())
+}
+
+func TestChatHandler_HandleChatByIDRequest_LocalTimes(t *testing.T) {
+	chatUUID := uuid.New()
+	createdAt := time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC)
+	chatService := chatMock.NewMockService(t)
+	chatService.EXPECT().GetChatHistory(mock.Anything, chatUUID).Return(&history.Chat{
+		ChatHistory: goai.ChatHistory{
+			UUID:      chatUUID,
+			CreatedAt: createdAt,
+			Messages: []goai.ChatHistoryMessage{
+				{LLMMessage: goai.LLMMessage{Role: goai.UserRole, Text: "Hi"}, GeneratedAt: createdAt.Add(time.Minute)},
+				{LLMMessage: goai.LLMMessage{Role: goai.AssistantRole, Text: "Hel"}, GeneratedAt: createdAt.Add(2 * time.Minute)},
+			},
+		},
+		Title:           "Greeting",
+		PartialMessages: []int{1},
+	}, nil).Once()
+
+	handler := NewChatHandler(chatService)
+	handler.Location = time.FixedZone("CET", 3600)
+	router := chi.NewRouter()
+	router.Get("/api/v1/chats/{chatId}", handler.HandleChatByIDRequest())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/chats/"+chatUUID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"uuid": "`+chatUUID.String()+`",
+		"title": "Greeting",
+		"created_at": "2026-03-01T22:30:00Z",
+		"created_at_local": "2026-03-01 23:30:00 CET",
+		"partial_messages": [1],
+		"messages": [
+			{"Role": "user", "Text": "Hi", "generated_at": "2026-03-01T22:31:00Z", "generated_at_local": "2026-03-01 23:31:00 CET"},
+			{"Role": "assistant", "Text": "Hel", "generated_at": "2026-03-01T22:32:00Z", "generated_at_local": "2026-03-01 23:32:00 CET", "partial": true}
+		]
+	}`, rec.Body.String(), "the local times should be added to the canonical UTC ones")
+}
+
+func TestChatHandler_HandleChatContinueRequest(t *testing.T) {
+	chatUUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		chatID     string
+		err        error
+		wantStatus int
+	}{
+		{name: "continues the answer", chatID: chatUUID.String(), wantStatus: http.StatusOK},
+		{name: "invalid chat ID", chatID: "not-a-uuid", wantStatus: http.StatusBadRequest},
+		{name: "unknown chat", chatID: chatUUID.String(), err: fmt.Errorf("failed to load chat: %w", history.ErrChatNotFound), wantStatus: http.StatusNotFound},
+		{name: "nothing to continue", chatID: chatUUID.String(), err: ErrNothingToContinue, wantStatus: http.StatusConflict},
+		{name: "empty continuation", chatID: chatUUID.String(), err: fmt.Errorf("failed to generate response: %w", ErrEmptyResponse), wantStatus: http.StatusBadGateway},
+		{name: "LLM failure", chatID: chatUUID.String(), err: errors.New("provider down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatService := chatMock.NewMockService(t)
+			if tt.wantStatus != http.StatusBadRequest {
+				chatService.EXPECT().Continue(mock.Anything, chatUUID).
+					Return(types.ChatResponse{ChatUUID: chatUUID, Answer: "more", FinishReason: types.FinishReasonStop}, tt.err).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/api/v1/chats/{chatId}/continue", NewChatHandler(chatService).HandleChatContinueRequest())
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/"+tt.chatID+"/continue", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.JSONEq(t, `{"chat_uuid":"`+chatUUID.String()+`","answer":"more","input_token":0,"output_token":0,"finish_reason":"stop"}`, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestChatHandler_HandleChatForkRequest(t *testing.T) {
+	chatUUID := uuid.New()
+	forkUUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantIndex  int
+		err        error
+		wantStatus int
+	}{
+		{name: "forks the whole chat without a body", wantIndex: -1, wantStatus: http.StatusCreated},
+		{name: "forks up to the index", body: `{"upto_message_index":2}`, wantIndex: 2, wantStatus: http.StatusCreated},
+		{name: "negative index", body: `{"upto_message_index":-1}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "invalid body", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "index past the last message", body: `{"upto_message_index":9}`, wantIndex: 9, err: ErrInvalidMessageIndex, wantStatus: http.StatusUnprocessableEntity},
+		{name: "unknown chat", wantIndex: -1, err: fmt.Errorf("failed to load chat: %w", history.ErrChatNotFound), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatService := chatMock.NewMockService(t)
+			if tt.wantStatus == http.StatusCreated || tt.err != nil {
+				chatService.EXPECT().ForkChat(mock.Anything, chatUUID, tt.wantIndex).Return(forkUUID, tt.err).Once()
+			}
+
+			router := chi.NewRouter()
+			router.Post("/api/v1/chats/{chatId}/fork", NewChatHandler(chatService).HandleChatForkRequest())
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/"+chatUUID.String()+"/fork", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusCreated {
+				assert.JSONEq(t, `{"chat_uuid":"`+forkUUID.String()+`"}`, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestChatHandler_HandleChatCancelRequest(t *testing.T) {
+	chatUUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "cancels the answer", wantStatus: http.StatusNoContent},
+		{name: "nothing generating", err: fmt.Errorf("%w in chat %s", ErrNotGenerating, chatUUID), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatService := chatMock.NewMockService(t)
+			chatService.EXPECT().Cancel(chatUUID).Return(tt.err).Once()
+
+			router := chi.NewRouter()
+			router.Post("/api/v1/chats/{chatId}/cancel", NewChatHandler(chatService).HandleChatCancelRequest())
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/"+chatUUID.String()+"/cancel", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestChatHandler_HandleChatDeleteRequest(t *testing.T) {
+	chatUUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "deletes the chat", wantStatus: http.StatusNoContent},
+		{name: "unknown chat", err: fmt.Errorf("%w: %s", history.ErrChatNotFound, chatUUID), wantStatus: http.StatusNotFound},
+		{name: "storage failure", err: errors.New("database is locked"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatService := chatMock.NewMockService(t)
+			chatService.EXPECT().DeleteChat(mock.Anything, chatUUID).Return(tt.err).Once()
+
+			router := chi.NewRouter()
+			router.Delete("/api/v1/chats/{chatId}", NewChatHandler(chatService).HandleChatDeleteRequest())
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/chats/"+chatUUID.String(), nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestChatHandler_HandleReadinessRequest_Cached(t *testing.T) {
+	chatService := chatMock.NewMockService(t)
+	chatService.EXPECT().HealthCheck(mock.Anything).Return(errors.New("connection refused")).Once()
+
+	handler := NewChatHandler(chatService)
+	probe := func(ctx context.Context) int {
+		rec := httptest.NewRecorder()
+		handler.HandleReadinessRequest()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx))
+		return rec.Code
+	}
+
+	// The second probe reuses the result of the first
+	assert.Equal(t, http.StatusServiceUnavailable, probe(context.Background()))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(context.Background()))
+
+	// Checked again once the result expires, a check cut short by the client isn't kept
+	handler.readiness.checkedAt = time.Now().Add(-readinessCacheTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	chatService.EXPECT().HealthCheck(mock.Anything).RunAndReturn(func(context.Context) error {
+		cancel()
+		return context.Canceled
+	}).Once()
+	assert.Equal(t, http.StatusServiceUnavailable, probe(ctx))
+
+	chatService.EXPECT().HealthCheck(mock.Anything).Return(nil).Once()
+	assert.Equal(t, http.StatusOK, probe(context.Background()))
+	assert.Equal(t, http.StatusOK, probe(context.Background()))
+}
+
+func TestBufferStream_Cancelled(t *testing.T) {
+	stream := make(chan goai.StreamingLLMResponse, 2)
+	stream <- goai.StreamingLLMResponse{Text: "Once"}
+	stream <- goai.StreamingLLMResponse{Error: ErrGenerationCancelled, Done: true}
+	close(stream)
+
+	buffer := newStreamBuffer()
+	bufferStream(context.Background(), stream, buffer, 0)
+
+	events, done, err := buffer.next(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []streamEvent{{ID: 2, Data: `{"error":"the answer was cancelled","done":true,"finish_reason":"cancelled"}`}}, events)
+}
+
+func TestBufferStream_Error(t *testing.T) {
+	stream := make(chan goai.StreamingLLMResponse, 2)
+	stream <- goai.StreamingLLMResponse{Text: "Once"}
+	stream <- goai.StreamingLLMResponse{Error: errors.New(`provider said "no"`), Done: true}
+	close(stream)
+
+	buffer := newStreamBuffer()
+	bufferStream(context.Background(), stream, buffer, 0)
+
+	events, done, err := buffer.next(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []streamEvent{{ID: 2, Data: `{"error":"provider said \"no\"","done":true}`}}, events)
+}
+
+func TestBufferStream_HistoryWarning(t *testing.T) {
+	stream := make(chan goai.StreamingLLMResponse, 2)
+	stream <- goai.StreamingLLMResponse{Text: "Hi", Done: true, TokenCount: 1}
+	stream <- goai.StreamingLLMResponse{Error: ErrHistoryNotSaved, Done: true}
+	close(stream)
+
+	buffer := newStreamBuffer()
+	bufferStream(context.Background(), stream, buffer, 0)
+
+	events, done, err := buffer.next(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []streamEvent{
+		{ID: 1, Data: `{"content":"Hi","done":true,"finish_reason":"stop"}`},
+		{ID: 2, Data: `{"warning":"the conversation could not be saved to chat history"}`},
+	}, events)
+}
+
+func TestBufferStream_FinishReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxTokens  int64
+		tokenCount int
+		wantDone   string
+	}{
+		{name: "completed", maxTokens: 10, tokenCount: 1, wantDone: `{"content":"","done":true,"finish_reason":"stop"}`},
+		{name: "cut off at the maximum tokens", maxTokens: 2, tokenCount: 1, wantDone: `{"content":"","done":true,"finish_reason":"length"}`},
+		{name: "no known maximum", maxTokens: 0, tokenCount: 1, wantDone: `{"content":"","done":true,"finish_reason":"stop"}`},
+		{name: "no token counts", maxTokens: 2, tokenCount: 0, wantDone: `{"content":"","done":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := make(chan goai.StreamingLLMResponse, 3)
+			stream <- goai.StreamingLLMResponse{Text: "Once", TokenCount: tt.tokenCount}
+			stream <- goai.StreamingLLMResponse{Text: " upon a", TokenCount: tt.tokenCount}
+			stream <- goai.StreamingLLMResponse{Done: true}
+			close(stream)
+
+			buffer := newStreamBuffer()
+			bufferStream(context.Background(), stream, buffer, tt.maxTokens)
+
+			events, done, err := buffer.next(context.Background(), 2)
+			assert.NoError(t, err)
+			assert.True(t, done)
+			assert.Equal(t, []streamEvent{{ID: 3, Data: tt.wantDone}}, events)
+		})
+	}
+}
+
+func TestChatHandler_StreamOutlivesWriteTimeout(t *testing.T) {
+	const writeTimeout = 200 * time.Millisecond
+
+	chatUUID := uuid.New()
+	stream := make(chan goai.StreamingLLMResponse)
+	chatService := chatMock.NewMockService(t)
+	chatService.EXPECT().ChatStreaming(mock.Anything, chatUUID, "Hello").Return(stream, nil).Once()
+
+	go func() {
+		defer close(stream)
+		for i := 0; i < 5; i++ {
+			time.Sleep(writeTimeout / 2)
+			stream <- goai.StreamingLLMResponse{Text: fmt.Sprintf("chunk %d ", i), TokenCount: 1}
+		}
+		stream <- goai.StreamingLLMResponse{Done: true}
+	}()
+
+	router := chi.NewRouter()
+	router.Post("/api/v1/chats/stream", NewChatHandler(chatService).HandleChatStreamRequest())
+	router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * writeTimeout)
+		fmt.Fprint(w, "too late")
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = writeTimeout
+	server.Start()
+	defer server.Close()
+
+	body := `{"question":"Hello","chat_uuid":"` + chatUUID.String() + `"}`
+	resp, err := http.Post(server.URL+"/api/v1/chats/stream", "application/json", strings.NewReader(body))
+	if !assert.NoError(t, err) {
+		return
+	}
+	streamed, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err, "the stream should not be cut off by the write timeout")
+	assert.Contains(t, string(streamed), "chunk 4")
+	assert.Contains(t, string(streamed), "\"done\":true")
+
+	resp, err = http.Get(server.URL + "/slow")
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	assert.Error(t, err, "a plain handler should still be bound by the write timeout")
+}
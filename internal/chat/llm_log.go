@@ -0,0 +1,114 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/goai"
+)
+
+// SetModel sets the provider and model the LLM calls are logged with
+func (s *ServiceImpl) SetModel(provider, model string) *ServiceImpl {
+	s.provider = provider
+	s.model = model
+	return s
+}
+
+// SetLogContent adds the messages sent to the LLM and its answers to the log of each
+// call. It is off by default, as they hold the whole conversation.
+func (s *ServiceImpl) SetLogContent(logContent bool) *ServiceImpl {
+	s.logContent = logContent
+	return s
+}
+
+// llmCall is what is logged of a call to the LLM
+type llmCall struct {
+	messages []goai.LLMMessage
+	started  time.Time
+	streamed bool
+	answer   string
+	// inputTokens is unknown for streamed answers, the providers only count their chunks
+	inputTokens  int
+	outputTokens int
+	finishReason string
+	err          error
+}
+
+// logLLMCall records the provider, model, token counts, duration and finish reason of
+// call, and its content if SetLogContent is on
+func (s *ServiceImpl) logLLMCall(call llmCall) {
+	fields := logger.Fields{
+		"provider":      s.provider,
+		"model":         s.model,
+		"streamed":      call.streamed,
+		"output_tokens": call.outputTokens,
+		"duration_ms":   time.Since(call.started).Milliseconds(),
+	}
+	if !call.streamed {
+		fields["input_tokens"] = call.inputTokens
+	}
+	if s.logContent {
+		fields["messages"] = call.messages
+		fields["answer"] = call.answer
+	}
+
+	if call.err != nil {
+		fields[logger.ErrorKey] = call.err
+		s.logger.WithFields(fields).Warn("LLM call failed")
+		return
+	}
+
+	if call.finishReason != "" {
+		fields["finish_reason"] = call.finishReason
+	}
+	s.logger.WithFields(fields).Info("LLM call finished")
+}
+
+// logStream forwards sourceChan and logs the streamed call once it ends
+func (s *ServiceImpl) logStream(ctx context.Context, call llmCall, sourceChan <-chan goai.StreamingLLMResponse) <-chan goai.StreamingLLMResponse {
+	responseChan := make(chan goai.StreamingLLMResponse)
+
+	logger.SafeGo(func() {
+		// Nobody reads the stream once ctx is done, the rest of it is drained so its sender
+		// doesn't block
+		defer func() {
+			for range sourceChan {
+			}
+		}()
+		defer close(responseChan)
+
+		var answer strings.Builder
+		defer func() {
+			call.answer = answer.String()
+			call.finishReason = finishReason(call.outputTokens, s.maxTokens)
+			if call.err == nil && ctx.Err() != nil {
+				if errors.Is(context.Cause(ctx), errStreamDurationExceeded) {
+					call.finishReason = types.FinishReasonMaxDuration
+				} else {
+					call.err = context.Cause(ctx)
+				}
+			}
+			s.logLLMCall(call)
+		}()
+
+		for response := range sourceChan {
+			answer.WriteString(response.Text)
+			call.outputTokens += response.TokenCount
+			if response.Error != nil {
+				call.err = cancelledError(ctx, response.Error)
+			}
+
+			select {
+			case responseChan <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, s.logger.WithField("goroutine", "log_stream"))
+
+	return responseChan
+}
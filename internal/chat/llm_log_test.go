@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	mocks2 "github.com/shaharia-lab/echoy/internal/llm/mocks"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	loggerMocks "github.com/shaharia-lab/echoy/internal/logger/mocks"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// expectCallLog makes mockLogger record the fields of the next LLM call log in fields
+func expectCallLog(mockLogger *loggerMocks.MockLogger, fields *logger.Fields, message string) {
+	mockLogger.EXPECT().WithFields(mock.Anything).Run(func(f logger.Fields) {
+		*fields = f
+	}).Return(mockLogger).Once()
+	if message == "LLM call failed" {
+		mockLogger.EXPECT().Warn(message).Return().Once()
+		return
+	}
+	mockLogger.EXPECT().Info(message).Return().Once()
+}
+
+func TestGenerate_LogsCall(t *testing.T) {
+	messages := []goai.LLMMessage{{Role: goai.UserRole, Text: "secret question"}}
+
+	tests := []struct {
+		name        string
+		logContent  bool
+		llmErr      error
+		wantMessage string
+	}{
+		{name: "metadata only by default", wantMessage: "LLM call finished"},
+		{name: "content when enabled", logContent: true, wantMessage: "LLM call finished"},
+		{name: "failed call", llmErr: errors.New("provider down"), wantMessage: "LLM call failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockLLMService := new(mocks2.MockService)
+			mockLogger := loggerMocks.NewMockLogger(t)
+			chatService := NewChatService(mockLLMService, nil, mockLogger).
+				SetModel("openai", "gpt-4o").
+				SetMaxTokens(2).
+				SetLogContent(tt.logContent)
+
+			mockLLMService.On("Generate", ctx, messages).
+				Return(goai.LLMResponse{Text: "secret answer", TotalInputToken: 5, TotalOutputToken: 2}, tt.llmErr)
+
+			var fields logger.Fields
+			expectCallLog(mockLogger, &fields, tt.wantMessage)
+
+			_, _ = chatService.generate(ctx, messages)
+
+			assert.Equal(t, "openai", fields["provider"])
+			assert.Equal(t, "gpt-4o", fields["model"])
+			assert.Contains(t, fields, "duration_ms")
+			if tt.llmErr != nil {
+				assert.Equal(t, tt.llmErr, fields[logger.ErrorKey])
+				return
+			}
+
+			assert.Equal(t, 5, fields["input_tokens"])
+			assert.Equal(t, 2, fields["output_tokens"])
+			assert.Equal(t, types.FinishReasonLength, fields["finish_reason"])
+			if tt.logContent {
+				assert.Equal(t, messages, fields["messages"])
+				assert.Equal(t, "secret answer", fields["answer"])
+			} else {
+				assert.NotContains(t, fields, "messages")
+				assert.NotContains(t, fields, "answer")
+			}
+		})
+	}
+}
+
+func TestGenerateStream_LogsCall(t *testing.T) {
+	messages := []goai.LLMMessage{{Role: goai.UserRole, Text: "Tell me a story"}}
+
+	t.Run("logs the tokens once the stream ends", func(t *testing.T) {
+		ctx := context.Background()
+		mockLLMService := new(mocks2.MockService)
+		mockLogger := loggerMocks.NewMockLogger(t)
+		chatService := NewChatService(mockLLMService, nil, mockLogger).SetModel("echo", "echo")
+
+		source := make(chan goai.StreamingLLMResponse, 3)
+		source <- goai.StreamingLLMResponse{Text: "Once", TokenCount: 1}
+		source <- goai.StreamingLLMResponse{Text: " upon", TokenCount: 1}
+		source <- goai.StreamingLLMResponse{Done: true}
+		close(source)
+		mockLLMService.On("GenerateStream", ctx, messages).Return((<-chan goai.StreamingLLMResponse)(source), nil)
+
+		var fields logger.Fields
+		logged := make(chan struct{})
+		mockLogger.EXPECT().WithField("goroutine", "log_stream").Return(mockLogger).Once()
+		mockLogger.EXPECT().WithFields(mock.Anything).Run(func(f logger.Fields) { fields = f }).Return(mockLogger).Once()
+		mockLogger.EXPECT().Info("LLM call finished").Run(func(args ...interface{}) { close(logged) }).Return().Once()
+
+		stream, err := chatService.generateStream(ctx, messages)
+		require.NoError(t, err)
+		for range stream {
+		}
+		<-logged
+
+		assert.Equal(t, true, fields["streamed"])
+		assert.Equal(t, 2, fields["output_tokens"])
+		assert.NotContains(t, fields, "input_tokens")
+		assert.NotContains(t, fields, "answer")
+		assert.Equal(t, types.FinishReasonStop, fields["finish_reason"])
+	})
+
+	t.Run("reports the maximum stream duration", func(t *testing.T) {
+		ctx, cancel := context.WithTimeoutCause(context.Background(), time.Millisecond, errStreamDurationExceeded)
+		defer cancel()
+		mockLLMService := new(mocks2.MockService)
+		mockLogger := loggerMocks.NewMockLogger(t)
+		chatService := NewChatService(mockLLMService, nil, mockLogger)
+
+		// The source never ends, like a model still writing when the cap is reached
+		source := make(chan goai.StreamingLLMResponse)
+		mockLLMService.On("GenerateStream", ctx, messages).Return((<-chan goai.StreamingLLMResponse)(source), nil)
+
+		var fields logger.Fields
+		logged := make(chan struct{})
+		mockLogger.EXPECT().WithField("goroutine", "log_stream").Return(mockLogger).Once()
+		mockLogger.EXPECT().WithFields(mock.Anything).Run(func(f logger.Fields) { fields = f }).Return(mockLogger).Once()
+		mockLogger.EXPECT().Info("LLM call finished").Run(func(args ...interface{}) { close(logged) }).Return().Once()
+
+		_, err := chatService.generateStream(ctx, messages)
+		require.NoError(t, err)
+		go func() { source <- goai.StreamingLLMResponse{Text: "Once"} }()
+		<-logged
+
+		assert.Equal(t, types.FinishReasonMaxDuration, fields["finish_reason"])
+	})
+
+	t.Run("drains the source once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mockLLMService := new(mocks2.MockService)
+		mockLogger := loggerMocks.NewMockLogger(t)
+		chatService := NewChatService(mockLLMService, nil, mockLogger)
+
+		source := make(chan goai.StreamingLLMResponse)
+		mockLLMService.On("GenerateStream", ctx, messages).Return((<-chan goai.StreamingLLMResponse)(source), nil)
+
+		mockLogger.EXPECT().WithField("goroutine", "log_stream").Return(mockLogger).Once()
+		mockLogger.EXPECT().WithFields(mock.Anything).Return(mockLogger).Once()
+		mockLogger.EXPECT().Warn("LLM call failed").Return().Once()
+
+		_, err := chatService.generateStream(ctx, messages)
+		require.NoError(t, err)
+		cancel()
+
+		// The provider keeps sending for a moment after the cancellation, nobody reads it
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			for _, text := range []string{"Once", " upon", " a time"} {
+				source <- goai.StreamingLLMResponse{Text: text}
+			}
+			close(source)
+		}()
+
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("the sender of the stream should not be blocked")
+		}
+	})
+}
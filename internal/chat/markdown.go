@@ -0,0 +1,232 @@
+package chat
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/shaharia-lab/echoy/internal/theme"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listItemPattern  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	blockquotePrefix = regexp.MustCompile(`^\s*>\s?`)
+)
+
+// markdownRenderer renders the Markdown of an answer with the theme's styles. It renders
+// whole blocks: a paragraph once a blank line or another block ends it, a code block once
+// its closing fence arrives, and headings and list items once their line is complete.
+// This keeps streamed answers readable while tokens arrive in arbitrary pieces.
+type markdownRenderer struct {
+	theme theme.Theme
+	width int
+
+	line      strings.Builder
+	paragraph []string
+
+	inCode   bool
+	codeLang string
+	code     []string
+}
+
+// newMarkdownRenderer creates a renderer wrapping paragraphs at width columns, or not at
+// all if width is zero
+func newMarkdownRenderer(t theme.Theme, width int) *markdownRenderer {
+	return &markdownRenderer{theme: t, width: width}
+}
+
+// Write implements io.Writer. Complete lines are rendered as soon as the block they
+// belong to is complete, the rest is held back.
+func (m *markdownRenderer) Write(p []byte) (int, error) {
+	for _, r := range string(p) {
+		if r != '\n' {
+			m.line.WriteRune(r)
+			continue
+		}
+
+		line := m.line.String()
+		m.line.Reset()
+		m.renderLine(line)
+	}
+
+	return len(p), nil
+}
+
+// Flush renders everything held back, including an unterminated line or code block
+func (m *markdownRenderer) Flush() error {
+	if m.line.Len() > 0 {
+		line := m.line.String()
+		m.line.Reset()
+		m.renderLine(line)
+	}
+
+	m.flushParagraph()
+	if m.inCode {
+		m.flushCode()
+	}
+
+	return nil
+}
+
+func (m *markdownRenderer) renderLine(line string) {
+	trimmed := strings.TrimSpace(line)
+
+	if m.inCode {
+		if strings.HasPrefix(trimmed, codeFence) {
+			m.flushCode()
+			return
+		}
+		m.code = append(m.code, line)
+		return
+	}
+
+	if strings.HasPrefix(trimmed, codeFence) {
+		m.flushParagraph()
+		m.inCode = true
+		m.codeLang = strings.TrimSpace(strings.TrimPrefix(trimmed, codeFence))
+		return
+	}
+
+	if trimmed == "" {
+		m.flushParagraph()
+		m.theme.Subtle().Println()
+		return
+	}
+
+	if match := headingPattern.FindStringSubmatch(trimmed); match != nil {
+		m.flushParagraph()
+		m.renderInline(m.theme.Primary(), match[2], 0)
+		return
+	}
+
+	if match := listItemPattern.FindStringSubmatch(line); match != nil {
+		m.flushParagraph()
+
+		bullet := "•"
+		if !strings.ContainsAny(match[2], "-*+") {
+			bullet = match[2]
+		}
+		indent := strings.Repeat(" ", len(match[1]))
+		prefix := indent + bullet + " "
+
+		m.theme.Secondary().Print(prefix)
+		m.renderInline(m.theme.Subtle(), match[3], len(prefix))
+		return
+	}
+
+	if loc := blockquotePrefix.FindStringIndex(line); loc != nil && loc[0] == 0 {
+		m.flushParagraph()
+		m.theme.Disabled().Print("│ ")
+		m.renderInline(m.theme.Subtle(), line[loc[1]:], 2)
+		return
+	}
+
+	m.paragraph = append(m.paragraph, trimmed)
+}
+
+// flushParagraph renders the pending paragraph, joining its lines so it is wrapped as a whole
+func (m *markdownRenderer) flushParagraph() {
+	if len(m.paragraph) == 0 {
+		return
+	}
+
+	text := strings.Join(m.paragraph, " ")
+	m.paragraph = nil
+	m.renderInline(m.theme.Subtle(), text, 0)
+}
+
+// flushCode renders the pending code block as is, without wrapping or inline styles
+func (m *markdownRenderer) flushCode() {
+	if m.codeLang != "" {
+		m.theme.Disabled().Println(m.codeLang)
+	}
+	for _, line := range m.code {
+		m.theme.Info().Println("  " + line)
+	}
+
+	m.inCode = false
+	m.codeLang = ""
+	m.code = nil
+}
+
+// renderInline prints text as a single wrapped line with bold and inline code styled.
+// col is the column the text starts at.
+func (m *markdownRenderer) renderInline(base theme.StylePrinter, text string, col int) {
+	inline := &inlineWriter{theme: m.theme, base: base}
+	wrap := newWrapWriter(inline, m.width, col)
+
+	_, _ = io.WriteString(wrap, text)
+	_ = wrap.Flush()
+	inline.close()
+	base.Println()
+}
+
+// inlineWriter prints text in the base style, switching to the theme's styles for
+// **bold** and `code` spans. Spans may continue across writes.
+type inlineWriter struct {
+	theme theme.Theme
+	base  theme.StylePrinter
+
+	bold    bool
+	code    bool
+	pending strings.Builder
+	// star is a '*' held back until the next rune shows whether it starts or ends bold
+	star bool
+}
+
+// Write implements io.Writer
+func (w *inlineWriter) Write(p []byte) (int, error) {
+	for _, r := range string(p) {
+		if w.star {
+			w.star = false
+			if r == '*' && !w.code {
+				w.flush()
+				w.bold = !w.bold
+				continue
+			}
+			w.pending.WriteRune('*')
+		}
+
+		switch {
+		case r == '`':
+			w.flush()
+			w.code = !w.code
+		case r == '*' && !w.code:
+			w.star = true
+		default:
+			w.pending.WriteRune(r)
+		}
+	}
+
+	w.flush()
+	return len(p), nil
+}
+
+// close prints what is still held back at the end of the text
+func (w *inlineWriter) close() {
+	if w.star {
+		w.star = false
+		w.pending.WriteRune('*')
+	}
+	w.flush()
+}
+
+// flush prints the pending text in the current style
+func (w *inlineWriter) flush() {
+	if w.pending.Len() == 0 {
+		return
+	}
+
+	text := w.pending.String()
+	w.pending.Reset()
+
+	switch {
+	case w.code:
+		w.theme.Info().Print(text)
+	case w.bold:
+		w.theme.Primary().Print(text)
+	default:
+		w.base.Print(text)
+	}
+}
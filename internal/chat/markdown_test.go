@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderMarkdown(t *testing.T, width int, tokens ...string) string {
+	var buf bytes.Buffer
+	th := theme.NewDefaultTheme()
+	th.SetEnabled(false)
+	th.SetOutput(&buf)
+
+	renderer := newMarkdownRenderer(th, width)
+	for _, token := range tokens {
+		_, err := io.WriteString(renderer, token)
+		require.NoError(t, err)
+	}
+	require.NoError(t, renderer.Flush())
+
+	return buf.String()
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		tokens []string
+		want   string
+	}{
+		{
+			name:   "heading and paragraph",
+			tokens: []string{"## Setup\n", "Run the **install** step.\n"},
+			want:   "Setup\nRun the install step.\n",
+		},
+		{
+			name:   "paragraph lines are joined and wrapped",
+			width:  20,
+			tokens: []string{"The quick brown\nfox jumps over the lazy dog.\n\nNext"},
+			want:   "The quick brown fox\njumps over the lazy\ndog.\n\nNext\n",
+		},
+		{
+			name:   "lists",
+			tokens: []string{"- one\n* two\n  - nested\n1. first\n"},
+			want:   "• one\n• two\n  • nested\n1. first\n",
+		},
+		{
+			name:   "code blocks are kept as is",
+			width:  10,
+			tokens: []string{"```go\nfmt.Println(\"**not bold**\")\n```\n"},
+			want:   "go\n  fmt.Println(\"**not bold**\")\n",
+		},
+		{
+			name:   "inline code and bold split across tokens",
+			tokens: []string{"Use `go te", "st` and *", "*really** check"},
+			want:   "Use go test and really check\n",
+		},
+		{
+			name:   "single stars are kept",
+			tokens: []string{"2 * 3 = 6"},
+			want:   "2 * 3 = 6\n",
+		},
+		{
+			name:   "blockquote",
+			tokens: []string{"> quoted text\n"},
+			want:   "│ quoted text\n",
+		},
+		{
+			name:   "unterminated code block is flushed",
+			tokens: []string{"```\nls -la"},
+			want:   "  ls -la\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderMarkdown(t, tt.width, tt.tokens...))
+		})
+	}
+}
+
+func TestMarkdownRenderer_WaitsForCompleteBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	th := theme.NewDefaultTheme()
+	th.SetEnabled(false)
+	th.SetOutput(&buf)
+
+	renderer := newMarkdownRenderer(th, 0)
+
+	_, _ = io.WriteString(renderer, "first line\nsecond")
+	assert.Empty(t, buf.String(), "a paragraph must not be rendered before it ends")
+
+	_, _ = io.WriteString(renderer, " line\n\n```\ncode\n")
+	assert.Equal(t, "first line second line\n\n", buf.String(), "an open code block must be held back")
+
+	_, _ = io.WriteString(renderer, "```\n")
+	assert.Equal(t, "first line second line\n\n  code\n", buf.String())
+}
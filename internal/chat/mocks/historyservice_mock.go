@@ -5,7 +5,9 @@ package mocks
 import (
 	context "context"
 
+	history "github.com/shaharia-lab/echoy/internal/history"
 	goai "github.com/shaharia-lab/goai"
+
 	mock "github.com/stretchr/testify/mock"
 
 	uuid "github.com/google/uuid"
@@ -72,6 +74,54 @@ func (_c *MockHistoryService_AddMessage_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// AddPartialMessage provides a mock function with given fields: ctx, _a1, message
+func (_m *MockHistoryService) AddPartialMessage(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage) error {
+	ret := _m.Called(ctx, _a1, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddPartialMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error); ok {
+		r0 = rf(ctx, _a1, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockHistoryService_AddPartialMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddPartialMessage'
+type MockHistoryService_AddPartialMessage_Call struct {
+	*mock.Call
+}
+
+// AddPartialMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+//   - message goai.ChatHistoryMessage
+func (_e *MockHistoryService_Expecter) AddPartialMessage(ctx interface{}, _a1 interface{}, message interface{}) *MockHistoryService_AddPartialMessage_Call {
+	return &MockHistoryService_AddPartialMessage_Call{Call: _e.mock.On("AddPartialMessage", ctx, _a1, message)}
+}
+
+func (_c *MockHistoryService_AddPartialMessage_Call) Run(run func(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage)) *MockHistoryService_AddPartialMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(goai.ChatHistoryMessage))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_AddPartialMessage_Call) Return(_a0 error) *MockHistoryService_AddPartialMessage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHistoryService_AddPartialMessage_Call) RunAndReturn(run func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error) *MockHistoryService_AddPartialMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateChat provides a mock function with given fields: ctx
 func (_m *MockHistoryService) CreateChat(ctx context.Context) (*goai.ChatHistory, error) {
 	ret := _m.Called(ctx)
@@ -130,6 +180,53 @@ func (_c *MockHistoryService_CreateChat_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// DeleteChat provides a mock function with given fields: ctx, _a1
+func (_m *MockHistoryService) DeleteChat(ctx context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockHistoryService_DeleteChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteChat'
+type MockHistoryService_DeleteChat_Call struct {
+	*mock.Call
+}
+
+// DeleteChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockHistoryService_Expecter) DeleteChat(ctx interface{}, _a1 interface{}) *MockHistoryService_DeleteChat_Call {
+	return &MockHistoryService_DeleteChat_Call{Call: _e.mock.On("DeleteChat", ctx, _a1)}
+}
+
+func (_c *MockHistoryService_DeleteChat_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockHistoryService_DeleteChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_DeleteChat_Call) Return(_a0 error) *MockHistoryService_DeleteChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHistoryService_DeleteChat_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockHistoryService_DeleteChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetChat provides a mock function with given fields: ctx, _a1
 func (_m *MockHistoryService) GetChat(ctx context.Context, _a1 uuid.UUID) (*goai.ChatHistory, error) {
 	ret := _m.Called(ctx, _a1)
@@ -189,6 +286,112 @@ func (_c *MockHistoryService_GetChat_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// GetChatDetails provides a mock function with given fields: ctx, _a1
+func (_m *MockHistoryService) GetChatDetails(ctx context.Context, _a1 uuid.UUID) (*history.Chat, error) {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChatDetails")
+	}
+
+	var r0 *history.Chat
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*history.Chat, error)); ok {
+		return rf(ctx, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *history.Chat); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*history.Chat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockHistoryService_GetChatDetails_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChatDetails'
+type MockHistoryService_GetChatDetails_Call struct {
+	*mock.Call
+}
+
+// GetChatDetails is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockHistoryService_Expecter) GetChatDetails(ctx interface{}, _a1 interface{}) *MockHistoryService_GetChatDetails_Call {
+	return &MockHistoryService_GetChatDetails_Call{Call: _e.mock.On("GetChatDetails", ctx, _a1)}
+}
+
+func (_c *MockHistoryService_GetChatDetails_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockHistoryService_GetChatDetails_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_GetChatDetails_Call) Return(_a0 *history.Chat, _a1 error) *MockHistoryService_GetChatDetails_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockHistoryService_GetChatDetails_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*history.Chat, error)) *MockHistoryService_GetChatDetails_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportChat provides a mock function with given fields: ctx, _a1
+func (_m *MockHistoryService) ImportChat(ctx context.Context, _a1 history.Chat) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, history.Chat) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockHistoryService_ImportChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportChat'
+type MockHistoryService_ImportChat_Call struct {
+	*mock.Call
+}
+
+// ImportChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 history.Chat
+func (_e *MockHistoryService_Expecter) ImportChat(ctx interface{}, _a1 interface{}) *MockHistoryService_ImportChat_Call {
+	return &MockHistoryService_ImportChat_Call{Call: _e.mock.On("ImportChat", ctx, _a1)}
+}
+
+func (_c *MockHistoryService_ImportChat_Call) Run(run func(ctx context.Context, _a1 history.Chat)) *MockHistoryService_ImportChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(history.Chat))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_ImportChat_Call) Return(_a0 error) *MockHistoryService_ImportChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHistoryService_ImportChat_Call) RunAndReturn(run func(context.Context, history.Chat) error) *MockHistoryService_ImportChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListChatHistories provides a mock function with given fields: ctx
 func (_m *MockHistoryService) ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error) {
 	ret := _m.Called(ctx)
@@ -247,6 +450,171 @@ func (_c *MockHistoryService_ListChatHistories_Call) RunAndReturn(run func(conte
 	return _c
 }
 
+// ListChats provides a mock function with given fields: ctx
+func (_m *MockHistoryService) ListChats(ctx context.Context) ([]history.Chat, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChats")
+	}
+
+	var r0 []history.Chat
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]history.Chat, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []history.Chat); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]history.Chat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockHistoryService_ListChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListChats'
+type MockHistoryService_ListChats_Call struct {
+	*mock.Call
+}
+
+// ListChats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockHistoryService_Expecter) ListChats(ctx interface{}) *MockHistoryService_ListChats_Call {
+	return &MockHistoryService_ListChats_Call{Call: _e.mock.On("ListChats", ctx)}
+}
+
+func (_c *MockHistoryService_ListChats_Call) Run(run func(ctx context.Context)) *MockHistoryService_ListChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_ListChats_Call) Return(_a0 []history.Chat, _a1 error) *MockHistoryService_ListChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockHistoryService_ListChats_Call) RunAndReturn(run func(context.Context) ([]history.Chat, error)) *MockHistoryService_ListChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SearchChats provides a mock function with given fields: ctx, query
+func (_m *MockHistoryService) SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchChats")
+	}
+
+	var r0 []goai.ChatHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]goai.ChatHistory, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []goai.ChatHistory); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]goai.ChatHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockHistoryService_SearchChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchChats'
+type MockHistoryService_SearchChats_Call struct {
+	*mock.Call
+}
+
+// SearchChats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+func (_e *MockHistoryService_Expecter) SearchChats(ctx interface{}, query interface{}) *MockHistoryService_SearchChats_Call {
+	return &MockHistoryService_SearchChats_Call{Call: _e.mock.On("SearchChats", ctx, query)}
+}
+
+func (_c *MockHistoryService_SearchChats_Call) Run(run func(ctx context.Context, query string)) *MockHistoryService_SearchChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_SearchChats_Call) Return(_a0 []goai.ChatHistory, _a1 error) *MockHistoryService_SearchChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockHistoryService_SearchChats_Call) RunAndReturn(run func(context.Context, string) ([]goai.ChatHistory, error)) *MockHistoryService_SearchChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetChatTitle provides a mock function with given fields: ctx, _a1, title
+func (_m *MockHistoryService) SetChatTitle(ctx context.Context, _a1 uuid.UUID, title string) error {
+	ret := _m.Called(ctx, _a1, title)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetChatTitle")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, _a1, title)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockHistoryService_SetChatTitle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetChatTitle'
+type MockHistoryService_SetChatTitle_Call struct {
+	*mock.Call
+}
+
+// SetChatTitle is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+//   - title string
+func (_e *MockHistoryService_Expecter) SetChatTitle(ctx interface{}, _a1 interface{}, title interface{}) *MockHistoryService_SetChatTitle_Call {
+	return &MockHistoryService_SetChatTitle_Call{Call: _e.mock.On("SetChatTitle", ctx, _a1, title)}
+}
+
+func (_c *MockHistoryService_SetChatTitle_Call) Run(run func(ctx context.Context, _a1 uuid.UUID, title string)) *MockHistoryService_SetChatTitle_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockHistoryService_SetChatTitle_Call) Return(_a0 error) *MockHistoryService_SetChatTitle_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHistoryService_SetChatTitle_Call) RunAndReturn(run func(context.Context, uuid.UUID, string) error) *MockHistoryService_SetChatTitle_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockHistoryService creates a new instance of MockHistoryService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockHistoryService(t interface {
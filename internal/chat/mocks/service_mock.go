@@ -5,7 +5,9 @@ package mocks
 import (
 	context "context"
 
+	history "github.com/shaharia-lab/echoy/internal/history"
 	goai "github.com/shaharia-lab/goai"
+
 	mock "github.com/stretchr/testify/mock"
 
 	types "github.com/shaharia-lab/echoy/internal/chat/types"
@@ -26,6 +28,52 @@ func (_m *MockService) EXPECT() *MockService_Expecter {
 	return &MockService_Expecter{mock: &_m.Mock}
 }
 
+// Cancel provides a mock function with given fields: sessionID
+func (_m *MockService) Cancel(sessionID uuid.UUID) error {
+	ret := _m.Called(sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Cancel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
+		r0 = rf(sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Cancel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Cancel'
+type MockService_Cancel_Call struct {
+	*mock.Call
+}
+
+// Cancel is a helper method to define mock.On call
+//   - sessionID uuid.UUID
+func (_e *MockService_Expecter) Cancel(sessionID interface{}) *MockService_Cancel_Call {
+	return &MockService_Cancel_Call{Call: _e.mock.On("Cancel", sessionID)}
+}
+
+func (_c *MockService_Cancel_Call) Run(run func(sessionID uuid.UUID)) *MockService_Cancel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockService_Cancel_Call) Return(_a0 error) *MockService_Cancel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Cancel_Call) RunAndReturn(run func(uuid.UUID) error) *MockService_Cancel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Chat provides a mock function with given fields: ctx, sessionID, message
 func (_m *MockService) Chat(ctx context.Context, sessionID uuid.UUID, message string) (types.ChatResponse, error) {
 	ret := _m.Called(ctx, sessionID, message)
@@ -144,24 +192,302 @@ func (_c *MockService_ChatStreaming_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// Continue provides a mock function with given fields: ctx, sessionID
+func (_m *MockService) Continue(ctx context.Context, sessionID uuid.UUID) (types.ChatResponse, error) {
+	ret := _m.Called(ctx, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Continue")
+	}
+
+	var r0 types.ChatResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (types.ChatResponse, error)); ok {
+		return rf(ctx, sessionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) types.ChatResponse); ok {
+		r0 = rf(ctx, sessionID)
+	} else {
+		r0 = ret.Get(0).(types.ChatResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_Continue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Continue'
+type MockService_Continue_Call struct {
+	*mock.Call
+}
+
+// Continue is a helper method to define mock.On call
+//   - ctx context.Context
+//   - sessionID uuid.UUID
+func (_e *MockService_Expecter) Continue(ctx interface{}, sessionID interface{}) *MockService_Continue_Call {
+	return &MockService_Continue_Call{Call: _e.mock.On("Continue", ctx, sessionID)}
+}
+
+func (_c *MockService_Continue_Call) Run(run func(ctx context.Context, sessionID uuid.UUID)) *MockService_Continue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockService_Continue_Call) Return(_a0 types.ChatResponse, _a1 error) *MockService_Continue_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_Continue_Call) RunAndReturn(run func(context.Context, uuid.UUID) (types.ChatResponse, error)) *MockService_Continue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateChat provides a mock function with given fields: ctx
+func (_m *MockService) CreateChat(ctx context.Context) (uuid.UUID, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChat")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (uuid.UUID, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) uuid.UUID); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_CreateChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateChat'
+type MockService_CreateChat_Call struct {
+	*mock.Call
+}
+
+// CreateChat is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) CreateChat(ctx interface{}) *MockService_CreateChat_Call {
+	return &MockService_CreateChat_Call{Call: _e.mock.On("CreateChat", ctx)}
+}
+
+func (_c *MockService_CreateChat_Call) Run(run func(ctx context.Context)) *MockService_CreateChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_CreateChat_Call) Return(_a0 uuid.UUID, _a1 error) *MockService_CreateChat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_CreateChat_Call) RunAndReturn(run func(context.Context) (uuid.UUID, error)) *MockService_CreateChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteChat provides a mock function with given fields: ctx, chatUUID
+func (_m *MockService) DeleteChat(ctx context.Context, chatUUID uuid.UUID) error {
+	ret := _m.Called(ctx, chatUUID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, chatUUID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_DeleteChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteChat'
+type MockService_DeleteChat_Call struct {
+	*mock.Call
+}
+
+// DeleteChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - chatUUID uuid.UUID
+func (_e *MockService_Expecter) DeleteChat(ctx interface{}, chatUUID interface{}) *MockService_DeleteChat_Call {
+	return &MockService_DeleteChat_Call{Call: _e.mock.On("DeleteChat", ctx, chatUUID)}
+}
+
+func (_c *MockService_DeleteChat_Call) Run(run func(ctx context.Context, chatUUID uuid.UUID)) *MockService_DeleteChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockService_DeleteChat_Call) Return(_a0 error) *MockService_DeleteChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_DeleteChat_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockService_DeleteChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExportChats provides a mock function with given fields: ctx
+func (_m *MockService) ExportChats(ctx context.Context) (types.ChatExport, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportChats")
+	}
+
+	var r0 types.ChatExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (types.ChatExport, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) types.ChatExport); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(types.ChatExport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ExportChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportChats'
+type MockService_ExportChats_Call struct {
+	*mock.Call
+}
+
+// ExportChats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) ExportChats(ctx interface{}) *MockService_ExportChats_Call {
+	return &MockService_ExportChats_Call{Call: _e.mock.On("ExportChats", ctx)}
+}
+
+func (_c *MockService_ExportChats_Call) Run(run func(ctx context.Context)) *MockService_ExportChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_ExportChats_Call) Return(_a0 types.ChatExport, _a1 error) *MockService_ExportChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ExportChats_Call) RunAndReturn(run func(context.Context) (types.ChatExport, error)) *MockService_ExportChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ForkChat provides a mock function with given fields: ctx, sourceID, uptoMessageIndex
+func (_m *MockService) ForkChat(ctx context.Context, sourceID uuid.UUID, uptoMessageIndex int) (uuid.UUID, error) {
+	ret := _m.Called(ctx, sourceID, uptoMessageIndex)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForkChat")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int) (uuid.UUID, error)); ok {
+		return rf(ctx, sourceID, uptoMessageIndex)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int) uuid.UUID); ok {
+		r0 = rf(ctx, sourceID, uptoMessageIndex)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int) error); ok {
+		r1 = rf(ctx, sourceID, uptoMessageIndex)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ForkChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ForkChat'
+type MockService_ForkChat_Call struct {
+	*mock.Call
+}
+
+// ForkChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - sourceID uuid.UUID
+//   - uptoMessageIndex int
+func (_e *MockService_Expecter) ForkChat(ctx interface{}, sourceID interface{}, uptoMessageIndex interface{}) *MockService_ForkChat_Call {
+	return &MockService_ForkChat_Call{Call: _e.mock.On("ForkChat", ctx, sourceID, uptoMessageIndex)}
+}
+
+func (_c *MockService_ForkChat_Call) Run(run func(ctx context.Context, sourceID uuid.UUID, uptoMessageIndex int)) *MockService_ForkChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockService_ForkChat_Call) Return(_a0 uuid.UUID, _a1 error) *MockService_ForkChat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ForkChat_Call) RunAndReturn(run func(context.Context, uuid.UUID, int) (uuid.UUID, error)) *MockService_ForkChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetChatHistory provides a mock function with given fields: ctx, chatUUID
-func (_m *MockService) GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error) {
+func (_m *MockService) GetChatHistory(ctx context.Context, chatUUID uuid.UUID) (*history.Chat, error) {
 	ret := _m.Called(ctx, chatUUID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetChatHistory")
 	}
 
-	var r0 *goai.ChatHistory
+	var r0 *history.Chat
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*goai.ChatHistory, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*history.Chat, error)); ok {
 		return rf(ctx, chatUUID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *goai.ChatHistory); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *history.Chat); ok {
 		r0 = rf(ctx, chatUUID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*goai.ChatHistory)
+			r0 = ret.Get(0).(*history.Chat)
 		}
 	}
 
@@ -193,12 +519,12 @@ func (_c *MockService_GetChatHistory_Call) Run(run func(ctx context.Context, cha
 	return _c
 }
 
-func (_c *MockService_GetChatHistory_Call) Return(_a0 *goai.ChatHistory, _a1 error) *MockService_GetChatHistory_Call {
+func (_c *MockService_GetChatHistory_Call) Return(_a0 *history.Chat, _a1 error) *MockService_GetChatHistory_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockService_GetChatHistory_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*goai.ChatHistory, error)) *MockService_GetChatHistory_Call {
+func (_c *MockService_GetChatHistory_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*history.Chat, error)) *MockService_GetChatHistory_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -259,6 +585,215 @@ func (_c *MockService_GetListChatHistories_Call) RunAndReturn(run func(context.C
 	return _c
 }
 
+// HealthCheck provides a mock function with given fields: ctx
+func (_m *MockService) HealthCheck(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HealthCheck")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_HealthCheck_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HealthCheck'
+type MockService_HealthCheck_Call struct {
+	*mock.Call
+}
+
+// HealthCheck is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) HealthCheck(ctx interface{}) *MockService_HealthCheck_Call {
+	return &MockService_HealthCheck_Call{Call: _e.mock.On("HealthCheck", ctx)}
+}
+
+func (_c *MockService_HealthCheck_Call) Run(run func(ctx context.Context)) *MockService_HealthCheck_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_HealthCheck_Call) Return(_a0 error) *MockService_HealthCheck_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_HealthCheck_Call) RunAndReturn(run func(context.Context) error) *MockService_HealthCheck_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportChats provides a mock function with given fields: ctx, export, onConflict
+func (_m *MockService) ImportChats(ctx context.Context, export types.ChatExport, onConflict types.ImportConflictStrategy) (types.ChatImportResult, error) {
+	ret := _m.Called(ctx, export, onConflict)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportChats")
+	}
+
+	var r0 types.ChatImportResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.ChatExport, types.ImportConflictStrategy) (types.ChatImportResult, error)); ok {
+		return rf(ctx, export, onConflict)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, types.ChatExport, types.ImportConflictStrategy) types.ChatImportResult); ok {
+		r0 = rf(ctx, export, onConflict)
+	} else {
+		r0 = ret.Get(0).(types.ChatImportResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, types.ChatExport, types.ImportConflictStrategy) error); ok {
+		r1 = rf(ctx, export, onConflict)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_ImportChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportChats'
+type MockService_ImportChats_Call struct {
+	*mock.Call
+}
+
+// ImportChats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - export types.ChatExport
+//   - onConflict types.ImportConflictStrategy
+func (_e *MockService_Expecter) ImportChats(ctx interface{}, export interface{}, onConflict interface{}) *MockService_ImportChats_Call {
+	return &MockService_ImportChats_Call{Call: _e.mock.On("ImportChats", ctx, export, onConflict)}
+}
+
+func (_c *MockService_ImportChats_Call) Run(run func(ctx context.Context, export types.ChatExport, onConflict types.ImportConflictStrategy)) *MockService_ImportChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.ChatExport), args[2].(types.ImportConflictStrategy))
+	})
+	return _c
+}
+
+func (_c *MockService_ImportChats_Call) Return(_a0 types.ChatImportResult, _a1 error) *MockService_ImportChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_ImportChats_Call) RunAndReturn(run func(context.Context, types.ChatExport, types.ImportConflictStrategy) (types.ChatImportResult, error)) *MockService_ImportChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenameChat provides a mock function with given fields: ctx, chatUUID, title
+func (_m *MockService) RenameChat(ctx context.Context, chatUUID uuid.UUID, title string) error {
+	ret := _m.Called(ctx, chatUUID, title)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, chatUUID, title)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_RenameChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenameChat'
+type MockService_RenameChat_Call struct {
+	*mock.Call
+}
+
+// RenameChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - chatUUID uuid.UUID
+//   - title string
+func (_e *MockService_Expecter) RenameChat(ctx interface{}, chatUUID interface{}, title interface{}) *MockService_RenameChat_Call {
+	return &MockService_RenameChat_Call{Call: _e.mock.On("RenameChat", ctx, chatUUID, title)}
+}
+
+func (_c *MockService_RenameChat_Call) Run(run func(ctx context.Context, chatUUID uuid.UUID, title string)) *MockService_RenameChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_RenameChat_Call) Return(_a0 error) *MockService_RenameChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_RenameChat_Call) RunAndReturn(run func(context.Context, uuid.UUID, string) error) *MockService_RenameChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SearchChats provides a mock function with given fields: ctx, query
+func (_m *MockService) SearchChats(ctx context.Context, query string) (types.ChatSearchResults, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchChats")
+	}
+
+	var r0 types.ChatSearchResults
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (types.ChatSearchResults, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) types.ChatSearchResults); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(types.ChatSearchResults)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockService_SearchChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchChats'
+type MockService_SearchChats_Call struct {
+	*mock.Call
+}
+
+// SearchChats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+func (_e *MockService_Expecter) SearchChats(ctx interface{}, query interface{}) *MockService_SearchChats_Call {
+	return &MockService_SearchChats_Call{Call: _e.mock.On("SearchChats", ctx, query)}
+}
+
+func (_c *MockService_SearchChats_Call) Run(run func(ctx context.Context, query string)) *MockService_SearchChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockService_SearchChats_Call) Return(_a0 types.ChatSearchResults, _a1 error) *MockService_SearchChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockService_SearchChats_Call) RunAndReturn(run func(context.Context, string) (types.ChatSearchResults, error)) *MockService_SearchChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
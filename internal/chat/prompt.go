@@ -0,0 +1,38 @@
+package chat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+)
+
+// promptTimeFormat is the format of the {time} placeholder in prompts
+const promptTimeFormat = "15:04"
+
+// formatPrompt replaces the placeholders of a prompt format with the user's name, the LLM
+// model and now
+func formatPrompt(format string, cfg *config.Config, now time.Time) string {
+	return strings.NewReplacer(
+		"{user}", cfg.User.Name,
+		"{model}", cfg.LLM.Model,
+		"{time}", now.Format(promptTimeFormat),
+	).Replace(format)
+}
+
+// formatWelcome replaces the placeholders of a welcome message, those of prompts and the
+// chat's sessionID
+func formatWelcome(format string, cfg *config.Config, sessionID uuid.UUID, now time.Time) string {
+	return strings.ReplaceAll(formatPrompt(format, cfg, now), "{session_id}", sessionID.String())
+}
+
+// userPrompt returns the prompt shown before the user's input
+func (s *Session) userPrompt() string {
+	return formatPrompt(s.config.Chat.ResolvedPromptFormat(), s.config, time.Now())
+}
+
+// assistantPrompt returns the label shown before an answer
+func (s *Session) assistantPrompt() string {
+	return formatPrompt(s.config.Chat.ResolvedAssistantPromptFormat(), s.config, time.Now())
+}
@@ -0,0 +1,94 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/theme/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFormatPrompt(t *testing.T) {
+	cfg := &config.Config{
+		User: config.UserConfig{Name: "Ada"},
+		LLM:  config.LLMConfig{Model: "gpt-4o"},
+	}
+	now := time.Date(2025, 1, 2, 9, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "default user prompt", format: config.DefaultPromptFormat, want: "Ada > "},
+		{name: "default assistant prompt", format: config.DefaultAssistantPromptFormat, want: "AI > "},
+		{name: "all placeholders", format: "[{time}] {user}@{model} $ ", want: "[09:05] Ada@gpt-4o $ "},
+		{name: "repeated placeholder", format: "{model}/{model}: ", want: "gpt-4o/gpt-4o: "},
+		{name: "unknown placeholder", format: "{host} > ", want: "{host} > "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatPrompt(tt.format, cfg, now))
+		})
+	}
+}
+
+func TestSession_Prompts(t *testing.T) {
+	session, _, _ := setupTestSession(t)
+	session.config.User.Name = "Ada"
+	session.config.LLM.Model = "gpt-4o"
+
+	assert.Equal(t, "Ada > ", session.userPrompt())
+	assert.Equal(t, "AI > ", session.assistantPrompt())
+
+	session.config.Chat.PromptFormat = "{user} ({model})> "
+	session.config.Chat.AssistantPromptFormat = "{model}: "
+
+	assert.Equal(t, "Ada (gpt-4o)> ", session.userPrompt())
+	assert.Equal(t, "gpt-4o: ", session.assistantPrompt())
+}
+
+func TestSession_WelcomeMessage(t *testing.T) {
+	sessionID := uuid.New()
+
+	t.Run("default", func(t *testing.T) {
+		mockTheme := mocks.NewMockTheme(t)
+		headline, body := mocks.NewMockWriter(t), mocks.NewMockWriter(t)
+		mockTheme.EXPECT().Info().Return(headline).Once()
+		mockTheme.EXPECT().Secondary().Return(body).Once()
+		headline.EXPECT().Println("\n🗨️ Chat session started.").Return().Once()
+		body.EXPECT().Println(mock.MatchedBy(func(text string) bool {
+			return strings.HasPrefix(text, "Session ID: "+sessionID.String()+"\n") && strings.HasSuffix(text, "Type 'exit' to end the session.")
+		})).Return().Once()
+
+		session := &Session{config: &config.Config{}, theme: mockTheme, sessionID: sessionID}
+		session.showWelcomeMessage()
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		mockTheme := mocks.NewMockTheme(t)
+		headline := mocks.NewMockWriter(t)
+		mockTheme.EXPECT().Info().Return(headline).Once()
+		headline.EXPECT().Println("\nAcme Assistant for Ada, chat " + sessionID.String()).Return().Once()
+
+		cfg := &config.Config{User: config.UserConfig{Name: "Ada"}}
+		cfg.Chat.Welcome = "Acme Assistant for {user}, chat {session_id}"
+		session := &Session{config: cfg, theme: mockTheme, sessionID: sessionID}
+		session.showWelcomeMessage()
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		showWelcome := false
+		cfg := &config.Config{}
+		cfg.Chat.ShowWelcome = &showWelcome
+
+		// The theme has no expectations, printing anything fails the test
+		session := &Session{config: cfg, theme: mocks.NewMockTheme(t), sessionID: sessionID}
+		session.showWelcomeMessage()
+	})
+}
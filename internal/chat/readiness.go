@@ -0,0 +1,57 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/llm"
+)
+
+// readinessCacheTTL is how long the result of the LLM health check is reused. Every check
+// is a completion billed by the provider, while probes may come every few seconds.
+const readinessCacheTTL = 30 * time.Second
+
+// readinessCache keeps the last result of the readiness check. Concurrent probes wait for
+// the check in progress rather than starting their own.
+type readinessCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	response  ReadinessResponse
+	status    int
+}
+
+// get returns the cached result, or that of check, bounded by readinessTimeout, if it is
+// older than readinessCacheTTL. A check cut short by the end of ctx, the context of the
+// request, isn't kept: it says nothing about the LLM.
+func (c *readinessCache) get(ctx context.Context, check func(context.Context) error) (ReadinessResponse, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < readinessCacheTTL {
+		return c.response, c.status
+	}
+
+	response := ReadinessResponse{Status: "ready"}
+	status := http.StatusOK
+
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := check(checkCtx); err != nil {
+		response = ReadinessResponse{Status: "unavailable", Kind: llm.ErrorKindUnknown, Error: err.Error()}
+		var providerErr *llm.ProviderError
+		if errors.As(err, &providerErr) {
+			response.Kind = providerErr.Kind
+		}
+		status = http.StatusServiceUnavailable
+	}
+
+	if ctx.Err() == nil {
+		c.checkedAt, c.response, c.status = time.Now(), response, status
+	}
+
+	return response, status
+}
@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+)
+
+// decodeChatRequest decodes and validates the chat request in the body of r. If it fails,
+// the error response is written and false returned: 400 for a body that isn't valid
+// JSON, and 422 listing the invalid fields for one that is but can't be answered.
+func decodeChatRequest(w http.ResponseWriter, r *http.Request) (types.ChatRequest, bool) {
+	// The chat UUID is decoded as a string, so an invalid one is reported as a field error
+	var body struct {
+		types.ChatRequest
+		ChatUUID string `json:"chat_uuid"`
+	}
+
+	var fieldErrors []types.FieldError
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("malformed JSON: %v", err)})
+			return types.ChatRequest{}, false
+		}
+		fieldErrors = append(fieldErrors, types.FieldError{Field: typeErr.Field, Message: fmt.Sprintf("must be of type %s", typeErr.Type)})
+	}
+
+	req := body.ChatRequest
+	if strings.TrimSpace(req.Question) == "" {
+		fieldErrors = append(fieldErrors, types.FieldError{Field: "question", Message: "is required"})
+	}
+	if body.ChatUUID != "" {
+		chatUUID, err := uuid.Parse(body.ChatUUID)
+		if err != nil {
+			fieldErrors = append(fieldErrors, types.FieldError{Field: "chat_uuid", Message: "must be a UUID"})
+		}
+		req.ChatUUID = chatUUID
+	}
+
+	if len(fieldErrors) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, types.ValidationErrorResponse{Error: "invalid chat request", Fields: fieldErrors})
+		return types.ChatRequest{}, false
+	}
+
+	return req, true
+}
+
+// writeJSON writes v as a JSON response with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
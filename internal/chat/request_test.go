@@ -0,0 +1,110 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	chatMock "github.com/shaharia-lab/echoy/internal/chat/mocks"
+	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeChatRequest(t *testing.T) {
+	chatUUID := uuid.New()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []types.FieldError
+		wantReq    types.ChatRequest
+	}{
+		{
+			name:    "valid",
+			body:    `{"question":"Hello","chat_uuid":"` + chatUUID.String() + `"}`,
+			wantReq: types.ChatRequest{Question: "Hello", ChatUUID: chatUUID},
+		},
+		{
+			name:    "without chat UUID",
+			body:    `{"question":"Hello"}`,
+			wantReq: types.ChatRequest{Question: "Hello"},
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"question":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "blank question",
+			body:       `{"question":"  "}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFields: []types.FieldError{{Field: "question", Message: "is required"}},
+		},
+		{
+			name:       "invalid chat UUID and missing question",
+			body:       `{"chat_uuid":"not-a-uuid"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFields: []types.FieldError{
+				{Field: "question", Message: "is required"},
+				{Field: "chat_uuid", Message: "must be a UUID"},
+			},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"question":"Hello","modelSettings":{"temperature":"hot"}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFields: []types.FieldError{{Field: "modelSettings.temperature", Message: "must be of type float64"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, ok := decodeChatRequest(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats", strings.NewReader(tt.body)))
+
+			if tt.wantStatus == 0 {
+				require.True(t, ok, rec.Body.String())
+				assert.Equal(t, tt.wantReq, req)
+				return
+			}
+
+			assert.False(t, ok)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var response types.ValidationErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.NotEmpty(t, response.Error)
+			assert.Equal(t, tt.wantFields, response.Fields)
+		})
+	}
+}
+
+func TestChatHandler_HandleChatRequest_InvalidRequest(t *testing.T) {
+	handler := NewChatHandler(chatMock.NewMockService(t))
+
+	rec := httptest.NewRecorder()
+	handler.HandleChatRequest()(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats", strings.NewReader(`{"question":""}`)))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "the chat service should not be called")
+}
+
+func TestChatHandler_HandleChatStreamRequest_StreamFalse(t *testing.T) {
+	handler := NewChatHandler(chatMock.NewMockService(t))
+
+	rec := httptest.NewRecorder()
+	handler.HandleChatStreamRequest()(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/stream", strings.NewReader(`{"question":"Hello","stream":false}`)))
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, "the chat service should not be called")
+
+	var response types.ValidationErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Fields, 1)
+	assert.Equal(t, "stream", response.Fields[0].Field)
+	assert.Contains(t, response.Fields[0].Message, "POST /api/v1/chats")
+}
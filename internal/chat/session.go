@@ -3,12 +3,18 @@ package chat
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/mattn/go-runewidth"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/shaharia-lab/echoy/internal/chat/types"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/theme"
 	"github.com/shaharia-lab/goai"
@@ -23,35 +29,71 @@ type Session struct {
 	sessionID             uuid.UUID
 	reader                *bufio.Reader
 	chatHistoryService    HistoryService
-	thinkingAnimationFunc func(theme theme.Theme, thinking chan bool)
+	thinkingAnimationFunc func(theme theme.Theme) (stop func())
+	// terminalWidth returns the width streamed answers are wrapped at, 0 disables wrapping
+	terminalWidth func() int
+	// renderMarkdown styles the Markdown in answers instead of printing it raw
+	renderMarkdown bool
+	// location is the timezone the times of chats are shown in, UTC when nil
+	location *time.Location
+
+	// out is where all session output goes. It remembers the first failed write, so the
+	// session can end once stdout is gone.
+	out *outputWriter
+
+	// outMu serializes writes to the terminal. The thinking animation runs in its own
+	// goroutine and must never interleave with the streamed answer.
+	outMu sync.Mutex
 }
 
 // NewChatSession creates and configures a new chat session
 func NewChatSession(config *config.Config, theme theme.Theme, chatService Service, chatHistoryService HistoryService) (*Session, error) {
 	ctx := context.Background()
 
+	location, err := config.UI.ResolvedLocation()
+	if err != nil {
+		return nil, err
+	}
+
 	sessionID, err := chatHistoryService.CreateChat(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error creating chat session: %w", err)
 	}
 
-	return &Session{
-		config:                config,
-		theme:                 theme,
-		chatService:           chatService,
-		chatHistoryService:    chatHistoryService,
-		sessionID:             sessionID.UUID,
-		reader:                bufio.NewReader(os.Stdin),
-		thinkingAnimationFunc: showThinkingAnimation,
-	}, nil
+	out := &outputWriter{out: os.Stdout}
+	theme.SetOutput(out)
+
+	s := &Session{
+		config:             config,
+		theme:              theme,
+		chatService:        chatService,
+		chatHistoryService: chatHistoryService,
+		sessionID:          sessionID.UUID,
+		out:                out,
+		reader:             bufio.NewReader(os.Stdin),
+		terminalWidth:      stdoutWidth,
+		renderMarkdown:     config.Chat.MarkdownEnabled() && stdoutWidth() > 0,
+		location:           location,
+	}
+	s.thinkingAnimationFunc = s.showThinkingAnimation
+
+	return s, nil
 }
 
-// Start begins the interactive chat session
+// Start begins the interactive chat session. The session ends like on "exit" once stdout
+// can't be written anymore, e.g. because the reader of the pipe it goes to has exited.
 func (s *Session) Start(ctx context.Context) error {
 	s.showWelcomeMessage()
 
 	for {
+		if s.outputClosed() {
+			return nil
+		}
+
 		input, err := s.readUserInput()
+		if s.outputClosed() {
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("error reading input: %w", err)
 		}
@@ -62,11 +104,31 @@ func (s *Session) Start(ctx context.Context) error {
 		}
 
 		if strings.ToLower(input) == "clear" {
-			fmt.Print("\033[H\033[2J")
+			fmt.Fprint(s.stdout(), "\033[H\033[2J")
+			continue
+		}
+
+		if strings.ToLower(input) == "/new" {
+			s.startNewChat(ctx)
+			continue
+		}
+
+		if strings.ToLower(input) == "/continue" {
+			s.continueAnswer(ctx)
 			continue
 		}
 
-		if s.config.LLM.Streaming {
+		if arg, ok := parseForkCommand(input); ok {
+			s.forkChat(ctx, arg)
+			continue
+		}
+
+		if query, ok := parseSearchCommand(input); ok {
+			s.searchChats(ctx, query)
+			continue
+		}
+
+		if s.config.InteractiveStreamingEnabled() {
 			if err := s.processMessageStreaming(ctx, input); err != nil {
 				return err
 			}
@@ -80,16 +142,121 @@ func (s *Session) Start(ctx context.Context) error {
 	}
 }
 
+// showWelcomeMessage prints the welcome message of the chat configuration, its first line
+// as the headline, unless it is disabled
 func (s *Session) showWelcomeMessage() {
-	s.theme.Info().Println("\n🗨️ Chat session started.")
+	if !s.config.Chat.WelcomeEnabled() {
+		return
+	}
+
+	welcome := formatWelcome(s.config.Chat.ResolvedWelcome(), s.config, s.sessionID, time.Now())
+	headline, body, _ := strings.Cut(welcome, "\n")
+
+	s.theme.Info().Println("\n" + headline)
+	if body != "" {
+		s.theme.Secondary().Println(body)
+	}
+}
+
+// startNewChat switches the session to a new, empty chat. The previous one stays in the
+// chat history.
+func (s *Session) startNewChat(ctx context.Context) {
+	chatHistory, err := s.chatHistoryService.CreateChat(ctx)
+	if err != nil {
+		s.theme.Error().Println(fmt.Sprintf("Failed to start a new chat: %v", err))
+		return
+	}
+
+	s.sessionID = chatHistory.UUID
+	s.theme.Info().Println("\n🗨️ New chat started.")
 	s.theme.Subtle().Println("Session ID: ", s.sessionID)
-	s.theme.Secondary().Println("Type your message and press Enter. For multi-line input, continue typing.")
-	s.theme.Secondary().Println("Press Enter twice (empty line) to submit your message.")
-	s.theme.Secondary().Println("Type 'exit' to end the session.")
+}
+
+// parseForkCommand extracts the optional message count from a "/fork [n]" input
+func parseForkCommand(input string) (string, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || strings.ToLower(fields[0]) != "/fork" {
+		return "", false
+	}
+
+	return strings.Join(fields[1:], " "), true
+}
+
+// forkChat switches the session to a new chat holding the first arg messages of the
+// current one, all of them if arg is empty. The current chat stays in the chat history.
+func (s *Session) forkChat(ctx context.Context, arg string) {
+	uptoMessageIndex := -1
+	if arg != "" {
+		count, err := strconv.Atoi(arg)
+		if err != nil || count < 1 {
+			s.theme.Warning().Println("Usage: /fork [n], n being the number of messages to keep")
+			return
+		}
+		uptoMessageIndex = count - 1
+	}
+
+	forkID, err := s.chatService.ForkChat(ctx, s.sessionID, uptoMessageIndex)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMessageIndex) {
+			s.theme.Warning().Println(fmt.Sprintf("This chat has fewer than %s messages.", arg))
+			return
+		}
+
+		s.theme.Error().Println(fmt.Sprintf("Failed to fork the chat: %v", err))
+		return
+	}
+
+	s.sessionID = forkID
+	s.theme.Info().Println("\n🗨️ Forked chat started.")
+	s.theme.Subtle().Println("Session ID: ", s.sessionID)
+}
+
+// parseSearchCommand extracts the query from a "/search <query>" input
+func parseSearchCommand(input string) (string, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || strings.ToLower(fields[0]) != "/search" {
+		return "", false
+	}
+
+	return strings.Join(fields[1:], " "), true
+}
+
+// searchChats prints the chats matching query
+func (s *Session) searchChats(ctx context.Context, query string) {
+	if query == "" {
+		s.theme.Warning().Println("Usage: /search <query>")
+		return
+	}
+
+	searchResults, err := s.chatService.SearchChats(ctx, query)
+	if err != nil {
+		s.theme.Error().Println(fmt.Sprintf("Search failed: %v", err))
+		return
+	}
+
+	if len(searchResults.Results) == 0 {
+		s.theme.Info().Println(fmt.Sprintf("No chats found matching %q", query))
+		return
+	}
+
+	s.theme.Info().Println(fmt.Sprintf("Found %d chat(s) matching %q:", len(searchResults.Results), query))
+	for _, result := range searchResults.Results {
+		s.theme.Primary().Println(fmt.Sprintf("%s  %s", s.localTime(result.Chat.CreatedAt).Format(time.DateTime), result.Chat.UUID))
+		s.theme.Subtle().Println("    " + result.Snippet)
+	}
+}
+
+// localTime returns t in the timezone the times of chats are shown in
+func (s *Session) localTime(t time.Time) time.Time {
+	if s.location == nil {
+		return t.UTC()
+	}
+
+	return t.In(s.location)
 }
 
 func (s *Session) readUserInput() (string, error) {
-	s.theme.Primary().Print(fmt.Sprintf("%s > ", s.config.User.Name))
+	s.theme.Primary().Print(s.userPrompt())
 
 	var builder strings.Builder
 	var lines []string
@@ -123,74 +290,215 @@ func (s *Session) readUserInput() (string, error) {
 	return builder.String(), nil
 }
 
-func (s *Session) processMessage(ctx context.Context, input string) error {
-	thinking := make(chan bool, 1)
-	defer close(thinking)
+// emptyResponseWarning is shown instead of an answer the model left empty
+const emptyResponseWarning = "The model returned no content, try again or rephrase your message."
 
-	go s.thinkingAnimationFunc(s.theme, thinking)
+func (s *Session) processMessage(ctx context.Context, input string) error {
+	stopThinking := s.thinkingAnimationFunc(s.theme)
 
 	response, err := s.chatService.Chat(ctx, s.sessionID, input)
+	stopThinking()
+	if errors.Is(err, ErrEmptyResponse) {
+		s.theme.Warning().Println(emptyResponseWarning)
+		return nil
+	}
 	if err != nil {
-		thinking <- true
 		return fmt.Errorf("error processing chat input: %w", err)
 	}
 
-	thinking <- true
-	fmt.Print("\r                \r")
+	s.printResponse(response)
+	return nil
+}
 
-	s.theme.Secondary().Print("AI > ")
-	s.theme.Subtle().Printf("%s\n", response.Answer)
+// continueAnswer asks the model to carry on with the last answer of the chat, e.g. one
+// that was cut off by the maximum length
+func (s *Session) continueAnswer(ctx context.Context) {
+	stopThinking := s.thinkingAnimationFunc(s.theme)
 
-	return nil
+	response, err := s.chatService.Continue(ctx, s.sessionID)
+	stopThinking()
+	if err != nil {
+		if errors.Is(err, ErrNothingToContinue) {
+			s.theme.Warning().Println("There is no answer to continue yet.")
+			return
+		}
+		if errors.Is(err, ErrEmptyResponse) {
+			s.theme.Warning().Println(emptyResponseWarning)
+			return
+		}
+
+		s.theme.Error().Println(fmt.Sprintf("Failed to continue the answer: %v", err))
+		return
+	}
+
+	s.printResponse(response)
 }
 
-func (s *Session) processMessageStreaming(ctx context.Context, input string) error {
-	thinking := make(chan bool, 1)
-	defer close(thinking)
+// printResponse shows a complete answer and the warning that came with it
+func (s *Session) printResponse(response types.ChatResponse) {
+	prompt := s.assistantPrompt()
+	if s.renderMarkdown {
+		s.theme.Secondary().Println(prompt)
+		answer := s.newAnswerWriter(prompt)
+		_, _ = io.WriteString(answer, response.Answer)
+		_ = answer.Flush()
+	} else {
+		s.theme.Secondary().Print(prompt)
+		s.theme.Subtle().Printf("%s\n", response.Answer)
+	}
+
+	if response.Warning != "" {
+		s.theme.Warning().Println(fmt.Sprintf("Warning: %s", response.Warning))
+	}
+}
 
-	go s.thinkingAnimationFunc(s.theme, thinking)
+func (s *Session) processMessageStreaming(ctx context.Context, input string) error {
+	stopThinking := sync.OnceFunc(s.thinkingAnimationFunc(s.theme))
+	defer stopThinking()
 
 	streamChan, err := s.chatService.ChatStreaming(ctx, s.sessionID, input)
 	if err != nil {
-		thinking <- true
 		return fmt.Errorf("error processing chat input: %w", err)
 	}
 
 	firstToken := true
-	s.theme.Secondary().Print("AI > ")
+	prompt := s.assistantPrompt()
+	answer := s.newAnswerWriter(prompt)
+	var warning string
 
 	for streamResp := range streamChan {
 		if firstToken {
-			thinking <- true
-			fmt.Print("\r                \r")
-			s.theme.Secondary().Print("AI > ")
+			// Stop waits until the animation is cleared, so the answer starts on a clean line
+			stopThinking()
+			s.print(func() {
+				if s.renderMarkdown {
+					s.theme.Secondary().Println(prompt)
+					return
+				}
+				s.theme.Secondary().Print(prompt)
+			})
 			firstToken = false
 		}
 
+		if errors.Is(streamResp.Error, ErrEmptyResponse) {
+			for range streamChan {
+			}
+			s.print(func() {
+				if !s.renderMarkdown {
+					fmt.Fprintln(s.stdout())
+				}
+				s.theme.Warning().Println(emptyResponseWarning)
+			})
+			return nil
+		}
+		// Follows the complete answer, which is shown before it
+		if errors.Is(streamResp.Error, ErrHistoryNotSaved) {
+			warning = streamResp.Error.Error()
+			continue
+		}
 		if streamResp.Error != nil {
 			return fmt.Errorf("error in streaming response: %w", streamResp.Error)
 		}
 
-		s.theme.Subtle().Print(streamResp.Text)
+		s.print(func() {
+			_, _ = io.WriteString(answer, streamResp.Text)
+		})
 	}
 
-	fmt.Println()
+	stopThinking()
+	s.print(func() {
+		_ = answer.Flush()
+		if !s.renderMarkdown {
+			fmt.Fprintln(s.stdout())
+		}
+		if warning != "" {
+			s.theme.Warning().Println(fmt.Sprintf("Warning: %s", warning))
+		}
+	})
 	return nil
 }
 
-func showThinkingAnimation(theme theme.Theme, thinking chan bool) {
-	go func() {
-		dots := []string{".  ", ".. ", "..."}
-		i := 0
-		for {
-			select {
-			case <-thinking:
-				return
-			default:
-				theme.Warning().Printf("\rThinking%s", dots[i%3])
-				i++
-				time.Sleep(300 * time.Millisecond)
-			}
-		}
-	}()
+// answerWriter prints an answer that may arrive in pieces
+type answerWriter interface {
+	io.Writer
+	// Flush prints what is held back at the end of the answer
+	Flush() error
+}
+
+// newAnswerWriter returns the Markdown renderer, or the word-wrapping writer if Markdown
+// rendering is off. The answer starts after prompt unless it is rendered as Markdown.
+func (s *Session) newAnswerWriter(prompt string) answerWriter {
+	width := 0
+	if s.terminalWidth != nil {
+		width = s.terminalWidth()
+	}
+
+	if s.renderMarkdown {
+		return newMarkdownRenderer(s.theme, width)
+	}
+
+	return newWrapWriter(styleWriter{style: s.theme.Subtle()}, width, runewidth.StringWidth(prompt))
+}
+
+// stdout returns the writer for output that doesn't go through the theme
+func (s *Session) stdout() io.Writer {
+	if s.out == nil {
+		return os.Stdout
+	}
+	return s.out
+}
+
+// outputClosed reports whether writing the session output has failed
+func (s *Session) outputClosed() bool {
+	return s.out != nil && s.out.Err() != nil
+}
+
+// print runs fn while holding the terminal lock
+func (s *Session) print(fn func()) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fn()
+}
+
+// showThinkingAnimation shows a spinner until the returned function is called. The
+// spinner shares the session's terminal lock.
+func (s *Session) showThinkingAnimation(t theme.Theme) func() {
+	spinner := theme.NewTerminalSpinner(t).WithOutputLock(&s.outMu)
+	spinner.Start("Thinking...")
+
+	return spinner.Stop
+}
+
+// outputWriter passes writes on to out until one fails. Later writes return the same
+// error without trying again, so a closed stdout is detected in one place.
+type outputWriter struct {
+	out io.Writer
+
+	mu  sync.Mutex
+	err error
+}
+
+// Write implements io.Writer
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.out.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// Err returns the error of the first failed write
+func (w *outputWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
 }
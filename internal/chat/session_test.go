@@ -4,12 +4,15 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	chatMock "github.com/shaharia-lab/echoy/internal/chat/mocks"
 	"github.com/shaharia-lab/echoy/internal/chat/types"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/echoy/internal/theme"
 	"github.com/shaharia-lab/echoy/internal/theme/mocks"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -31,9 +34,11 @@ func setupMockTheme(t *testing.T) *mocks.MockTheme {
 	mockTheme.EXPECT().Warning().Return(mockWriter).Maybe()
 	mockTheme.EXPECT().Error().Return(mockWriter).Maybe()
 	mockTheme.EXPECT().Subtle().Return(mockWriter).Maybe()
+	mockTheme.EXPECT().SetOutput(mock.Anything).Return().Maybe()
 
 	mockWriter.EXPECT().Print(mock.Anything).Return().Maybe()
 	mockWriter.EXPECT().Println(mock.Anything).Return().Maybe()
+	mockWriter.EXPECT().Println(mock.Anything, mock.Anything).Return().Maybe()
 	mockWriter.EXPECT().Printf(mock.Anything, mock.Anything).Return().Maybe()
 
 	return mockTheme
@@ -52,8 +57,8 @@ func setupTestSession(t *testing.T) (*Session, *chatMock.MockService, *chatMock.
 		chatService:        mockChatService,
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			ch <- true
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
+			return func() {}
 		},
 	}
 
@@ -98,10 +103,8 @@ func TestNewChatSession_Error(t *testing.T) {
 
 func TestProcessMessage(t *testing.T) {
 	session, mockChatService, _ := setupTestSession(t)
-	session.thinkingAnimationFunc = func(theme theme.Theme, ch chan bool) {
-		go func() {
-			<-ch
-		}()
+	session.thinkingAnimationFunc = func(theme theme.Theme) func() {
+		return func() {}
 	}
 
 	ctx := context.Background()
@@ -144,9 +147,8 @@ func TestProcessMessage_Error(t *testing.T) {
 		chatService:        mockChatService,
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			for range ch {
-			}
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
+			return func() {}
 		},
 	}
 
@@ -181,13 +183,12 @@ func TestProcessMessageStreaming(t *testing.T) {
 		chatService:        mockChatService,
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
 			thinkingMutex.Lock()
 			thinkingCalled = true
 			thinkingMutex.Unlock()
 
-			for range ch {
-			}
+			return func() {}
 		},
 	}
 
@@ -250,13 +251,11 @@ func TestProcessMessageStreaming_InitialError(t *testing.T) {
 		chatService:        mockChatService,
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			select {
-			case <-ch:
-			case <-time.After(100 * time.Millisecond):
-				return
-			}
-		},
+	}
+
+	stopCalls := 0
+	session.thinkingAnimationFunc = func(theme theme.Theme) func() {
+		return func() { stopCalls++ }
 	}
 
 	ctx := context.Background()
@@ -271,15 +270,14 @@ func TestProcessMessageStreaming_InitialError(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), testErr.Error())
+	assert.Equal(t, 1, stopCalls, "the thinking animation must be stopped exactly once")
 }
 
 func TestProcessMessageStreaming_ResponseError(t *testing.T) {
 	session, mockChatService, _ := setupTestSession(t)
 
-	session.thinkingAnimationFunc = func(theme theme.Theme, ch chan bool) {
-		go func() {
-			<-ch
-		}()
+	session.thinkingAnimationFunc = func(theme theme.Theme) func() {
+		return func() {}
 	}
 
 	ctx := context.Background()
@@ -311,6 +309,31 @@ func TestProcessMessageStreaming_ResponseError(t *testing.T) {
 	assert.Contains(t, err.Error(), expectedErr.Error())
 }
 
+func TestProcessMessageStreaming_HistoryWarning(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+
+	// The warning is shown once, after the answer
+	warningWriter := mocks.NewMockWriter(t)
+	warningWriter.EXPECT().Println("Warning: " + historyWarning).Return().Once()
+	mockTheme := setupMockTheme(t)
+	themeWithWarning := mocks.NewMockTheme(t)
+	themeWithWarning.EXPECT().Warning().Return(warningWriter)
+	themeWithWarning.EXPECT().Secondary().RunAndReturn(mockTheme.Secondary).Maybe()
+	themeWithWarning.EXPECT().Subtle().RunAndReturn(mockTheme.Subtle).Maybe()
+	session.theme = themeWithWarning
+
+	ctx := context.Background()
+	streamingChan := make(chan goai.StreamingLLMResponse, 2)
+	streamingChan <- goai.StreamingLLMResponse{Text: "Hi", Done: true}
+	streamingChan <- goai.StreamingLLMResponse{Error: ErrHistoryNotSaved, Done: true}
+	close(streamingChan)
+	mockChatService.EXPECT().
+		ChatStreaming(ctx, session.sessionID, "Hello").
+		Return(streamingChan, nil)
+
+	assert.NoError(t, session.processMessageStreaming(ctx, "Hello"))
+}
+
 func TestStart_StreamingEnabled(t *testing.T) {
 	mockConfig := &config.Config{
 		LLM: config.LLMConfig{
@@ -350,14 +373,8 @@ func TestStart_StreamingEnabled(t *testing.T) {
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
 		reader:             mockReader,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			go func() {
-				select {
-				case <-ch:
-				case <-time.After(100 * time.Millisecond):
-					return
-				}
-			}()
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
+			return func() {}
 		},
 	}
 
@@ -428,14 +445,8 @@ func TestStart_NoStreaming(t *testing.T) {
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
 		reader:             mockReader,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			go func() {
-				select {
-				case <-ch:
-				case <-time.After(100 * time.Millisecond):
-					return
-				}
-			}()
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
+			return func() {}
 		},
 	}
 
@@ -490,14 +501,8 @@ func TestStart_ClearCommand(t *testing.T) {
 		chatHistoryService: mockHistoryService,
 		sessionID:          sessionUUID,
 		reader:             mockReader,
-		thinkingAnimationFunc: func(theme theme.Theme, ch chan bool) {
-			go func() {
-				select {
-				case <-ch:
-				case <-time.After(100 * time.Millisecond):
-					return
-				}
-			}()
+		thinkingAnimationFunc: func(theme theme.Theme) func() {
+			return func() {}
 		},
 	}
 
@@ -511,3 +516,156 @@ func TestStart_ClearCommand(t *testing.T) {
 		t.Logf("Received expected EOF error: %v", err)
 	}
 }
+
+func TestStart_SearchCommand(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+	session.config.User.Name = "Test User"
+	session.reader = bufio.NewReader(strings.NewReader("/search  go routines\n\nexit\n\n"))
+
+	mockChatService.EXPECT().
+		SearchChats(mock.Anything, "go routines").
+		Return(types.ChatSearchResults{
+			Query: "go routines",
+			Results: []types.ChatSearchResult{
+				{Chat: types.NewChat(history.Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New()}}), Snippet: "about go routines"},
+			},
+		}, nil).
+		Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestStart_NewCommand(t *testing.T) {
+	session, _, mockHistoryService := setupTestSession(t)
+	previousID := session.sessionID
+	newID := uuid.New()
+	session.reader = bufio.NewReader(strings.NewReader("/new\n\nexit\n\n"))
+
+	mockHistoryService.EXPECT().CreateChat(mock.Anything).Return(&goai.ChatHistory{UUID: newID}, nil).Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, previousID, session.sessionID)
+	assert.Equal(t, newID, session.sessionID, "messages should go to the new chat")
+}
+
+func TestStart_NewCommand_Error(t *testing.T) {
+	session, _, mockHistoryService := setupTestSession(t)
+	previousID := session.sessionID
+	session.reader = bufio.NewReader(strings.NewReader("/new\n\nexit\n\n"))
+
+	mockHistoryService.EXPECT().CreateChat(mock.Anything).Return(nil, errors.New("database locked")).Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err, "the session should go on with the current chat")
+	assert.Equal(t, previousID, session.sessionID)
+}
+
+func TestStart_ContinueCommand(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+	session.reader = bufio.NewReader(strings.NewReader("/continue\n\n/continue\n\nexit\n\n"))
+
+	mockChatService.EXPECT().Continue(mock.Anything, session.sessionID).
+		Return(types.ChatResponse{Answer: "and they lived happily ever after."}, nil).Once()
+	mockChatService.EXPECT().Continue(mock.Anything, session.sessionID).
+		Return(types.ChatResponse{}, ErrNothingToContinue).Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err, "failing to continue should not end the session")
+}
+
+func TestStart_EmptyResponse(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+	session.reader = bufio.NewReader(strings.NewReader("Hello\n\n/continue\n\nexit\n\n"))
+
+	mockChatService.EXPECT().Chat(mock.Anything, session.sessionID, "Hello").
+		Return(types.ChatResponse{}, fmt.Errorf("failed to generate response: %w", ErrEmptyResponse)).Once()
+	mockChatService.EXPECT().Continue(mock.Anything, session.sessionID).
+		Return(types.ChatResponse{}, fmt.Errorf("failed to generate response: %w", ErrEmptyResponse)).Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err, "an empty answer should not end the session")
+}
+
+func TestStart_ForkCommand(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+	sourceID := session.sessionID
+	forkID := uuid.New()
+	session.reader = bufio.NewReader(strings.NewReader("/fork 9\n\n/fork two\n\n/fork 2\n\nexit\n\n"))
+
+	mockChatService.EXPECT().ForkChat(mock.Anything, sourceID, 8).Return(uuid.Nil, ErrInvalidMessageIndex).Once()
+	mockChatService.EXPECT().ForkChat(mock.Anything, sourceID, 1).Return(forkID, nil).Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, forkID, session.sessionID, "messages should go to the forked chat")
+}
+
+// brokenPipe fails every write after the first n bytes, like stdout piped into head
+type brokenPipe struct {
+	n int
+}
+
+func (w *brokenPipe) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, syscall.EPIPE
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestStart_OutputClosed(t *testing.T) {
+	session, mockChatService, _ := setupTestSession(t)
+	session.config.User.Name = "Test User"
+	session.config.LLM.Streaming = true
+	session.reader = bufio.NewReader(strings.NewReader("Hello\n\nHello again\n\n"))
+	session.out = &outputWriter{out: &brokenPipe{n: 1000}}
+
+	out := theme.NewDefaultTheme()
+	out.SetOutput(session.out)
+	session.theme = out
+
+	streamingChan := make(chan goai.StreamingLLMResponse, 2)
+	streamingChan <- goai.StreamingLLMResponse{Text: strings.Repeat("long answer ", 200)}
+	streamingChan <- goai.StreamingLLMResponse{Done: true}
+	close(streamingChan)
+
+	mockChatService.EXPECT().
+		ChatStreaming(mock.Anything, session.sessionID, "Hello").
+		Return(streamingChan, nil).
+		Once()
+
+	err := session.Start(context.Background())
+
+	assert.NoError(t, err, "a closed stdout should end the session like exit")
+	assert.ErrorIs(t, session.out.Err(), syscall.EPIPE)
+}
+
+func TestParseSearchCommand(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedQuery string
+		expectedOK    bool
+	}{
+		{input: "/search goroutines", expectedQuery: "goroutines", expectedOK: true},
+		{input: "/SEARCH  two   words", expectedQuery: "two words", expectedOK: true},
+		{input: "/search", expectedQuery: "", expectedOK: true},
+		{input: "/searching", expectedOK: false},
+		{input: "please /search this", expectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			query, ok := parseSearchCommand(tc.input)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedQuery, query)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxStreamDurationKey is the context key of the cap set by WithMaxStreamDuration
+type maxStreamDurationKey struct{}
+
+// WithMaxStreamDuration returns a copy of ctx capping how long answers streamed for the
+// request may take. The answer is ended with a terminal event once d elapses, however
+// much it is still producing, and its LLM call is cancelled.
+func WithMaxStreamDuration(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, maxStreamDurationKey{}, d)
+}
+
+// errStreamDurationExceeded is the cause of a generation ended by the maximum stream duration
+var errStreamDurationExceeded = errors.New("the answer took longer than the maximum stream duration")
+
+// withStreamLimit bounds the generation context of an answer streamed for requestCtx by
+// the cap set with WithMaxStreamDuration, if any
+func withStreamLimit(ctx, requestCtx context.Context) (context.Context, context.CancelFunc) {
+	if d, _ := requestCtx.Value(maxStreamDurationKey{}).(time.Duration); d > 0 {
+		return context.WithTimeoutCause(ctx, d, errStreamDurationExceeded)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// streamRetention is how long the events of a finished answer are kept for clients that
+// reconnect after it ended
+const streamRetention = time.Minute
+
+// streamEvent is an SSE event of a streamed answer. Its ID is its position in the answer,
+// starting at 1.
+type streamEvent struct {
+	ID   int
+	Data string
+}
+
+// streamBuffer records the events of an answer while it is generated, so a client that
+// reconnects with Last-Event-ID gets the rest of it instead of a new generation
+type streamBuffer struct {
+	mu     sync.Mutex
+	events []string
+	done   bool
+	// changed is closed and replaced whenever an event is added or the answer ends
+	changed chan struct{}
+}
+
+func newStreamBuffer() *streamBuffer {
+	return &streamBuffer{changed: make(chan struct{})}
+}
+
+// add appends an event with data
+func (b *streamBuffer) add(data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, data)
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// finish marks the answer as complete
+func (b *streamBuffer) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return
+	}
+	b.done = true
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// next returns the events after the one with ID lastID. If there are none yet it waits
+// for more, and returns done once the answer is complete and all events were returned.
+func (b *streamBuffer) next(ctx context.Context, lastID int) (events []streamEvent, done bool, err error) {
+	for {
+		b.mu.Lock()
+		for i := lastID; i < len(b.events); i++ {
+			events = append(events, streamEvent{ID: i + 1, Data: b.events[i]})
+		}
+		done, changed := b.done, b.changed
+		b.mu.Unlock()
+
+		if len(events) > 0 || done {
+			return events, done, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// streamRegistry holds the buffers of the answers being streamed, keyed by chat session
+type streamRegistry struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID]*streamBuffer
+}
+
+func newStreamRegistry() *streamRegistry {
+	return &streamRegistry{streams: make(map[uuid.UUID]*streamBuffer)}
+}
+
+// start registers a buffer for a new answer in sessionID, replacing the previous one
+func (r *streamRegistry) start(sessionID uuid.UUID) *streamBuffer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buffer := newStreamBuffer()
+	r.streams[sessionID] = buffer
+	return buffer
+}
+
+// get returns the buffer of the last answer in sessionID
+func (r *streamRegistry) get(sessionID uuid.UUID) (*streamBuffer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buffer, ok := r.streams[sessionID]
+	return buffer, ok
+}
+
+// expire removes buffer after streamRetention, unless a newer answer replaced it
+func (r *streamRegistry) expire(sessionID uuid.UUID, buffer *streamBuffer) {
+	time.AfterFunc(streamRetention, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.streams[sessionID] == buffer {
+			delete(r.streams, sessionID)
+		}
+	})
+}
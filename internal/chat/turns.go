@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// turns serializes the turns of each chat session, so the answer of a turn is in the
+// history before the question of the next one, even if the answer is saved after the
+// stream delivering it has ended
+type turns struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*turnLock
+}
+
+// turnLock is held by the turn running in a chat session
+type turnLock struct {
+	held chan struct{}
+	// waiters counts the turns holding or waiting for the lock, it is dropped at zero
+	waiters int
+}
+
+// lockTurn waits until no other turn runs in sessionID and returns the function ending
+// the turn, which must be called once its messages are saved. It fails if ctx ends while
+// waiting. Turns of a new chat, with a nil sessionID, don't wait.
+func (s *ServiceImpl) lockTurn(ctx context.Context, sessionID uuid.UUID) (func(), error) {
+	if sessionID == uuid.Nil {
+		return func() {}, nil
+	}
+
+	s.turns.mu.Lock()
+	if s.turns.locks == nil {
+		s.turns.locks = make(map[uuid.UUID]*turnLock)
+	}
+	l, ok := s.turns.locks[sessionID]
+	if !ok {
+		l = &turnLock{held: make(chan struct{}, 1)}
+		s.turns.locks[sessionID] = l
+	}
+	l.waiters++
+	s.turns.mu.Unlock()
+
+	select {
+	case l.held <- struct{}{}:
+	case <-ctx.Done():
+		s.releaseTurnLock(sessionID, l)
+		return nil, fmt.Errorf("waiting for the previous answer in chat %s: %w", sessionID, context.Cause(ctx))
+	}
+
+	return sync.OnceFunc(func() {
+		<-l.held
+		s.releaseTurnLock(sessionID, l)
+	}), nil
+}
+
+// releaseTurnLock drops l once no turn holds or waits for it
+func (s *ServiceImpl) releaseTurnLock(sessionID uuid.UUID, l *turnLock) {
+	s.turns.mu.Lock()
+	defer s.turns.mu.Unlock()
+
+	l.waiters--
+	if l.waiters == 0 {
+		delete(s.turns.locks, sessionID)
+	}
+}
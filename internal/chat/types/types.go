@@ -3,7 +3,9 @@ package types
 import (
 	"github.com/google/uuid"
 	"github.com/shaharia-lab/echoy/internal/api"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/goai"
+	"time"
 )
 
 type ModelSettings struct {
@@ -30,6 +32,22 @@ type ChatRequest struct {
 	ModelSettings  ModelSettings  `json:"modelSettings"`
 	LLMProvider    LLMProvider    `json:"llmProvider"`
 	StreamSettings StreamSettings `json:"stream_settings"`
+	// Stream asks POST /api/v1/chats for the answer as server-sent events when true and
+	// as a single JSON response when false. It takes precedence over the Accept header.
+	Stream *bool `json:"stream,omitempty"`
+}
+
+// FieldError tells why a field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is the body of 422 responses to requests that are well-formed
+// JSON but have invalid fields
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
 }
 
 type ChatResponse struct {
@@ -37,9 +55,130 @@ type ChatResponse struct {
 	Answer      string    `json:"answer"`
 	InputToken  int       `json:"input_token"`
 	OutputToken int       `json:"output_token"`
+	// FinishReason tells why the answer ended, see FinishReasonStop and the others. It is
+	// left out if it isn't known, because the provider didn't report the token count.
+	FinishReason string `json:"finish_reason,omitempty"`
+	// Warning is set when the answer was generated but something else, e.g. saving it to
+	// the chat history, went wrong
+	Warning string `json:"warning,omitempty"`
+}
+
+// Reasons an answer ended. goai doesn't pass on the reason reported by the provider, so it
+// is derived: an answer that used up the maximum number of tokens was most likely cut off.
+const (
+	// FinishReasonStop is an answer the model completed
+	FinishReasonStop = "stop"
+	// FinishReasonLength is an answer cut off at the maximum number of tokens
+	FinishReasonLength = "length"
+	// FinishReasonMaxDuration is a streamed answer ended by the maximum stream duration
+	FinishReasonMaxDuration = "max_duration"
+	// FinishReasonCancelled is an answer stopped through the cancel endpoint
+	FinishReasonCancelled = "cancelled"
+)
+
+// LocalTimeFormat is how the *_local fields of the API render times for people to read
+const LocalTimeFormat = "2006-01-02 15:04:05 MST"
+
+// LocalTime renders t in location with LocalTimeFormat. It is empty for a zero t or a nil
+// location.
+func LocalTime(t time.Time, location *time.Location) string {
+	if t.IsZero() || location == nil {
+		return ""
+	}
+
+	return t.In(location).Format(LocalTimeFormat)
+}
+
+// Message is a message of a chat in API responses. GeneratedAt keeps the canonical UTC
+// time, GeneratedAtLocal renders it in the timezone of the user.
+type Message struct {
+	goai.ChatHistoryMessage
+	GeneratedAtLocal string `json:"generated_at_local,omitempty"`
+	// Partial is set on an answer whose stream ended before it was complete, so clients can
+	// mark it as interrupted. Its text is only what was delivered.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Chat is a chat in API responses. Its times stay in UTC, the *_local fields render them in
+// the timezone of the user once Localize is called.
+type Chat struct {
+	history.Chat
+	Messages       []Message `json:"messages"`
+	CreatedAtLocal string    `json:"created_at_local,omitempty"`
+}
+
+// NewChat returns the API representation of chat, without local times
+func NewChat(chat history.Chat) Chat {
+	messages := make([]Message, 0, len(chat.Messages))
+	for i, message := range chat.Messages {
+		messages = append(messages, Message{ChatHistoryMessage: message, Partial: chat.IsPartial(i)})
+	}
+
+	return Chat{Chat: chat, Messages: messages}
+}
+
+// Localize renders the times of the chat and its messages in location
+func (c *Chat) Localize(location *time.Location) {
+	c.CreatedAtLocal = LocalTime(c.CreatedAt, location)
+	for i := range c.Messages {
+		c.Messages[i].GeneratedAtLocal = LocalTime(c.Messages[i].GeneratedAt, location)
+	}
 }
 
 type ChatHistoryList struct {
-	Chats []goai.ChatHistory `json:"chats"`
+	Chats []Chat `json:"chats"`
 	api.Pagination
 }
+
+// ChatSearchResult is a chat matching a search query along with an excerpt of the first matching message
+type ChatSearchResult struct {
+	Chat    Chat   `json:"chat"`
+	Snippet string `json:"snippet"`
+}
+
+type ChatSearchResults struct {
+	Query   string             `json:"query"`
+	Results []ChatSearchResult `json:"results"`
+	api.Pagination
+}
+
+// ChatExportVersion is the format version written to chat exports
+const ChatExportVersion = 1
+
+// ChatExport is the portable representation of all stored chats
+type ChatExport struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Chats      []history.Chat `json:"chats"`
+}
+
+// ImportConflictStrategy decides what happens when an imported chat's UUID is already stored
+type ImportConflictStrategy string
+
+const (
+	// ImportConflictSkip keeps the stored chat and ignores the imported one
+	ImportConflictSkip ImportConflictStrategy = "skip"
+	// ImportConflictRemap imports the chat under a newly generated UUID
+	ImportConflictRemap ImportConflictStrategy = "remap"
+)
+
+// NewChatResponse is the response of POST /api/v1/chats/new
+type NewChatResponse struct {
+	ChatUUID uuid.UUID `json:"chat_uuid"`
+}
+
+// ChatForkRequest is the body of POST /api/v1/chats/{id}/fork. UptoMessageIndex is the
+// index of the last message copied to the new chat, all of them are copied without it.
+type ChatForkRequest struct {
+	UptoMessageIndex *int `json:"upto_message_index,omitempty"`
+}
+
+type ChatRenameRequest struct {
+	Title string `json:"title"`
+}
+
+type ChatImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Remapped int `json:"remapped"`
+}
@@ -0,0 +1,160 @@
+package chat
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"golang.org/x/term"
+)
+
+// tabWidth is the number of columns a tab is assumed to take
+const tabWidth = 4
+
+// codeFence starts and ends a fenced code block. Code isn't wrapped, so it stays intact.
+const codeFence = "```"
+
+// wrapWriter word-wraps streamed text at width columns. Tokens can end mid-word, so the
+// current word and the whitespace before it are held back until the next whitespace
+// shows where the word ends. A width of zero or less passes text through unchanged.
+type wrapWriter struct {
+	out   io.Writer
+	width int
+
+	col    int
+	spaces strings.Builder
+	word   strings.Builder
+
+	// lineStart is true until the first word of the answer or of a line after a newline
+	lineStart bool
+	inCode    bool
+}
+
+// newWrapWriter creates a wrapWriter that starts writing at column col, e.g. after a prompt
+func newWrapWriter(out io.Writer, width, col int) *wrapWriter {
+	return &wrapWriter{out: out, width: width, col: col, lineStart: true}
+}
+
+// Write implements io.Writer
+func (w *wrapWriter) Write(p []byte) (int, error) {
+	if w.width <= 0 {
+		return w.out.Write(p)
+	}
+
+	for _, r := range string(p) {
+		var err error
+		switch r {
+		case '\n':
+			err = w.newline()
+		case ' ', '\t':
+			if w.word.Len() > 0 {
+				err = w.flushWord()
+			}
+			w.spaces.WriteRune(r)
+		default:
+			w.word.WriteRune(r)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the word held back at the end of the stream
+func (w *wrapWriter) Flush() error {
+	if w.width <= 0 || w.word.Len() == 0 {
+		return nil
+	}
+
+	return w.flushWord()
+}
+
+// newline ends the current line. Whitespace before it is dropped, except in code.
+func (w *wrapWriter) newline() error {
+	if w.word.Len() > 0 {
+		if err := w.flushWord(); err != nil {
+			return err
+		}
+	}
+
+	text := "\n"
+	if w.inCode {
+		text = w.spaces.String() + text
+	}
+	w.spaces.Reset()
+
+	w.col = 0
+	w.lineStart = true
+	return w.writeString(text)
+}
+
+// flushWord writes the pending whitespace and word, breaking the line first if the word
+// doesn't fit. Whitespace at a soft break is dropped, indentation after a newline is kept.
+func (w *wrapWriter) flushWord() error {
+	word := w.word.String()
+	spaces := w.spaces.String()
+	w.word.Reset()
+	w.spaces.Reset()
+
+	if w.lineStart && strings.HasPrefix(word, codeFence) {
+		w.inCode = !w.inCode
+	}
+
+	wordWidth := runewidth.StringWidth(word)
+	spacesWidth := whitespaceWidth(spaces)
+
+	var b strings.Builder
+	if !w.inCode && !w.lineStart && w.col > 0 && w.col+spacesWidth+wordWidth > w.width {
+		b.WriteString("\n")
+		w.col = 0
+	} else {
+		b.WriteString(spaces)
+		w.col += spacesWidth
+	}
+
+	b.WriteString(word)
+	w.col += wordWidth
+	w.lineStart = false
+
+	return w.writeString(b.String())
+}
+
+func (w *wrapWriter) writeString(s string) error {
+	_, err := io.WriteString(w.out, s)
+	return err
+}
+
+func whitespaceWidth(s string) int {
+	return len(s) + strings.Count(s, "\t")*(tabWidth-1)
+}
+
+// styleWriter adapts a theme style to io.Writer
+type styleWriter struct {
+	style theme.StylePrinter
+}
+
+// Write implements io.Writer
+func (w styleWriter) Write(p []byte) (int, error) {
+	w.style.Print(string(p))
+	return len(p), nil
+}
+
+// stdoutWidth returns the width of the terminal on stdout, or 0 if stdout isn't a terminal
+func stdoutWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+
+	return width
+}
@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		col    int
+		tokens []string
+		want   string
+	}{
+		{
+			name:   "passes through without a width",
+			width:  0,
+			tokens: []string{"a very long line that would otherwise be wrapped"},
+			want:   "a very long line that would otherwise be wrapped",
+		},
+		{
+			name:   "wraps at word boundaries",
+			width:  10,
+			tokens: []string{"the quick brown fox jumps"},
+			want:   "the quick\nbrown fox\njumps",
+		},
+		{
+			name:   "words split across tokens",
+			width:  10,
+			tokens: []string{"the qu", "ick br", "own f", "ox ", "jumps"},
+			want:   "the quick\nbrown fox\njumps",
+		},
+		{
+			name:   "starts after the prompt",
+			width:  10,
+			col:    5,
+			tokens: []string{"hello world"},
+			want:   "hello\nworld",
+		},
+		{
+			name:   "keeps newlines and indentation",
+			width:  10,
+			tokens: []string{"list:\n  - one\n  - two"},
+			want:   "list:\n  - one\n  - two",
+		},
+		{
+			name:   "long words overflow",
+			width:  10,
+			tokens: []string{"see https://example.com/a/long/path now"},
+			want:   "see\nhttps://example.com/a/long/path\nnow",
+		},
+		{
+			name:   "code blocks are not wrapped",
+			width:  10,
+			tokens: []string{"```go\nfmt.Println(\"a\", \"b\", \"c\")\n```\nafter the code"},
+			want:   "```go\nfmt.Println(\"a\", \"b\", \"c\")\n```\nafter the\ncode",
+		},
+		{
+			name:   "wide characters",
+			width:  6,
+			tokens: []string{"日本 日本 日本"},
+			want:   "日本\n日本\n日本",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			w := newWrapWriter(&out, tt.width, tt.col)
+
+			for _, token := range tt.tokens {
+				_, err := w.Write([]byte(token))
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Flush())
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
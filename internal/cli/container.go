@@ -1,13 +1,21 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/echoy/internal/initializer"
 	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/secret"
+	"github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
+	"net"
+	"os"
 	"path"
+	"time"
 )
 
 // Container holds all application dependencies
@@ -20,8 +28,15 @@ type Container struct {
 	Initializer    *initializer.Initializer
 	ConfigFromFile config.Config
 	SocketFilePath string
+	// DisableFileLog turns off the log files, see ProcessLogConfig
+	DisableFileLog bool
+	// Telemetry collects the usage events of the run, they are sent when main flushes it
+	Telemetry *telemetry.Batch
 }
 
+// RequiresConfigAnnotation marks commands that can't run without an initialized configuration
+const RequiresConfigAnnotation = "echoy.requires-config"
+
 // InitOptions contains options for initialization
 type InitOptions struct {
 	Version  string
@@ -29,16 +44,35 @@ type InitOptions struct {
 	Date     string
 	LogLevel logger.Level
 	Theme    theme.Theme
+	// Quiet suppresses non-essential themed output
+	Quiet bool
+	// DisableFileLog turns off the log files, the CLI then only logs to stderr
+	DisableFileLog bool
 }
 
 // NewContainer creates and initializes all application dependencies
 func NewContainer(opts InitOptions) (*Container, error) {
-	container := &Container{}
+	return NewContainerWithContext(context.Background(), opts)
+}
+
+// NewContainerWithContext creates and initializes all application dependencies. The
+// filesystem work can be abandoned by cancelling ctx. Each initialization step is logged
+// as a debug event; with the debug log level, the steps are printed to stderr if the
+// initialization fails before the logger could be created.
+func NewContainerWithContext(ctx context.Context, opts InitOptions) (*Container, error) {
+	container := &Container{DisableFileLog: opts.DisableFileLog}
 	var err error
 
+	diagnostics := &startupDiagnostics{}
+	if opts.LogLevel == logger.DebugLevel {
+		diagnostics.fallback = os.Stderr
+	}
+
 	defer func() {
 		if container.Logger != nil {
 			defer container.Logger.Flush()
+		} else {
+			diagnostics.flush()
 		}
 	}()
 
@@ -56,10 +90,17 @@ func NewContainer(opts InitOptions) (*Container, error) {
 	}
 
 	container.ThemeMgr = theme.NewManager(theme.NewDefaultTheme(), container.Config, &theme.StdoutWriter{})
+	container.ThemeMgr.SetQuiet(opts.Quiet)
 
-	container.Filesystem = filesystem.NewAppFilesystem(container.Config)
+	permissions, err := filesystem.PermissionsFromEnv()
+	if err != nil {
+		return container, err
+	}
+	container.Filesystem = filesystem.NewAppFilesystem(container.Config).WithPermissions(permissions)
 
-	container.Paths, err = container.Filesystem.EnsureAllPaths()
+	started := time.Now()
+	container.Paths, err = runStep(ctx, container.Filesystem.EnsureAllPaths)
+	diagnostics.record("ensure_paths", started, err, logger.Fields{"app_directory": container.Paths[filesystem.AppDirectory]})
 	if err != nil {
 		return container, fmt.Errorf("failed to ensure all application paths: %w", err)
 	}
@@ -70,37 +111,137 @@ func NewContainer(opts InitOptions) (*Container, error) {
 
 	container.SocketFilePath = path.Join(container.Paths[filesystem.AppDirectory], "echoy.sock")
 
-	systemConfig, err := container.Filesystem.GetSystemConfig()
+	started = time.Now()
+	systemConfig, err := runStep(ctx, container.Filesystem.GetSystemConfig)
+	diagnostics.record("load_system_config", started, err, nil)
 	if err != nil {
 		return container, fmt.Errorf("failed to get system config: %w", err)
 	}
 
 	container.Config.SystemConfig = systemConfig
 
+	logFilePath := fmt.Sprintf("%s/echoy.log", container.Paths[filesystem.LogsDirectory])
+	if opts.DisableFileLog {
+		// Unlike the daemon's, the CLI's stdout is for the output of the commands
+		logFilePath = ""
+	}
+
+	started = time.Now()
 	log, err := logger.NewZapLogger(logger.Config{
-		LogLevel:    logger.DebugLevel,
+		LogLevel:    opts.LogLevel,
 		UseConsole:  true,
 		Development: true,
 
-		LogFilePath: fmt.Sprintf("%s/echoy.log", container.Paths[filesystem.LogsDirectory]),
+		LogFilePath: logFilePath,
 
 		MaxSizeMB:  50,
 		MaxAgeDays: 14,
 		MaxBackups: 5,
 	})
 	if err != nil {
+		diagnostics.record("create_logger", started, err, logger.Fields{"log_file": logFilePath})
 		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 	}
 
 	container.Logger = log
+	diagnostics.setLogger(log)
+	diagnostics.record("create_logger", started, nil, logger.Fields{"log_file": logFilePath})
+
+	configFilePath := container.Paths[filesystem.ConfigFilePath]
 
-	container.ConfigFromFile, err = initializer.NewDefaultConfigManager(container.Paths[filesystem.ConfigFilePath]).LoadConfig()
+	// The user's configuration is layered on the system-wide one of managed installs
+	configManager := initializer.NewDefaultConfigManager(configFilePath)
+
+	started = time.Now()
+	container.ConfigFromFile, err = runStep(ctx, configManager.LoadConfig)
+	// Without a configuration the defaults are used, commands that need one ask for 'echoy init'
+	initialized := !errors.Is(err, config.ErrConfigNotInitialized)
+	if !initialized {
+		err = nil
+	}
+	diagnostics.record("load_config", started, err, logger.Fields{"config_file": configFilePath, "system_config_file": config.SystemConfigPath(), "initialized": initialized})
 	if err != nil {
-		container.Logger.WithField(logger.ErrorKey, err).Error("error loading configuration")
-		return container, fmt.Errorf("error loading configuration: %w", err)
+		return container, fmt.Errorf("error loading configuration from %s: %w (run 'echoy init' to recreate it)", configFilePath, err)
+	}
+
+	container.Telemetry = telemetry.NewBatch(container.Config, container.ConfigFromFile.UsageTracking.Enabled)
+
+	// A failing token source is only logged, so init can still be run to fix the configuration
+	tokenSource := container.ConfigFromFile.LLM.ResolvedTokenSource()
+	started = time.Now()
+	token, err := runStep(ctx, func() (string, error) { return secret.Resolve(container.ConfigFromFile.LLM) })
+	diagnostics.record("resolve_token", started, err, logger.Fields{"token_source": tokenSource})
+	if err == nil {
+		container.ConfigFromFile.LLM.Token = token
+	}
+
+	if container.ConfigFromFile.Chat.ResolvedHistoryBackend() == config.HistoryBackendSQLite {
+		if err := checkChatHistory(ctx, container, diagnostics); err != nil {
+			return container, err
+		}
 	}
 
-	configManager := initializer.NewDefaultConfigManager(container.Paths[filesystem.ConfigFilePath])
 	container.Initializer = initializer.NewInitializer(container.Logger, container.Config, container.ThemeMgr, configManager)
 	return container, nil
 }
+
+// OpenHistoryStorage opens the chat history storage selected by the chat.history_backend
+// setting. The caller must close it.
+func (c *Container) OpenHistoryStorage() (history.Storage, error) {
+	return history.Open(c.ConfigFromFile.Chat, c.Paths[filesystem.ChatHistoryDB], c.Logger)
+}
+
+// daemonProbeTimeout bounds how long DaemonRunning waits for the socket to accept
+const daemonProbeTimeout = time.Second
+
+// DaemonRunning reports whether a process accepts connections on the daemon's socket. The
+// daemon and the web server it hosts keep the chat history database open, so it must not
+// be replaced while they run.
+func (c *Container) DaemonRunning() bool {
+	if c.SocketFilePath == "" {
+		return false
+	}
+
+	conn, err := net.DialTimeout("unix", c.SocketFilePath, daemonProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
+
+// checkChatHistory makes sure a corrupt chat history database doesn't break every command.
+// A corrupt database is moved aside and replaced by an empty one, unless the daemon still
+// has it open. Other failures, e.g. a database locked by the daemon, are only logged.
+func checkChatHistory(ctx context.Context, container *Container, diagnostics *startupDiagnostics) error {
+	dbPath := container.Paths[filesystem.ChatHistoryDB]
+
+	started := time.Now()
+	_, err := runStep(ctx, func() (struct{}, error) { return struct{}{}, history.CheckIntegrity(dbPath, false) })
+	diagnostics.record("check_chat_history", started, err, logger.Fields{"db_file": dbPath})
+	if !errors.Is(err, history.ErrCorrupt) {
+		return nil
+	}
+
+	// The daemon would keep writing to the replaced file, so its chats would be lost
+	if container.DaemonRunning() {
+		container.Logger.WithFields(logger.Fields{
+			logger.ErrorKey: err,
+			"db_file":       dbPath,
+		}).Error("chat history database is corrupt, stop the daemon with 'echoy stop' and run 'echoy chats repair'")
+		return nil
+	}
+
+	backupPath, err := history.Recreate(dbPath)
+	if err != nil {
+		return fmt.Errorf("the chat history database %s is corrupt and could not be recreated: %w (move it aside and try again)", dbPath, err)
+	}
+
+	container.Logger.WithFields(logger.Fields{
+		"db_file":     dbPath,
+		"backup_file": backupPath,
+	}).Error("chat history database was corrupt and has been recreated, previous chats are in the backup file")
+
+	return nil
+}
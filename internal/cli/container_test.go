@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckChatHistory(t *testing.T) {
+	newContainer := func(t *testing.T) (*Container, string) {
+		// Unix socket paths are limited to about 100 characters, t.TempDir can be longer
+		dir, err := os.MkdirTemp("", "echoy")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		dbPath := filepath.Join(dir, "chat_history.db")
+		require.NoError(t, os.WriteFile(dbPath, []byte("not a database"), 0600))
+
+		return &Container{
+			Logger:         logger.NewNoopLogger(),
+			Paths:          map[filesystem.PathType]string{filesystem.ChatHistoryDB: dbPath},
+			SocketFilePath: filepath.Join(dir, "echoy.sock"),
+		}, dbPath
+	}
+
+	t.Run("recreates a corrupt database", func(t *testing.T) {
+		container, dbPath := newContainer(t)
+
+		require.NoError(t, checkChatHistory(context.Background(), container, &startupDiagnostics{}))
+
+		backups, err := filepath.Glob(dbPath + ".corrupt-*")
+		require.NoError(t, err)
+		assert.Len(t, backups, 1, "the corrupt database should be moved aside")
+	})
+
+	t.Run("keeps the database the daemon has open", func(t *testing.T) {
+		container, dbPath := newContainer(t)
+
+		listener, err := net.Listen("unix", container.SocketFilePath)
+		require.NoError(t, err)
+		defer listener.Close()
+		require.True(t, container.DaemonRunning())
+
+		require.NoError(t, checkChatHistory(context.Background(), container, &startupDiagnostics{}))
+
+		data, err := os.ReadFile(dbPath)
+		require.NoError(t, err)
+		assert.Equal(t, "not a database", string(data), "the database shouldn't be replaced under the daemon")
+	})
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+)
+
+// startupEvent is one finished container initialization step
+type startupEvent struct {
+	step     string
+	duration time.Duration
+	err      error
+	fields   logger.Fields
+}
+
+// startupDiagnostics logs the container initialization steps as debug events. The logger
+// is only created halfway through the initialization, so earlier steps are kept and
+// replayed once it's available.
+type startupDiagnostics struct {
+	logger  logger.Logger
+	pending []startupEvent
+
+	// fallback receives the pending steps if initialization fails before the logger exists
+	fallback io.Writer
+}
+
+// record logs the outcome of step, which began at started
+func (d *startupDiagnostics) record(step string, started time.Time, err error, fields logger.Fields) {
+	event := startupEvent{step: step, duration: time.Since(started), err: err, fields: fields}
+	if d.logger == nil {
+		d.pending = append(d.pending, event)
+		return
+	}
+
+	d.log(event)
+}
+
+// setLogger starts logging through log and replays the steps recorded so far
+func (d *startupDiagnostics) setLogger(log logger.Logger) {
+	d.logger = log
+	for _, event := range d.pending {
+		d.log(event)
+	}
+	d.pending = nil
+}
+
+// flush writes the steps that couldn't be logged to the fallback writer
+func (d *startupDiagnostics) flush() {
+	if d.fallback == nil {
+		return
+	}
+
+	for _, event := range d.pending {
+		fmt.Fprintln(d.fallback, event.String())
+	}
+	d.pending = nil
+}
+
+func (d *startupDiagnostics) log(event startupEvent) {
+	fields := logger.Fields{
+		"step":        event.step,
+		"duration_ms": event.duration.Milliseconds(),
+	}
+	for key, value := range event.fields {
+		fields[key] = value
+	}
+
+	if event.err != nil {
+		d.logger.WithFields(fields).WithField(logger.ErrorKey, event.err).Error("container initialization step failed")
+		return
+	}
+
+	d.logger.WithFields(fields).Debug("container initialization step finished")
+}
+
+// String renders the event as a single key=value line
+func (e startupEvent) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "startup: step=%s duration_ms=%d", e.step, e.duration.Milliseconds())
+
+	keys := make([]string, 0, len(e.fields))
+	for key := range e.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, e.fields[key])
+	}
+
+	if e.err != nil {
+		fmt.Fprintf(&b, " error=%q", e.err.Error())
+	}
+
+	return b.String()
+}
+
+// runStep runs fn but returns early with the context's error once ctx is done. Filesystem
+// work can't be interrupted, so fn keeps running in the background in that case.
+func runStep[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-done:
+		return r.value, r.err
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+	loggerMocks "github.com/shaharia-lab/echoy/internal/logger/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupDiagnostics_ReplaysStepsOnceLoggerExists(t *testing.T) {
+	diagnostics := &startupDiagnostics{}
+	diagnostics.record("ensure_paths", time.Now(), nil, logger.Fields{"app_directory": "/home/user/.echoy"})
+	diagnostics.record("load_system_config", time.Now(), nil, nil)
+	assert.Len(t, diagnostics.pending, 2)
+
+	var steps []string
+	mockLogger := loggerMocks.NewMockLogger(t)
+	mockLogger.EXPECT().WithFields(mock.Anything).RunAndReturn(func(fields logger.Fields) logger.Logger {
+		steps = append(steps, fields["step"].(string))
+		return mockLogger
+	})
+	mockLogger.EXPECT().Debug("container initialization step finished").Return()
+
+	diagnostics.setLogger(mockLogger)
+	diagnostics.record("create_logger", time.Now(), nil, nil)
+
+	assert.Equal(t, []string{"ensure_paths", "load_system_config", "create_logger"}, steps)
+	assert.Empty(t, diagnostics.pending)
+}
+
+func TestStartupDiagnostics_FlushWithoutLogger(t *testing.T) {
+	var buf bytes.Buffer
+	diagnostics := &startupDiagnostics{fallback: &buf}
+
+	diagnostics.record("ensure_paths", time.Now(), errors.New("permission denied"), logger.Fields{"app_directory": "/home/user/.echoy"})
+	diagnostics.flush()
+
+	assert.Contains(t, buf.String(), "startup: step=ensure_paths")
+	assert.Contains(t, buf.String(), "app_directory=/home/user/.echoy")
+	assert.Contains(t, buf.String(), `error="permission denied"`)
+	assert.Empty(t, diagnostics.pending)
+}
+
+func TestRunStep(t *testing.T) {
+	value, err := runStep(context.Background(), func() (string, error) {
+		return "done", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runStep(ctx, func() (string, error) {
+		t.Error("step must not run with a cancelled context")
+		return "", nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	_, err = runStep(ctx, func() (string, error) {
+		<-release
+		return "too late", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
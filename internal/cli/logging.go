@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/logger"
+)
+
+// DisableFileLogEnv is the environment variable turning off the log files, e.g. in a
+// container whose logs are collected from the output of the process
+const DisableFileLogEnv = "ECHOY_DISABLE_FILE_LOG"
+
+// DisableFileLogFromEnv reports whether DisableFileLogEnv, looked up with lookup, e.g.
+// os.LookupEnv, turns off the log files. Values that don't parse as a bool leave them on.
+// The container is built before the configuration is loaded, so this can't be a setting.
+func DisableFileLogFromEnv(lookup func(string) (string, bool)) bool {
+	value, ok := lookup(DisableFileLogEnv)
+	if !ok {
+		return false
+	}
+
+	disabled, err := strconv.ParseBool(value)
+	return err == nil && disabled
+}
+
+// ProcessLogConfig returns the logger configuration of the long-running processes, the
+// daemon and the web server, logging to fileName in the logs directory. With the log
+// files disabled, the logs are written to stdout as JSON instead.
+func (c *Container) ProcessLogConfig(fileName string) logger.Config {
+	return logger.Config{
+		LogLevel:       logger.DebugLevel,
+		LogFilePath:    filepath.Join(c.Paths[filesystem.LogsDirectory], fileName),
+		DisableFileLog: c.DisableFileLog,
+
+		MaxSizeMB:  50,
+		MaxAgeDays: 14,
+		MaxBackups: 5,
+	}
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisableFileLogFromEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		disabled bool
+	}{
+		{name: "unset", env: map[string]string{}, disabled: false},
+		{name: "true", env: map[string]string{DisableFileLogEnv: "true"}, disabled: true},
+		{name: "one", env: map[string]string{DisableFileLogEnv: "1"}, disabled: true},
+		{name: "false", env: map[string]string{DisableFileLogEnv: "false"}, disabled: false},
+		{name: "invalid value keeps the files", env: map[string]string{DisableFileLogEnv: "yes please"}, disabled: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				value, ok := tc.env[key]
+				return value, ok
+			}
+
+			assert.Equal(t, tc.disabled, DisableFileLogFromEnv(lookup))
+		})
+	}
+}
+
+func TestContainer_ProcessLogConfig(t *testing.T) {
+	container := &Container{Paths: map[filesystem.PathType]string{filesystem.LogsDirectory: "/var/log/echoy"}}
+
+	cfg := container.ProcessLogConfig("daemon.log")
+	assert.Equal(t, "/var/log/echoy/daemon.log", cfg.LogFilePath)
+	assert.False(t, cfg.DisableFileLog)
+
+	container.DisableFileLog = true
+	assert.True(t, container.ProcessLogConfig("webserver.log").DisableFileLog)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+// ExitError makes the process exit with Code without printing anything else. It is
+// returned by commands that already reported the failure themselves, e.g. as JSON.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error implements the error interface
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Output prints a command's result either as themed text or, with --json, as JSON
+type Output struct {
+	JSON bool
+
+	cmd      *cobra.Command
+	themeMgr *theme.Manager
+	// writer overrides the output of cmd, where JSON results are written by default
+	writer io.Writer
+}
+
+// NewOutput registers the --json flag on cmd and returns the Output bound to it
+func NewOutput(cmd *cobra.Command, themeMgr *theme.Manager) *Output {
+	o := &Output{
+		cmd:      cmd,
+		themeMgr: themeMgr,
+	}
+
+	cmd.Flags().BoolVar(&o.JSON, "json", false, "print the result as JSON")
+
+	return o
+}
+
+// SetWriter changes where JSON results are written to
+func (o *Output) SetWriter(w io.Writer) *Output {
+	o.writer = w
+	return o
+}
+
+// Success prints result as JSON, or calls text with the current theme in text mode
+func (o *Output) Success(result interface{}, text func(t theme.Theme)) error {
+	if o.JSON {
+		return o.encode(result)
+	}
+
+	text(o.themeMgr.GetCurrentTheme())
+	return nil
+}
+
+// Fail reports a failed command and returns the error for the command to return. In JSON
+// mode result is printed and err is wrapped in an ExitError so it isn't printed again.
+func (o *Output) Fail(result interface{}, err error, text func(t theme.Theme)) error {
+	if !o.JSON {
+		text(o.themeMgr.GetCurrentTheme())
+		return err
+	}
+
+	o.cmd.SilenceErrors = true
+	o.cmd.SilenceUsage = true
+
+	if encodeErr := o.encode(result); encodeErr != nil {
+		return encodeErr
+	}
+
+	return &ExitError{Code: 1, Err: err}
+}
+
+func (o *Output) encode(result interface{}) error {
+	writer := o.writer
+	if writer == nil {
+		writer = o.cmd.OutOrStdout()
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode result as JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResult struct {
+	Running bool `json:"running"`
+}
+
+func newTestOutput(t *testing.T, args ...string) (*Output, *cobra.Command, *bytes.Buffer, *bytes.Buffer) {
+	var jsonBuf, textBuf bytes.Buffer
+
+	defaultTheme := theme.NewDefaultTheme()
+	defaultTheme.SetEnabled(false)
+	themeMgr := theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&textBuf)
+
+	cmd := &cobra.Command{Use: "status"}
+	output := NewOutput(cmd, themeMgr).SetWriter(&jsonBuf)
+	require.NoError(t, cmd.ParseFlags(args))
+
+	return output, cmd, &jsonBuf, &textBuf
+}
+
+func TestOutput_Text(t *testing.T) {
+	output, cmd, jsonBuf, textBuf := newTestOutput(t)
+	assert.False(t, output.JSON)
+
+	err := output.Success(testResult{Running: true}, func(t theme.Theme) {
+		t.Success().Println("Daemon is running")
+	})
+	require.NoError(t, err)
+
+	failure := errors.New("daemon is not running")
+	err = output.Fail(testResult{}, failure, func(t theme.Theme) {
+		t.Error().Println("Daemon is not running")
+	})
+	assert.Same(t, failure, err)
+
+	assert.Empty(t, jsonBuf.String())
+	assert.Contains(t, textBuf.String(), "Daemon is running\n")
+	assert.Contains(t, textBuf.String(), "Daemon is not running\n")
+	assert.False(t, cmd.SilenceErrors)
+}
+
+func TestOutput_JSON(t *testing.T) {
+	output, cmd, jsonBuf, textBuf := newTestOutput(t, "--json")
+	assert.True(t, output.JSON)
+
+	err := output.Success(testResult{Running: true}, func(t theme.Theme) {
+		t.Success().Println("Daemon is running")
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"running":true}`, jsonBuf.String())
+
+	jsonBuf.Reset()
+	failure := errors.New("daemon is not running")
+	err = output.Fail(testResult{}, failure, nil)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.Code)
+	assert.ErrorIs(t, err, failure)
+	assert.JSONEq(t, `{"running":false}`, jsonBuf.String())
+	assert.True(t, cmd.SilenceErrors)
+	assert.True(t, cmd.SilenceUsage)
+
+	assert.Empty(t, textBuf.String())
+}
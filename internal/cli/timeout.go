@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const timeoutFlag = "timeout"
+
+// AddTimeoutFlag registers the --timeout flag on flags
+func AddTimeoutFlag(flags *pflag.FlagSet) {
+	flags.Duration(timeoutFlag, 0, "give up on the command after this long, e.g. 30s (default no limit)")
+}
+
+// ApplyTimeout bounds the context of cmd by the --timeout flag, so commands using
+// cmd.Context() stop when it elapses. Without the flag the context isn't bounded. The
+// returned function releases the context's resources.
+func ApplyTimeout(cmd *cobra.Command) (context.CancelFunc, error) {
+	timeout, err := cmd.Flags().GetDuration(timeoutFlag)
+	if err != nil || timeout == 0 {
+		return func() {}, nil
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("--%s must not be negative, got %s", timeoutFlag, timeout)
+	}
+
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeoutCause(parent, timeout, fmt.Errorf("command timed out after %s (--%s)", timeout, timeoutFlag))
+	cmd.SetContext(ctx)
+
+	return cancel, nil
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTimeoutTestCmd(run func(ctx context.Context)) *cobra.Command {
+	root := &cobra.Command{Use: "echoy"}
+	AddTimeoutFlag(root.PersistentFlags())
+
+	child := &cobra.Command{
+		Use: "child",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			cancel, err := ApplyTimeout(cmd)
+			if err != nil {
+				return err
+			}
+			cmd.PostRun = func(*cobra.Command, []string) { cancel() }
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) { run(cmd.Context()) },
+	}
+	root.AddCommand(child)
+
+	return root
+}
+
+func TestApplyTimeout(t *testing.T) {
+	t.Run("bounds the command context", func(t *testing.T) {
+		var deadline time.Time
+		var cause error
+		root := newTimeoutTestCmd(func(ctx context.Context) {
+			deadline, _ = ctx.Deadline()
+			<-ctx.Done()
+			cause = context.Cause(ctx)
+		})
+		root.SetArgs([]string{"child", "--timeout", "20ms"})
+
+		started := time.Now()
+		require.NoError(t, root.Execute())
+
+		assert.WithinDuration(t, started.Add(20*time.Millisecond), deadline, time.Second)
+		assert.ErrorContains(t, cause, "--timeout")
+	})
+
+	t.Run("no limit by default", func(t *testing.T) {
+		hasDeadline := true
+		root := newTimeoutTestCmd(func(ctx context.Context) {
+			_, hasDeadline = ctx.Deadline()
+		})
+		root.SetArgs([]string{"child"})
+
+		require.NoError(t, root.Execute())
+		assert.False(t, hasDeadline)
+	})
+
+	t.Run("negative timeout", func(t *testing.T) {
+		root := newTimeoutTestCmd(func(ctx context.Context) {})
+		root.SetArgs([]string{"child", "--timeout", "-1s"})
+		root.SilenceErrors = true
+		root.SilenceUsage = true
+
+		assert.ErrorContains(t, root.Execute(), "must not be negative")
+	})
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/spf13/pflag"
+)
+
+const (
+	quietFlag   = "quiet"
+	verboseFlag = "verbose"
+)
+
+// Verbosity is how chatty the CLI is: negative is quiet, zero is the default and
+// every --verbose/-v raises it by one
+type Verbosity int
+
+const (
+	// VerbosityQuiet only shows errors
+	VerbosityQuiet Verbosity = -1
+	// VerbosityNormal is the default output
+	VerbosityNormal Verbosity = 0
+	// VerbosityVerbose adds debug logs
+	VerbosityVerbose Verbosity = 1
+)
+
+// Quiet reports whether non-essential output should be suppressed
+func (v Verbosity) Quiet() bool {
+	return v < VerbosityNormal
+}
+
+// LogLevel returns the console log level for v
+func (v Verbosity) LogLevel() logger.Level {
+	switch {
+	case v < VerbosityNormal:
+		return logger.ErrorLevel
+	case v >= VerbosityVerbose:
+		return logger.DebugLevel
+	default:
+		return logger.InfoLevel
+	}
+}
+
+// AddVerbosityFlags registers the --quiet and --verbose/-v flags on flags
+func AddVerbosityFlags(flags *pflag.FlagSet) {
+	flags.BoolP(quietFlag, "q", false, "only print errors")
+	flags.CountP(verboseFlag, "v", "print debug logs (can be repeated)")
+}
+
+// VerbosityFromArgs resolves the verbosity from the command line arguments. The container
+// is built before cobra parses the flags, so they are looked up here ahead of time.
+// --quiet wins over --verbose.
+func VerbosityFromArgs(args []string) Verbosity {
+	flags := pflag.NewFlagSet("verbosity", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.Usage = func() {}
+	AddVerbosityFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return VerbosityNormal
+	}
+
+	if quiet, _ := flags.GetBool(quietFlag); quiet {
+		return VerbosityQuiet
+	}
+
+	verbose, _ := flags.GetCount(verboseFlag)
+	return Verbosity(verbose)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbosityFromArgs(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		verbosity Verbosity
+		level     logger.Level
+	}{
+		{name: "default", args: []string{"chat"}, verbosity: VerbosityNormal, level: logger.InfoLevel},
+		{name: "quiet", args: []string{"--quiet", "status"}, verbosity: VerbosityQuiet, level: logger.ErrorLevel},
+		{name: "quiet shorthand after subcommand", args: []string{"status", "-q"}, verbosity: VerbosityQuiet, level: logger.ErrorLevel},
+		{name: "verbose", args: []string{"-v", "start"}, verbosity: VerbosityVerbose, level: logger.DebugLevel},
+		{name: "stacked verbose", args: []string{"-vv", "start", "--verbose"}, verbosity: 3, level: logger.DebugLevel},
+		{name: "quiet wins", args: []string{"-v", "--quiet"}, verbosity: VerbosityQuiet, level: logger.ErrorLevel},
+		{name: "unknown flags are ignored", args: []string{"start", "--foreground", "-f", "--on-conflict=skip"}, verbosity: VerbosityNormal, level: logger.InfoLevel},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			verbosity := VerbosityFromArgs(tc.args)
+			assert.Equal(t, tc.verbosity, verbosity)
+			assert.Equal(t, tc.level, verbosity.LogLevel())
+			assert.Equal(t, tc.verbosity == VerbosityQuiet, verbosity.Quiet())
+		})
+	}
+}
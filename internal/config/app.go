@@ -10,6 +10,11 @@ type Repository struct {
 	Repo  string
 }
 
+// URL returns the address of the repository on GitHub
+func (r Repository) URL() string {
+	return fmt.Sprintf("https://github.com/%s/%s", r.Owner, r.Repo)
+}
+
 type SystemConfig struct {
 	UUID string `json:"uuid"`
 }
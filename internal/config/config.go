@@ -1,5 +1,16 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrConfigNotInitialized is returned when the configuration file is missing or blank, so
+// 'echoy init' has to be run before Echoy can be used
+var ErrConfigNotInitialized = errors.New("configuration is not initialized")
+
 // AssistantConfig represents the assistant configuration
 type AssistantConfig struct {
 	Name string `yaml:"name"`
@@ -39,19 +50,322 @@ type ToolsConfig struct {
 
 // LLMConfig represents the LLM configuration
 type LLMConfig struct {
-	Provider    string  `yaml:"provider"`
-	Model       string  `yaml:"model"`
-	Token       string  `yaml:"token"`
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	// Token is the token of Provider. Files written before credentials were kept per
+	// provider store it here, newer ones in Credentials.
+	Token string `yaml:"token,omitempty" secret:"true"`
+	// Credentials are the tokens entered for each provider, keyed by the lower-cased
+	// provider ID, so switching providers doesn't lose them
+	Credentials map[string]ProviderCreds `yaml:"credentials,omitempty"`
+	// TokenFile is a file holding the token, read when the configuration is loaded
+	TokenFile string `yaml:"token_file,omitempty"`
+	// TokenSource selects where the token is read from: config, file or keychain.
+	// It defaults to file if TokenFile is set and to config otherwise.
+	TokenSource string  `yaml:"token_source,omitempty"`
 	MaxTokens   int64   `yaml:"max_tokens"`
 	Streaming   bool    `yaml:"streaming"`
 	TopP        float64 `yaml:"top_p"`
 	Temperature float64 `yaml:"temperature"`
 	TopK        int64   `yaml:"top_k"`
+	// RequestTimeout bounds how long the provider may take to answer, or for a streamed
+	// answer to start. It defaults to DefaultLLMRequestTimeout.
+	RequestTimeout time.Duration `yaml:"request_timeout,omitempty"`
+	// EchoLatency is how long the echo provider waits before each streamed word, to mimic
+	// a real model
+	EchoLatency time.Duration `yaml:"echo_latency,omitempty"`
+	// LogContent adds the messages sent to the provider and its answers to the log of
+	// each LLM call. Only the metadata of the calls is logged without it, for privacy.
+	LogContent bool `yaml:"log_content,omitempty"`
+	// BaseURL replaces the API endpoint of the provider, e.g. to route the calls through
+	// a gateway such as LiteLLM or Helicone. The gemini provider doesn't support it.
+	BaseURL string `yaml:"base_url,omitempty"`
+	// ExtraHeaders are sent with every call to the provider, e.g. the tracking or
+	// authentication headers of a gateway. Their values may hold keys, so they are secret.
+	ExtraHeaders map[string]string `yaml:"extra_headers,omitempty" secret:"true"`
+}
+
+// ProviderCreds are the credentials of a single LLM provider
+type ProviderCreds struct {
+	Token string `yaml:"token,omitempty" secret:"true"`
+}
+
+// ProviderToken returns the token of provider. Token takes precedence for the current
+// provider, as it holds the token resolved from the configured token source.
+func (c LLMConfig) ProviderToken(provider string) string {
+	if strings.EqualFold(provider, c.Provider) && c.Token != "" {
+		return c.Token
+	}
+
+	return c.Credentials[strings.ToLower(provider)].Token
+}
+
+// SetProviderToken stores token in the credentials of provider, an empty token removes them
+func (c *LLMConfig) SetProviderToken(provider, token string) {
+	if provider == "" {
+		return
+	}
+
+	if token == "" {
+		delete(c.Credentials, strings.ToLower(provider))
+		return
+	}
+
+	if c.Credentials == nil {
+		c.Credentials = make(map[string]ProviderCreds)
+	}
+	c.Credentials[strings.ToLower(provider)] = ProviderCreds{Token: token}
+}
+
+// EchoProvider is the ID of the offline provider that repeats messages back. It doesn't
+// call any API and needs no token.
+const EchoProvider = "echo"
+
+// RequiresToken reports whether the provider needs an API token
+func (c LLMConfig) RequiresToken() bool {
+	return !strings.EqualFold(c.Provider, EchoProvider)
+}
+
+// DefaultLLMRequestTimeout is used when LLMConfig.RequestTimeout isn't set
+const DefaultLLMRequestTimeout = 2 * time.Minute
+
+// ResolvedRequestTimeout returns the request timeout, applying the default
+func (c LLMConfig) ResolvedRequestTimeout() time.Duration {
+	if c.RequestTimeout <= 0 {
+		return DefaultLLMRequestTimeout
+	}
+
+	return c.RequestTimeout
+}
+
+// Token sources for LLMConfig.TokenSource
+const (
+	TokenSourceConfig   = "config"
+	TokenSourceFile     = "file"
+	TokenSourceKeychain = "keychain"
+)
+
+// ResolvedTokenSource returns the source the token is read from, applying the defaults
+func (c LLMConfig) ResolvedTokenSource() string {
+	switch {
+	case c.TokenSource != "":
+		return strings.ToLower(c.TokenSource)
+	case c.TokenFile != "":
+		return TokenSourceFile
+	default:
+		return TokenSourceConfig
+	}
+}
+
+// StoresToken reports whether the token is kept in the configuration file itself. Tokens
+// from other sources must never be written back to it.
+func (c LLMConfig) StoresToken() bool {
+	return c.ResolvedTokenSource() == TokenSourceConfig
+}
+
+// ChatConfig represents the chat history configuration
+type ChatConfig struct {
+	// RetentionDays deletes chats older than this many days, 0 keeps them forever
+	RetentionDays int `yaml:"retention_days,omitempty"`
+	// MaxChats keeps only this many of the most recent chats, 0 means no limit
+	MaxChats int `yaml:"max_chats,omitempty"`
+	// RenderMarkdown styles Markdown in answers when the output is a terminal. It is on
+	// unless set to false.
+	RenderMarkdown *bool `yaml:"render_markdown,omitempty"`
+	// HistoryBackend selects where chats are stored: sqlite, memory or none. It defaults to
+	// sqlite. Chats in memory are lost when the process exits, none doesn't keep them at all.
+	HistoryBackend string `yaml:"history_backend,omitempty"`
+	// MemoryMaxChats bounds the memory backend to this many chats, evicting the ones
+	// accessed least recently. It defaults to DefaultMemoryMaxChats.
+	MemoryMaxChats int `yaml:"memory_max_chats,omitempty"`
+	// InteractiveStreaming shows answers in the chat session while they are generated. It
+	// defaults to LLM.Streaming.
+	InteractiveStreaming *bool `yaml:"interactive_streaming,omitempty"`
+	// PromptFormat is the prompt shown before the user's input in the chat session. {user}
+	// is replaced by the user's name, {model} by the LLM model and {time} by the current
+	// time. It defaults to DefaultPromptFormat.
+	PromptFormat string `yaml:"prompt_format,omitempty"`
+	// AssistantPromptFormat is the label shown before answers in the chat session, with the
+	// same placeholders as PromptFormat. It defaults to DefaultAssistantPromptFormat.
+	AssistantPromptFormat string `yaml:"assistant_prompt_format,omitempty"`
+	// ShowWelcome prints Welcome when the chat session starts. It is on unless set to false.
+	ShowWelcome *bool `yaml:"show_welcome,omitempty"`
+	// Welcome is the message printed when the chat session starts, its first line as the
+	// headline. It has the placeholders of PromptFormat and {session_id} for the ID of
+	// the chat. It defaults to DefaultWelcome.
+	Welcome string `yaml:"welcome,omitempty"`
+}
+
+// Defaults of ChatConfig.PromptFormat and ChatConfig.AssistantPromptFormat
+const (
+	DefaultPromptFormat          = "{user} > "
+	DefaultAssistantPromptFormat = "AI > "
+)
+
+// DefaultWelcome is used when ChatConfig.Welcome isn't set
+const DefaultWelcome = `🗨️ Chat session started.
+Session ID: {session_id}
+Type your message and press Enter. For multi-line input, continue typing.
+Press Enter twice (empty line) to submit your message.
+Type '/search <query>' to find previous chats by content.
+Type '/new' to start a new chat.
+Type '/continue' to have the last answer carry on where it stopped.
+Type '/fork [n]' to start a new chat with the first n messages of this one, all without n.
+Type 'exit' to end the session.`
+
+// History backends for ChatConfig.HistoryBackend
+const (
+	HistoryBackendSQLite = "sqlite"
+	HistoryBackendMemory = "memory"
+	HistoryBackendNone   = "none"
+)
+
+// DefaultMemoryMaxChats is how many chats the memory history backend keeps by default
+const DefaultMemoryMaxChats = 1000
+
+// ResolvedMemoryMaxChats returns how many chats the memory backend keeps, applying the
+// default
+func (c ChatConfig) ResolvedMemoryMaxChats() int {
+	if c.MemoryMaxChats <= 0 {
+		return DefaultMemoryMaxChats
+	}
+
+	return c.MemoryMaxChats
+}
+
+// ResolvedHistoryBackend returns the backend chats are stored in, applying the default
+func (c ChatConfig) ResolvedHistoryBackend() string {
+	if c.HistoryBackend == "" {
+		return HistoryBackendSQLite
+	}
+
+	return strings.ToLower(c.HistoryBackend)
+}
+
+// ResolvedPromptFormat returns the format of the user's prompt, applying the default
+func (c ChatConfig) ResolvedPromptFormat() string {
+	if c.PromptFormat == "" {
+		return DefaultPromptFormat
+	}
+
+	return c.PromptFormat
+}
+
+// ResolvedAssistantPromptFormat returns the format of the label before answers, applying the default
+func (c ChatConfig) ResolvedAssistantPromptFormat() string {
+	if c.AssistantPromptFormat == "" {
+		return DefaultAssistantPromptFormat
+	}
+
+	return c.AssistantPromptFormat
+}
+
+// ResolvedWelcome returns the message printed when the chat session starts, applying the
+// default
+func (c ChatConfig) ResolvedWelcome() string {
+	if c.Welcome == "" {
+		return DefaultWelcome
+	}
+
+	return c.Welcome
+}
+
+// WelcomeEnabled reports whether the welcome message is printed when the chat session starts
+func (c ChatConfig) WelcomeEnabled() bool {
+	return c.ShowWelcome == nil || *c.ShowWelcome
+}
+
+// MarkdownEnabled reports whether answers should be rendered as Markdown in a terminal
+func (c ChatConfig) MarkdownEnabled() bool {
+	return c.RenderMarkdown == nil || *c.RenderMarkdown
 }
 
 // FrontendConfig represents the frontend configuration
 type FrontendConfig struct {
 	Enabled bool `yaml:"enabled"`
+	// GitHubAPIURL is the GitHub API the web UI releases are looked up in, e.g. the
+	// /api/v3 endpoint of GitHub Enterprise. It defaults to the public GitHub API.
+	GitHubAPIURL string `yaml:"github_api_url,omitempty"`
+	// DownloadURL replaces the scheme and host of release asset URLs, for mirrors that
+	// serve the assets under the same paths as GitHub
+	DownloadURL string `yaml:"download_url,omitempty"`
+}
+
+// HTTPConfig configures the outbound HTTP calls, to the LLM provider and to GitHub for the
+// web UI. Proxies are taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables.
+type HTTPConfig struct {
+	// CAFile is a PEM file of certificate authorities trusted in addition to the system
+	// ones, e.g. of a proxy inspecting TLS
+	CAFile string `yaml:"ca_file,omitempty"`
+}
+
+// WebServerConfig represents the web server configuration
+type WebServerConfig struct {
+	// APIPort is where the web server listens, a bare port such as 8080 on all interfaces,
+	// or a host:port such as 127.0.0.1:8080. It defaults to DefaultAPIPort.
+	APIPort string `yaml:"api_port,omitempty"`
+	// DefaultStreaming makes POST /api/v1/chats stream the answer as server-sent events
+	// unless the client asks for JSON. It defaults to LLM.Streaming.
+	DefaultStreaming *bool `yaml:"default_streaming,omitempty"`
+	// RestartOnCrash makes the daemon start the web server again if it exits on its own
+	RestartOnCrash bool `yaml:"restart_on_crash,omitempty"`
+	// MaxRestarts is how often the web server is restarted before the daemon gives up. It
+	// defaults to DefaultWebServerMaxRestarts.
+	MaxRestarts int `yaml:"max_restarts,omitempty"`
+	// EnableCompression gzips API responses for clients that accept it. It is on unless
+	// set to false.
+	EnableCompression *bool `yaml:"enable_compression,omitempty"`
+	// MaxStreamDuration caps how long a streamed answer may take before it is ended and
+	// its LLM call cancelled. It defaults to DefaultMaxStreamDuration.
+	MaxStreamDuration time.Duration `yaml:"max_stream_duration,omitempty"`
+	// IdleShutdown stops the web server once it served no request for this long, the
+	// daemon keeps running. Zero, the default, keeps it running.
+	IdleShutdown time.Duration `yaml:"idle_shutdown,omitempty"`
+	// EnablePprof serves the Go profiler under /debug/pprof/. It is off by default, the
+	// profiles reveal the internals of the process to anyone reaching the port.
+	EnablePprof bool `yaml:"enable_pprof,omitempty"`
+}
+
+// DefaultAPIPort is used when WebServerConfig.APIPort isn't set
+const DefaultAPIPort = "10222"
+
+// ResolvedAPIPort returns APIPort, or its default if it isn't set
+func (c WebServerConfig) ResolvedAPIPort() string {
+	if strings.TrimSpace(c.APIPort) == "" {
+		return DefaultAPIPort
+	}
+
+	return c.APIPort
+}
+
+// DefaultMaxStreamDuration is used when WebServerConfig.MaxStreamDuration isn't set
+const DefaultMaxStreamDuration = 10 * time.Minute
+
+// ResolvedMaxStreamDuration returns MaxStreamDuration, or its default if it isn't set
+func (c WebServerConfig) ResolvedMaxStreamDuration() time.Duration {
+	if c.MaxStreamDuration <= 0 {
+		return DefaultMaxStreamDuration
+	}
+
+	return c.MaxStreamDuration
+}
+
+// DefaultWebServerMaxRestarts is used when WebServerConfig.MaxRestarts isn't set
+const DefaultWebServerMaxRestarts = 5
+
+// ResolvedMaxRestarts returns MaxRestarts, or its default if it isn't set
+func (c WebServerConfig) ResolvedMaxRestarts() int {
+	if c.MaxRestarts <= 0 {
+		return DefaultWebServerMaxRestarts
+	}
+
+	return c.MaxRestarts
+}
+
+// CompressionEnabled reports whether API responses should be compressed
+func (c WebServerConfig) CompressionEnabled() bool {
+	return c.EnableCompression == nil || *c.EnableCompression
 }
 
 // Config represents the main configuration
@@ -60,10 +374,55 @@ type Config struct {
 	User          UserConfig      `yaml:"user"`
 	Tools         ToolsConfig     `yaml:"tools"`
 	LLM           LLMConfig       `yaml:"llm"`
+	Chat          ChatConfig      `yaml:"chat"`
 	Frontend      FrontendConfig  `yaml:"frontend"`
+	WebServer     WebServerConfig `yaml:"webserver,omitempty"`
+	HTTP          HTTPConfig      `yaml:"http,omitempty"`
+	UI            UIConfig        `yaml:"ui,omitempty"`
 	UsageTracking UsageTracking   `yaml:"usage_tracking"`
 }
 
+// InteractiveStreamingEnabled reports whether the chat session streams answers
+func (c Config) InteractiveStreamingEnabled() bool {
+	if c.Chat.InteractiveStreaming != nil {
+		return *c.Chat.InteractiveStreaming
+	}
+
+	return c.LLM.Streaming
+}
+
+// APIStreamingEnabled reports whether the chat API streams answers by default
+func (c Config) APIStreamingEnabled() bool {
+	if c.WebServer.DefaultStreaming != nil {
+		return *c.WebServer.DefaultStreaming
+	}
+
+	return c.LLM.Streaming
+}
+
+// UIConfig holds how the chat session and the API present things to the user
+type UIConfig struct {
+	// Timezone is the IANA name of the timezone the times of chats are shown in, e.g.
+	// Europe/Berlin. It defaults to the timezone of the system. Times are stored in UTC
+	// either way.
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// ResolvedLocation returns the location of Timezone, or time.Local if it isn't set. It
+// fails for a name that isn't in the timezone database.
+func (c UIConfig) ResolvedLocation() (*time.Location, error) {
+	if strings.TrimSpace(c.Timezone) == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(strings.TrimSpace(c.Timezone))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ui.timezone %q: %w", c.Timezone, err)
+	}
+
+	return location, nil
+}
+
 // UsageTracking represents the usage tracking configuration
 type UsageTracking struct {
 	Enabled bool `yaml:"enabled"`
@@ -114,3 +473,9 @@ func (c *Config) Default() Config {
 		},
 	}
 }
+
+// IsInitialized reports whether the configuration went through the init flow,
+// i.e. it names an LLM provider and carries a token for it or names where to read it from.
+func (c *Config) IsInitialized() bool {
+	return c.LLM.Provider != "" && (c.LLM.ProviderToken(c.LLM.Provider) != "" || !c.LLM.StoresToken() || !c.LLM.RequiresToken())
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_StreamingDefaults(t *testing.T) {
+	on, off := true, false
+
+	tests := []struct {
+		name            string
+		config          Config
+		wantInteractive bool
+		wantAPI         bool
+	}{
+		{
+			name:            "fall back to llm.streaming",
+			config:          Config{LLM: LLMConfig{Streaming: true}},
+			wantInteractive: true,
+			wantAPI:         true,
+		},
+		{
+			name: "streaming in the terminal, buffered API",
+			config: Config{
+				LLM:       LLMConfig{Streaming: false},
+				Chat:      ChatConfig{InteractiveStreaming: &on},
+				WebServer: WebServerConfig{DefaultStreaming: &off},
+			},
+			wantInteractive: true,
+			wantAPI:         false,
+		},
+		{
+			name: "explicitly off",
+			config: Config{
+				LLM:  LLMConfig{Streaming: true},
+				Chat: ChatConfig{InteractiveStreaming: &off},
+			},
+			wantInteractive: false,
+			wantAPI:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantInteractive, tt.config.InteractiveStreamingEnabled())
+			assert.Equal(t, tt.wantAPI, tt.config.APIStreamingEnabled())
+		})
+	}
+}
+
+func TestLLMConfig_ProviderToken(t *testing.T) {
+	llm := LLMConfig{Provider: "openai", Token: "sk-openai"}
+	assert.Equal(t, "sk-openai", llm.ProviderToken("OpenAI"), "the top-level token belongs to the current provider")
+	assert.Empty(t, llm.ProviderToken("anthropic"))
+
+	llm.SetProviderToken("Anthropic", "sk-anthropic")
+	assert.Equal(t, "sk-anthropic", llm.ProviderToken("anthropic"))
+	assert.Equal(t, map[string]ProviderCreds{"anthropic": {Token: "sk-anthropic"}}, llm.Credentials)
+
+	llm.SetProviderToken("openai", "sk-stored")
+	assert.Equal(t, "sk-openai", llm.ProviderToken("openai"), "the resolved token takes precedence for the current provider")
+	llm.Token = ""
+	assert.Equal(t, "sk-stored", llm.ProviderToken("openai"))
+
+	llm.SetProviderToken("anthropic", "")
+	assert.Empty(t, llm.ProviderToken("anthropic"), "an empty token should remove the credentials")
+}
+
+func TestUIConfig_ResolvedLocation(t *testing.T) {
+	location, err := UIConfig{}.ResolvedLocation()
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, location, "the timezone of the system is the default")
+
+	location, err = UIConfig{Timezone: " UTC "}.ResolvedLocation()
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, location)
+
+	_, err = UIConfig{Timezone: "Mars/Olympus_Mons"}.ResolvedLocation()
+	assert.ErrorContains(t, err, `invalid ui.timezone "Mars/Olympus_Mons"`)
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces the values of fields tagged with secret:"true" in a diff or a
+// redacted config
+const redactedValue = "<redacted>"
+
+// FieldChange is a configuration value that differs between two configurations
+type FieldChange struct {
+	// Path is the dotted YAML path of the field, e.g. llm.model
+	Path string
+	Old  string
+	New  string
+}
+
+// String renders the change as a single line
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %s → %s", c.Path, c.Old, c.New)
+}
+
+// Diff returns the fields that differ between old and new, in the order they are declared.
+// Values of secret fields are redacted, so the result is safe to print.
+func Diff(old, new Config) []FieldChange {
+	var changes []FieldChange
+	diffValues("", reflect.ValueOf(old), reflect.ValueOf(new), false, &changes)
+	return changes
+}
+
+func diffValues(path string, old, new reflect.Value, secret bool, changes *[]FieldChange) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			diffValues(
+				joinPath(path, yamlName(field)),
+				old.Field(i),
+				new.Field(i),
+				secret || field.Tag.Get("secret") == "true",
+				changes,
+			)
+		}
+		return
+	}
+
+	// Map entries are compared one by one, so secrets in their values stay redacted
+	if old.Kind() == reflect.Map {
+		for _, key := range mapKeys(old, new) {
+			diffValues(joinPath(path, key.String()), mapIndex(old, key), mapIndex(new, key), secret, changes)
+		}
+		return
+	}
+
+	oldText, newText := formatValue(old), formatValue(new)
+	if oldText == newText {
+		return
+	}
+
+	if secret {
+		oldText, newText = redact(old), redact(new)
+	}
+
+	*changes = append(*changes, FieldChange{Path: path, Old: oldText, New: newText})
+}
+
+// mapKeys returns the keys of both maps, sorted
+func mapKeys(a, b reflect.Value) []reflect.Value {
+	seen := make(map[string]reflect.Value)
+	for _, m := range []reflect.Value{a, b} {
+		for _, key := range m.MapKeys() {
+			seen[key.String()] = key
+		}
+	}
+
+	keys := make([]reflect.Value, 0, len(seen))
+	for _, key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	return keys
+}
+
+// mapIndex returns the value of key in m, or the zero value if m doesn't have it
+func mapIndex(m, key reflect.Value) reflect.Value {
+	if value := m.MapIndex(key); value.IsValid() {
+		return value
+	}
+	return reflect.Zero(m.Type().Elem())
+}
+
+// yamlName returns the key the field is saved under
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// formatValue renders v for display. Nil and empty slices render the same, as both are
+// saved as an empty list. Pointers render the value they point to, a nil one the default.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return "(default)"
+		}
+		return formatValue(v.Elem())
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func redact(v reflect.Value) string {
+	if v.IsZero() {
+		return "(empty)"
+	}
+	return redactedValue
+}
+
+// Redact returns a copy of cfg with the values of fields tagged with secret:"true"
+// replaced, so it can be shown to the user
+func Redact(cfg Config) Config {
+	redactFields(reflect.ValueOf(&cfg).Elem(), false)
+	return cfg
+}
+
+func redactFields(v reflect.Value, secret bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.IsExported() {
+				redactFields(v.Field(i), secret || field.Tag.Get("secret") == "true")
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		// Map values aren't addressable, so the entries are redacted into a copy
+		redacted := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			redactFields(value, secret)
+			redacted.SetMapIndex(key, value)
+		}
+		v.Set(redacted)
+	case reflect.String:
+		if secret && v.String() != "" {
+			v.SetString(redactedValue)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	base := (&Config{}).Default()
+	base.LLM.Token = "sk-old"
+	base.Chat.RenderMarkdown = boolPtr(true)
+	base.Chat.ShowWelcome = boolPtr(true)
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []FieldChange
+	}{
+		{
+			name:   "no changes",
+			modify: func(c *Config) {},
+			want:   nil,
+		},
+		{
+			name: "nested fields in declaration order",
+			modify: func(c *Config) {
+				c.Assistant.Name = "Helper"
+				c.LLM.Model = "gpt-4o"
+				c.LLM.Temperature = 0.2
+				c.UsageTracking.Enabled = true
+			},
+			want: []FieldChange{
+				{Path: "Assistant.name", Old: `"Echoy"`, New: `"Helper"`},
+				{Path: "llm.model", Old: `"gpt-3.5-turbo"`, New: `"gpt-4o"`},
+				{Path: "llm.temperature", Old: "0.7", New: "0.2"},
+				{Path: "usage_tracking.enabled", Old: "false", New: "true"},
+			},
+		},
+		{
+			name: "slices",
+			modify: func(c *Config) {
+				c.Tools.Git.WhitelistedRepoPaths = []string{"/src/a", "/src/b"}
+			},
+			want: []FieldChange{
+				{Path: "tools.git.whitelisted_repo_paths", Old: "[]", New: `["/src/a", "/src/b"]`},
+			},
+		},
+		{
+			name: "nil and empty slices are equal",
+			modify: func(c *Config) {
+				c.Tools.Git.BlockedOperations = nil
+			},
+			want: nil,
+		},
+		{
+			name: "pointers are compared by value",
+			modify: func(c *Config) {
+				c.Chat.ShowWelcome = boolPtr(false)
+			},
+			want: []FieldChange{
+				{Path: "chat.show_welcome", Old: "true", New: "false"},
+			},
+		},
+		{
+			name: "unset pointers",
+			modify: func(c *Config) {
+				c.Chat.InteractiveStreaming = boolPtr(true)
+			},
+			want: []FieldChange{
+				{Path: "chat.interactive_streaming", Old: "(default)", New: "true"},
+			},
+		},
+		{
+			name: "secrets are redacted",
+			modify: func(c *Config) {
+				c.LLM.Token = "sk-new"
+			},
+			want: []FieldChange{
+				{Path: "llm.token", Old: redactedValue, New: redactedValue},
+			},
+		},
+		{
+			name: "cleared secret",
+			modify: func(c *Config) {
+				c.LLM.Token = ""
+			},
+			want: []FieldChange{
+				{Path: "llm.token", Old: redactedValue, New: "(empty)"},
+			},
+		},
+		{
+			name: "credentials are compared per provider and redacted",
+			modify: func(c *Config) {
+				c.LLM.SetProviderToken("anthropic", "sk-anthropic")
+			},
+			want: []FieldChange{
+				{Path: "llm.credentials.anthropic.token", Old: "(empty)", New: redactedValue},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base
+			// Equal values behind other pointers aren't changes
+			updated.Chat.RenderMarkdown = boolPtr(true)
+			updated.Chat.ShowWelcome = boolPtr(true)
+			updated.Tools.Git.WhitelistedRepoPaths = append([]string{}, base.Tools.Git.WhitelistedRepoPaths...)
+			tt.modify(&updated)
+
+			assert.Equal(t, tt.want, Diff(base, updated))
+		})
+	}
+}
+
+func TestFieldChange_String(t *testing.T) {
+	change := FieldChange{Path: "llm.model", Old: `"a"`, New: `"b"`}
+	assert.Equal(t, `llm.model: "a" → "b"`, change.String())
+}
+
+func TestRedact(t *testing.T) {
+	cfg := (&Config{}).Default()
+	cfg.LLM.Token = "sk-secret"
+
+	redacted := Redact(cfg)
+	assert.Equal(t, redactedValue, redacted.LLM.Token)
+	assert.Equal(t, cfg.LLM.Model, redacted.LLM.Model, "fields that aren't secret should be kept")
+	assert.Equal(t, "sk-secret", cfg.LLM.Token, "the original should not be modified")
+
+	cfg.LLM.Token = ""
+	assert.Empty(t, Redact(cfg).LLM.Token, "an empty secret should stay empty")
+
+	cfg.LLM.SetProviderToken("openai", "sk-openai")
+	assert.Equal(t, redactedValue, Redact(cfg).LLM.Credentials["openai"].Token)
+	assert.Equal(t, "sk-openai", cfg.LLM.Credentials["openai"].Token, "the credentials of the original should not be modified")
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
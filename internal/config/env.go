@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the names of the environment variables overriding settings
+const EnvPrefix = "ECHOY_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// EnvName returns the environment variable overriding the setting at the dotted YAML
+// path: the path upper-cased with underscores for dots, after EnvPrefix. For example
+// llm.model is overridden by ECHOY_LLM_MODEL.
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// ApplyEnv overrides the settings of cfg with the environment variables named by EnvName,
+// looked up with lookup, e.g. os.LookupEnv. Strings, numbers, bools and durations can be
+// overridden, lists, maps and secrets can't, the LLM token has its own token sources. It
+// returns the paths of the overridden settings, and
+// fails on a value that doesn't parse as the type of its setting.
+func ApplyEnv(cfg *Config, lookup func(string) (string, bool)) ([]string, error) {
+	var overridden []string
+
+	err := walkSettings("", reflect.ValueOf(cfg).Elem(), func(path string, setting reflect.Value) error {
+		value, ok := lookup(EnvName(path))
+		if !ok {
+			return nil
+		}
+
+		if err := setSetting(setting, value); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", EnvName(path), err)
+		}
+		overridden = append(overridden, path)
+		return nil
+	})
+
+	return overridden, err
+}
+
+// RevertEnv undoes the overrides of ApplyEnv before cfg is saved, so the environment
+// doesn't end up in the config file. The settings at paths are reset to their value in
+// files, the configuration before ApplyEnv, unless they were changed since it returned
+// loaded.
+func RevertEnv(cfg *Config, files, loaded Config, paths []string) {
+	for _, path := range paths {
+		current := settingByPath(reflect.ValueOf(cfg).Elem(), path)
+		if !current.IsValid() || !reflect.DeepEqual(current.Interface(), settingByPath(reflect.ValueOf(loaded), path).Interface()) {
+			continue
+		}
+
+		current.Set(settingByPath(reflect.ValueOf(files), path))
+	}
+}
+
+// walkSettings calls fn with the path of every setting of the struct v that can be
+// overridden, in the order they are declared
+func walkSettings(path string, v reflect.Value, fn func(path string, setting reflect.Value) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || yamlName(field) == "-" || field.Tag.Get("secret") == "true" {
+			continue
+		}
+
+		fieldPath := joinPath(path, yamlName(field))
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if err := walkSettings(fieldPath, v.Field(i), fn); err != nil {
+				return err
+			}
+		case isScalar(field.Type) || (field.Type.Kind() == reflect.Pointer && isScalar(field.Type.Elem())):
+			if err := fn(fieldPath, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// settingByPath returns the field at the dotted YAML path in the struct v, or the zero
+// Value if there is none
+func settingByPath(v reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+
+		next := reflect.Value{}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() && yamlName(field) == name {
+				next = v.Field(i)
+				break
+			}
+		}
+		if !next.IsValid() {
+			return next
+		}
+		v = next
+	}
+
+	return v
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// setSetting parses value as the type of setting and sets it. Pointers, settings that are
+// on unless set to false, get a new value.
+func setSetting(setting reflect.Value, value string) error {
+	if setting.Kind() == reflect.Pointer {
+		target := reflect.New(setting.Type().Elem())
+		if err := setSetting(target.Elem(), value); err != nil {
+			return err
+		}
+		setting.Set(target)
+		return nil
+	}
+
+	if setting.Kind() == reflect.String {
+		setting.SetString(value)
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case setting.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		setting.SetInt(int64(d))
+	case setting.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		setting.SetBool(b)
+	case setting.Kind() == reflect.Int || setting.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		setting.SetInt(n)
+	case setting.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		setting.SetFloat(f)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "ECHOY_LLM_MODEL", EnvName("llm.model"))
+	assert.Equal(t, "ECHOY_WEBSERVER_MAX_STREAM_DURATION", EnvName("webserver.max_stream_duration"))
+	assert.Equal(t, "ECHOY_ASSISTANT_NAME", EnvName("Assistant.name"))
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"ECHOY_LLM_MODEL":                    "gpt-4o",
+		"ECHOY_LLM_STREAMING":                "true",
+		"ECHOY_LLM_MAX_TOKENS":               " 2048 ",
+		"ECHOY_LLM_TEMPERATURE":              "0.2",
+		"ECHOY_LLM_REQUEST_TIMEOUT":          "90s",
+		"ECHOY_CHAT_RENDER_MARKDOWN":         "false",
+		"ECHOY_CHAT_MAX_CHATS":               "50",
+		"ECHOY_TOOLS_GIT_ENABLED":            "true",
+		"ECHOY_LLM_TOKEN":                    "sk-from-env",
+		"ECHOY_LLM_CREDENTIALS_OPENAI_TOKEN": "sk-from-env",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	cfg := (&Config{}).Default()
+	cfg.LLM.Model = "from-file"
+	cfg.LLM.Token = "sk-from-file"
+
+	overridden, err := ApplyEnv(&cfg, lookup)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"tools.git.enabled",
+		"llm.model",
+		"llm.max_tokens",
+		"llm.streaming",
+		"llm.temperature",
+		"llm.request_timeout",
+		"chat.max_chats",
+		"chat.render_markdown",
+	}, overridden)
+	assert.Equal(t, "gpt-4o", cfg.LLM.Model)
+	assert.True(t, cfg.LLM.Streaming)
+	assert.Equal(t, int64(2048), cfg.LLM.MaxTokens)
+	assert.Equal(t, 0.2, cfg.LLM.Temperature)
+	assert.Equal(t, 90*time.Second, cfg.LLM.RequestTimeout)
+	assert.False(t, cfg.Chat.MarkdownEnabled())
+	assert.Equal(t, 50, cfg.Chat.MaxChats)
+	assert.True(t, cfg.Tools.Git.Enabled)
+	assert.Equal(t, "sk-from-file", cfg.LLM.Token, "secrets are only read from their token source")
+}
+
+func TestApplyEnv_InvalidValue(t *testing.T) {
+	cfg := Config{}
+	_, err := ApplyEnv(&cfg, func(name string) (string, bool) {
+		return "soon", name == "ECHOY_LLM_REQUEST_TIMEOUT"
+	})
+
+	assert.ErrorContains(t, err, "invalid value of ECHOY_LLM_REQUEST_TIMEOUT")
+}
+
+func TestRevertEnv(t *testing.T) {
+	files := Config{LLM: LLMConfig{Model: "from-file", MaxTokens: 100}}
+	loaded := files
+	overridden, err := ApplyEnv(&loaded, func(name string) (string, bool) {
+		switch name {
+		case "ECHOY_LLM_MODEL":
+			return "from-env", true
+		case "ECHOY_LLM_MAX_TOKENS":
+			return "200", true
+		case "ECHOY_CHAT_RENDER_MARKDOWN":
+			return "false", true
+		}
+		return "", false
+	})
+	require.NoError(t, err)
+
+	// The user changes the maximum tokens, the model keeps the value from the environment
+	toSave := loaded
+	toSave.LLM.MaxTokens = 300
+	RevertEnv(&toSave, files, loaded, overridden)
+
+	assert.Equal(t, "from-file", toSave.LLM.Model)
+	assert.Equal(t, int64(300), toSave.LLM.MaxTokens, "changed settings are saved")
+	assert.Nil(t, toSave.Chat.RenderMarkdown)
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+// SystemConfigPath returns the path of the system-wide configuration, which holds the
+// defaults of managed installs. The configuration of the user is layered on top of it.
+func SystemConfigPath() string {
+	return "/etc/echoy/config.yaml"
+}
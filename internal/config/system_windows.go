@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SystemConfigPath returns the path of the system-wide configuration, which holds the
+// defaults of managed installs. The configuration of the user is layered on top of it.
+func SystemConfigPath() string {
+	programData := os.Getenv("PROGRAMDATA")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+
+	return filepath.Join(programData, "echoy", "config.yaml")
+}
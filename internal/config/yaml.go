@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileHeader starts a configuration file written for the first time
+const fileHeader = `Echoy configuration, created by 'echoy init'.
+Comments and the order of the keys are kept when the configuration is saved again.`
+
+// fieldComments describe the keys of the configuration file, by their dotted path. They
+// are written above keys that weren't in the file before, so a new file documents itself
+// and comments removed by the user don't come back.
+var fieldComments = map[string]string{
+	"Assistant":                        "How the assistant introduces itself",
+	"user":                             "Who you are, so the assistant can address you",
+	"tools":                            "Tools the assistant may use",
+	"tools.git.whitelisted_repo_paths": "Repositories the git tool may access, all if empty",
+	"tools.git.blocked_operation":      "git operations the tool must never run",
+	"llm":                              "The model answering your questions",
+	"llm.provider":                     "anthropic, gemini, openai or echo (offline, not a real model)",
+	"llm.model":                        "Model ID of the provider, see 'echoy init' for the supported ones",
+	"llm.token":                        "API token, only used if token_source is config",
+	"llm.credentials":                  "API tokens of each provider, only used if token_source is config",
+	"llm.token_file":                   "File holding the API token",
+	"llm.token_source":                 "Where the API token is read from: config, file or keychain",
+	"llm.max_tokens":                   "Maximum length of an answer in tokens",
+	"llm.streaming":                    "Show answers while they are generated",
+	"llm.top_p":                        "Nucleus sampling, between 0.0 and 1.0",
+	"llm.temperature":                  "Creativity of the answers, between 0.0 and 1.0",
+	"llm.top_k":                        "Sample only from the k most likely tokens",
+	"llm.request_timeout":              "How long the provider may take to answer, e.g. 2m",
+	"llm.echo_latency":                 "Delay before each word of the echo provider, e.g. 50ms",
+	"llm.log_content":                  "Log the messages and answers of LLM calls, not just their token counts",
+	"llm.base_url":                     "API endpoint replacing the provider's, e.g. of an LLM gateway",
+	"llm.extra_headers":                "Headers sent with every call to the provider, e.g. of an LLM gateway",
+	"chat":                             "Chat history",
+	"chat.retention_days":              "Delete chats older than this many days, 0 keeps them forever",
+	"chat.max_chats":                   "Keep only this many of the most recent chats, 0 means no limit",
+	"chat.render_markdown":             "Style Markdown in answers shown in a terminal",
+	"chat.history_backend":             "Where chats are stored: sqlite, memory or none",
+	"chat.memory_max_chats":            "Chats kept by the memory backend, the least recently used are dropped",
+	"chat.interactive_streaming":       "Show answers while they are generated in the chat session, defaults to llm.streaming",
+	"chat.prompt_format":               "Prompt before your input in the chat session, {user}, {model} and {time} are replaced",
+	"chat.assistant_prompt_format":     "Label before answers in the chat session, {user}, {model} and {time} are replaced",
+	"chat.show_welcome":                "Print the welcome message when the chat session starts",
+	"chat.welcome":                     "Welcome message of the chat session, {session_id} and the prompt placeholders are replaced",
+	"webserver":                        "The HTTP API started by 'echoy webserver start'",
+	"webserver.api_port":               "Port the web server listens on, e.g. 8080, or a host:port such as 127.0.0.1:8080",
+	"webserver.default_streaming":      "Stream answers of the API unless the client asks for JSON, defaults to llm.streaming",
+	"webserver.restart_on_crash":       "Start the web server again if it stops on its own",
+	"webserver.max_restarts":           "How often the web server is restarted before giving up",
+	"webserver.enable_compression":     "Gzip API responses for clients that accept it",
+	"webserver.max_stream_duration":    "Longest a streamed answer may take before it is ended, e.g. 10m",
+	"webserver.idle_shutdown":          "Stop the web server after it served no request for this long, e.g. 30m, 0 keeps it running",
+	"webserver.enable_pprof":           "Serve the Go profiler under /debug/pprof/, to diagnose leaks",
+	"ui":                               "How chats are presented in the chat session and the API",
+	"ui.timezone":                      "Timezone the times of chats are shown in, e.g. Europe/Berlin, defaults to the system's",
+	"frontend":                         "The web UI served by the daemon",
+	"frontend.github_api_url":          "GitHub API to look up web UI releases in, e.g. https://github.example.com/api/v3",
+	"frontend.download_url":            "Base URL of a mirror serving the release assets under GitHub's paths",
+	"http":                             "Outbound calls to the LLM provider and GitHub",
+	"http.ca_file":                     "PEM file of certificate authorities to trust besides the system ones, e.g. of a TLS-inspecting proxy",
+	"usage_tracking":                   "Anonymous usage statistics that help improve Echoy",
+}
+
+// Marshal encodes cfg as YAML. The comments and key order of previous, the current
+// content of the file, are kept, and new keys get a description. previous may be empty or
+// invalid, then a fresh, commented file is written.
+func Marshal(cfg Config, previous []byte) ([]byte, error) {
+	return marshal(cfg, nil, previous)
+}
+
+// MarshalOverrides encodes cfg like Marshal, but leaves out the settings with the same
+// value in base, the layer cfg is loaded on top of. Mappings are compared key by key, the
+// way they are merged on load, lists as a whole.
+func MarshalOverrides(cfg, base Config, previous []byte) ([]byte, error) {
+	return marshal(cfg, &base, previous)
+}
+
+func marshal(cfg Config, base *Config, previous []byte) ([]byte, error) {
+	var values yaml.Node
+	if err := values.Encode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if base != nil {
+		var baseValues yaml.Node
+		if err := baseValues.Encode(base); err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		pruneNodes(&values, &baseValues)
+	}
+
+	document := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&values}}
+
+	var old yaml.Node
+	if err := yaml.Unmarshal(previous, &old); err == nil && len(old.Content) > 0 {
+		document.HeadComment = old.HeadComment
+		document.FootComment = old.FootComment
+		mergeNodes(&values, old.Content[0], "")
+	} else {
+		document.HeadComment = fileHeader
+		describeNew(&values, nil, "")
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mergeNodes copies the comments of old to the matching keys of values and orders the
+// keys of values like in old, with new keys last. Keys only in old are dropped.
+func mergeNodes(values, old *yaml.Node, path string) {
+	copyComments(values, old)
+
+	if values.Kind != yaml.MappingNode || old.Kind != yaml.MappingNode {
+		return
+	}
+
+	oldKeys := make(map[string]int, len(old.Content)/2)
+	for i := 0; i+1 < len(old.Content); i += 2 {
+		oldKeys[old.Content[i].Value] = i
+	}
+
+	type pair struct {
+		key, value *yaml.Node
+		rank       int
+	}
+
+	var known []pair
+	var added []*yaml.Node
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		key, value := values.Content[i], values.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+
+		j, ok := oldKeys[key.Value]
+		if !ok {
+			describeNew(value, key, keyPath)
+			added = append(added, key, value)
+			continue
+		}
+
+		copyComments(key, old.Content[j])
+		mergeNodes(value, old.Content[j+1], keyPath)
+		known = append(known, pair{key: key, value: value, rank: j})
+	}
+
+	sort.SliceStable(known, func(a, b int) bool { return known[a].rank < known[b].rank })
+
+	values.Content = values.Content[:0]
+	for _, p := range known {
+		values.Content = append(values.Content, p.key, p.value)
+	}
+	values.Content = append(values.Content, added...)
+}
+
+// pruneNodes removes the keys of values that have the same value in base, and the
+// mappings left empty by it
+func pruneNodes(values, base *yaml.Node) {
+	if values.Kind != yaml.MappingNode || base.Kind != yaml.MappingNode {
+		return
+	}
+
+	baseKeys := make(map[string]*yaml.Node, len(base.Content)/2)
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		baseKeys[base.Content[i].Value] = base.Content[i+1]
+	}
+
+	content := values.Content[:0]
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		key, value := values.Content[i], values.Content[i+1]
+
+		if baseValue, ok := baseKeys[key.Value]; ok {
+			if value.Kind == yaml.MappingNode && baseValue.Kind == yaml.MappingNode {
+				pruneNodes(value, baseValue)
+				if len(value.Content) == 0 {
+					continue
+				}
+			} else if equalNodes(value, baseValue) {
+				continue
+			}
+		}
+
+		content = append(content, key, value)
+	}
+	values.Content = content
+}
+
+// equalNodes reports whether a and b encode the same value, their style and comments
+// aside
+func equalNodes(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+
+	for i := range a.Content {
+		if !equalNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describeNew sets the description of a key that wasn't in the file before, and of the
+// keys below it
+func describeNew(value, key *yaml.Node, path string) {
+	if key != nil && key.HeadComment == "" {
+		key.HeadComment = fieldComments[path]
+	}
+
+	if value.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		describeNew(value.Content[i+1], value.Content[i], joinPath(path, value.Content[i].Value))
+	}
+}
+
+func copyComments(to, from *yaml.Node) {
+	to.HeadComment = from.HeadComment
+	to.LineComment = from.LineComment
+	to.FootComment = from.FootComment
+}
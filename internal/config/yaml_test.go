@@ -0,0 +1,94 @@
+package config
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshal_FirstWrite(t *testing.T) {
+	cfg := new(Config).Default()
+
+	data, err := Marshal(cfg, nil)
+	require.NoError(t, err)
+
+	text := string(data)
+	assert.True(t, strings.HasPrefix(text, "# Echoy configuration"))
+	assert.Contains(t, text, "    # anthropic, gemini, openai or echo (offline, not a real model)\n    provider: openai\n")
+
+	var decoded Config
+	require.NoError(t, yaml.Unmarshal(data, &decoded))
+	assert.Empty(t, Diff(cfg, decoded))
+}
+
+func TestMarshal_KeepsCommentsAndOrder(t *testing.T) {
+	previous := []byte(`# managed in my dotfiles repo
+
+llm:
+    # switched to the bigger model for work
+    model: gpt-4
+    provider: openai # the company account
+    max_tokens: 1000
+Assistant:
+    name: Echoy
+`)
+
+	cfg := new(Config).Default()
+	cfg.LLM.Model = "gpt-4o-mini"
+
+	data, err := Marshal(cfg, previous)
+	require.NoError(t, err)
+
+	text := string(data)
+	assert.True(t, strings.HasPrefix(text, "# managed in my dotfiles repo\n\nllm:\n    # switched to the bigger model for work\n    model: gpt-4o-mini\n    provider: openai # the company account\n"))
+	assert.Less(t, strings.Index(text, "llm:"), strings.Index(text, "Assistant:"), "keys keep their order")
+	assert.Less(t, strings.Index(text, "Assistant:"), strings.Index(text, "tools:"), "new keys come last")
+	assert.Contains(t, text, "# Show answers while they are generated\n    streaming: true", "new keys are described")
+	assert.NotContains(t, text, "# Maximum length of an answer", "existing keys don't get a description")
+	assert.NotContains(t, text, "# Echoy configuration")
+
+	var decoded Config
+	require.NoError(t, yaml.Unmarshal(data, &decoded))
+	assert.Empty(t, Diff(cfg, decoded))
+}
+
+func TestMarshal_InvalidPrevious(t *testing.T) {
+	data, err := Marshal(new(Config).Default(), []byte("llm: [unclosed"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# Echoy configuration"))
+}
+
+func TestMarshalOverrides(t *testing.T) {
+	base := new(Config).Default()
+	base.Tools.Git.WhitelistedRepoPaths = []string{"/srv/repos", "/opt/repos"}
+	base.LLM.ExtraHeaders = map[string]string{"X-Team": "platform", "X-Env": "prod"}
+
+	cfg := base
+	cfg.LLM.Model = "gpt-4o"
+	cfg.Tools.Git.WhitelistedRepoPaths = []string{"/srv/repos"}
+	cfg.LLM.ExtraHeaders = map[string]string{"X-Team": "platform", "X-Env": "dev"}
+
+	data, err := MarshalOverrides(cfg, base, nil)
+	require.NoError(t, err)
+
+	var overrides map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &overrides))
+	assert.Equal(t, map[string]any{
+		"llm": map[string]any{
+			"model":         "gpt-4o",
+			"extra_headers": map[string]any{"X-Env": "dev"},
+		},
+		"tools": map[string]any{
+			"git": map[string]any{"whitelisted_repo_paths": []any{"/srv/repos"}},
+		},
+	}, overrides, "maps should keep the changed keys and lists be written as a whole")
+
+	loaded := base
+	loaded.LLM.ExtraHeaders = maps.Clone(base.LLM.ExtraHeaders)
+	require.NoError(t, yaml.Unmarshal(data, &loaded))
+	assert.Empty(t, Diff(cfg, loaded), "loading the overrides on top of base should give cfg back")
+}
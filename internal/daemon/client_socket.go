@@ -24,15 +24,26 @@ type ConnectionProvider interface {
 	Connect(ctx context.Context) (net.Conn, error)
 }
 
+// DefaultDialAttempts is how often the CLI tries to reach the daemon before reporting
+// it as not running, to ride out a daemon that is still starting up
+const DefaultDialAttempts = 3
+
+// dialRetryDelay is the pause between two attempts to reach the daemon
+const dialRetryDelay = 150 * time.Millisecond
+
 // UnixSocketProvider provides connections to a Unix socket
 type UnixSocketProvider struct {
 	SocketPath string
 	Timeout    time.Duration
+	// Attempts is how often dialing is tried before giving up. Zero means once.
+	Attempts int
+	// Wait keeps retrying until the socket is reachable or Wait has elapsed
+	Wait time.Duration
 }
 
 // Connect implements ConnectionProvider.Connect
 func (p *UnixSocketProvider) Connect(ctx context.Context) (net.Conn, error) {
-	conn, err := net.DialTimeout("unix", p.SocketPath, p.Timeout)
+	conn, err := dialUnix(ctx, p.SocketPath, p.Timeout, p.Attempts, p.Wait)
 	if err != nil {
 		return nil, err
 	}
@@ -47,11 +58,37 @@ func (p *UnixSocketProvider) Connect(ctx context.Context) (net.Conn, error) {
 	return conn, nil
 }
 
+// dialUnix connects to socketPath, trying at least attempts times and for at least wait
+// before it gives up with the error of the last attempt
+func dialUnix(ctx context.Context, socketPath string, timeout time.Duration, attempts int, wait time.Duration) (net.Conn, error) {
+	deadline := time.Now().Add(wait)
+
+	for attempt := 1; ; attempt++ {
+		conn, err := net.DialTimeout("unix", socketPath, timeout)
+		if err == nil {
+			return conn, nil
+		}
+
+		if attempt >= attempts && !time.Now().Before(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(dialRetryDelay):
+		}
+	}
+}
+
 // Client implements Commander using a ConnectionProvider
 type Client struct {
 	Provider     ConnectionProvider
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// OnProgress is called with the message of every PROGRESS: line the daemon sends
+	// before the response, see ReportProgress. These lines aren't part of the response.
+	OnProgress func(message string)
 }
 
 // NewClient creates a new DaemonClient with a UnixSocketProvider
@@ -63,7 +100,9 @@ func NewClient(connectionProvider ConnectionProvider, readTimeout, writeTimeout
 	}
 }
 
-// Execute implements Commander.Execute
+// Execute implements Commander.Execute. Reads never block past the deadline of ctx, and
+// cancelling ctx unblocks a pending read whatever the provider does with the connection.
+// ReadTimeout starts over with every progress line of the daemon.
 func (c *Client) Execute(ctx context.Context, cmd string, args []string) (string, error) {
 	conn, err := c.Provider.Connect(ctx)
 	if err != nil {
@@ -71,6 +110,12 @@ func (c *Client) Execute(ctx context.Context, cmd string, args []string) (string
 	}
 	defer conn.Close()
 
+	// A deadline in the past makes a blocked read return right away
+	stopUnblocking := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stopUnblocking()
+
 	if c.WriteTimeout > 0 {
 		if err := conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout)); err != nil {
 			return "", errors.New("failed to set write deadline: " + err.Error())
@@ -90,39 +135,98 @@ func (c *Client) Execute(ctx context.Context, cmd string, args []string) (string
 		return "", errors.New("failed to send command: " + err.Error())
 	}
 
-	// The rest of the method remains the same
-	if c.ReadTimeout > 0 {
-		if err := conn.SetReadDeadline(time.Now().Add(c.ReadTimeout)); err != nil {
-			return "", errors.New("failed to set read deadline: " + err.Error())
-		}
-	}
-
+	readDeadline := c.readDeadline(ctx)
 	reader := bufio.NewReader(conn)
 	var response strings.Builder
 
 	for {
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF || response.Len() > 0 {
-					return strings.TrimSpace(response.String()), nil
-				}
-				return "", errors.New("failed to read response: " + err.Error())
+		if !readDeadline.IsZero() {
+			if err := conn.SetReadDeadline(readDeadline); err != nil {
+				return "", errors.New("failed to set read deadline: " + err.Error())
 			}
+		}
 
-			response.WriteString(line)
+		// Checked after the deadline is set, a cancellation from now on resets it
+		if err := ctx.Err(); err != nil {
+			if response.Len() > 0 {
+				return strings.TrimSpace(response.String()), nil
+			}
+			return "", err
+		}
 
-			trimmed := strings.TrimSpace(line)
-			if trimmed == "END" || trimmed == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// The response read so far is kept, even if the context ended waiting for the rest
+			if response.Len() > 0 {
 				return strings.TrimSpace(response.String()), nil
 			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return "", ctxErr
+			}
+			// The read deadline can pass a moment before the context notices its own
+			var netErr net.Error
+			if deadline, ok := ctx.Deadline(); ok && errors.As(err, &netErr) && netErr.Timeout() && !time.Now().Before(deadline) {
+				return "", context.DeadlineExceeded
+			}
+			if err == io.EOF {
+				return "", nil
+			}
+			return "", errors.New("failed to read response: " + err.Error())
+		}
+
+		trimmed := strings.TrimSpace(line)
+		// Progress lines only come before the response, later ones are part of it
+		if message, ok := strings.CutPrefix(trimmed, ProgressPrefix); ok && response.Len() == 0 {
+			if c.OnProgress != nil {
+				c.OnProgress(strings.TrimSpace(message))
+			}
+			readDeadline = c.readDeadline(ctx)
+			continue
 		}
+
+		if trimmed == ResponseEnd || trimmed == "" {
+			return strings.TrimSpace(response.String()), nil
+		}
+
+		response.WriteString(line)
 	}
 }
 
+// readDeadline returns the deadline of the next read, ReadTimeout from now but no later than
+// the deadline of ctx. It is zero if there is neither.
+func (c *Client) readDeadline(ctx context.Context) time.Time {
+	var readDeadline time.Time
+	if c.ReadTimeout > 0 {
+		readDeadline = time.Now().Add(c.ReadTimeout)
+	}
+	if deadline, ok := ctx.Deadline(); ok && (readDeadline.IsZero() || deadline.Before(readDeadline)) {
+		readDeadline = deadline
+	}
+
+	return readDeadline
+}
+
+// trimResponseEnd trims the whitespace and the ResponseEnd line off a response read from
+// the connection at once, rather than line by line like Client.Execute does
+func trimResponseEnd(response string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), ResponseEnd))
+}
+
+// ParseResponse returns the payload of a response of the daemon without its OK: prefix, or
+// the error an ERROR: response carries
+func ParseResponse(response string) (string, error) {
+	response = strings.TrimSpace(response)
+
+	if message, ok := strings.CutPrefix(response, "ERROR:"); ok {
+		return "", errors.New(strings.TrimSpace(message))
+	}
+	if payload, ok := strings.CutPrefix(response, "OK:"); ok {
+		return strings.TrimSpace(payload), nil
+	}
+
+	return response, nil
+}
+
 // IsRunning implements Commander.IsRunning
 func (c *Client) IsRunning(ctx context.Context) (bool, string) {
 	response, err := c.Execute(ctx, "PING", nil)
@@ -6,12 +6,15 @@ import (
 	daemonMocks "github.com/shaharia-lab/echoy/internal/daemon/mocks"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockConnection implements net.Conn for testing
@@ -158,6 +161,183 @@ func TestDaemonClient_Execute(t *testing.T) {
 	}
 }
 
+func TestDaemonClient_Execute_ContextEndsSlowResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A pipe isn't closed by the provider on cancellation, unlike Unix socket connections
+			clientConn, serverConn := net.Pipe()
+			t.Cleanup(func() {
+				clientConn.Close()
+				serverConn.Close()
+			})
+
+			go func() {
+				buf := make([]byte, 64)
+				_, _ = serverConn.Read(buf)
+				// No response until the client gives up
+			}()
+
+			provider := daemonMocks.NewMockConnectionProvider(t)
+			provider.EXPECT().Connect(mock.Anything).Return(clientConn, nil)
+
+			client := NewClient(provider, time.Minute, time.Second)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			started := time.Now()
+			response, err := client.Execute(ctx, "SLOW", nil)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, response)
+			assert.Less(t, time.Since(started), 2*time.Second, "Execute should return promptly once the context ends")
+		})
+	}
+}
+
+func TestDaemonClient_Execute_ContextEndsAfterResponse(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 64)
+		_, _ = serverConn.Read(buf)
+		// The response without a terminator, the connection stays open
+		_, _ = serverConn.Write([]byte("OK: {\"status\":\"running\"}\n"))
+	}()
+
+	provider := daemonMocks.NewMockConnectionProvider(t)
+	provider.EXPECT().Connect(mock.Anything).Return(clientConn, nil)
+
+	client := NewClient(provider, time.Minute, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	response, err := client.Execute(ctx, "SERVICE", nil)
+	require.NoError(t, err, "a response read before the context ended should be returned")
+	assert.Equal(t, `OK: {"status":"running"}`, response)
+}
+
+func TestDaemonClient_Execute_ResponseEnd(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		contains string
+		minLines int
+	}{
+		{cmd: "HELP", contains: "PING - Check that the daemon is responsive", minLines: 3},
+		{cmd: "METRICS", contains: "connections_rejected_total: 0", minLines: 6},
+		{cmd: "CONNECTIONS", contains: "command=CONNECTIONS", minLines: 1},
+		{cmd: "DEBUG", contains: "gc_cpu_fraction: ", minLines: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			d, socketPath := createTestDaemon(t, Config{})
+			RegisterDefaultCommands(d)
+			require.NoError(t, d.Start())
+			defer d.Stop()
+
+			// The read timeout never passes, the END line completes the response
+			client := NewClient(&UnixSocketProvider{SocketPath: socketPath, Timeout: time.Second}, time.Minute, time.Second)
+
+			started := time.Now()
+			response, err := client.Execute(context.Background(), tt.cmd, nil)
+			require.NoError(t, err)
+			assert.Less(t, time.Since(started), 2*time.Second)
+			assert.Contains(t, response, tt.contains)
+			assert.GreaterOrEqual(t, len(strings.Split(response, "\n")), tt.minLines, "all the lines of the response should be read")
+			assert.False(t, strings.HasSuffix(response, ResponseEnd))
+		})
+	}
+}
+
+func TestDaemonClient_Execute_Progress(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+
+	readTimeout := 100 * time.Millisecond
+	go func() {
+		buf := make([]byte, 64)
+		_, _ = serverConn.Read(buf)
+		// Together the progress lines take longer than the read timeout
+		for _, message := range []string{"downloading", "extracting"} {
+			time.Sleep(readTimeout * 2 / 3)
+			_, _ = serverConn.Write([]byte(ProgressPrefix + " " + message + "\n"))
+		}
+		time.Sleep(readTimeout * 2 / 3)
+		_, _ = serverConn.Write([]byte("OK: done\nPROGRESS: is part of the response\n\n"))
+	}()
+
+	provider := daemonMocks.NewMockConnectionProvider(t)
+	provider.EXPECT().Connect(mock.Anything).Return(clientConn, nil)
+
+	var progress []string
+	client := NewClient(provider, readTimeout, time.Second)
+	client.OnProgress = func(message string) {
+		progress = append(progress, message)
+	}
+
+	response, err := client.Execute(context.Background(), "SLOW", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "OK: done\nPROGRESS: is part of the response", response)
+	assert.Equal(t, []string{"downloading", "extracting"}, progress)
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		wantErr  string
+	}{
+		{name: "ok", response: "OK: {\"service\":\"webserver\"}\n", want: `{"service":"webserver"}`},
+		{name: "error", response: "ERROR: unknown command 'FOO'\n", wantErr: "unknown command 'FOO'"},
+		{name: "no prefix", response: "PONG\n", want: "PONG"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := ParseResponse(tt.response)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, payload)
+		})
+	}
+}
+
 func TestDaemonClient_IsRunning(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -212,3 +392,34 @@ func TestDaemonClient_IsRunning(t *testing.T) {
 		})
 	}
 }
+
+func TestUnixSocketProvider_Connect_Retry(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "echoy.sock")
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		provider := &UnixSocketProvider{SocketPath: socketPath, Timeout: 100 * time.Millisecond, Attempts: 2}
+
+		startedAt := time.Now()
+		_, err := provider.Connect(context.Background())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.GreaterOrEqual(t, time.Since(startedAt), dialRetryDelay)
+	})
+
+	t.Run("waits for the daemon to come up", func(t *testing.T) {
+		listeners := make(chan net.Listener, 1)
+		go func() {
+			time.Sleep(3 * dialRetryDelay)
+			listener, err := net.Listen("unix", socketPath)
+			if err == nil {
+				listeners <- listener
+			}
+		}()
+
+		provider := &UnixSocketProvider{SocketPath: socketPath, Timeout: 100 * time.Millisecond, Wait: 5 * time.Second}
+
+		conn, err := provider.Connect(context.Background())
+		require.NoError(t, err)
+		conn.Close()
+		(<-listeners).Close()
+	})
+}
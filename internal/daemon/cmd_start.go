@@ -6,50 +6,49 @@ import (
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/cli"
 	"github.com/shaharia-lab/echoy/internal/filesystem"
+	"github.com/shaharia-lab/echoy/internal/history"
 	"github.com/shaharia-lab/echoy/internal/webserver"
 	"log/slog"
 	"net"
 	"os"
 	"os/exec"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
-	"github.com/shaharia-lab/telemetry-collector"
 	"github.com/spf13/cobra"
 
-	"github.com/shaharia-lab/echoy/internal/config"
 	loggerInt "github.com/shaharia-lab/echoy/internal/logger"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
 )
 
+// daemonReadyPollInterval is how often 'start --wait' checks whether the daemon responds
+const daemonReadyPollInterval = 200 * time.Millisecond
+
 // NewStartCmd creates a command to run the daemon
-func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *config.AppConfig, themeManager *theme.Manager, socketPath string, webUIStaticDirectory string, sLogger *slog.Logger) *cobra.Command {
+func NewStartCmd(container *cli.Container, themeManager *theme.Manager, socketPath string, webUIStaticDirectory string, sLogger *slog.Logger) *cobra.Command {
 	var foreground bool
+	var wait time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the Echoy daemon",
-		Long:  `Starts the Echoy daemon process that listens for commands via a Unix socket.`,
+		Long: `Starts the Echoy daemon process that listens for commands via a Unix socket.
+
+By default the command returns as soon as the background process is spawned. Use
+--wait to block until the daemon responds, e.g. before running 'echoy webserver start'
+from a script.`,
+		Example: "  echoy start --wait 10s  # Wait up to 10s for the daemon to be ready",
+		Annotations: map[string]string{
+			cli.RequiresConfigAnnotation: "true",
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			defer container.Logger.Flush()
 
-			if container.ConfigFromFile.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					context.Background(), appConfig, "daemon.start.attempt",
-					telemetry.SeverityInfo, "Attempting to start daemon", nil,
-				)
-			}
+			container.Telemetry.Add(telemetryEvent.DaemonStartAttempted())
 
-			daemonLog, err := loggerInt.NewZapLogger(loggerInt.Config{
-				LogLevel:    loggerInt.DebugLevel,
-				LogFilePath: fmt.Sprintf("%s/daemon.log", container.Paths[filesystem.LogsDirectory]),
-				MaxSizeMB:   50,
-				MaxAgeDays:  14,
-				MaxBackups:  5,
-			})
+			daemonLog, err := loggerInt.NewZapLogger(container.ProcessLogConfig("daemon.log"))
 			if err != nil {
 				panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 			}
@@ -111,12 +110,7 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 					return fmt.Errorf("failed to start daemon process: %w", err)
 				}
 
-				if appConf.UsageTracking.Enabled {
-					telemetryEvent.SendTelemetryEvent(
-						context.Background(), appConfig, "daemon.start.background.success",
-						telemetry.SeverityInfo, "Daemon started in background", nil,
-					)
-				}
+				container.Telemetry.Add(telemetryEvent.DaemonStarted(true))
 
 				pid := -1
 				if daemonCmd.Process != nil {
@@ -129,7 +123,42 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 					"command":    "start",
 				}).Info("Daemon starting in background mode")
 
-				themeManager.GetCurrentTheme().Success().Println(successMsg)
+				if wait <= 0 {
+					themeManager.GetCurrentTheme().Success().Println(successMsg)
+					return nil
+				}
+
+				exited := make(chan error, 1)
+				go func() {
+					exited <- daemonCmd.Wait()
+				}()
+
+				spinner := theme.NewTerminalSpinner(themeManager.GetCurrentTheme())
+				spinner.Start(fmt.Sprintf("Waiting for the daemon (PID: %d) to become ready...", pid))
+
+				ctx, cancel := context.WithTimeout(cmd.Context(), wait)
+				defer cancel()
+
+				if err := waitForDaemon(ctx, socketPath, container.Logger, exited); err != nil {
+					container.Logger.WithFields(map[string]interface{}{
+						loggerInt.ErrorKey: err,
+						"socket":           socketPath,
+						"daemon_pid":       pid,
+						"command":          "start",
+						"wait":             wait.String(),
+					}).Error("Daemon did not become ready")
+
+					spinner.Fail(fmt.Sprintf("Daemon did not become ready: %v", err))
+					return fmt.Errorf("daemon did not become ready: %w", err)
+				}
+
+				container.Logger.WithFields(map[string]interface{}{
+					"socket":     socketPath,
+					"daemon_pid": pid,
+					"command":    "start",
+				}).Info("Daemon is ready")
+
+				spinner.Success(fmt.Sprintf("Daemon is ready (PID: %d). Listening on %s", pid, socketPath))
 				return nil
 			}
 
@@ -138,7 +167,7 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 				"command": "start",
 			}).Info("Starting daemon in foreground mode...")
 
-			webSrvr, err := webserver.BuildWebserver(appConf, themeManager, webUIStaticDirectory, container.Paths[filesystem.LogsDirectory])
+			webSrvr, err := webserver.BuildWebserver(container.ConfigFromFile, container.Config, themeManager, webUIStaticDirectory, container.ProcessLogConfig("webserver.log"), container.Paths[filesystem.ChatHistoryDB])
 			if err != nil {
 				container.Logger.WithFields(map[string]interface{}{
 					loggerInt.ErrorKey: err,
@@ -158,17 +187,51 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 				WriteTimeout:       10 * time.Second,
 				CommandExecTimeout: 5 * time.Second,
 				MaxConnections:     100,
+				QueueTimeout:       2 * time.Second,
 			}
 
-			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
 			defer stop()
+
+			stopPruner, err := history.StartPruner(ctx, container.ConfigFromFile.Chat, container.Paths[filesystem.ChatHistoryDB], daemonLog)
+			if err != nil {
+				container.Logger.WithField(loggerInt.ErrorKey, err).Error("Failed to open chat history storage for pruning")
+				return err
+			}
+			defer stopPruner()
+
+			if container.ConfigFromFile.UsageTracking.Enabled {
+				loggerInt.SetPanicReporter(func(recovered interface{}, stack []byte) {
+					container.Telemetry.Add(telemetryEvent.DaemonPanicked(recovered))
+					go flushTelemetry(container.Telemetry)
+				})
+				defer loggerInt.SetPanicReporter(nil)
+			}
+
 			daemonInstance := NewDaemon(daemonCfg, daemonLog)
 			daemonInstance.SetCancelFunc(stop)
 
-			daemonInstance.RegisterCommand("PING", DefaultPingHandler)
-			daemonInstance.RegisterCommand("STATUS", MakeDefaultStatusHandler(daemonInstance))
-			daemonInstance.RegisterCommand("STOP", MakeDefaultStopHandler(daemonInstance))
-			daemonInstance.RegisterCommand("WEBSERVER", webSrvr.DaemonCommandHandler())
+			// The web server is controlled with the WEBSERVER command, STATUS shows whether it runs
+			daemonInstance.AddStatusField("WebServer", webSrvr.Status)
+
+			if webServerCfg := container.ConfigFromFile.WebServer; webServerCfg.RestartOnCrash {
+				watchdog := NewWatchdog(webSrvr, WatchdogConfig{MaxRestarts: webServerCfg.ResolvedMaxRestarts()}, daemonLog)
+				go watchdog.Run(ctx)
+
+				daemonInstance.AddStatusField("WebServer restarts", func() string {
+					return fmt.Sprintf("%d (Limit: %d)", watchdog.Restarts(), webServerCfg.ResolvedMaxRestarts())
+				})
+			}
+
+			if err := RegisterDefaultCommands(daemonInstance); err != nil {
+				container.Logger.WithField(loggerInt.ErrorKey, err).Error("Failed to register daemon commands")
+				return err
+			}
+			// The web server drains its requests before the daemon closes its socket
+			if err := daemonInstance.RegisterService(webSrvr); err != nil {
+				container.Logger.WithField(loggerInt.ErrorKey, err).Error("Failed to register the web server service")
+				return err
+			}
 
 			errChan := make(chan error, 1)
 			daemonStopped := make(chan struct{})
@@ -191,6 +254,9 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 				}
 			}()
 
+			spinner := theme.NewTerminalSpinner(themeManager.GetCurrentTheme())
+			spinner.Start("Starting daemon...")
+
 			select {
 			case err := <-errChan:
 				container.Logger.WithFields(map[string]interface{}{
@@ -199,22 +265,21 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 					"socket":           socketPath,
 				}).Error("Daemon failed to start")
 
-				themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to start daemon: %v", err))
+				spinner.Fail(fmt.Sprintf("Failed to start daemon: %v", err))
 				return err
-			case <-time.After(200 * time.Millisecond):
+			case <-daemonInstance.Ready():
+				notifySystemdState(container.Logger, sdNotifyReady)
+
 				container.Logger.WithFields(map[string]interface{}{
 					"socket":  socketPath,
 					"command": "start",
 				}).Info("Daemon started successfully and listening...")
 
-				themeManager.GetCurrentTheme().Success().Printf("Daemon started and listening on %s\n", daemonCfg.SocketPath)
-				if appConf.UsageTracking.Enabled {
-					telemetryEvent.SendTelemetryEvent(
-						context.Background(), appConfig, "daemon.start.foreground.success",
-						telemetry.SeverityInfo, "Daemon started in foreground", nil,
-					)
-				}
+				spinner.Success(fmt.Sprintf("Daemon started and listening on %s", daemonCfg.SocketPath))
+				container.Telemetry.Add(telemetryEvent.DaemonStarted(false))
+				go flushTelemetry(container.Telemetry)
 			case <-ctx.Done():
+				spinner.Stop()
 				container.Logger.WithFields(map[string]interface{}{
 					"socket":  socketPath,
 					"command": "start",
@@ -251,6 +316,7 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 
 			themeManager.GetCurrentTheme().Info().Println("Shutting down daemon...")
 
+			notifySystemdState(container.Logger, sdNotifyStopping)
 			daemonInstance.Stop()
 
 			<-daemonStopped
@@ -280,10 +346,55 @@ func NewStartCmd(container *cli.Container, appConf config.Config, appConfig *con
 	}
 
 	cmd.Flags().BoolVarP(&foreground, "foreground", "f", false, "Run daemon in foreground (don't detach)")
+	cmd.Flags().DurationVar(&wait, "wait", 0, "Block until the background daemon responds, failing after this duration, e.g. 10s")
 
 	return cmd
 }
 
+// notifySystemdState sends state to systemd if echoy runs as a Type=notify service and logs the outcome
+func notifySystemdState(logger loggerInt.Logger, state string) {
+	sent, err := notifySystemd(state)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			loggerInt.ErrorKey: err,
+			"command":          "start",
+			"state":            state,
+		}).Warn("Failed to notify systemd")
+		return
+	}
+
+	if sent {
+		logger.WithFields(map[string]interface{}{
+			"command": "start",
+			"state":   state,
+		}).Info("Notified systemd")
+	}
+}
+
+// waitForDaemon polls the daemon at socketPath until it answers PING. It fails when the
+// daemon process exits first, which is reported on exited, or when ctx is done.
+func waitForDaemon(ctx context.Context, socketPath string, logger loggerInt.Logger, exited <-chan error) error {
+	ticker := time.NewTicker(daemonReadyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if isRunning, _ := isDaemonRunning(socketPath, logger); isRunning {
+			return nil
+		}
+
+		select {
+		case err := <-exited:
+			if err == nil {
+				return errors.New("daemon process exited before it was ready")
+			}
+			return fmt.Errorf("daemon process exited before it was ready: %w", err)
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the daemon: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
 func isDaemonRunning(socketPath string, logger loggerInt.Logger) (bool, error) {
 	logger.Debug("Checking if daemon is running", "socket", socketPath)
 	conn, err := net.DialTimeout("unix", socketPath, 1*time.Second)
@@ -317,8 +428,7 @@ func isDaemonRunning(socketPath string, logger loggerInt.Logger) (bool, error) {
 		return false, err
 	}
 
-	response := string(buffer[:n])
-	trimmedResponse := strings.TrimSpace(response)
+	trimmedResponse := trimResponseEnd(string(buffer[:n]))
 	logger.Debug("Daemon check received response", "socket", socketPath, "response", trimmedResponse)
 
 	if trimmedResponse == "PONG" {
@@ -329,3 +439,12 @@ func isDaemonRunning(socketPath string, logger loggerInt.Logger) (bool, error) {
 	logger.Warn("Daemon check received unexpected response", "socket", socketPath, "response", trimmedResponse)
 	return false, fmt.Errorf("unexpected response from daemon: %q", trimmedResponse)
 }
+
+// flushTelemetry sends the recorded events right away. The daemon runs for long, so its
+// events can't wait until the process exits like those of other commands.
+func flushTelemetry(events *telemetryEvent.Batch) {
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryEvent.FlushTimeout)
+	defer cancel()
+
+	_ = events.Flush(ctx)
+}
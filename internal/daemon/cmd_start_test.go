@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForDaemon(t *testing.T) {
+	t.Run("ready once the daemon answers", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "echoy.sock")
+
+		go func() {
+			time.Sleep(2 * daemonReadyPollInterval)
+			listener, err := net.Listen("unix", socketPath)
+			if err != nil {
+				return
+			}
+			defer listener.Close()
+
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			if _, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+				conn.Write([]byte("PONG\n"))
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, waitForDaemon(ctx, socketPath, logger.NewNoopLogger(), nil))
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*daemonReadyPollInterval)
+		defer cancel()
+
+		err := waitForDaemon(ctx, filepath.Join(t.TempDir(), "echoy.sock"), logger.NewNoopLogger(), nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("daemon process exits", func(t *testing.T) {
+		exited := make(chan error, 1)
+		exitErr := errors.New("exit status 1")
+		exited <- exitErr
+
+		err := waitForDaemon(context.Background(), filepath.Join(t.TempDir(), "echoy.sock"), logger.NewNoopLogger(), exited)
+		assert.ErrorIs(t, err, exitErr)
+	})
+}
+
+func TestStartCmd_WaitFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "not set", args: nil, want: 0},
+		{name: "separate value", args: []string{"--wait", "30s"}, want: 30 * time.Second},
+		{name: "inline value", args: []string{"--wait=30s"}, want: 30 * time.Second},
+		{name: "missing value", args: []string{"--wait"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewStartCmd(nil, nil, "", "", nil)
+
+			err := cmd.ParseFlags(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			wait, err := cmd.Flags().GetDuration("wait")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, wait)
+			assert.Empty(t, cmd.Flags().Args(), "the duration shouldn't be left as an argument")
+		})
+	}
+}
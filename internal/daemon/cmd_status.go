@@ -2,66 +2,108 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/cli"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
-	"github.com/shaharia-lab/telemetry-collector"
 	"github.com/spf13/cobra"
 	"os"
 	"text/tabwriter"
 	"time"
 )
 
+// StatusResult is the JSON output of the status command
+type StatusResult struct {
+	Running   bool   `json:"running"`
+	Socket    string `json:"socket"`
+	LatencyMs int64  `json:"latencyMs"`
+	Details   string `json:"details,omitempty"`
+	// WebServer is the state of the web server, if the daemon runs
+	WebServer *ServiceResult `json:"webServer,omitempty"`
+}
+
 // NewStatusCmd creates a command to check the daemon status
-func NewStatusCmd(config config.Config, appConfig *config.AppConfig, logger logger.Logger, themeManager *theme.Manager, socketPath string) *cobra.Command {
+func NewStatusCmd(events *telemetryEvent.Batch, logger logger.Logger, themeManager *theme.Manager, socketPath string) *cobra.Command {
+	var output *cli.Output
+	var wait time.Duration
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Check the status of the Echoy daemon",
-		Long:  `Checks if the Echoy daemon is currently running.`,
+		Long: `Checks if the Echoy daemon is currently running.
+
+With --wait the daemon is polled until it responds or the duration has elapsed,
+e.g. right after 'echoy start'. With --json the result is printed as JSON and the command exits with a non-zero
+status if the daemon is not running.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if config.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					context.Background(),
-					appConfig,
-					"daemon.status",
-					telemetry.SeverityInfo, "Checking daemon status",
-					nil,
-				)
-			}
+			events.Add(telemetryEvent.DaemonStatusChecked())
 
 			logger.Info("Checking daemon status...")
 			defer logger.Flush()
 
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-
-			fmt.Fprintln(w, "COMPONENT\tSTATUS\tDETAILS")
-
 			provider := &UnixSocketProvider{
 				SocketPath: socketPath,
 				Timeout:    500 * time.Millisecond,
+				Attempts:   DefaultDialAttempts,
+				Wait:       wait,
 			}
 
 			client := NewClient(provider, 500*time.Millisecond, 2*time.Second)
 
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Second+wait)
 			defer cancel()
 
+			startedAt := time.Now()
 			isRunning, status := client.IsRunning(ctx)
 
+			result := StatusResult{
+				Running:   isRunning,
+				Socket:    socketPath,
+				LatencyMs: time.Since(startedAt).Milliseconds(),
+			}
+
 			if isRunning {
-				fmt.Fprintln(w, fmt.Sprintf("daemon\trunning\t-"))
-				w.Flush()
-				themeManager.GetCurrentTheme().Success().Println("\nDaemon is running correctly")
-			} else {
-				fmt.Fprintln(w, fmt.Sprintf("daemon\t%s\t%s", "not running", status))
-				w.Flush()
-				themeManager.GetCurrentTheme().Warning().Println("\nDaemon is not running. Start it with 'echoy daemon start'")
+				if webServer, err := ExecuteService(ctx, client, "WEBSERVER", "status"); err == nil {
+					result.WebServer = &webServer
+				} else {
+					logger.WithField("error", err).Warn("Failed to get the web server status")
+				}
+
+				return output.Success(result, func(t theme.Theme) {
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+					fmt.Fprintln(w, "COMPONENT\tSTATUS\tDETAILS")
+					fmt.Fprintln(w, "daemon\trunning\t-")
+					if result.WebServer != nil {
+						state, details := "stopped", "-"
+						if result.WebServer.Running {
+							state, details = "running", result.WebServer.Status
+						}
+						fmt.Fprintln(w, fmt.Sprintf("webserver\t%s\t%s", state, details))
+					}
+					w.Flush()
+					t.Success().Println("\nDaemon is running correctly")
+				})
 			}
 
+			result.Details = status
+			if output.JSON {
+				return output.Fail(result, errors.New("daemon is not running"), nil)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "COMPONENT\tSTATUS\tDETAILS")
+			fmt.Fprintln(w, fmt.Sprintf("daemon\t%s\t%s", "not running", status))
+			w.Flush()
+			themeManager.GetCurrentTheme().Warning().Println("\nDaemon is not running. Start it with 'echoy daemon start'")
+
 			return nil
 		},
 	}
+
+	output = cli.NewOutput(cmd, themeManager)
+	cmd.Flags().DurationVar(&wait, "wait", 0, "keep polling until the daemon is reachable or this duration has elapsed, e.g. 10s")
+
 	return cmd
 }
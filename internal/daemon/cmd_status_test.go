@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/cli"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runJSONCommand runs cmd with --json and returns what it printed and the error it returned
+func runJSONCommand(t *testing.T, cmd *cobra.Command) ([]byte, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--json"})
+
+	err := cmd.ExecuteContext(context.Background())
+	return stdout.Bytes(), err
+}
+
+func newTestThemeManager() *theme.Manager {
+	defaultTheme := theme.NewDefaultTheme()
+	defaultTheme.SetEnabled(false)
+	return theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&bytes.Buffer{})
+}
+
+func TestStatusCmd_JSON(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		d, socketPath := createTestDaemon(t, Config{})
+		t.Cleanup(func() { os.RemoveAll(socketPath) })
+		require.NoError(t, d.RegisterCommand("PING", DefaultPingHandler))
+		require.NoError(t, d.RegisterService(&fakeWebServer{}))
+		require.NoError(t, d.Start())
+		defer d.Stop()
+
+		output, err := runJSONCommand(t, NewStatusCmd(nil, logger.NewNoopLogger(), newTestThemeManager(), socketPath))
+		require.NoError(t, err)
+
+		var result StatusResult
+		require.NoError(t, json.Unmarshal(output, &result), "the output should be JSON: %s", output)
+		assert.True(t, result.Running)
+		assert.Equal(t, socketPath, result.Socket)
+		assert.Empty(t, result.Details)
+		require.NotNil(t, result.WebServer)
+		assert.Equal(t, ServiceResult{Service: "webserver", Action: "status", Status: "stopped", Message: "webserver is stopped"}, *result.WebServer)
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		socketPath := tempSocketPath(t)
+
+		output, err := runJSONCommand(t, NewStatusCmd(nil, logger.NewNoopLogger(), newTestThemeManager(), socketPath))
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr, "a daemon that isn't running should fail the command")
+
+		var result StatusResult
+		require.NoError(t, json.Unmarshal(output, &result), "the output should be JSON: %s", output)
+		assert.False(t, result.Running)
+		assert.Equal(t, socketPath, result.Socket)
+		assert.NotEmpty(t, result.Details)
+		assert.Nil(t, result.WebServer)
+	})
+}
+
+// fakeWebServer is a stopped service named like the web server
+type fakeWebServer struct {
+	fakeService
+}
+
+func (s *fakeWebServer) Name() string { return "webserver" }
@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopCmd_JSON(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		d, socketPath := createTestDaemon(t, Config{})
+		t.Cleanup(func() { os.RemoveAll(socketPath) })
+		require.NoError(t, RegisterDefaultCommands(d))
+		require.NoError(t, d.Start())
+		defer d.Stop()
+
+		output, err := runJSONCommand(t, NewStopCmd(nil, logger.NewNoopLogger(), newTestThemeManager(), socketPath))
+		require.NoError(t, err)
+
+		var result StopResult
+		require.NoError(t, json.Unmarshal(output, &result), "the output should be JSON: %s", output)
+		assert.True(t, result.Stopped)
+		assert.True(t, result.WasRunning)
+		assert.NotEmpty(t, result.Message)
+		assert.Empty(t, result.Error)
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		output, err := runJSONCommand(t, NewStopCmd(nil, logger.NewNoopLogger(), newTestThemeManager(), tempSocketPath(t)))
+		require.NoError(t, err, "stopping a daemon that isn't running isn't an error")
+
+		var result StopResult
+		require.NoError(t, json.Unmarshal(output, &result), "the output should be JSON: %s", output)
+		assert.Equal(t, StopResult{Message: "Daemon is not running."}, result)
+	})
+}
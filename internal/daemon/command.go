@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shaharia-lab/echoy/internal/types"
+)
+
+// UnlimitedArgs can be used as CommandSpec.MaxArgs to accept any number of arguments
+const UnlimitedArgs = -1
+
+// NamespaceSeparator separates a command's namespace from its name, e.g. MYAPP:SYNC
+const NamespaceSeparator = ":"
+
+// CommandSpec describes a daemon command for help output and argument validation
+type CommandSpec struct {
+	Name string
+	// Namespace groups commands registered by embedders to avoid collisions with the
+	// built-in ones. Clients call namespaced commands as NAMESPACE:NAME.
+	Namespace   string
+	Usage       string
+	Description string
+	MinArgs     int
+	MaxArgs     int
+
+	// NoExecTimeout runs the handler without Config.CommandExecTimeout. Its context is
+	// only cancelled when the client hangs up, the connection is killed or the daemon
+	// stops, which suits long-running commands such as streams that have no sensible
+	// fixed timeout.
+	NoExecTimeout bool
+}
+
+// command is a registered command handler together with its spec
+type command struct {
+	spec    CommandSpec
+	handler types.CommandFunc
+}
+
+// FullName returns the upper-cased name the command is called by, including its namespace
+func (s CommandSpec) FullName() string {
+	name := strings.ToUpper(s.Name)
+	if s.Namespace == "" {
+		return name
+	}
+
+	return strings.ToUpper(s.Namespace) + NamespaceSeparator + name
+}
+
+// validate checks that the command can be called by its full name
+func (s CommandSpec) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("command name is required")
+	}
+
+	if strings.ContainsAny(s.Name, " \t\r\n"+NamespaceSeparator) {
+		return fmt.Errorf("invalid command name '%s': must not contain whitespace or '%s'", s.Name, NamespaceSeparator)
+	}
+
+	if strings.ContainsAny(s.Namespace, " \t\r\n"+NamespaceSeparator) {
+		return fmt.Errorf("invalid namespace '%s' for command '%s': must not contain whitespace or '%s'", s.Namespace, s.Name, NamespaceSeparator)
+	}
+
+	return nil
+}
+
+// validateArgs checks the argument count against the spec and returns a usage error on mismatch
+func (s CommandSpec) validateArgs(args []string) error {
+	if len(args) < s.MinArgs || (s.MaxArgs != UnlimitedArgs && len(args) > s.MaxArgs) {
+		return fmt.Errorf("invalid number of arguments for '%s', usage: %s", s.FullName(), s.Usage)
+	}
+
+	return nil
+}
+
+// String renders the spec as multi-line help text
+func (s CommandSpec) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage: %s", s.Usage)
+	if s.Description != "" {
+		fmt.Fprintf(&b, "\nDescription: %s", s.Description)
+	}
+
+	switch {
+	case s.MaxArgs == UnlimitedArgs:
+		fmt.Fprintf(&b, "\nArguments: at least %d", s.MinArgs)
+	case s.MinArgs == s.MaxArgs:
+		fmt.Fprintf(&b, "\nArguments: exactly %d", s.MinArgs)
+	default:
+		fmt.Fprintf(&b, "\nArguments: %d to %d", s.MinArgs, s.MaxArgs)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+)
+
+// Metrics are counters of the connections of the daemon
+type Metrics struct {
+	ActiveConnections int
+	// QueuedConnections are waiting for a slot because MaxConnections was reached
+	QueuedConnections   int
+	AcceptedConnections uint64
+	RejectedConnections uint64
+}
+
+// ConnectionInfo describes an active client connection
+type ConnectionInfo struct {
+	// ID identifies the connection for KillConnection. IDs are assigned in the order the
+	// connections are accepted and never reused.
+	ID         uint64
+	RemoteAddr string
+	OpenedAt   time.Time
+	// Command is the name of the command the connection is running, empty while it is idle
+	Command string
+}
+
+// connectionInfo is what the daemon tracks of a connection, guarded by connMu
+type connectionInfo struct {
+	ConnectionInfo
+	// cancel cancels the context of the commands of the connection
+	cancel context.CancelFunc
+}
+
+// Connections returns the active connections, ordered by ID
+func (d *Daemon) Connections() []ConnectionInfo {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	connections := make([]ConnectionInfo, 0, len(d.connections))
+	for _, info := range d.connections {
+		connections = append(connections, info.ConnectionInfo)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].ID < connections[j].ID
+	})
+
+	return connections
+}
+
+// KillConnection closes the connection with ID id and cancels the command it is running
+func (d *Daemon) KillConnection(id uint64) error {
+	d.connMu.RLock()
+	var target net.Conn
+	var cancel context.CancelFunc
+	for conn, info := range d.connections {
+		if info.ID == id {
+			target, cancel = conn, info.cancel
+			break
+		}
+	}
+	d.connMu.RUnlock()
+
+	if target == nil {
+		return fmt.Errorf("no active connection with ID %d", id)
+	}
+
+	d.logger.Warn("Killing client connection", "connection_id", id, "remote_addr", target.RemoteAddr())
+	if cancel != nil {
+		cancel()
+	}
+	if err := target.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("failed to close connection %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// setConnectionCancel records the function cancelling the commands of conn
+func (d *Daemon) setConnectionCancel(conn net.Conn, cancel context.CancelFunc) {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	if info, ok := d.connections[conn]; ok {
+		info.cancel = cancel
+	}
+}
+
+// setConnectionCommand records the command conn is running, empty when it finished
+func (d *Daemon) setConnectionCommand(conn net.Conn, command string) {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	if info, ok := d.connections[conn]; ok {
+		info.Command = command
+	}
+}
+
+// Metrics returns the current connection counters
+func (d *Daemon) Metrics() Metrics {
+	d.connMu.RLock()
+	defer d.connMu.RUnlock()
+
+	return Metrics{
+		ActiveConnections:   len(d.connections),
+		QueuedConnections:   d.queuedConns,
+		AcceptedConnections: d.acceptedConns,
+		RejectedConnections: d.rejectedConns,
+	}
+}
+
+// admitConnection handles conn if a slot is free. Otherwise it queues conn for up to
+// QueueTimeout, or rejects it if queueing is disabled or the queue is full.
+func (d *Daemon) admitConnection(conn net.Conn) {
+	remoteAddr := conn.RemoteAddr()
+
+	d.connMu.Lock()
+	if !d.atConnectionLimit() {
+		d.serveConnection(conn)
+		d.connMu.Unlock()
+		return
+	}
+
+	if d.config.QueueTimeout <= 0 || d.queuedConns >= d.config.QueueSize {
+		d.rejectedConns++
+		d.connMu.Unlock()
+
+		d.logger.Warn("Max connections reached, rejecting new connection", "limit", d.config.MaxConnections, "remote_addr", remoteAddr)
+		d.rejectConnection(conn, fmt.Sprintf("daemon is busy: the limit of %d connections is reached, try again later", d.config.MaxConnections))
+		return
+	}
+
+	d.queuedConns++
+	queued := d.queuedConns
+	d.wg.Add(1)
+	d.connMu.Unlock()
+
+	d.logger.Info("Max connections reached, queueing new connection", "limit", d.config.MaxConnections, "queued", queued, "remote_addr", remoteAddr)
+
+	logger.SafeGo(func() {
+		defer d.wg.Done()
+		d.waitForSlot(conn)
+	}, d.logger.WithFields(logger.Fields{"goroutine": "queue_connection", "remote_addr": remoteAddr}))
+}
+
+// waitForSlot handles conn once another connection closes, or rejects it when QueueTimeout
+// elapses first
+func (d *Daemon) waitForSlot(conn net.Conn) {
+	timer := time.NewTimer(d.config.QueueTimeout)
+	defer timer.Stop()
+
+	for {
+		d.connMu.Lock()
+		if !d.atConnectionLimit() {
+			d.queuedConns--
+			d.serveConnection(conn)
+			d.connMu.Unlock()
+			return
+		}
+		freed := d.connFreed
+		d.connMu.Unlock()
+
+		select {
+		case <-freed:
+		case <-timer.C:
+			d.connMu.Lock()
+			d.queuedConns--
+			d.rejectedConns++
+			d.connMu.Unlock()
+
+			d.logger.Warn("No connection slot freed up in time, rejecting queued connection", "queue_timeout", d.config.QueueTimeout, "remote_addr", conn.RemoteAddr())
+			d.rejectConnection(conn, fmt.Sprintf("daemon is busy: no connection slot freed up within %s, try again later", d.config.QueueTimeout))
+			return
+		case <-d.stopChan:
+			d.connMu.Lock()
+			d.queuedConns--
+			d.connMu.Unlock()
+
+			conn.Close()
+			return
+		}
+	}
+}
+
+// atConnectionLimit reports whether MaxConnections is reached. connMu must be held.
+func (d *Daemon) atConnectionLimit() bool {
+	return d.config.MaxConnections > 0 && len(d.connections) >= d.config.MaxConnections
+}
+
+// serveConnection tracks conn and handles it in its own goroutine. connMu must be held.
+func (d *Daemon) serveConnection(conn net.Conn) {
+	remoteAddr := conn.RemoteAddr()
+
+	d.acceptedConns++
+	d.connections[conn] = &connectionInfo{ConnectionInfo: ConnectionInfo{
+		ID:         d.acceptedConns,
+		RemoteAddr: remoteAddr.String(),
+		OpenedAt:   time.Now(),
+	}}
+	d.wg.Add(1)
+
+	d.logger.Info("Accepted new client connection", "remote_addr", remoteAddr, "current_connections", len(d.connections))
+
+	logger.SafeGo(func() {
+		defer d.wg.Done()
+		d.handleConnection(conn)
+	}, d.logger.WithFields(logger.Fields{"goroutine": "handle_connection", "remote_addr": remoteAddr}))
+}
+
+// releaseConnectionSlot wakes up the queued connections after connections were removed.
+// connMu must be held.
+func (d *Daemon) releaseConnectionSlot() {
+	close(d.connFreed)
+	d.connFreed = make(chan struct{})
+}
+
+// rejectConnection tells the client why conn is rejected and closes it
+func (d *Daemon) rejectConnection(conn net.Conn, reason string) {
+	defer conn.Close()
+
+	if d.config.WriteTimeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(d.config.WriteTimeout))
+	}
+	if _, err := conn.Write([]byte("ERROR: " + reason + "\n" + ResponseEnd + "\n")); err != nil {
+		d.logger.Debug("Failed to tell the client its connection is rejected", "remote_addr", conn.RemoteAddr(), "error", err)
+	}
+}
@@ -16,6 +16,10 @@ import (
 	"unicode"
 )
 
+// ResponseEnd is the line every response ends with, so clients don't have to wait for
+// more of a response that may span several lines
+const ResponseEnd = "END"
+
 // Config holds the configuration for the daemon
 type Config struct {
 	SocketPath         string
@@ -25,6 +29,28 @@ type Config struct {
 	CommandExecTimeout time.Duration
 	Logger             logger.Logger
 	MaxConnections     int
+
+	// QueueTimeout is how long a connection over MaxConnections waits for a slot before it
+	// is rejected. Zero rejects it right away.
+	QueueTimeout time.Duration
+	// QueueSize is the accept backlog: how many connections may wait for a slot at once.
+	// It defaults to MaxConnections when QueueTimeout is set.
+	QueueSize int
+
+	// ReaderBufferSize is the size of the buffer command lines are read with
+	ReaderBufferSize int
+	// MaxCommandLength is the longest command line accepted, newline included. It defaults
+	// to ReaderBufferSize; longer lines are read in several fragments of the buffer. The
+	// client of a longer line gets an error and its connection is closed.
+	MaxCommandLength int
+
+	// ResponseChunkSize is the largest piece of a response written at once. The write
+	// deadline is renewed for every chunk, so big responses to slow readers don't time out.
+	ResponseChunkSize int
+
+	// AcceptPollInterval is how often the accept loop wakes up to check for shutdown.
+	// Stop closes the listener, which unblocks Accept right away, so this is only a fallback.
+	AcceptPollInterval time.Duration
 }
 
 // Daemon represents the main daemon structure
@@ -33,17 +59,44 @@ type Daemon struct {
 	listener    net.Listener
 	stopOnce    sync.Once
 	stopChan    chan struct{}
+	readyOnce   sync.Once
+	ready       chan struct{}
 	wg          sync.WaitGroup
-	connections map[net.Conn]struct{}
+	connections map[net.Conn]*connectionInfo
 	connMu      sync.RWMutex
-	commands    map[string]types.CommandFunc
-	cmdMu       sync.RWMutex
-	logger      logger.Logger
-	cancelCtx   context.CancelFunc
+	// connFreed is closed and replaced whenever connections are removed, waking up the
+	// queued connections. It and the counters below are guarded by connMu.
+	connFreed     chan struct{}
+	queuedConns   int
+	acceptedConns uint64
+	rejectedConns uint64
+	commands      map[string]command
+	cmdMu         sync.RWMutex
+	logger        logger.Logger
+	cancelCtx     context.CancelFunc
+	// createdAt is when the daemon was created, the uptime reported by DEBUG
+	createdAt time.Time
+
+	// stoppers are shut down by Stop before the socket and client connections are closed
+	stoppers   []Stopper
+	stoppersMu sync.Mutex
+
+	// statusFields are extra lines of the STATUS output
+	statusFields   []statusField
+	statusFieldsMu sync.RWMutex
+
+	// rootCtx is the parent of all command contexts and is cancelled by Stop
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
 }
 
 const defaultReaderSize = 4096
 
+// errCommandTooLong is returned by readCommandLine for lines over MaxCommandLength
+var errCommandTooLong = errors.New("command too long")
+
+const defaultResponseChunkSize = 32 * 1024
+
 // NewDaemon creates a new Daemon instance with the provided configuration
 func NewDaemon(cfg Config, logger logger.Logger) *Daemon {
 	cfg.Logger = logger
@@ -62,32 +115,147 @@ func NewDaemon(cfg Config, logger logger.Logger) *Daemon {
 	if cfg.MaxConnections == 0 {
 		cfg.MaxConnections = 0
 	}
+	if cfg.QueueTimeout > 0 && cfg.QueueSize <= 0 {
+		cfg.QueueSize = cfg.MaxConnections
+	}
+	if cfg.ReaderBufferSize <= 0 {
+		cfg.ReaderBufferSize = defaultReaderSize
+	}
+	if cfg.MaxCommandLength <= 0 {
+		cfg.MaxCommandLength = cfg.ReaderBufferSize
+	}
+	if cfg.ResponseChunkSize <= 0 {
+		cfg.ResponseChunkSize = defaultResponseChunkSize
+	}
+	if cfg.AcceptPollInterval == 0 {
+		cfg.AcceptPollInterval = time.Second
+	}
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
 
 	d := &Daemon{
+		rootCtx:     rootCtx,
+		rootCancel:  rootCancel,
 		config:      cfg,
 		stopChan:    make(chan struct{}),
-		connections: make(map[net.Conn]struct{}),
-		commands:    make(map[string]types.CommandFunc),
+		ready:       make(chan struct{}),
+		connections: make(map[net.Conn]*connectionInfo),
+		connFreed:   make(chan struct{}),
+		commands:    make(map[string]command),
 		logger:      cfg.Logger,
+		createdAt:   time.Now(),
 	}
 
 	return d
 }
 
+// Stopper is a component running alongside the daemon, such as the web server, that has to
+// be shut down with it
+type Stopper interface {
+	Name() string
+	Stop(ctx context.Context) error
+}
+
+// AddStopper registers s to be stopped when the daemon stops. Stoppers are stopped in reverse
+// order of registration before the socket is closed, so their in-flight requests can drain
+// while the daemon is still reachable. They share the daemon's shutdown timeout.
+func (d *Daemon) AddStopper(s Stopper) {
+	d.stoppersMu.Lock()
+	defer d.stoppersMu.Unlock()
+
+	d.stoppers = append(d.stoppers, s)
+}
+
+// statusField is a line of the STATUS output, computed when STATUS runs
+type statusField struct {
+	name  string
+	value func() string
+}
+
+// AddStatusField adds a "name: value" line to the STATUS output. value is called every time
+// STATUS runs, so it must be safe for concurrent use.
+func (d *Daemon) AddStatusField(name string, value func() string) {
+	d.statusFieldsMu.Lock()
+	defer d.statusFieldsMu.Unlock()
+
+	d.statusFields = append(d.statusFields, statusField{name: name, value: value})
+}
+
 func (d *Daemon) SetCancelFunc(cancelFunc context.CancelFunc) {
 	d.cancelCtx = cancelFunc
 }
 
-// RegisterCommand adds or replaces a command handler. Not safe for concurrent use after Start().
-func (d *Daemon) RegisterCommand(name string, handler types.CommandFunc) {
+// Ready returns a channel that is closed once the listener is created and the accept loop is running
+func (d *Daemon) Ready() <-chan struct{} {
+	return d.ready
+}
+
+// SocketPath returns the path of the Unix socket the daemon listens on
+func (d *Daemon) SocketPath() string {
+	return d.config.SocketPath
+}
+
+// ErrCommandExists is returned when registering a command under a name that is already taken
+var ErrCommandExists = errors.New("command already registered")
+
+// RegisterCommand adds a command handler accepting any number of arguments. It fails with
+// ErrCommandExists if the name is taken, use ReplaceCommand to overwrite a handler.
+// Not safe for concurrent use after Start().
+func (d *Daemon) RegisterCommand(name string, handler types.CommandFunc) error {
+	return d.RegisterCommandSpec(CommandSpec{Name: name, MaxArgs: UnlimitedArgs}, handler)
+}
+
+// RegisterCommandSpec adds a command handler described by spec. Arguments are validated
+// against the spec before the handler is invoked. It fails with ErrCommandExists if the
+// command is already registered.
+func (d *Daemon) RegisterCommandSpec(spec CommandSpec, handler types.CommandFunc) error {
+	return d.registerCommand(spec, handler, false)
+}
+
+// ReplaceCommand adds or replaces a command handler accepting any number of arguments
+func (d *Daemon) ReplaceCommand(name string, handler types.CommandFunc) error {
+	return d.ReplaceCommandSpec(CommandSpec{Name: name, MaxArgs: UnlimitedArgs}, handler)
+}
+
+// ReplaceCommandSpec adds or replaces a command handler described by spec
+func (d *Daemon) ReplaceCommandSpec(spec CommandSpec, handler types.CommandFunc) error {
+	return d.registerCommand(spec, handler, true)
+}
+
+func (d *Daemon) registerCommand(spec CommandSpec, handler types.CommandFunc, replace bool) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
 	d.cmdMu.Lock()
 	defer d.cmdMu.Unlock()
-	upperName := strings.ToUpper(name)
-	if _, exists := d.commands[upperName]; exists {
-		d.logger.Warn("Overwriting existing command handler", "command", upperName)
+
+	spec.Name = strings.ToUpper(spec.Name)
+	spec.Namespace = strings.ToUpper(spec.Namespace)
+	name := spec.FullName()
+	if spec.Usage == "" {
+		spec.Usage = name
 	}
-	d.commands[upperName] = handler
-	d.logger.Debug("Registered command", "command", upperName)
+
+	if _, exists := d.commands[name]; exists {
+		if !replace {
+			return fmt.Errorf("%w: %s", ErrCommandExists, name)
+		}
+		d.logger.Warn("Replacing existing command handler", "command", name)
+	}
+
+	d.commands[name] = command{spec: spec, handler: handler}
+	d.logger.Debug("Registered command", "command", name)
+
+	return nil
+}
+
+// CommandSpec returns the spec of a registered command
+func (d *Daemon) CommandSpec(name string) (CommandSpec, bool) {
+	d.cmdMu.RLock()
+	defer d.cmdMu.RUnlock()
+	cmd, found := d.commands[strings.ToUpper(name)]
+	return cmd.spec, found
 }
 
 // Start initializes the listener and begins accepting connections.
@@ -101,9 +269,8 @@ func (d *Daemon) Start() error {
 	// Use platform-specific umask setting
 	defer setSocketUmask(d)()
 
-	if err := os.RemoveAll(d.config.SocketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
-		d.logger.Error("Failed to remove existing socket file", "path", d.config.SocketPath, "error", err)
-		return fmt.Errorf("failed to remove existing socket %s: %w", d.config.SocketPath, err)
+	if err := d.removeStaleSocket(); err != nil {
+		return err
 	}
 
 	var err error
@@ -130,10 +297,10 @@ func (d *Daemon) Start() error {
 	d.logger.Info("Daemon starting listener loop", "socket", d.config.SocketPath)
 
 	d.wg.Add(1)
-	go func() {
+	logger.SafeGo(func() {
 		defer d.wg.Done()
 		d.acceptConnections()
-	}()
+	}, d.logger.WithField("goroutine", "accept_connections"))
 
 	cleanupListener = false
 	return nil
@@ -144,6 +311,8 @@ func (d *Daemon) Stop() {
 	d.stopOnce.Do(func() {
 		d.logger.Info("Stop: Initiating daemon shutdown...")
 
+		d.stopStoppers()
+
 		if d.cancelCtx != nil {
 			d.logger.Debug("Stop: Calling main context cancel function.")
 			d.cancelCtx()
@@ -151,6 +320,9 @@ func (d *Daemon) Stop() {
 			d.logger.Warn("Stop: Main context cancel function is nil.")
 		}
 
+		d.logger.Debug("Stop: Cancelling command contexts...")
+		d.rootCancel()
+
 		d.logger.Debug("Stop: Closing stopChan...")
 		close(d.stopChan) // Signal internal loops
 
@@ -207,6 +379,58 @@ func (d *Daemon) Stop() {
 	})
 }
 
+// stopStoppers stops the registered stoppers, newest first, within the shutdown timeout.
+// A failing stopper is logged and doesn't keep the others from stopping.
+func (d *Daemon) stopStoppers() {
+	d.stoppersMu.Lock()
+	stoppers := append([]Stopper(nil), d.stoppers...)
+	d.stoppersMu.Unlock()
+
+	if len(stoppers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.ShutdownTimeout)
+	defer cancel()
+
+	for i := len(stoppers) - 1; i >= 0; i-- {
+		serviceLog := d.logger.WithField("service", stoppers[i].Name())
+		serviceLog.Info("Stop: Stopping service")
+		if err := stoppers[i].Stop(ctx); err != nil {
+			serviceLog.WithField(logger.ErrorKey, err).Error("Stop: Failed to stop service")
+			continue
+		}
+		serviceLog.Info("Stop: Service stopped")
+	}
+}
+
+// removeStaleSocket removes the socket file a daemon left behind when it didn't shut down
+// cleanly. The file is only removed when nothing accepts connections on it anymore, so
+// starting a second daemon can't take the socket away from a running one.
+func (d *Daemon) removeStaleSocket() error {
+	path := d.config.SocketPath
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	running, err := isDaemonRunning(path, d.logger)
+	if running {
+		return fmt.Errorf("a daemon is already running on socket %s", path)
+	}
+	if err != nil {
+		// Something accepted the connection but didn't answer PING like a daemon
+		return fmt.Errorf("socket %s is in use by another process: %w", path, err)
+	}
+
+	d.logger.Warn("Removing stale socket file left by a daemon that didn't shut down cleanly", "path", path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		d.logger.Error("Failed to remove existing socket file", "path", path, "error", err)
+		return fmt.Errorf("failed to remove existing socket %s: %w", path, err)
+	}
+
+	return nil
+}
+
 // closeConnections closes all tracked active client connections.
 func (d *Daemon) closeConnections() {
 	d.connMu.Lock()
@@ -215,7 +439,8 @@ func (d *Daemon) closeConnections() {
 		connsToClose = append(connsToClose, conn)
 	}
 
-	d.connections = make(map[net.Conn]struct{})
+	d.connections = make(map[net.Conn]*connectionInfo)
+	d.releaseConnectionSlot()
 	connCount := len(connsToClose)
 	d.connMu.Unlock()
 
@@ -240,10 +465,13 @@ func (d *Daemon) closeConnections() {
 
 func (d *Daemon) acceptConnections() {
 	d.logger.Info("Starting connection accept loop")
+	d.readyOnce.Do(func() {
+		close(d.ready)
+	})
 
 	for {
 		if unixListener, ok := d.listener.(*net.UnixListener); ok {
-			if err := unixListener.SetDeadline(time.Now().Add(1 * time.Second)); err != nil {
+			if err := unixListener.SetDeadline(time.Now().Add(d.config.AcceptPollInterval)); err != nil {
 				if errors.Is(err, net.ErrClosed) {
 					d.logger.Info("Listener closed (detected in SetDeadline), exiting accept loop.")
 					return
@@ -283,26 +511,7 @@ func (d *Daemon) acceptConnections() {
 		default:
 		}
 
-		remoteAddr := conn.RemoteAddr()
-		d.connMu.Lock()
-		if d.config.MaxConnections > 0 && len(d.connections) >= d.config.MaxConnections {
-			d.connMu.Unlock()
-			d.logger.Warn("Max connections reached, rejecting new connection", "limit", d.config.MaxConnections, "remote_addr", remoteAddr)
-			conn.Close()
-			continue
-		}
-
-		d.connections[conn] = struct{}{}
-		d.wg.Add(1)
-		currentConns := len(d.connections)
-		d.connMu.Unlock()
-
-		d.logger.Info("Accepted new client connection", "remote_addr", remoteAddr, "current_connections", currentConns)
-
-		go func(c net.Conn) {
-			defer d.wg.Done()
-			d.handleConnection(c)
-		}(conn)
+		d.admitConnection(conn)
 	}
 }
 
@@ -321,13 +530,20 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 		conn.Close()
 		d.connMu.Lock()
 		delete(d.connections, conn)
+		d.releaseConnectionSlot()
 		connCount := len(d.connections)
 		d.connMu.Unlock()
 		d.logger.Info("Connection closed and removed", "remote_addr", remoteAddr, "remaining_connections", connCount)
 	}()
 
 	d.logger.Debug("Handling connection", "remote_addr", remoteAddr)
-	reader := bufio.NewReaderSize(conn, defaultReaderSize)
+	reader := bufio.NewReaderSize(conn, d.config.ReaderBufferSize)
+
+	// connCtx bounds the commands of this connection and is cancelled when the daemon stops,
+	// the connection is killed or the client hangs up during a command
+	connCtx, connCancel := context.WithCancel(d.rootCtx)
+	defer connCancel()
+	d.setConnectionCancel(conn, connCancel)
 
 	for {
 		select {
@@ -344,7 +560,7 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 			}
 		}
 
-		commandLine, err := reader.ReadString('\n')
+		commandLine, err := readCommandLine(reader, d.config.MaxCommandLength)
 
 		if d.config.ReadTimeout > 0 {
 			_ = conn.SetReadDeadline(time.Time{})
@@ -363,9 +579,10 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 				d.logger.Info("Connection closed while reading", "remote_addr", remoteAddr)
 				return
 			}
-			if errors.Is(err, bufio.ErrBufferFull) {
-				d.logger.Error("Command line exceeded buffer size", "remote_addr", remoteAddr, "limit", defaultReaderSize)
-				if writeErr := d.writeResponse(conn, "ERROR: Command too long.\n", remoteAddr); writeErr != nil {
+			if errors.Is(err, errCommandTooLong) {
+				d.logger.Error("Command line exceeded the maximum length", "remote_addr", remoteAddr, "limit", d.config.MaxCommandLength)
+				response := fmt.Sprintf("ERROR: Command too long, the limit is %d bytes.\n%s\n", d.config.MaxCommandLength, ResponseEnd)
+				if writeErr := d.writeResponse(conn, response, remoteAddr); writeErr != nil {
 					d.logger.Warn("Failed to write 'Command too long' error to client", "remote_addr", remoteAddr, "error", writeErr)
 				}
 				return
@@ -390,19 +607,36 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 		var cmdErr error
 
 		d.cmdMu.RLock()
-		handler, found := d.commands[commandName]
+		cmd, found := d.commands[commandName]
 		d.cmdMu.RUnlock()
 
 		if found {
-			cmdCtx, cmdCancel := context.WithTimeout(context.Background(), d.config.CommandExecTimeout)
-			response, cmdErr = handler(cmdCtx, args)
+			cmdErr = cmd.spec.validateArgs(args)
+		}
+
+		if found && cmdErr == nil {
+			cmdCtx, cmdCancel := d.commandContext(connCtx, cmd.spec)
+			cmdProgress := &commandProgress{write: func(line string) error {
+				return d.writeResponse(conn, line, remoteAddr)
+			}}
+			d.setConnectionCommand(conn, commandName)
+			stopWatching := watchHangUp(conn, reader, connCancel)
+			response, cmdErr = cmd.handler(withProgress(cmdCtx, cmdProgress), args)
+			stopWatching()
+			cmdProgress.finish()
+			d.setConnectionCommand(conn, "")
 			cmdCancel()
 
+			if errors.Is(cmdErr, context.Canceled) && connCtx.Err() != nil {
+				d.logger.Info("Command cancelled by daemon shutdown, KILL or the client hanging up, closing connection", "remote_addr", remoteAddr, "command", commandName)
+				return
+			}
+
 			if errors.Is(cmdErr, context.DeadlineExceeded) {
 				d.logger.Error("Command execution timed out", "remote_addr", remoteAddr, "command", commandName, "timeout", d.config.CommandExecTimeout)
 				cmdErr = fmt.Errorf("command '%s' timed out after %v", commandName, d.config.CommandExecTimeout)
 			}
-		} else {
+		} else if !found {
 			cmdErr = fmt.Errorf("unknown command '%s'", commandName)
 		}
 
@@ -419,7 +653,7 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 			d.logger.Debug("Command execution successful", "remote_addr", remoteAddr, "command", commandName)
 		}
 
-		writeErr := d.writeResponse(conn, response, remoteAddr)
+		writeErr := d.writeResponse(conn, response+ResponseEnd+"\n", remoteAddr)
 		if writeErr != nil {
 			return
 		}
@@ -431,22 +665,76 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 	}
 }
 
+// readCommandLine reads a line of at most maxLength bytes from reader, newline included.
+// Lines longer than the buffer of reader are read in fragments, so the limit doesn't depend
+// on the buffer size.
+func readCommandLine(reader *bufio.Reader, maxLength int) (string, error) {
+	var line []byte
+	for {
+		fragment, err := reader.ReadSlice('\n')
+		if len(line)+len(fragment) > maxLength {
+			return "", errCommandTooLong
+		}
+		line = append(line, fragment...)
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return string(line), err
+		}
+	}
+}
+
+// commandContext returns the context a command runs with, which is bounded by the exec
+// timeout unless the command's spec opts out of it
+func (d *Daemon) commandContext(connCtx context.Context, spec CommandSpec) (context.Context, context.CancelFunc) {
+	if spec.NoExecTimeout {
+		return context.WithCancel(connCtx)
+	}
+
+	return context.WithTimeout(connCtx, d.config.CommandExecTimeout)
+}
+
+// watchHangUp calls cancel if the client closes conn while a command runs, so the command
+// doesn't keep running for nobody. The returned function stops watching; a next command
+// the client sends meanwhile is left in reader.
+func watchHangUp(conn net.Conn, reader *bufio.Reader, cancel context.CancelFunc) func() {
+	stopping := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		if _, err := reader.Peek(1); err != nil {
+			select {
+			case <-stopping:
+			default:
+				cancel()
+			}
+		}
+	}()
+
+	return func() {
+		close(stopping)
+		// A deadline in the past makes the pending peek return right away
+		_ = conn.SetReadDeadline(time.Now())
+		<-done
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+}
+
 func (d *Daemon) writeResponse(conn net.Conn, response string, remoteAddr string) error {
 	if d.config.WriteTimeout > 0 {
-		if err := conn.SetWriteDeadline(time.Now().Add(d.config.WriteTimeout)); err != nil {
-			d.logger.Error("Failed to set write deadline", "remote_addr", remoteAddr, "error", err)
-		}
 		defer func() {
 			_ = conn.SetWriteDeadline(time.Time{})
 		}()
 	}
 
-	n, writeErr := conn.Write([]byte(response))
+	w := &chunkedWriter{conn: conn, chunkSize: d.config.ResponseChunkSize, timeout: d.config.WriteTimeout}
+	n, writeErr := w.Write([]byte(response))
 
 	logFields := map[string]interface{}{
 		"remote_addr":   remoteAddr,
 		"response_len":  len(response),
 		"bytes_written": n,
+		"writes":        w.writes,
 	}
 	if writeErr != nil {
 		if netErr, ok := writeErr.(net.Error); ok && netErr.Timeout() {
@@ -459,13 +747,46 @@ func (d *Daemon) writeResponse(conn net.Conn, response string, remoteAddr string
 		return writeErr
 	}
 
-	if n < len(response) {
-		d.logger.WithFields(logFields).Warn("Partial write to client")
-
-		return io.ErrShortWrite
-	}
-
 	d.logger.WithFields(logFields).Debug("Successfully wrote response")
 
 	return nil
 }
+
+// chunkedWriter writes to a connection in chunks of at most chunkSize bytes and renews the
+// write deadline before each chunk. A write that only accepts part of a chunk is continued
+// with the rest, until everything is written or the deadline fires.
+type chunkedWriter struct {
+	conn      net.Conn
+	chunkSize int
+	timeout   time.Duration
+	writes    int
+}
+
+// Write implements io.Writer
+func (w *chunkedWriter) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		end := len(p)
+		if w.chunkSize > 0 && n+w.chunkSize < end {
+			end = n + w.chunkSize
+		}
+
+		if w.timeout > 0 {
+			if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+				return n, fmt.Errorf("failed to set write deadline: %w", err)
+			}
+		}
+
+		written, err := w.conn.Write(p[n:end])
+		n += written
+		w.writes++
+		if err != nil {
+			return n, err
+		}
+		if written == 0 {
+			return n, io.ErrShortWrite
+		}
+	}
+
+	return n, nil
+}
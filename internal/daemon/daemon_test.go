@@ -2,7 +2,9 @@ package daemon
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/logger"
@@ -25,6 +27,7 @@ func TestNewDaemon(t *testing.T) {
 	defaultWriteTimeout := 10 * time.Second
 	defaultCommandExecTimeout := 5 * time.Second
 	defaultMaxConnections := 0
+	defaultAcceptPollInterval := time.Second
 
 	testCases := []struct {
 		name         string
@@ -44,6 +47,7 @@ func TestNewDaemon(t *testing.T) {
 				WriteTimeout:       defaultWriteTimeout,
 				CommandExecTimeout: defaultCommandExecTimeout,
 				MaxConnections:     defaultMaxConnections,
+				AcceptPollInterval: defaultAcceptPollInterval,
 			},
 			expectLogger: true,
 		},
@@ -63,6 +67,7 @@ func TestNewDaemon(t *testing.T) {
 				WriteTimeout:       defaultWriteTimeout,
 				CommandExecTimeout: defaultCommandExecTimeout,
 				MaxConnections:     50,
+				AcceptPollInterval: defaultAcceptPollInterval,
 			},
 			expectLogger: true,
 		},
@@ -76,6 +81,7 @@ func TestNewDaemon(t *testing.T) {
 				CommandExecTimeout: 10 * time.Second,
 				Logger:             logger.NewNoopLogger(),
 				MaxConnections:     100,
+				AcceptPollInterval: 250 * time.Millisecond,
 			},
 			expectedCfg: Config{
 				SocketPath:         "/tmp/full.sock",
@@ -84,6 +90,7 @@ func TestNewDaemon(t *testing.T) {
 				WriteTimeout:       15 * time.Second,
 				CommandExecTimeout: 10 * time.Second,
 				MaxConnections:     100,
+				AcceptPollInterval: 250 * time.Millisecond,
 			},
 			expectLogger: true,
 		},
@@ -117,6 +124,12 @@ func TestNewDaemon(t *testing.T) {
 			if d.config.MaxConnections != tc.expectedCfg.MaxConnections {
 				t.Errorf("expected MaxConnections %d, got %d", tc.expectedCfg.MaxConnections, d.config.MaxConnections)
 			}
+			if d.config.ReaderBufferSize != defaultReaderSize || d.config.MaxCommandLength != defaultReaderSize {
+				t.Errorf("expected ReaderBufferSize and MaxCommandLength %d, got %d and %d", defaultReaderSize, d.config.ReaderBufferSize, d.config.MaxCommandLength)
+			}
+			if d.config.AcceptPollInterval != tc.expectedCfg.AcceptPollInterval {
+				t.Errorf("expected AcceptPollInterval %v, got %v", tc.expectedCfg.AcceptPollInterval, d.config.AcceptPollInterval)
+			}
 
 			if tc.expectLogger && d.logger == nil {
 				t.Error("expected Logger to be non-nil, but got nil")
@@ -263,7 +276,7 @@ func TestHandleConnection_Ping(t *testing.T) {
 	}
 
 	response := string(responseBytes[:n])
-	expectedResponse := "PONG\n"
+	expectedResponse := "PONG\nEND\n"
 	if response != expectedResponse {
 		t.Errorf("Expected response %q, got %q", expectedResponse, response)
 	}
@@ -272,6 +285,57 @@ func TestHandleConnection_Ping(t *testing.T) {
 	waitForWg(t, &wg, 2*time.Second)
 }
 
+func TestHandleConnection_MaxCommandLength(t *testing.T) {
+	t.Parallel()
+
+	d, _ := createTestDaemon(t, Config{ReaderBufferSize: 16, MaxCommandLength: 64})
+	d.RegisterCommand("ECHO", func(ctx context.Context, args []string) (string, error) {
+		return strings.Join(args, " "), nil
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.handleConnection(serverConn)
+	}()
+
+	reader := bufio.NewReader(clientConn)
+
+	// A line longer than the buffer but within the limit is read in fragments
+	payload := strings.Repeat("a", 40)
+	if _, err := clientConn.Write([]byte("ECHO " + payload + "\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if response != "OK: "+payload+"\n" {
+		t.Errorf("Expected response %q, got %q", "OK: "+payload+"\n", response)
+	}
+	if end, err := reader.ReadString('\n'); err != nil || end != "END\n" {
+		t.Fatalf("Expected the response to end with END, got %q (%v)", end, err)
+	}
+
+	go func() {
+		_, _ = clientConn.Write([]byte("ECHO " + strings.Repeat("a", 100) + "\n"))
+	}()
+	response, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	expectedResponse := "ERROR: Command too long, the limit is 64 bytes.\n"
+	if response != expectedResponse {
+		t.Errorf("Expected response %q, got %q", expectedResponse, response)
+	}
+
+	waitForWg(t, &wg, 2*time.Second)
+}
+
 func TestHandleConnection_UnknownCommand(t *testing.T) {
 	t.Parallel()
 
@@ -440,6 +504,249 @@ func TestHandleConnection_CommandExecTimeout(t *testing.T) {
 	waitForWg(t, &wg, readWriteTimeout)
 }
 
+func TestHandleConnection_NoExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	execTimeout := 50 * time.Millisecond
+	d, _ := createTestDaemon(t, Config{
+		CommandExecTimeout: execTimeout,
+		ReadTimeout:        execTimeout * 10,
+		WriteTimeout:       execTimeout * 10,
+	})
+
+	d.RegisterCommandSpec(CommandSpec{Name: "STREAM", MaxArgs: UnlimitedArgs, NoExecTimeout: true}, func(ctx context.Context, args []string) (string, error) {
+		select {
+		case <-time.After(execTimeout * 3):
+			return "stream finished", nil
+		case <-ctx.Done():
+			return "", fmt.Errorf("handler context cancelled: %w", ctx.Err())
+		}
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.handleConnection(serverConn)
+	}()
+
+	if _, err := clientConn.Write([]byte("STREAM\n")); err != nil {
+		t.Fatalf("Client write command failed: %v", err)
+	}
+
+	responseBytes := make([]byte, 256)
+	n, err := clientConn.Read(responseBytes)
+	if err != nil {
+		t.Fatalf("Client read failed: %v", err)
+	}
+
+	assert.Equal(t, "OK: stream finished\nEND\n", string(responseBytes[:n]))
+
+	clientConn.Close()
+	waitForWg(t, &wg, time.Second)
+}
+
+func TestHandleConnection_ClientHangsUp(t *testing.T) {
+	t.Parallel()
+
+	d, _ := createTestDaemon(t, Config{})
+
+	cancelled := make(chan error, 1)
+	d.RegisterCommandSpec(CommandSpec{Name: "STREAM", NoExecTimeout: true}, func(ctx context.Context, args []string) (string, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return "", ctx.Err()
+	})
+
+	serverConn, clientConn := net.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.handleConnection(serverConn)
+	}()
+
+	_, err := clientConn.Write([]byte("STREAM\n"))
+	require.NoError(t, err)
+	clientConn.Close()
+
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("the command should be cancelled when the client hangs up")
+	}
+
+	waitForWg(t, &wg, time.Second)
+}
+
+func TestHandleConnection_NextCommandDuringCommand(t *testing.T) {
+	t.Parallel()
+
+	d, _ := createTestDaemon(t, Config{ReadTimeout: time.Second, WriteTimeout: time.Second})
+	require.NoError(t, d.RegisterCommand("PING", DefaultPingHandler))
+
+	release := make(chan struct{})
+	d.RegisterCommandSpec(CommandSpec{Name: "SLOW"}, func(ctx context.Context, args []string) (string, error) {
+		select {
+		case <-release:
+			return "done", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.handleConnection(serverConn)
+	}()
+
+	_, err := clientConn.Write([]byte("SLOW\n"))
+	require.NoError(t, err)
+	// Sent while SLOW runs, the next command must be kept for after its response
+	_, err = clientConn.Write([]byte("PING\n"))
+	require.NoError(t, err)
+	close(release)
+
+	reader := bufio.NewReader(clientConn)
+	var lines []string
+	for len(lines) < 4 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"OK: done\n", "END\n", "PONG\n", "END\n"}, lines)
+
+	clientConn.Close()
+	waitForWg(t, &wg, time.Second)
+}
+
+func TestHandleConnection_Progress(t *testing.T) {
+	t.Parallel()
+
+	d, _ := createTestDaemon(t, Config{ReadTimeout: time.Second, WriteTimeout: time.Second})
+
+	var afterResponse error
+	responded := make(chan struct{})
+	d.RegisterCommandSpec(CommandSpec{Name: "SLOW", MaxArgs: UnlimitedArgs}, func(ctx context.Context, args []string) (string, error) {
+		if err := ReportProgress(ctx, "downloading"); err != nil {
+			return "", err
+		}
+		if err := ReportProgress(ctx, "extracting\nfiles"); err != nil {
+			return "", err
+		}
+
+		go func() {
+			<-responded
+			afterResponse = ReportProgress(ctx, "too late")
+			close(responded)
+		}()
+		return "done", nil
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.handleConnection(serverConn)
+	}()
+
+	_, err := clientConn.Write([]byte("SLOW\n"))
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(clientConn)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"PROGRESS: downloading\n", "PROGRESS: extracting files\n", "OK: done\n"}, lines)
+
+	responded <- struct{}{}
+	<-responded
+	assert.Error(t, afterResponse, "progress can't be reported once the command responded")
+	assert.NoError(t, ReportProgress(context.Background(), "ignored"), "progress outside of the daemon should be ignored")
+
+	clientConn.Close()
+	waitForWg(t, &wg, time.Second)
+}
+
+// shortWriteConn accepts at most maxWrite bytes per Write call, like a slow reader's socket
+type shortWriteConn struct {
+	net.Conn
+	maxWrite  int
+	err       error
+	written   bytes.Buffer
+	largest   int
+	deadlines int
+}
+
+func (c *shortWriteConn) Write(b []byte) (int, error) {
+	if len(b) > c.largest {
+		c.largest = len(b)
+	}
+	if len(b) > c.maxWrite {
+		b = b[:c.maxWrite]
+	}
+	c.written.Write(b)
+	return len(b), c.err
+}
+
+func (c *shortWriteConn) SetWriteDeadline(t time.Time) error {
+	if !t.IsZero() {
+		c.deadlines++
+	}
+	return nil
+}
+
+func TestWriteResponse_PartialWrites(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+	response := strings.Repeat("STATUS line\n", 100)
+
+	conn := &shortWriteConn{maxWrite: 7}
+	require.NoError(t, d.writeResponse(conn, response, "test"))
+	assert.Equal(t, response, conn.written.String())
+
+	failing := &shortWriteConn{maxWrite: 7, err: os.ErrDeadlineExceeded}
+	err := d.writeResponse(failing, response, "test")
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Equal(t, response[:7], failing.written.String())
+}
+
+func TestWriteResponse_Chunked(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{
+		Logger:            logger.NewNoopLogger(),
+		WriteTimeout:      time.Second,
+		ResponseChunkSize: 100,
+	})
+	response := strings.Repeat("x", 1050)
+
+	conn := &shortWriteConn{maxWrite: len(response)}
+	require.NoError(t, d.writeResponse(conn, response, "test"))
+	assert.Equal(t, response, conn.written.String())
+	assert.Equal(t, 100, conn.largest, "no write may exceed the chunk size")
+	assert.Equal(t, 11, conn.deadlines, "the write deadline must be renewed for every chunk")
+
+	// Partial writes within a chunk are continued and each retry gets a fresh deadline
+	partial := &shortWriteConn{maxWrite: 30}
+	require.NoError(t, d.writeResponse(partial, response, "test"))
+	assert.Equal(t, response, partial.written.String())
+	assert.Equal(t, 35, partial.deadlines)
+}
+
 func TestHandleConnection_MaxConnections(t *testing.T) {
 	maxConns := 2
 	socketPath := tempSocketPath(t)
@@ -504,16 +811,10 @@ func TestHandleConnection_MaxConnections(t *testing.T) {
 	defer excessConn.Close()
 
 	excessConn.SetReadDeadline(time.Now().Add(1 * time.Second))
-	buf := make([]byte, 1)
-	n, readErr := excessConn.Read(buf)
-
-	if readErr == nil {
-		t.Errorf("Expected error reading from rejected connection, but read %d bytes", n)
-	} else if !errors.Is(readErr, io.EOF) && !strings.Contains(readErr.Error(), "closed") && !strings.Contains(readErr.Error(), "reset by peer") && !strings.Contains(readErr.Error(), "broken pipe") {
-		t.Errorf("Expected EOF or closed/reset error reading from rejected connection, got: %v", readErr)
-	} else {
-		t.Logf("Received expected error from rejected connection: %v", readErr)
-	}
+	response, readErr := io.ReadAll(excessConn)
+	require.NoError(t, readErr, "the rejected connection should be closed after the error message")
+	assert.Equal(t, "ERROR: daemon is busy: the limit of 2 connections is reached, try again later\nEND\n", string(response))
+	assert.Equal(t, uint64(1), d.Metrics().RejectedConnections)
 
 	d.connMu.RLock()
 	finalCount := len(d.connections)
@@ -523,6 +824,71 @@ func TestHandleConnection_MaxConnections(t *testing.T) {
 	}
 }
 
+func TestHandleConnection_QueuedConnections(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{
+		MaxConnections: 1,
+		QueueTimeout:   300 * time.Millisecond,
+		ReadTimeout:    5 * time.Second,
+	})
+	require.NoError(t, d.RegisterCommand("PING", DefaultPingHandler))
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	dial := func() net.Conn {
+		conn, err := net.DialTimeout("unix", socketPath, time.Second)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	waitForQueue := func(depth int) {
+		require.Eventually(t, func() bool { return d.Metrics().QueuedConnections == depth }, time.Second, 10*time.Millisecond)
+	}
+
+	active := dial()
+	require.Eventually(t, func() bool { return d.Metrics().ActiveConnections == 1 }, time.Second, 10*time.Millisecond)
+
+	t.Run("served once a slot frees up", func(t *testing.T) {
+		queued := dial()
+		waitForQueue(1)
+
+		_, err := queued.Write([]byte("PING\n"))
+		require.NoError(t, err)
+		active.Close()
+
+		queued.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := bufio.NewReader(queued).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "PONG\n", line)
+		assert.Equal(t, 0, d.Metrics().QueuedConnections)
+	})
+
+	t.Run("rejected with a message when the queue timeout elapses", func(t *testing.T) {
+		queued := dial()
+		waitForQueue(1)
+
+		queued.SetReadDeadline(time.Now().Add(time.Second))
+		response, err := io.ReadAll(queued)
+		require.NoError(t, err)
+		assert.Equal(t, "ERROR: daemon is busy: no connection slot freed up within 300ms, try again later\nEND\n", string(response))
+
+		metrics := d.Metrics()
+		assert.Equal(t, 0, metrics.QueuedConnections)
+		assert.Equal(t, uint64(1), metrics.RejectedConnections)
+		assert.Equal(t, uint64(2), metrics.AcceptedConnections)
+	})
+
+	t.Run("rejected right away when the queue is full", func(t *testing.T) {
+		dial()
+		waitForQueue(1)
+
+		full := dial()
+		full.SetReadDeadline(time.Now().Add(time.Second))
+		response, err := io.ReadAll(full)
+		require.NoError(t, err)
+		assert.Contains(t, string(response), "the limit of 1 connections is reached")
+	})
+}
+
 /*func TestHandleConnection_ReadTimeoutOnLongLine(t *testing.T) {
 	t.Parallel()
 
@@ -683,6 +1049,113 @@ func TestStartStop_Lifecycle(t *testing.T) {
 	d3.Stop()
 }
 
+func TestStart_Ready(t *testing.T) {
+	socketPath := tempSocketPath(t)
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+	d, _ := createTestDaemon(t, Config{SocketPath: socketPath})
+
+	select {
+	case <-d.Ready():
+		t.Fatal("Ready() closed before Start()")
+	default:
+	}
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	select {
+	case <-d.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Ready() was not closed after Start()")
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	require.NoError(t, err, "daemon should accept connections once ready")
+	conn.Close()
+}
+
+func TestStart_StaleSocket(t *testing.T) {
+	socketPath := tempSocketPath(t)
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	// A daemon that crashed leaves its socket file behind
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	require.FileExists(t, socketPath)
+
+	d, _ := createTestDaemon(t, Config{SocketPath: socketPath})
+	d.RegisterCommand("PING", DefaultPingHandler)
+	require.NoError(t, d.Start(), "a stale socket should be replaced")
+	defer d.Stop()
+
+	running, err := isDaemonRunning(socketPath, logger.NewNoopLogger())
+	assert.NoError(t, err)
+	assert.True(t, running)
+}
+
+func TestStart_SocketInUse(t *testing.T) {
+	socketPath := tempSocketPath(t)
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	first, _ := createTestDaemon(t, Config{SocketPath: socketPath})
+	first.RegisterCommand("PING", DefaultPingHandler)
+	require.NoError(t, first.Start())
+	defer first.Stop()
+
+	second, _ := createTestDaemon(t, Config{SocketPath: socketPath})
+	assert.ErrorContains(t, second.Start(), "a daemon is already running on socket")
+
+	running, _ := isDaemonRunning(socketPath, logger.NewNoopLogger())
+	assert.True(t, running, "the running daemon should keep its socket")
+}
+
+func TestStart_SocketInUseByAnotherProcess(t *testing.T) {
+	socketPath := tempSocketPath(t)
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	d, _ := createTestDaemon(t, Config{SocketPath: socketPath})
+	assert.ErrorContains(t, d.Start(), "is in use by another process")
+	assert.FileExists(t, socketPath)
+}
+
+func TestStop_ExitsAcceptLoopPromptly(t *testing.T) {
+	socketPath := tempSocketPath(t)
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+	d, _ := createTestDaemon(t, Config{SocketPath: socketPath, AcceptPollInterval: time.Minute})
+
+	require.NoError(t, d.Start())
+	<-d.Ready()
+
+	stopped := make(chan struct{})
+	start := time.Now()
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		assert.Less(t, time.Since(start), 2*time.Second, "Stop should not wait for the accept poll interval")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return while the accept loop was blocked in Accept")
+	}
+}
+
 func TestStop_ClosesConnections(t *testing.T) {
 	socketPath := tempSocketPath(t)
 	t.Cleanup(func() { os.RemoveAll(socketPath) })
@@ -786,6 +1259,206 @@ func TestStop_ClosesConnections(t *testing.T) {
 	}
 }
 
+func TestStop_CancelsCommandContext(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{ShutdownTimeout: 2 * time.Second})
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	d.RegisterCommandSpec(CommandSpec{Name: "FOLLOW", MaxArgs: UnlimitedArgs, NoExecTimeout: true}, func(ctx context.Context, args []string) (string, error) {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return "", ctx.Err()
+	})
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("FOLLOW\n"))
+	require.NoError(t, err)
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("FOLLOW handler did not start")
+	}
+
+	go d.Stop()
+
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("command context was not cancelled by Stop")
+	}
+}
+
+type recordingStopper struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+func (s recordingStopper) Name() string { return s.name }
+
+func (s recordingStopper) Stop(ctx context.Context) error { return s.stop(ctx) }
+
+func TestStop_StopsStoppersBeforeClosingSocket(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{ShutdownTimeout: 2 * time.Second})
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	var order []string
+	var dialErr error
+	d.AddStopper(recordingStopper{name: "first", stop: func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	}})
+	d.AddStopper(recordingStopper{name: "second", stop: func(ctx context.Context) error {
+		order = append(order, "second")
+
+		conn, err := net.DialTimeout("unix", socketPath, time.Second)
+		if err == nil {
+			conn.Close()
+		}
+		dialErr = err
+
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline, "stoppers should be bounded by the shutdown timeout")
+		return errors.New("failed to stop")
+	}})
+
+	require.NoError(t, d.Start())
+	d.Stop()
+
+	assert.Equal(t, []string{"second", "first"}, order, "stoppers should be stopped newest first, also after one fails")
+	assert.NoError(t, dialErr, "the socket should still accept connections while stoppers drain")
+	_, err := os.Stat(socketPath)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "socket should be removed after Stop")
+}
+
+type fakeService struct {
+	running bool
+	stopped int
+}
+
+func (s *fakeService) Name() string { return "fake" }
+
+func (s *fakeService) Start(ctx context.Context) error {
+	if s.running {
+		return errors.New("already running")
+	}
+	s.running = true
+	return nil
+}
+
+func (s *fakeService) Stop(ctx context.Context) error {
+	s.running = false
+	s.stopped++
+	return nil
+}
+
+func (s *fakeService) Running() bool { return s.running }
+
+func (s *fakeService) Status() string {
+	if s.running {
+		return "running"
+	}
+	return "stopped"
+}
+
+func TestRegisterService(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{})
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	service := &fakeService{}
+	require.NoError(t, d.RegisterService(service))
+	assert.Error(t, d.RegisterService(service), "a service should only be registered once")
+
+	spec, ok := d.CommandSpec("fake")
+	require.True(t, ok, "the service command should be registered under the upper-cased name")
+	assert.Equal(t, "FAKE start|stop|status", spec.Usage)
+
+	handler := d.commands["FAKE"].handler
+	run := func(subcommand string) (ServiceResult, error) {
+		response, err := handler(context.Background(), []string{subcommand})
+		if err != nil {
+			return ServiceResult{}, err
+		}
+
+		var result ServiceResult
+		require.NoError(t, json.Unmarshal([]byte(response), &result), "the response should be a ServiceResult")
+		return result, nil
+	}
+
+	result, err := run("START")
+	require.NoError(t, err)
+	assert.Equal(t, ServiceResult{Service: "fake", Action: "start", Running: true, Status: "running", Message: "fake started, running"}, result)
+
+	_, err = run("start")
+	assert.ErrorContains(t, err, "failed to start fake")
+
+	result, err = run("status")
+	require.NoError(t, err)
+	assert.Equal(t, ServiceResult{Service: "fake", Action: "status", Running: true, Status: "running", Message: "fake is running"}, result)
+
+	result, err = run("stop")
+	require.NoError(t, err)
+	assert.Equal(t, ServiceResult{Service: "fake", Action: "stop", Status: "stopped", Message: "fake stopped"}, result)
+
+	_, err = run("restart")
+	assert.ErrorContains(t, err, "unknown subcommand 'restart'")
+
+	require.NoError(t, d.Start())
+	d.Stop()
+	assert.Equal(t, 2, service.stopped, "registered services should be stopped with the daemon")
+}
+
+// slowService takes steps*step to start, reporting its progress after each step
+type slowService struct {
+	fakeService
+	steps int
+	step  time.Duration
+}
+
+func (s *slowService) Start(ctx context.Context) error {
+	for i := 1; i <= s.steps; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.step):
+		}
+		if err := ReportProgress(ctx, fmt.Sprintf("step %d", i)); err != nil {
+			return err
+		}
+	}
+	return s.fakeService.Start(ctx)
+}
+
+func TestRegisterService_SlowStart(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{CommandExecTimeout: 50 * time.Millisecond})
+	service := &slowService{steps: 4, step: 40 * time.Millisecond}
+	require.NoError(t, d.RegisterService(service))
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	// Each step is quicker than the read timeout of the client, all of them together take
+	// longer than the exec timeout of the daemon
+	client := NewClient(&UnixSocketProvider{SocketPath: socketPath, Timeout: time.Second}, time.Second, 100*time.Millisecond)
+	var messages []string
+	client.OnProgress = func(message string) {
+		messages = append(messages, message)
+	}
+
+	result, err := ExecuteService(context.Background(), client, "fake", "start")
+	require.NoError(t, err, "starting a service shouldn't be bounded by the exec timeout")
+	assert.True(t, result.Running)
+	assert.Equal(t, []string{"Starting fake", "step 1", "step 2", "step 3", "step 4"}, messages)
+}
+
 func TestDaemon_CommandArgHandling(t *testing.T) {
 	socketPath := tempSocketPath(t)
 	t.Cleanup(func() { os.RemoveAll(socketPath) })
@@ -860,6 +1533,113 @@ func TestDaemon_CommandArgHandling(t *testing.T) {
 	}
 }
 
+func TestDaemon_CommandSpecArgValidation(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	var handlerCalls int
+	d.RegisterCommandSpec(CommandSpec{
+		Name:    "greet",
+		Usage:   "GREET <name> [greeting]",
+		MinArgs: 1,
+		MaxArgs: 2,
+	}, func(ctx context.Context, args []string) (string, error) {
+		handlerCalls++
+		return "hello " + args[0], nil
+	})
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	client := NewClient(&UnixSocketProvider{SocketPath: socketPath, Timeout: 500 * time.Millisecond}, 500*time.Millisecond, 2*time.Second)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedCalls int
+	}{
+		{
+			name:          "too few arguments",
+			args:          nil,
+			expected:      "ERROR: invalid number of arguments for 'GREET', usage: GREET <name> [greeting]",
+			expectedCalls: 0,
+		},
+		{
+			name:          "too many arguments",
+			args:          []string{"a", "b", "c"},
+			expected:      "ERROR: invalid number of arguments for 'GREET', usage: GREET <name> [greeting]",
+			expectedCalls: 0,
+		},
+		{
+			name:          "valid arguments",
+			args:          []string{"echoy"},
+			expected:      "OK: hello echoy",
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerCalls = 0
+			response, err := client.Execute(context.Background(), "GREET", tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, response)
+			assert.Equal(t, tc.expectedCalls, handlerCalls)
+		})
+	}
+}
+
+func TestDaemon_RegisterCommandDuplicates(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+
+	first := func(ctx context.Context, args []string) (string, error) { return "first", nil }
+	second := func(ctx context.Context, args []string) (string, error) { return "second", nil }
+
+	require.NoError(t, d.RegisterCommand("sync", first))
+
+	err := d.RegisterCommand("SYNC", second)
+	assert.ErrorIs(t, err, ErrCommandExists)
+	response, _ := d.commands["SYNC"].handler(context.Background(), nil)
+	assert.Equal(t, "first", response, "a failed registration must keep the existing handler")
+
+	require.NoError(t, d.ReplaceCommand("Sync", second))
+	response, _ = d.commands["SYNC"].handler(context.Background(), nil)
+	assert.Equal(t, "second", response)
+}
+
+func TestDaemon_NamespacedCommands(t *testing.T) {
+	d, socketPath := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+	t.Cleanup(func() { os.RemoveAll(socketPath) })
+
+	require.NoError(t, RegisterDefaultCommands(d))
+	require.NoError(t, d.RegisterCommandSpec(CommandSpec{Namespace: "myapp", Name: "status", Description: "Status of my app"}, func(ctx context.Context, args []string) (string, error) {
+		return "myapp is fine", nil
+	}))
+
+	spec, found := d.CommandSpec("MYAPP:STATUS")
+	require.True(t, found)
+	assert.Equal(t, "MYAPP:STATUS", spec.FullName())
+	assert.Equal(t, "MYAPP:STATUS", spec.Usage)
+
+	assert.Error(t, d.RegisterCommandSpec(CommandSpec{Namespace: "my:app", Name: "status"}, DefaultPingHandler))
+	assert.Error(t, d.RegisterCommand("my app", DefaultPingHandler))
+	assert.Error(t, d.RegisterCommand("", DefaultPingHandler))
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	client := NewClient(&UnixSocketProvider{SocketPath: socketPath, Timeout: 500 * time.Millisecond}, 500*time.Millisecond, 2*time.Second)
+
+	response, err := client.Execute(context.Background(), "myapp:status", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "OK: myapp is fine", response)
+
+	response, err = client.Execute(context.Background(), "STATUS", nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "MYAPP:STATUS")
+}
+
 func TestStopCommandTerminatesDaemon(t *testing.T) {
 	socketPath := tempSocketPath(t)
 	t.Logf("Test: Using socket path: %s", socketPath) // Keep path info
@@ -954,6 +1734,9 @@ func TestStopCommandTerminatesDaemon(t *testing.T) {
 	response, err := reader.ReadString('\n')
 	require.NoError(t, err)
 	assert.Equal(t, "PONG\n", response)
+	response, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "END\n", response)
 	err = conn.SetReadDeadline(time.Time{})
 	require.NoError(t, err)
 
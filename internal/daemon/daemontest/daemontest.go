@@ -0,0 +1,103 @@
+// Package daemontest provides helpers for integration tests that need a real daemon
+// listening on a Unix socket.
+package daemontest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/daemon"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/types"
+)
+
+// Option customizes the daemon started by StartTestDaemon
+type Option func(*options)
+
+type options struct {
+	config   daemon.Config
+	logger   logger.Logger
+	commands map[string]types.CommandFunc
+}
+
+// WithConfig lets the caller adjust the daemon configuration before it starts.
+// The socket path is always overridden with a temporary one.
+func WithConfig(modify func(cfg *daemon.Config)) Option {
+	return func(o *options) {
+		modify(&o.config)
+	}
+}
+
+// WithLogger sets the logger used by the daemon. Defaults to a no-op logger.
+func WithLogger(l logger.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithCommand registers an additional command handler next to the default ones
+func WithCommand(name string, handler types.CommandFunc) Option {
+	return func(o *options) {
+		o.commands[name] = handler
+	}
+}
+
+// StartTestDaemon starts a real daemon on a temporary socket with the default commands
+// registered and returns a client connected to it along with a cleanup function that
+// stops the daemon and removes the socket directory.
+func StartTestDaemon(t testing.TB, opts ...Option) (*daemon.Client, func()) {
+	t.Helper()
+
+	o := &options{
+		config: daemon.Config{
+			ShutdownTimeout:    2 * time.Second,
+			ReadTimeout:        time.Second,
+			WriteTimeout:       time.Second,
+			CommandExecTimeout: 2 * time.Second,
+		},
+		logger:   logger.NewNoopLogger(),
+		commands: make(map[string]types.CommandFunc),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Unix socket paths are limited to ~100 bytes, so t.TempDir (which embeds the
+	// test name) is not safe to use here.
+	dir, err := os.MkdirTemp("", "echoy-daemontest-")
+	if err != nil {
+		t.Fatalf("failed to create socket directory: %v", err)
+	}
+	o.config.SocketPath = filepath.Join(dir, "daemon.sock")
+
+	d := daemon.NewDaemon(o.config, o.logger)
+	if err := daemon.RegisterDefaultCommands(d); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to register default commands: %v", err)
+	}
+	for name, handler := range o.commands {
+		if err := d.RegisterCommand(name, handler); err != nil {
+			os.RemoveAll(dir)
+			t.Fatalf("failed to register command %s: %v", name, err)
+		}
+	}
+
+	if err := d.Start(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to start test daemon: %v", err)
+	}
+
+	client := daemon.NewClient(&daemon.UnixSocketProvider{
+		SocketPath: d.SocketPath(),
+		Timeout:    time.Second,
+	}, time.Second, time.Second)
+
+	cleanup := func() {
+		d.Stop()
+		os.RemoveAll(dir)
+	}
+
+	return client, cleanup
+}
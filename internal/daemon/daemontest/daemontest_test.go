@@ -0,0 +1,53 @@
+package daemontest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartTestDaemon_DefaultCommands(t *testing.T) {
+	client, cleanup := StartTestDaemon(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	running, status := client.IsRunning(ctx)
+	assert.True(t, running, "daemon should answer PING, got %q", status)
+
+	response, err := client.Execute(ctx, "STATUS", nil)
+	require.NoError(t, err)
+	assert.Contains(t, response, "PING")
+	assert.Contains(t, response, "STATUS")
+	assert.Contains(t, response, "STOP")
+}
+
+func TestStartTestDaemon_WithCommand(t *testing.T) {
+	client, cleanup := StartTestDaemon(t, WithCommand("ECHO", func(ctx context.Context, args []string) (string, error) {
+		return strings.Join(args, " "), nil
+	}))
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	response, err := client.Execute(ctx, "echo", []string{"hello", "world"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK: hello world", response)
+}
+
+func TestStartTestDaemon_CleanupStopsDaemon(t *testing.T) {
+	client, cleanup := StartTestDaemon(t)
+	cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	running, _ := client.IsRunning(ctx)
+	assert.False(t, running, "daemon should not answer after cleanup")
+}
@@ -4,10 +4,124 @@ import (
 	"context"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/types"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// RegisterDefaultCommands registers the built-in PING, STATUS, METRICS, DEBUG, CONNECTIONS, KILL, STOP, HELP, SET, GET and DEL commands on the daemon.
+// It fails if any of them is already registered.
+func RegisterDefaultCommands(d *Daemon) error {
+	store := NewKVStore()
+
+	defaults := []struct {
+		spec    CommandSpec
+		handler types.CommandFunc
+	}{
+		{
+			spec: CommandSpec{
+				Name:        "PING",
+				Description: "Check that the daemon is responsive",
+			},
+			handler: DefaultPingHandler,
+		},
+		{
+			spec: CommandSpec{
+				Name:        "STATUS",
+				Description: "Show connection and command statistics",
+			},
+			handler: MakeDefaultStatusHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "METRICS",
+				Description: "Show connection counters, including queued and rejected connections",
+			},
+			handler: MakeMetricsHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "DEBUG",
+				Description: "Show the resource usage of the daemon: goroutines, heap and garbage collection",
+			},
+			handler: MakeDebugHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "CONNECTIONS",
+				Description: "List the active connections with their ID, age and running command",
+			},
+			handler: MakeConnectionsHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "KILL",
+				Usage:       "KILL <id>",
+				Description: "Close the connection with the ID listed by CONNECTIONS",
+				MinArgs:     1,
+				MaxArgs:     1,
+			},
+			handler: MakeKillHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "STOP",
+				Description: "Shut the daemon down gracefully",
+			},
+			handler: MakeDefaultStopHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "HELP",
+				Usage:       "HELP [command]",
+				Description: "List commands or show usage for a single command",
+				MaxArgs:     1,
+			},
+			handler: MakeHelpHandler(d),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "SET",
+				Usage:       "SET <key> <value> [ttl]",
+				Description: "Store a value in memory, optionally expiring after ttl (e.g. 30s)",
+				MinArgs:     2,
+				MaxArgs:     3,
+			},
+			handler: MakeSetHandler(store),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "GET",
+				Usage:       "GET <key>",
+				Description: "Return a stored value",
+				MinArgs:     1,
+				MaxArgs:     1,
+			},
+			handler: MakeGetHandler(store),
+		},
+		{
+			spec: CommandSpec{
+				Name:        "DEL",
+				Usage:       "DEL <key>",
+				Description: "Remove a stored value",
+				MinArgs:     1,
+				MaxArgs:     1,
+			},
+			handler: MakeDelHandler(store),
+		},
+	}
+
+	for _, c := range defaults {
+		if err := d.RegisterCommandSpec(c.spec, c.handler); err != nil {
+			return fmt.Errorf("failed to register default commands: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // DefaultPingHandler is a simple ping handler that responds with "PONG".
 func DefaultPingHandler(ctx context.Context, args []string) (string, error) {
 	select {
@@ -46,11 +160,112 @@ func MakeDefaultStatusHandler(d *Daemon) types.CommandFunc {
 				cmdCount,
 				strings.Join(cmdNames, ", "),
 			)
+
+			d.statusFieldsMu.RLock()
+			for _, field := range d.statusFields {
+				status += fmt.Sprintf("\n%s: %s", field.name, field.value())
+			}
+			d.statusFieldsMu.RUnlock()
+
 			return status, nil
 		}
 	}
 }
 
+// MakeMetricsHandler creates a handler reporting the connection counters of the daemon,
+// one "name: value" line each
+func MakeMetricsHandler(d *Daemon) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("metrics cancelled: %w", err)
+		}
+
+		m := d.Metrics()
+		return fmt.Sprintf(
+			"connections_active: %d\nconnections_limit: %d\nconnections_queued: %d\nconnections_accepted_total: %d\nconnections_rejected_total: %d\nqueue_timeout: %s",
+			m.ActiveConnections,
+			d.config.MaxConnections,
+			m.QueuedConnections,
+			m.AcceptedConnections,
+			m.RejectedConnections,
+			d.config.QueueTimeout,
+		), nil
+	}
+}
+
+// MakeDebugHandler creates a handler reporting the resource usage of the daemon process,
+// one "name: value" line each, to diagnose memory and goroutine leaks
+func MakeDebugHandler(d *Daemon) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("debug cancelled: %w", err)
+		}
+
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		lastGC := "never"
+		if m.LastGC > 0 {
+			lastGC = time.Since(time.Unix(0, int64(m.LastGC))).Round(time.Millisecond).String() + " ago"
+		}
+
+		return fmt.Sprintf(
+			"uptime: %s\ngoroutines: %d\nheap_alloc_bytes: %d\nheap_inuse_bytes: %d\nheap_sys_bytes: %d\nheap_objects: %d\ntotal_alloc_bytes: %d\nsys_bytes: %d\ngc_cycles: %d\ngc_pause_total: %s\ngc_last: %s\ngc_next_heap_bytes: %d\ngc_cpu_fraction: %.4f",
+			time.Since(d.createdAt).Round(time.Second),
+			runtime.NumGoroutine(),
+			m.HeapAlloc,
+			m.HeapInuse,
+			m.HeapSys,
+			m.HeapObjects,
+			m.TotalAlloc,
+			m.Sys,
+			m.NumGC,
+			time.Duration(m.PauseTotalNs),
+			lastGC,
+			m.NextGC,
+			m.GCCPUFraction,
+		), nil
+	}
+}
+
+// MakeConnectionsHandler creates a handler listing the active connections, one line each
+func MakeConnectionsHandler(d *Daemon) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("connections cancelled: %w", err)
+		}
+
+		connections := d.Connections()
+		lines := make([]string, 0, len(connections))
+		for _, c := range connections {
+			command := c.Command
+			if command == "" {
+				command = "-"
+			}
+			lines = append(lines, fmt.Sprintf("id=%d remote_addr=%s age=%s command=%s",
+				c.ID, c.RemoteAddr, time.Since(c.OpenedAt).Round(time.Second), command))
+		}
+
+		return strings.Join(lines, "\n"), nil
+	}
+}
+
+// MakeKillHandler creates a handler closing the connection with the given ID
+func MakeKillHandler(d *Daemon) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid connection ID '%s'", args[0])
+		}
+
+		if err := d.KillConnection(id); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Connection %d closed", id), nil
+	}
+}
+
 // MakeDefaultStopHandler creates a stop handler closure capturing the daemon instance.
 func MakeDefaultStopHandler(d *Daemon) types.CommandFunc {
 	return func(ctx context.Context, args []string) (string, error) {
@@ -59,3 +274,38 @@ func MakeDefaultStopHandler(d *Daemon) types.CommandFunc {
 		return "Daemon stop initiated.", nil
 	}
 }
+
+// MakeHelpHandler creates a handler that lists registered commands or describes a single one.
+func MakeHelpHandler(d *Daemon) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		if len(args) == 1 {
+			spec, found := d.CommandSpec(args[0])
+			if !found {
+				return "", fmt.Errorf("unknown command '%s'", strings.ToUpper(args[0]))
+			}
+			return spec.String(), nil
+		}
+
+		d.cmdMu.RLock()
+		specs := make([]CommandSpec, 0, len(d.commands))
+		for _, cmd := range d.commands {
+			specs = append(specs, cmd.spec)
+		}
+		d.cmdMu.RUnlock()
+
+		sort.Slice(specs, func(i, j int) bool {
+			return specs[i].FullName() < specs[j].FullName()
+		})
+
+		lines := make([]string, 0, len(specs))
+		for _, spec := range specs {
+			if spec.Description == "" {
+				lines = append(lines, spec.Usage)
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s - %s", spec.Usage, spec.Description))
+		}
+
+		return strings.Join(lines, "\n"), nil
+	}
+}
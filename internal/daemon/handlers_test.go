@@ -2,8 +2,11 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	"github.com/shaharia-lab/echoy/internal/types"
+	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -135,6 +138,135 @@ func TestMakeDefaultStatusHandler(t *testing.T) {
 	}
 }
 
+func TestMakeDefaultStatusHandler_StatusFields(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+
+	restarts := 0
+	d.AddStatusField("WebServer restarts", func() string { return strconv.Itoa(restarts) })
+
+	restarts = 2
+	got, err := MakeDefaultStatusHandler(d)(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("StatusHandler() error = %v", err)
+	}
+	if !strings.HasSuffix(got, "\nWebServer restarts: 2") {
+		t.Errorf("StatusHandler() should end with the status field computed at call time\nGot: %s", got)
+	}
+}
+
+func TestMakeMetricsHandler(t *testing.T) {
+	d := NewDaemon(Config{MaxConnections: 10, QueueTimeout: time.Second}, logger.NewNoopLogger())
+	d.acceptedConns = 7
+	d.rejectedConns = 2
+	d.queuedConns = 1
+
+	got, err := MakeMetricsHandler(d)(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("MetricsHandler() error = %v", err)
+	}
+	want := "connections_active: 0\nconnections_limit: 10\nconnections_queued: 1\nconnections_accepted_total: 7\nconnections_rejected_total: 2\nqueue_timeout: 1s"
+	if got != want {
+		t.Errorf("MetricsHandler() =\n%s\nwant:\n%s", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MakeMetricsHandler(d)(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("MetricsHandler() with a cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMakeDebugHandler(t *testing.T) {
+	d := NewDaemon(Config{}, logger.NewNoopLogger())
+
+	got, err := MakeDebugHandler(d)(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("DebugHandler() error = %v", err)
+	}
+	for _, name := range []string{"uptime", "goroutines", "heap_alloc_bytes", "heap_objects", "gc_cycles", "gc_pause_total", "gc_last"} {
+		if !strings.Contains(got, "\n"+name+": ") && !strings.HasPrefix(got, name+": ") {
+			t.Errorf("DebugHandler() should report %s\nGot: %s", name, got)
+		}
+	}
+	if strings.Contains(got, "goroutines: 0\n") {
+		t.Errorf("DebugHandler() should count the running goroutines\nGot: %s", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MakeDebugHandler(d)(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("DebugHandler() with a cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMakeConnectionsAndKillHandlers(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+
+	cancelled := make(chan struct{})
+	err := d.RegisterCommandSpec(CommandSpec{Name: "WAIT", NoExecTimeout: true}, func(ctx context.Context, args []string) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("RegisterCommandSpec() error = %v", err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	d.connMu.Lock()
+	d.serveConnection(serverConn)
+	d.connMu.Unlock()
+
+	if _, err := clientConn.Write([]byte("WAIT\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	connections := MakeConnectionsHandler(d)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, err := connections(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ConnectionsHandler() error = %v", err)
+		}
+		if strings.HasPrefix(got, "id=1 remote_addr=pipe age=0s command=WAIT") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ConnectionsHandler() = %q, want the connection running WAIT", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	kill := MakeKillHandler(d)
+	if _, err := kill(context.Background(), []string{"abc"}); err == nil || !strings.Contains(err.Error(), "invalid connection ID") {
+		t.Errorf("KillHandler() with an invalid ID error = %v", err)
+	}
+	if _, err := kill(context.Background(), []string{"2"}); err == nil || !strings.Contains(err.Error(), "no active connection with ID 2") {
+		t.Errorf("KillHandler() with an unknown ID error = %v", err)
+	}
+
+	got, err := kill(context.Background(), []string{"1"})
+	if err != nil || got != "Connection 1 closed" {
+		t.Fatalf("KillHandler() = %q, %v, want the connection closed", got, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the command of the killed connection wasn't cancelled")
+	}
+	if _, err := clientConn.Read(make([]byte, 16)); err == nil {
+		t.Error("the killed connection should be closed")
+	}
+
+	waitForWg(t, &d.wg, 2*time.Second)
+	if got, _ := connections(context.Background(), nil); got != "" {
+		t.Errorf("ConnectionsHandler() after KILL = %q, want no connections", got)
+	}
+}
+
 func TestMakeDefaultStopHandler(t *testing.T) {
 	t.Run("Stop command", func(t *testing.T) {
 		d, _ := createTestDaemon(t, Config{
@@ -178,6 +310,67 @@ func TestMakeDefaultStopHandler(t *testing.T) {
 	})
 }
 
+func TestMakeHelpHandler(t *testing.T) {
+	d, _ := createTestDaemon(t, Config{Logger: logger.NewNoopLogger()})
+	RegisterDefaultCommands(d)
+	d.RegisterCommand("CUSTOM", func(ctx context.Context, args []string) (string, error) { return "", nil })
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectContains []string
+		expectError    bool
+	}{
+		{
+			name: "List all commands",
+			args: []string{},
+			expectContains: []string{
+				"CUSTOM",
+				"HELP [command] - List commands or show usage for a single command",
+				"PING - Check that the daemon is responsive",
+			},
+		},
+		{
+			name: "Single command",
+			args: []string{"help"},
+			expectContains: []string{
+				"Usage: HELP [command]",
+				"Description: List commands or show usage for a single command",
+				"Arguments: 0 to 1",
+			},
+		},
+		{
+			name: "Command without spec accepts any arguments",
+			args: []string{"custom"},
+			expectContains: []string{
+				"Usage: CUSTOM",
+				"Arguments: at least 0",
+			},
+		},
+		{
+			name:        "Unknown command",
+			args:        []string{"nope"},
+			expectError: true,
+		},
+	}
+
+	handler := MakeHelpHandler(d)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handler(context.Background(), tt.args)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("HelpHandler() error = %v, expectError %v", err, tt.expectError)
+			}
+
+			for _, expected := range tt.expectContains {
+				if !strings.Contains(got, expected) {
+					t.Errorf("HelpHandler() output doesn't contain %q\nGot: %s", expected, got)
+				}
+			}
+		})
+	}
+}
+
 // Helper function for creating a cancelled context
 func cancelledContext() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
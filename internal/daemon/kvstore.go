@@ -0,0 +1,143 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/types"
+)
+
+// kvEntry is a stored value and the time it expires at. A zero expiresAt never expires.
+type kvEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// KVStore is an in-memory key-value store that lets scripts share small bits of state,
+// such as feature flags or last-run markers, across connections. It is lost when the
+// daemon stops.
+type KVStore struct {
+	mu      sync.Mutex
+	entries map[string]kvEntry
+	now     func() time.Time
+}
+
+// NewKVStore creates an empty KVStore
+func NewKVStore() *KVStore {
+	return &KVStore{
+		entries: make(map[string]kvEntry),
+		now:     time.Now,
+	}
+}
+
+// Set stores value under key. The key expires after ttl, or never if ttl is zero.
+func (s *KVStore) Set(key, value string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.removeExpired(now)
+
+	entry := kvEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	s.entries[key] = entry
+}
+
+// Get returns the value stored under key and whether it exists and hasn't expired
+func (s *KVStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	if entry.expired(s.now()) {
+		delete(s.entries, key)
+		return "", false
+	}
+
+	return entry.value, true
+}
+
+// Delete removes key and reports whether it existed
+func (s *KVStore) Delete(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return false
+	}
+
+	delete(s.entries, key)
+	return !entry.expired(s.now())
+}
+
+// Len returns the number of keys that haven't expired
+func (s *KVStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired(s.now())
+	return len(s.entries)
+}
+
+// removeExpired drops the expired keys so keys that are set with a TTL and never read
+// again don't pile up. The caller must hold s.mu.
+func (s *KVStore) removeExpired(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func (e kvEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MakeSetHandler creates the SET handler, which stores a value with an optional TTL
+// such as 30s or 5m
+func MakeSetHandler(store *KVStore) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		var ttl time.Duration
+		if len(args) == 3 {
+			parsed, err := time.ParseDuration(args[2])
+			if err != nil || parsed <= 0 {
+				return "", fmt.Errorf("invalid TTL '%s': must be a positive duration such as 30s or 5m", args[2])
+			}
+			ttl = parsed
+		}
+
+		store.Set(args[0], args[1], ttl)
+		return "stored", nil
+	}
+}
+
+// MakeGetHandler creates the GET handler, which returns the value stored under a key
+func MakeGetHandler(store *KVStore) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		value, ok := store.Get(args[0])
+		if !ok {
+			return "", fmt.Errorf("key '%s' not found", args[0])
+		}
+		return value, nil
+	}
+}
+
+// MakeDelHandler creates the DEL handler, which removes a key and responds with the
+// number of keys removed
+func MakeDelHandler(store *KVStore) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		if store.Delete(args[0]) {
+			return "1", nil
+		}
+		return "0", nil
+	}
+}
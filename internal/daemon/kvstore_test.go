@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVStore_TTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewKVStore()
+	store.now = func() time.Time { return now }
+
+	store.Set("flag", "on", 0)
+	store.Set("marker", "run-42", time.Minute)
+
+	value, ok := store.Get("marker")
+	require.True(t, ok)
+	assert.Equal(t, "run-42", value)
+	assert.Equal(t, 2, store.Len())
+
+	now = now.Add(time.Minute)
+
+	_, ok = store.Get("marker")
+	assert.False(t, ok, "the key must expire once its TTL has passed")
+	value, ok = store.Get("flag")
+	require.True(t, ok, "a key without TTL must not expire")
+	assert.Equal(t, "on", value)
+	assert.Equal(t, 1, store.Len())
+
+	assert.True(t, store.Delete("flag"))
+	assert.False(t, store.Delete("flag"))
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestKVStoreHandlers(t *testing.T) {
+	store := NewKVStore()
+	set, get, del := MakeSetHandler(store), MakeGetHandler(store), MakeDelHandler(store)
+	ctx := context.Background()
+
+	response, err := set(ctx, []string{"last-run", "2025-01-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "stored", response)
+
+	response, err = get(ctx, []string{"last-run"})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-01", response)
+
+	_, err = set(ctx, []string{"key", "value", "soon"})
+	assert.ErrorContains(t, err, "invalid TTL 'soon'")
+	_, err = set(ctx, []string{"key", "value", "-1s"})
+	assert.Error(t, err)
+
+	response, err = del(ctx, []string{"last-run"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", response)
+
+	response, err = del(ctx, []string{"last-run"})
+	require.NoError(t, err)
+	assert.Equal(t, "0", response)
+
+	_, err = get(ctx, []string{"last-run"})
+	assert.ErrorContains(t, err, "key 'last-run' not found")
+}
@@ -0,0 +1,219 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockRestartable is an autogenerated mock type for the Restartable type
+type MockRestartable struct {
+	mock.Mock
+}
+
+type MockRestartable_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRestartable) EXPECT() *MockRestartable_Expecter {
+	return &MockRestartable_Expecter{mock: &_m.Mock}
+}
+
+// Exited provides a mock function with no fields
+func (_m *MockRestartable) Exited() <-chan error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exited")
+	}
+
+	var r0 <-chan error
+	if rf, ok := ret.Get(0).(func() <-chan error); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan error)
+		}
+	}
+
+	return r0
+}
+
+// MockRestartable_Exited_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exited'
+type MockRestartable_Exited_Call struct {
+	*mock.Call
+}
+
+// Exited is a helper method to define mock.On call
+func (_e *MockRestartable_Expecter) Exited() *MockRestartable_Exited_Call {
+	return &MockRestartable_Exited_Call{Call: _e.mock.On("Exited")}
+}
+
+func (_c *MockRestartable_Exited_Call) Run(run func()) *MockRestartable_Exited_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockRestartable_Exited_Call) Return(_a0 <-chan error) *MockRestartable_Exited_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRestartable_Exited_Call) RunAndReturn(run func() <-chan error) *MockRestartable_Exited_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Name provides a mock function with no fields
+func (_m *MockRestartable) Name() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Name")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockRestartable_Name_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Name'
+type MockRestartable_Name_Call struct {
+	*mock.Call
+}
+
+// Name is a helper method to define mock.On call
+func (_e *MockRestartable_Expecter) Name() *MockRestartable_Name_Call {
+	return &MockRestartable_Name_Call{Call: _e.mock.On("Name")}
+}
+
+func (_c *MockRestartable_Name_Call) Run(run func()) *MockRestartable_Name_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockRestartable_Name_Call) Return(_a0 string) *MockRestartable_Name_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRestartable_Name_Call) RunAndReturn(run func() string) *MockRestartable_Name_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Running provides a mock function with no fields
+func (_m *MockRestartable) Running() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Running")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockRestartable_Running_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Running'
+type MockRestartable_Running_Call struct {
+	*mock.Call
+}
+
+// Running is a helper method to define mock.On call
+func (_e *MockRestartable_Expecter) Running() *MockRestartable_Running_Call {
+	return &MockRestartable_Running_Call{Call: _e.mock.On("Running")}
+}
+
+func (_c *MockRestartable_Running_Call) Run(run func()) *MockRestartable_Running_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockRestartable_Running_Call) Return(_a0 bool) *MockRestartable_Running_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRestartable_Running_Call) RunAndReturn(run func() bool) *MockRestartable_Running_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Start provides a mock function with given fields: ctx
+func (_m *MockRestartable) Start(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRestartable_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type MockRestartable_Start_Call struct {
+	*mock.Call
+}
+
+// Start is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockRestartable_Expecter) Start(ctx interface{}) *MockRestartable_Start_Call {
+	return &MockRestartable_Start_Call{Call: _e.mock.On("Start", ctx)}
+}
+
+func (_c *MockRestartable_Start_Call) Run(run func(ctx context.Context)) *MockRestartable_Start_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockRestartable_Start_Call) Return(_a0 error) *MockRestartable_Start_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRestartable_Start_Call) RunAndReturn(run func(context.Context) error) *MockRestartable_Start_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRestartable creates a new instance of MockRestartable. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRestartable(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRestartable {
+	mock := &MockRestartable{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
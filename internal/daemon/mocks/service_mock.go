@@ -0,0 +1,173 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockService is an autogenerated mock type for the Service type
+type MockService struct {
+	mock.Mock
+}
+
+type MockService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockService) EXPECT() *MockService_Expecter {
+	return &MockService_Expecter{mock: &_m.Mock}
+}
+
+// Name provides a mock function with no fields
+func (_m *MockService) Name() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Name")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockService_Name_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Name'
+type MockService_Name_Call struct {
+	*mock.Call
+}
+
+// Name is a helper method to define mock.On call
+func (_e *MockService_Expecter) Name() *MockService_Name_Call {
+	return &MockService_Name_Call{Call: _e.mock.On("Name")}
+}
+
+func (_c *MockService_Name_Call) Run(run func()) *MockService_Name_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockService_Name_Call) Return(_a0 string) *MockService_Name_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Name_Call) RunAndReturn(run func() string) *MockService_Name_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Start provides a mock function with given fields: ctx
+func (_m *MockService) Start(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Start_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Start'
+type MockService_Start_Call struct {
+	*mock.Call
+}
+
+// Start is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) Start(ctx interface{}) *MockService_Start_Call {
+	return &MockService_Start_Call{Call: _e.mock.On("Start", ctx)}
+}
+
+func (_c *MockService_Start_Call) Run(run func(ctx context.Context)) *MockService_Start_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_Start_Call) Return(_a0 error) *MockService_Start_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Start_Call) RunAndReturn(run func(context.Context) error) *MockService_Start_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stop provides a mock function with given fields: ctx
+func (_m *MockService) Stop(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stop")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Stop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stop'
+type MockService_Stop_Call struct {
+	*mock.Call
+}
+
+// Stop is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) Stop(ctx interface{}) *MockService_Stop_Call {
+	return &MockService_Stop_Call{Call: _e.mock.On("Stop", ctx)}
+}
+
+func (_c *MockService_Stop_Call) Run(run func(ctx context.Context)) *MockService_Stop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_Stop_Call) Return(_a0 error) *MockService_Stop_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Stop_Call) RunAndReturn(run func(context.Context) error) *MockService_Stop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockService {
+	mock := &MockService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,122 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockServiceStatus is an autogenerated mock type for the ServiceStatus type
+type MockServiceStatus struct {
+	mock.Mock
+}
+
+type MockServiceStatus_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockServiceStatus) EXPECT() *MockServiceStatus_Expecter {
+	return &MockServiceStatus_Expecter{mock: &_m.Mock}
+}
+
+// Running provides a mock function with no fields
+func (_m *MockServiceStatus) Running() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Running")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockServiceStatus_Running_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Running'
+type MockServiceStatus_Running_Call struct {
+	*mock.Call
+}
+
+// Running is a helper method to define mock.On call
+func (_e *MockServiceStatus_Expecter) Running() *MockServiceStatus_Running_Call {
+	return &MockServiceStatus_Running_Call{Call: _e.mock.On("Running")}
+}
+
+func (_c *MockServiceStatus_Running_Call) Run(run func()) *MockServiceStatus_Running_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockServiceStatus_Running_Call) Return(_a0 bool) *MockServiceStatus_Running_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockServiceStatus_Running_Call) RunAndReturn(run func() bool) *MockServiceStatus_Running_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Status provides a mock function with no fields
+func (_m *MockServiceStatus) Status() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Status")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockServiceStatus_Status_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Status'
+type MockServiceStatus_Status_Call struct {
+	*mock.Call
+}
+
+// Status is a helper method to define mock.On call
+func (_e *MockServiceStatus_Expecter) Status() *MockServiceStatus_Status_Call {
+	return &MockServiceStatus_Status_Call{Call: _e.mock.On("Status")}
+}
+
+func (_c *MockServiceStatus_Status_Call) Run(run func()) *MockServiceStatus_Status_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockServiceStatus_Status_Call) Return(_a0 string) *MockServiceStatus_Status_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockServiceStatus_Status_Call) RunAndReturn(run func() string) *MockServiceStatus_Status_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockServiceStatus creates a new instance of MockServiceStatus. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockServiceStatus(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockServiceStatus {
+	mock := &MockServiceStatus{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
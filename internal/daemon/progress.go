@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/shaharia-lab/echoy/internal/progress"
+)
+
+// ProgressPrefix starts the intermediate lines a command can send before its final OK: or
+// ERROR: response, see ReportProgress
+const ProgressPrefix = "PROGRESS:"
+
+// errCommandResponded is returned by ReportProgress once the command has responded
+var errCommandResponded = errors.New("the command has already responded")
+
+// progress writes the PROGRESS: lines of the command running on a connection
+type commandProgress struct {
+	mu        sync.Mutex
+	responded bool
+	write     func(line string) error
+}
+
+// withProgress returns a copy of ctx the handler of a command reports its progress with
+func withProgress(ctx context.Context, p *commandProgress) context.Context {
+	return progress.WithReporter(ctx, p.report)
+}
+
+// ReportProgress sends message to the client of the command running with ctx as a
+// PROGRESS: line, ahead of the final response. Each line is written with a fresh write
+// deadline and renews the read timeout of Client, so slow commands can keep the client
+// waiting without timing out. It doesn't extend Config.CommandExecTimeout, commands that
+// may take longer are registered with CommandSpec.NoExecTimeout.
+//
+// It does nothing if ctx isn't the context of a command run by the daemon, and fails once
+// the command has responded or if the line can't be written. Runs of whitespace in message,
+// line breaks included, are written as a single space. Progress reported with the progress
+// package, e.g. by the web UI downloader, is sent the same way.
+func ReportProgress(ctx context.Context, message string) error {
+	return progress.Report(ctx, message)
+}
+
+// report writes message as a PROGRESS: line, see ReportProgress
+func (p *commandProgress) report(message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.responded {
+		return errCommandResponded
+	}
+
+	message = strings.Join(strings.Fields(message), " ")
+	return p.write(ProgressPrefix + " " + message + "\n")
+}
+
+// finish stops the progress lines before the final response is written. It waits for a
+// line being written, so the final response can't be interleaved with it.
+func (p *commandProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.responded = true
+}
@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/types"
+)
+
+// Service is a component the daemon runs alongside its socket, such as the web server.
+// Registered services can be started and stopped by clients and are stopped with the daemon.
+type Service interface {
+	Name() string
+	// Start starts the service. Its progress can be reported with ReportProgress.
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// ServiceStatus is implemented by services that can report whether they are running and
+// describe their current state, e.g. the address they listen on
+type ServiceStatus interface {
+	Running() bool
+	Status() string
+}
+
+// ServiceResult is the response of a service command, encoded as a single line of JSON so
+// clients don't have to parse prose
+type ServiceResult struct {
+	Service string `json:"service"`
+	Action  string `json:"action"`
+	// Running and Status are only set for services implementing ServiceStatus
+	Running bool   `json:"running"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message"`
+}
+
+// RegisterService registers s and a "<NAME> start|stop|status" command controlling it,
+// NAME being the upper-cased name of the service. The service is stopped when the daemon
+// stops, see AddStopper. The command runs without Config.CommandExecTimeout, as starting
+// may take a while, e.g. when the web server downloads the web UI; it's cancelled when the
+// client hangs up. Stopping is bounded by Config.ShutdownTimeout instead.
+func (d *Daemon) RegisterService(s Service) error {
+	name := strings.ToUpper(s.Name())
+	if name == "" {
+		return fmt.Errorf("service name cannot be empty")
+	}
+
+	err := d.RegisterCommandSpec(CommandSpec{
+		Name:          name,
+		Usage:         name + " start|stop|status",
+		Description:   fmt.Sprintf("Start, stop or show the status of the %s service", s.Name()),
+		MinArgs:       1,
+		MaxArgs:       1,
+		NoExecTimeout: true,
+	}, serviceCommandHandler(s, d.config.ShutdownTimeout))
+	if err != nil {
+		return fmt.Errorf("failed to register service %s: %w", s.Name(), err)
+	}
+
+	d.AddStopper(s)
+	return nil
+}
+
+// serviceCommandHandler returns the handler of the command controlling s. It responds
+// with a ServiceResult. Stopping s is given up after stopTimeout.
+func serviceCommandHandler(s Service, stopTimeout time.Duration) types.CommandFunc {
+	return func(ctx context.Context, args []string) (string, error) {
+		subcommand := strings.ToLower(args[0])
+		result := ServiceResult{Service: s.Name(), Action: subcommand}
+
+		switch subcommand {
+		case "start":
+			// Starting can take a while, e.g. when the web server downloads the web UI
+			_ = ReportProgress(ctx, fmt.Sprintf("Starting %s", s.Name()))
+			if err := s.Start(ctx); err != nil {
+				return "", fmt.Errorf("failed to start %s: %w", s.Name(), err)
+			}
+			result.Message = fmt.Sprintf("%s started", s.Name())
+
+		case "stop":
+			_ = ReportProgress(ctx, fmt.Sprintf("Stopping %s", s.Name()))
+			stopCtx, cancel := context.WithTimeout(ctx, stopTimeout)
+			err := s.Stop(stopCtx)
+			cancel()
+			if err != nil {
+				return "", fmt.Errorf("failed to stop %s: %w", s.Name(), err)
+			}
+			result.Message = fmt.Sprintf("%s stopped", s.Name())
+
+		case "status":
+			result.Message = fmt.Sprintf("%s is registered", s.Name())
+
+		default:
+			return "", fmt.Errorf("unknown subcommand '%s': valid subcommands are 'start', 'stop', and 'status'", subcommand)
+		}
+
+		if status, ok := s.(ServiceStatus); ok {
+			result.Running = status.Running()
+			result.Status = status.Status()
+			if subcommand == "status" {
+				result.Message = fmt.Sprintf("%s is %s", s.Name(), result.Status)
+			} else if subcommand == "start" {
+				result.Message += ", " + result.Status
+			}
+		}
+
+		payload, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the result of %s %s: %w", s.Name(), subcommand, err)
+		}
+
+		return string(payload), nil
+	}
+}
+
+// ExecuteService runs action, one of start, stop and status, on the service registered
+// under name and decodes its result
+func ExecuteService(ctx context.Context, commander Commander, name, action string) (ServiceResult, error) {
+	response, err := commander.Execute(ctx, name, []string{action})
+	if err != nil {
+		return ServiceResult{}, err
+	}
+
+	payload, err := ParseResponse(response)
+	if err != nil {
+		return ServiceResult{}, err
+	}
+
+	var result ServiceResult
+	if err := json.Unmarshal([]byte(payload), &result); err != nil {
+		return ServiceResult{}, fmt.Errorf("unexpected response from the daemon: %s", payload)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// systemd notification states, see sd_notify(3)
+const (
+	sdNotifyReady    = "READY=1"
+	sdNotifyStopping = "STOPPING=1"
+)
+
+// notifySystemd sends a state notification to systemd when echoy runs as a Type=notify
+// service. It reports whether a notification was sent; without NOTIFY_SOCKET it is a no-op.
+func notifySystemd(state string) (bool, error) {
+	socketAddr := os.Getenv("NOTIFY_SOCKET")
+	if socketAddr == "" {
+		return false, nil
+	}
+
+	addr := &net.UnixAddr{Name: socketAddr, Net: "unixgram"}
+	// A leading '@' denotes a socket in the abstract namespace
+	if strings.HasPrefix(socketAddr, "@") {
+		addr.Name = "\x00" + socketAddr[1:]
+	}
+
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to systemd notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to send %q to systemd: %w", state, err)
+	}
+
+	return true, nil
+}
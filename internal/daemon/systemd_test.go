@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifySystemd(t *testing.T) {
+	t.Run("No notify socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+
+		sent, err := notifySystemd(sdNotifyReady)
+		assert.NoError(t, err)
+		assert.False(t, sent)
+	})
+
+	t.Run("Sends state to notify socket", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "echoy-notify-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		socketPath := filepath.Join(dir, "notify.sock")
+		listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+		require.NoError(t, err)
+		defer listener.Close()
+
+		t.Setenv("NOTIFY_SOCKET", socketPath)
+
+		sent, err := notifySystemd(sdNotifyReady)
+		require.NoError(t, err)
+		assert.True(t, sent)
+
+		require.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+		buf := make([]byte, 64)
+		n, _, err := listener.ReadFromUnix(buf)
+		require.NoError(t, err)
+		assert.Equal(t, sdNotifyReady, string(buf[:n]))
+	})
+
+	t.Run("Unreachable notify socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", filepath.Join(os.TempDir(), "echoy-missing-notify.sock"))
+
+		sent, err := notifySystemd(sdNotifyStopping)
+		assert.Error(t, err)
+		assert.False(t, sent)
+	})
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+)
+
+// Default backoff between restarts of a crashed service
+const (
+	DefaultWatchdogInitialBackoff = time.Second
+	DefaultWatchdogMaxBackoff     = 30 * time.Second
+)
+
+// Restartable is a service the watchdog can bring back after it exits on its own
+type Restartable interface {
+	Name() string
+	Start(ctx context.Context) error
+	// Running reports whether the service is started and hasn't exited
+	Running() bool
+	// Exited receives the error the service exited with when it stops on its own. It isn't
+	// signalled when the service is stopped deliberately.
+	Exited() <-chan error
+}
+
+// WatchdogConfig controls how often and how fast a crashed service is restarted
+type WatchdogConfig struct {
+	// MaxRestarts is the number of restart attempts after which the watchdog gives up
+	MaxRestarts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Watchdog restarts a service that exits unexpectedly, waiting longer after each attempt
+type Watchdog struct {
+	service  Restartable
+	config   WatchdogConfig
+	logger   logger.Logger
+	restarts atomic.Int64
+}
+
+// NewWatchdog creates a watchdog for service. Run starts watching it.
+func NewWatchdog(service Restartable, cfg WatchdogConfig, logger logger.Logger) *Watchdog {
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultWatchdogInitialBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = max(DefaultWatchdogMaxBackoff, cfg.InitialBackoff)
+	}
+
+	return &Watchdog{service: service, config: cfg, logger: logger}
+}
+
+// Restarts returns how often the service has been restarted so far
+func (w *Watchdog) Restarts() int {
+	return int(w.restarts.Load())
+}
+
+// Run restarts the service whenever it exits on its own, until ctx is done
+func (w *Watchdog) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-w.service.Exited():
+			w.logger.WithFields(logger.Fields{"service": w.service.Name(), logger.ErrorKey: err}).Error("Watchdog: Service exited unexpectedly")
+			w.restart(ctx)
+		}
+	}
+}
+
+// restart starts the service again with exponential backoff, until it is running or the
+// restart budget is used up
+func (w *Watchdog) restart(ctx context.Context) {
+	backoff := w.config.InitialBackoff
+	for {
+		if w.Restarts() >= w.config.MaxRestarts {
+			w.logger.WithFields(logger.Fields{"service": w.service.Name(), "restarts": w.Restarts()}).Error("Watchdog: Giving up restarting service")
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// It may have been started by hand in the meantime
+		if w.service.Running() {
+			return
+		}
+
+		attempt := w.restarts.Add(1)
+		attemptLog := w.logger.WithFields(logger.Fields{"service": w.service.Name(), "attempt": attempt, "max_restarts": w.config.MaxRestarts})
+		attemptLog.Warn("Watchdog: Restarting service")
+		err := w.service.Start(ctx)
+		if err == nil {
+			attemptLog.Info("Watchdog: Service restarted")
+			return
+		}
+
+		attemptLog.WithField(logger.ErrorKey, err).Error("Watchdog: Failed to restart service")
+		backoff = min(backoff*2, w.config.MaxBackoff)
+	}
+}
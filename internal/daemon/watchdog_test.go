@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRestartable struct {
+	mu        sync.Mutex
+	running   bool
+	startErrs []error
+	starts    int
+	exited    chan error
+	started   chan struct{}
+}
+
+func newFakeRestartable(startErrs ...error) *fakeRestartable {
+	return &fakeRestartable{
+		startErrs: startErrs,
+		exited:    make(chan error, 1),
+		started:   make(chan struct{}, 10),
+	}
+}
+
+func (f *fakeRestartable) Name() string { return "fake" }
+
+func (f *fakeRestartable) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.starts++
+	f.started <- struct{}{}
+	if len(f.startErrs) > 0 {
+		err := f.startErrs[0]
+		f.startErrs = f.startErrs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.running = true
+	return nil
+}
+
+func (f *fakeRestartable) Running() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.running
+}
+
+func (f *fakeRestartable) Exited() <-chan error { return f.exited }
+
+func (f *fakeRestartable) crash() {
+	f.mu.Lock()
+	f.running = false
+	f.mu.Unlock()
+	f.exited <- errors.New("crashed")
+}
+
+func (f *fakeRestartable) startCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.starts
+}
+
+func TestWatchdog_RestartsCrashedService(t *testing.T) {
+	service := newFakeRestartable(errors.New("port in use"), nil)
+	watchdog := NewWatchdog(service, WatchdogConfig{MaxRestarts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}, logger.NewNoopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchdog.Run(ctx)
+
+	service.crash()
+
+	require.Eventually(t, service.Running, time.Second, time.Millisecond, "service should be running again")
+	assert.Equal(t, 2, service.startCount(), "the failed start should be retried")
+	assert.Equal(t, 2, watchdog.Restarts())
+}
+
+func TestWatchdog_GivesUpAfterMaxRestarts(t *testing.T) {
+	failure := errors.New("port in use")
+	service := newFakeRestartable(failure, failure, failure, failure)
+	watchdog := NewWatchdog(service, WatchdogConfig{MaxRestarts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}, logger.NewNoopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchdog.Run(ctx)
+
+	service.crash()
+
+	require.Eventually(t, func() bool { return watchdog.Restarts() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 2, service.startCount(), "no restart should be attempted beyond the limit")
+	assert.False(t, service.Running())
+}
+
+func TestWatchdog_SkipsServiceStartedByHand(t *testing.T) {
+	service := newFakeRestartable()
+	watchdog := NewWatchdog(service, WatchdogConfig{MaxRestarts: 3, InitialBackoff: 50 * time.Millisecond}, logger.NewNoopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchdog.Run(ctx)
+
+	service.crash()
+	require.NoError(t, service.Start(context.Background()))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, service.startCount())
+	assert.Equal(t, 0, watchdog.Restarts())
+}
+
+func TestWatchdog_StopsWithContext(t *testing.T) {
+	service := newFakeRestartable()
+	watchdog := NewWatchdog(service, WatchdogConfig{MaxRestarts: 3, InitialBackoff: time.Hour}, logger.NewNoopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchdog.Run(ctx)
+	}()
+
+	service.crash()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not stop when its context was cancelled")
+	}
+	assert.Equal(t, 0, service.startCount())
+}
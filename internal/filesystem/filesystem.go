@@ -11,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -48,21 +49,98 @@ const (
 	ChatHistoryDB PathType = "chat_history_db"
 )
 
+// Environment variables overriding where Echoy keeps its files, e.g. for volumes mounted
+// into a container. EnvHomeDir replaces the app directory, ~/.echoy, the others replace
+// a single directory in it. They must be absolute paths.
+const (
+	EnvHomeDir   = "ECHOY_HOME"
+	EnvConfigDir = "ECHOY_CONFIG_DIR"
+	EnvDataDir   = "ECHOY_DATA_DIR"
+	EnvCacheDir  = "ECHOY_CACHE_DIR"
+	EnvLogsDir   = "ECHOY_LOGS_DIR"
+)
+
+// Environment variables overriding the octal modes of Permissions.PrivateDir and
+// Permissions.PrivateFile, e.g. 0750 to share the data with a group
+const (
+	EnvPrivateDirMode  = "ECHOY_PRIVATE_DIR_MODE"
+	EnvPrivateFileMode = "ECHOY_PRIVATE_FILE_MODE"
+)
+
+// Permissions are the modes directories and files are created with. Existing private paths
+// lose the bits their mode doesn't allow, the others keep their mode. The process umask
+// still applies on top of them.
+type Permissions struct {
+	// PrivateDir is used for the config and data directories
+	PrivateDir os.FileMode
+	// PrivateFile is used for config.yaml, which may hold the LLM token, system.json and
+	// the chat history database
+	PrivateFile os.FileMode
+	// SharedDir is used for the app, cache and logs directories
+	SharedDir os.FileMode
+	// SharedFile is used for the log file
+	SharedFile os.FileMode
+}
+
+// DefaultPermissions keeps the config and data only accessible to the current user
+func DefaultPermissions() Permissions {
+	return Permissions{
+		PrivateDir:  0700,
+		PrivateFile: 0600,
+		SharedDir:   0755,
+		SharedFile:  0644,
+	}
+}
+
+// PermissionsFromEnv returns DefaultPermissions with the private modes set by the
+// EnvPrivateDirMode and EnvPrivateFileMode environment variables
+func PermissionsFromEnv() (Permissions, error) {
+	permissions := DefaultPermissions()
+
+	for envVar, mode := range map[string]*os.FileMode{
+		EnvPrivateDirMode:  &permissions.PrivateDir,
+		EnvPrivateFileMode: &permissions.PrivateFile,
+	} {
+		value := strings.TrimSpace(os.Getenv(envVar))
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || parsed > 0777 {
+			return permissions, fmt.Errorf("%s must be an octal mode such as 0700, got '%s'", envVar, value)
+		}
+		*mode = os.FileMode(parsed)
+	}
+
+	return permissions, nil
+}
+
 // Filesystem provides filesystem related operations
 type Filesystem struct {
-	logger *logrus.Logger
-	appCfg *config.AppConfig
-	paths  map[PathType]string
+	logger      *logrus.Logger
+	appCfg      *config.AppConfig
+	paths       map[PathType]string
+	permissions Permissions
 }
 
 // NewAppFilesystem creates a new filesystem instance
 func NewAppFilesystem(appCfg *config.AppConfig) *Filesystem {
 	return &Filesystem{
-		appCfg: appCfg,
+		appCfg:      appCfg,
+		permissions: DefaultPermissions(),
 	}
 }
 
-// EnsureAllPaths ensures all required paths exist
+// WithPermissions changes the modes directories and files are created with
+func (s *Filesystem) WithPermissions(permissions Permissions) *Filesystem {
+	s.permissions = permissions
+	return s
+}
+
+// EnsureAllPaths ensures all required paths exist. Directories named by the EnvHomeDir,
+// EnvConfigDir, EnvDataDir, EnvCacheDir and EnvLogsDir environment variables are used
+// instead of the defaults.
 func (s *Filesystem) EnsureAllPaths() (map[PathType]string, error) {
 	paths := map[PathType]string{}
 
@@ -72,37 +150,35 @@ func (s *Filesystem) EnsureAllPaths() (map[PathType]string, error) {
 	}
 	paths[AppDirectory] = appDirectory
 
-	cacheDir := filepath.Join(appDirectory, "cache")
-	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(cacheDir, 0755); err != nil {
-			return paths, err
-		}
+	cacheDir, err := ensureOverridableDirectory(EnvCacheDir, filepath.Join(appDirectory, "cache"), s.permissions.SharedDir)
+	if err != nil {
+		return paths, err
 	}
 	paths[CacheDirectory] = cacheDir
 
-	configDir := filepath.Join(appDirectory, "config")
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(configDir, 0755); err != nil {
-			return paths, err
-		}
+	configDir, err := ensureOverridableDirectory(EnvConfigDir, filepath.Join(appDirectory, "config"), s.permissions.PrivateDir)
+	if err != nil {
+		return paths, err
 	}
 	paths[ConfigDirectory] = configDir
 
-	logsDir := filepath.Join(appDirectory, "logs")
-	if _, err := os.Stat(logsDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(logsDir, 0755); err != nil {
-			return paths, err
-		}
+	logsDir, err := ensureOverridableDirectory(EnvLogsDir, filepath.Join(appDirectory, "logs"), s.permissions.SharedDir)
+	if err != nil {
+		return paths, err
 	}
 	paths[LogsDirectory] = logsDir
 
-	dataDir := filepath.Join(appDirectory, "data")
-	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dataDir, 0755); err != nil {
+	dataDir, err := ensureOverridableDirectory(EnvDataDir, filepath.Join(appDirectory, "data"), s.permissions.PrivateDir)
+	if err != nil {
+		return paths, err
+	}
+	paths[DataDirectory] = dataDir
+
+	for _, dir := range []string{configDir, dataDir} {
+		if err := restrictPermissions(dir, s.permissions.PrivateDir); err != nil {
 			return paths, err
 		}
 	}
-	paths[DataDirectory] = dataDir
 
 	chatHistoryDBFilePath, err := s.createChatHistoryDBFile(dataDir, "chat_history.db")
 	if err != nil {
@@ -112,48 +188,109 @@ func (s *Filesystem) EnsureAllPaths() (map[PathType]string, error) {
 
 	systemFilePath := filepath.Join(dataDir, "system.json")
 	if _, err := os.Stat(systemFilePath); os.IsNotExist(err) {
-		file, err := os.Create(systemFilePath)
-		if err != nil {
-			return paths, err
-		}
-		defer file.Close()
-
 		uid := uuid.New().String()
 		systemData := fmt.Sprintf(`{"uuid": "%s"}`, uid)
-		if _, err := file.Write([]byte(systemData)); err != nil {
+		if err := os.WriteFile(systemFilePath, []byte(systemData), s.permissions.PrivateFile); err != nil {
 			return paths, err
 		}
 	}
 
 	// webui frontend
 	frontendDir := filepath.Join(cacheDir, "webui_build")
-	if _, err := os.Stat(frontendDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(frontendDir, 0755); err != nil {
-			return paths, err
-		}
+	if err := ensureDirectory(frontendDir, s.permissions.SharedDir); err != nil {
+		return paths, err
 	}
 	paths[CacheWebuiBuild] = frontendDir
 
 	configFilePath := filepath.Join(configDir, configYamlFileName)
-	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
-		if _, err := os.Create(configFilePath); err != nil {
-			return paths, err
-		}
+	if err := ensureFile(configFilePath, s.permissions.PrivateFile); err != nil {
+		return paths, err
 	}
 	paths[ConfigFilePath] = configFilePath
 
-	logFilePath := filepath.Join(logsDir, fmt.Sprintf("%s.log", strings.ToLower(s.appCfg.Name)))
-	if _, err := os.Stat(logFilePath); os.IsNotExist(err) {
-		if _, err := os.Create(logFilePath); err != nil {
+	// Installs created before the private modes existed have world-readable files
+	for _, file := range []string{chatHistoryDBFilePath, systemFilePath, configFilePath} {
+		if err := restrictPermissions(file, s.permissions.PrivateFile); err != nil {
 			return paths, err
 		}
 	}
+
+	logFilePath := filepath.Join(logsDir, fmt.Sprintf("%s.log", strings.ToLower(s.appCfg.Name)))
+	if err := ensureFile(logFilePath, s.permissions.SharedFile); err != nil {
+		return paths, err
+	}
 	paths[LogsFilePath] = logFilePath
 
 	return paths, nil
 }
 
+// ensureDirectory creates dir with mode unless it exists
+func ensureDirectory(dir string, mode os.FileMode) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return os.MkdirAll(dir, mode)
+	}
+	return nil
+}
+
+// ensureFile creates an empty file with mode unless it exists
+func ensureFile(path string, mode os.FileMode) error {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// restrictPermissions removes the bits mode doesn't allow from the mode of path. It never
+// adds any, so a mode tightened by the user is kept.
+func restrictPermissions(path string, mode os.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	current := info.Mode().Perm()
+	if current&^mode == 0 {
+		return nil
+	}
+
+	if err := os.Chmod(path, current&mode); err != nil {
+		return fmt.Errorf("failed to restrict the permissions of %s: %w", path, err)
+	}
+	return nil
+}
+
+// ensureOverridableDirectory creates the directory named by the environment variable
+// envVar, or defaultDir if it isn't set, and returns its path
+func ensureOverridableDirectory(envVar, defaultDir string, mode os.FileMode) (string, error) {
+	dir := defaultDir
+	if override := strings.TrimSpace(os.Getenv(envVar)); override != "" {
+		if !filepath.IsAbs(override) {
+			return "", fmt.Errorf("%s must be an absolute path, got '%s'", envVar, override)
+		}
+		dir = filepath.Clean(override)
+	}
+
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	if err := ensureDirectory(dir, mode); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	return dir, nil
+}
+
 func (s *Filesystem) ensureAppDirectory() (string, error) {
+	if strings.TrimSpace(os.Getenv(EnvHomeDir)) != "" {
+		return ensureOverridableDirectory(EnvHomeDir, "", s.permissions.SharedDir)
+	}
+
 	homeDir, err := s.getUserHomeDirectory()
 	if err != nil {
 		return "", err
@@ -161,10 +298,8 @@ func (s *Filesystem) ensureAppDirectory() (string, error) {
 
 	appDir := filepath.Join(homeDir, fmt.Sprintf(".%s", strings.ToLower(s.appCfg.Name)))
 
-	if _, err := os.Stat(appDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(appDir, 0755); err != nil {
-			return "", err
-		}
+	if err := ensureDirectory(appDir, s.permissions.SharedDir); err != nil {
+		return "", err
 	}
 
 	return appDir, nil
@@ -176,7 +311,7 @@ func (s *Filesystem) createChatHistoryDBFile(dataDirectory, fileName string) (st
 		return dbFilePath, nil
 	}
 
-	file, err := os.Create(dbFilePath)
+	file, err := os.OpenFile(dbFilePath, os.O_CREATE|os.O_WRONLY, s.permissions.PrivateFile)
 	if err != nil {
 		return "", err
 	}
@@ -3,6 +3,7 @@ package filesystem
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/shaharia-lab/echoy/internal/config"
@@ -121,6 +122,192 @@ func TestEnsureAllPaths(t *testing.T) {
 	assert.Equal(t, paths, pathsAgain, "Paths map should be the same on second call")
 }
 
+func TestEnsureAllPaths_EnvironmentOverrides(t *testing.T) {
+	tempHome, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mounts := t.TempDir()
+	t.Setenv(EnvHomeDir, filepath.Join(mounts, "home"))
+	t.Setenv(EnvConfigDir, filepath.Join(mounts, "etc", "echoy"))
+	t.Setenv(EnvDataDir, filepath.Join(mounts, "var", "lib", "echoy"))
+	t.Setenv(EnvCacheDir, "")
+	t.Setenv(EnvLogsDir, filepath.Join(mounts, "var", "log", "echoy")+"/")
+
+	paths, err := NewAppFilesystem(&config.AppConfig{Name: "TestApp"}).EnsureAllPaths()
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(mounts, "home"), paths[AppDirectory])
+	assert.Equal(t, filepath.Join(mounts, "home", "cache"), paths[CacheDirectory], "unset overrides should stay in the app directory")
+	assert.Equal(t, filepath.Join(mounts, "etc", "echoy", "config.yaml"), paths[ConfigFilePath])
+	assert.Equal(t, filepath.Join(mounts, "var", "lib", "echoy", "chat_history.db"), paths[ChatHistoryDB])
+	assert.Equal(t, filepath.Join(mounts, "var", "log", "echoy"), paths[LogsDirectory])
+	assert.FileExists(t, filepath.Join(mounts, "var", "lib", "echoy", "system.json"))
+
+	_, err = os.Stat(filepath.Join(tempHome, ".testapp"))
+	assert.True(t, os.IsNotExist(err), "the default app directory should not be created")
+}
+
+func TestEnsureAllPaths_InvalidEnvironmentOverrides(t *testing.T) {
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Run("relative path", func(t *testing.T) {
+		t.Setenv(EnvDataDir, "data")
+
+		_, err := NewAppFilesystem(&config.AppConfig{Name: "TestApp"}).EnsureAllPaths()
+		assert.ErrorContains(t, err, EnvDataDir)
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "config")
+		require.NoError(t, os.WriteFile(file, nil, 0600))
+		t.Setenv(EnvConfigDir, file)
+
+		_, err := NewAppFilesystem(&config.AppConfig{Name: "TestApp"}).EnsureAllPaths()
+		assert.ErrorContains(t, err, "is not a directory")
+	})
+}
+
+func TestEnsureAllPaths_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	fs := NewAppFilesystem(&config.AppConfig{Name: "TestApp"})
+
+	paths, err := fs.EnsureAllPaths()
+	require.NoError(t, err)
+
+	// The umask can only remove bits, so private paths must match exactly and shared
+	// paths must not be more permissive than configured
+	tests := []struct {
+		path    string
+		mode    os.FileMode
+		private bool
+	}{
+		{path: paths[ConfigDirectory], mode: 0700, private: true},
+		{path: paths[DataDirectory], mode: 0700, private: true},
+		{path: paths[ConfigFilePath], mode: 0600, private: true},
+		{path: paths[ChatHistoryDB], mode: 0600, private: true},
+		{path: filepath.Join(paths[DataDirectory], "system.json"), mode: 0600, private: true},
+		{path: paths[AppDirectory], mode: 0755},
+		{path: paths[CacheDirectory], mode: 0755},
+		{path: paths[LogsDirectory], mode: 0755},
+		{path: paths[LogsFilePath], mode: 0644},
+	}
+
+	for _, tt := range tests {
+		info, err := os.Stat(tt.path)
+		require.NoError(t, err)
+
+		if tt.private {
+			assert.Equal(t, tt.mode, info.Mode().Perm(), "unexpected mode for %s", tt.path)
+			continue
+		}
+		assert.Zero(t, info.Mode().Perm()&^tt.mode, "%s is more permissive than %v", tt.path, tt.mode)
+	}
+}
+
+func TestEnsureAllPaths_CustomPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	permissions := DefaultPermissions()
+	permissions.PrivateDir = 0750
+	permissions.PrivateFile = 0640
+	fs := NewAppFilesystem(&config.AppConfig{Name: "TestApp"}).WithPermissions(permissions)
+
+	paths, err := fs.EnsureAllPaths()
+	require.NoError(t, err)
+
+	info, err := os.Stat(paths[DataDirectory])
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode().Perm()&^os.FileMode(0750))
+
+	info, err = os.Stat(paths[ConfigFilePath])
+	require.NoError(t, err)
+	assert.Zero(t, info.Mode().Perm()&^os.FileMode(0640))
+}
+
+func TestEnsureAllPaths_RestrictsExistingPaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+
+	tempHome, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// An install created before the private modes existed
+	appDir := filepath.Join(tempHome, ".testapp")
+	for _, dir := range []string{"config", "data", "logs"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(appDir, dir), 0755))
+		require.NoError(t, os.Chmod(filepath.Join(appDir, dir), 0755))
+	}
+	for _, file := range []string{"config/config.yaml", "data/system.json", "logs/testapp.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(appDir, file), []byte(`{"uuid": "test"}`), 0644))
+		require.NoError(t, os.Chmod(filepath.Join(appDir, file), 0644))
+	}
+
+	paths, err := NewAppFilesystem(&config.AppConfig{Name: "TestApp"}).EnsureAllPaths()
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		mode os.FileMode
+	}{
+		{path: paths[ConfigDirectory], mode: 0700},
+		{path: paths[DataDirectory], mode: 0700},
+		{path: paths[ConfigFilePath], mode: 0600},
+		{path: filepath.Join(paths[DataDirectory], "system.json"), mode: 0600},
+		{path: paths[LogsDirectory], mode: 0755},
+		{path: paths[LogsFilePath], mode: 0644},
+	}
+
+	for _, tt := range tests {
+		info, err := os.Stat(tt.path)
+		require.NoError(t, err)
+		assert.Equal(t, tt.mode, info.Mode().Perm(), "unexpected mode for %s", tt.path)
+	}
+}
+
+func TestPermissionsFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(EnvPrivateDirMode, "")
+		t.Setenv(EnvPrivateFileMode, "")
+
+		permissions, err := PermissionsFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultPermissions(), permissions)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv(EnvPrivateDirMode, "0750")
+		t.Setenv(EnvPrivateFileMode, " 640 ")
+
+		permissions, err := PermissionsFromEnv()
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0750), permissions.PrivateDir)
+		assert.Equal(t, os.FileMode(0640), permissions.PrivateFile)
+		assert.Equal(t, DefaultPermissions().SharedDir, permissions.SharedDir)
+	})
+
+	for _, value := range []string{"rwx", "0800", "17777"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			t.Setenv(EnvPrivateFileMode, value)
+
+			_, err := PermissionsFromEnv()
+			assert.ErrorContains(t, err, EnvPrivateFileMode)
+		})
+	}
+}
+
 func TestCreateSQLiteDBFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "sqlite_test_*")
 	require.NoError(t, err, "Failed to create temp directory")
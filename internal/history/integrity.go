@@ -0,0 +1,105 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// maxIntegrityProblems limits how many problems of a failed integrity check are reported
+const maxIntegrityProblems = 5
+
+// ErrCorrupt is returned when the chat history database fails its integrity check
+var ErrCorrupt = errors.New("chat history database is corrupt")
+
+// CheckIntegrity checks the database at dbPath for corruption and returns an error wrapping
+// ErrCorrupt if it is damaged. The quick check is cheap enough to run on every start, the
+// thorough one also verifies the indexes. A missing database is not an error.
+func CheckIntegrity(dbPath string, thorough bool) error {
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", dbPath))
+	if err != nil {
+		return fmt.Errorf("failed to open chat history database: %w", err)
+	}
+	defer db.Close()
+
+	pragma := "PRAGMA quick_check"
+	if thorough {
+		pragma = "PRAGMA integrity_check"
+	}
+
+	rows, err := db.Query(pragma)
+	if err != nil {
+		return classifyIntegrityError(err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return classifyIntegrityError(err)
+		}
+		if result != "ok" && len(problems) < maxIntegrityProblems {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return classifyIntegrityError(err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrCorrupt, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// classifyIntegrityError wraps errors that mean the file is damaged or not a database in
+// ErrCorrupt. Others, such as a locked database, are returned as they are.
+func classifyIntegrityError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrCorrupt || sqliteErr.Code == sqlite3.ErrNotADB) {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	return fmt.Errorf("failed to check chat history database: %w", err)
+}
+
+// Recreate moves the database at dbPath and its journal files aside and creates an empty
+// database with the same file mode in its place. It returns the path the old database was
+// moved to, so its chats can still be rescued by hand.
+func Recreate(dbPath string) (string, error) {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(dbPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	backupPath := fmt.Sprintf("%s.corrupt-%s", dbPath, time.Now().Format("20060102-150405"))
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Rename(dbPath+suffix, backupPath+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to move %s aside: %w", dbPath+suffix, err)
+		}
+	}
+
+	file, err := os.OpenFile(dbPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return backupPath, fmt.Errorf("failed to create chat history database: %w", err)
+	}
+	file.Close()
+
+	storage, err := NewSQLiteStorage(dbPath)
+	if err != nil {
+		return backupPath, err
+	}
+
+	return backupPath, storage.Close()
+}
@@ -0,0 +1,65 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("missing database", func(t *testing.T) {
+		assert.NoError(t, CheckIntegrity(filepath.Join(dir, "missing.db"), true))
+	})
+
+	t.Run("healthy database", func(t *testing.T) {
+		dbPath := filepath.Join(dir, "healthy.db")
+		storage, err := NewSQLiteStorage(dbPath)
+		require.NoError(t, err)
+		require.NoError(t, storage.Close())
+
+		assert.NoError(t, CheckIntegrity(dbPath, false))
+		assert.NoError(t, CheckIntegrity(dbPath, true))
+	})
+
+	t.Run("not a database", func(t *testing.T) {
+		dbPath := filepath.Join(dir, "garbage.db")
+		require.NoError(t, os.WriteFile(dbPath, []byte(strings.Repeat("not a sqlite database ", 100)), 0600))
+
+		err := CheckIntegrity(dbPath, false)
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+}
+
+func TestRecreate(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "chat_history.db")
+	require.NoError(t, os.WriteFile(dbPath, []byte("garbage"), 0640))
+	require.NoError(t, os.WriteFile(dbPath+"-wal", []byte("garbage"), 0640))
+
+	backupPath, err := Recreate(dbPath)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(backupPath)
+	require.NoError(t, err)
+	assert.Equal(t, "garbage", string(data))
+	assert.FileExists(t, backupPath+"-wal")
+
+	info, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.NoError(t, CheckIntegrity(dbPath, true))
+
+	storage, err := NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	_, err = storage.CreateChat(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,276 @@
+package history
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/goai"
+)
+
+// MemoryStorage keeps chat histories in memory only, so they are lost when the process
+// exits. It behaves like SQLiteStorage otherwise, except that it can be bounded to a
+// number of chats with SetMaxChats.
+type MemoryStorage struct {
+	mu sync.Mutex
+	// chats are the elements of recent, whose values are the *Chat
+	chats map[uuid.UUID]*list.Element
+	// recent orders the chats by when they were last accessed, most recent first
+	recent   *list.List
+	maxChats int
+	logger   logger.Logger
+}
+
+// NewMemoryStorage creates an empty MemoryStorage without a limit on the number of chats
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		chats:  make(map[uuid.UUID]*list.Element),
+		recent: list.New(),
+		logger: logger.NewNoopLogger(),
+	}
+}
+
+// SetMaxChats bounds the storage to maxChats chats. Once there are more, the chats
+// accessed least recently are evicted. Zero means no limit.
+func (s *MemoryStorage) SetMaxChats(maxChats int) *MemoryStorage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxChats = maxChats
+	s.evict()
+	return s
+}
+
+// SetLogger sets the logger evictions are logged with
+func (s *MemoryStorage) SetLogger(log logger.Logger) *MemoryStorage {
+	s.logger = log
+	return s
+}
+
+// Close implements Storage, there is nothing to release
+func (s *MemoryStorage) Close() error {
+	return nil
+}
+
+// get returns the chat with chatUUID and marks it as the most recently accessed. s.mu
+// must be held.
+func (s *MemoryStorage) get(chatUUID uuid.UUID) (*Chat, bool) {
+	element, ok := s.chats[chatUUID]
+	if !ok {
+		return nil, false
+	}
+
+	s.recent.MoveToFront(element)
+	return element.Value.(*Chat), true
+}
+
+// add stores chat as the most recently accessed and evicts the chats beyond the limit.
+// s.mu must be held.
+func (s *MemoryStorage) add(chat *Chat) {
+	s.chats[chat.UUID] = s.recent.PushFront(chat)
+	s.evict()
+}
+
+// evict removes the least recently accessed chats until there are no more than
+// maxChats. s.mu must be held.
+func (s *MemoryStorage) evict() {
+	for s.maxChats > 0 && len(s.chats) > s.maxChats {
+		chat := s.recent.Remove(s.recent.Back()).(*Chat)
+		delete(s.chats, chat.UUID)
+
+		s.logger.WithFields(logger.Fields{
+			"chat_uuid": chat.UUID.String(),
+			"max_chats": s.maxChats,
+		}).Debug("evicted least recently used chat from memory history")
+	}
+}
+
+// CreateChat initializes a new chat conversation
+func (s *MemoryStorage) CreateChat(ctx context.Context) (*goai.ChatHistory, error) {
+	chat := &Chat{
+		ChatHistory: goai.ChatHistory{
+			UUID:      uuid.New(),
+			Messages:  []goai.ChatHistoryMessage{},
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+
+	s.mu.Lock()
+	s.add(chat)
+	s.mu.Unlock()
+
+	return copyChatHistory(chat.ChatHistory), nil
+}
+
+// AddMessage appends a message to an existing conversation
+func (s *MemoryStorage) AddMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.get(chatUUID)
+	if !ok {
+		return fmt.Errorf("failed to add message to chat %s: %w", chatUUID, ErrChatNotFound)
+	}
+
+	chat.Messages = append(chat.Messages, message)
+	return nil
+}
+
+// AddPartialMessage appends an answer whose stream ended before it was complete, which is
+// listed in Chat.PartialMessages
+func (s *MemoryStorage) AddPartialMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.get(chatUUID)
+	if !ok {
+		return fmt.Errorf("failed to add message to chat %s: %w", chatUUID, ErrChatNotFound)
+	}
+
+	chat.PartialMessages = append(chat.PartialMessages, len(chat.Messages))
+	chat.Messages = append(chat.Messages, message)
+	return nil
+}
+
+// SetChatTitle renames a conversation
+func (s *MemoryStorage) SetChatTitle(ctx context.Context, chatUUID uuid.UUID, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.get(chatUUID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	chat.Title = title
+	return nil
+}
+
+// GetChat retrieves a conversation with all of its messages
+func (s *MemoryStorage) GetChat(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.get(chatUUID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	return copyChatHistory(chat.ChatHistory), nil
+}
+
+// GetChatDetails retrieves a conversation with all of its messages and its metadata
+func (s *MemoryStorage) GetChatDetails(ctx context.Context, chatUUID uuid.UUID) (*Chat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chat, ok := s.get(chatUUID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	copied := copyChat(*chat)
+	return &copied, nil
+}
+
+// ImportChat stores a complete chat, keeping its UUID, title, creation time and messages.
+// It returns ErrChatExists if a chat with the same UUID is already stored.
+func (s *MemoryStorage) ImportChat(ctx context.Context, chat Chat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chats[chat.UUID]; ok {
+		return fmt.Errorf("failed to import chat %s: %w", chat.UUID, ErrChatExists)
+	}
+
+	chat = copyChat(chat)
+	s.add(&chat)
+	return nil
+}
+
+// ListChatHistories returns all stored conversations, newest first
+func (s *MemoryStorage) ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error) {
+	chats, err := s.ListChats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return chatHistories(chats), nil
+}
+
+// ListChats returns all stored conversations with their metadata, newest first
+func (s *MemoryStorage) ListChats(ctx context.Context) ([]Chat, error) {
+	return s.filterChats(func(*Chat) bool { return true }), nil
+}
+
+// DeleteChat removes a conversation and its messages
+func (s *MemoryStorage) DeleteChat(ctx context.Context, chatUUID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.chats[chatUUID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	s.recent.Remove(element)
+	delete(s.chats, chatUUID)
+	return nil
+}
+
+// SearchChats returns the conversations with at least one message containing query,
+// newest first. Matching is case-insensitive.
+func (s *MemoryStorage) SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error) {
+	query = strings.ToLower(query)
+
+	chats := s.filterChats(func(chat *Chat) bool {
+		for _, message := range chat.Messages {
+			if strings.Contains(strings.ToLower(message.Text), query) {
+				return true
+			}
+		}
+		return false
+	})
+
+	return chatHistories(chats), nil
+}
+
+// filterChats returns copies of the chats matching keep, newest first. Listing doesn't
+// count as accessing the chats.
+func (s *MemoryStorage) filterChats(keep func(chat *Chat) bool) []Chat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chats []Chat
+	for _, element := range s.chats {
+		if chat := element.Value.(*Chat); keep(chat) {
+			chats = append(chats, copyChat(*chat))
+		}
+	}
+
+	sort.Slice(chats, func(i, j int) bool {
+		return chats[i].CreatedAt.After(chats[j].CreatedAt)
+	})
+
+	return chats
+}
+
+// copyChat copies a chat so callers can't modify the stored messages and metadata
+func copyChat(chat Chat) Chat {
+	chat.ChatHistory = *copyChatHistory(chat.ChatHistory)
+	chat.PartialMessages = slices.Clone(chat.PartialMessages)
+	return chat
+}
+
+// copyChatHistory copies a chat history so callers can't modify the stored messages
+func copyChatHistory(chat goai.ChatHistory) *goai.ChatHistory {
+	chat.Messages = append([]goai.ChatHistoryMessage{}, chat.Messages...)
+	return &chat
+}
@@ -0,0 +1,144 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	history "github.com/shaharia-lab/echoy/internal/history"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockPruneStore is an autogenerated mock type for the PruneStore type
+type MockPruneStore struct {
+	mock.Mock
+}
+
+type MockPruneStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockPruneStore) EXPECT() *MockPruneStore_Expecter {
+	return &MockPruneStore_Expecter{mock: &_m.Mock}
+}
+
+// DeleteChat provides a mock function with given fields: ctx, _a1
+func (_m *MockPruneStore) DeleteChat(ctx context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockPruneStore_DeleteChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteChat'
+type MockPruneStore_DeleteChat_Call struct {
+	*mock.Call
+}
+
+// DeleteChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockPruneStore_Expecter) DeleteChat(ctx interface{}, _a1 interface{}) *MockPruneStore_DeleteChat_Call {
+	return &MockPruneStore_DeleteChat_Call{Call: _e.mock.On("DeleteChat", ctx, _a1)}
+}
+
+func (_c *MockPruneStore_DeleteChat_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockPruneStore_DeleteChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockPruneStore_DeleteChat_Call) Return(_a0 error) *MockPruneStore_DeleteChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPruneStore_DeleteChat_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockPruneStore_DeleteChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListChatTimestamps provides a mock function with given fields: ctx
+func (_m *MockPruneStore) ListChatTimestamps(ctx context.Context) ([]history.ChatTimestamp, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChatTimestamps")
+	}
+
+	var r0 []history.ChatTimestamp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]history.ChatTimestamp, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []history.ChatTimestamp); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]history.ChatTimestamp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPruneStore_ListChatTimestamps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListChatTimestamps'
+type MockPruneStore_ListChatTimestamps_Call struct {
+	*mock.Call
+}
+
+// ListChatTimestamps is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockPruneStore_Expecter) ListChatTimestamps(ctx interface{}) *MockPruneStore_ListChatTimestamps_Call {
+	return &MockPruneStore_ListChatTimestamps_Call{Call: _e.mock.On("ListChatTimestamps", ctx)}
+}
+
+func (_c *MockPruneStore_ListChatTimestamps_Call) Run(run func(ctx context.Context)) *MockPruneStore_ListChatTimestamps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockPruneStore_ListChatTimestamps_Call) Return(_a0 []history.ChatTimestamp, _a1 error) *MockPruneStore_ListChatTimestamps_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPruneStore_ListChatTimestamps_Call) RunAndReturn(run func(context.Context) ([]history.ChatTimestamp, error)) *MockPruneStore_ListChatTimestamps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockPruneStore creates a new instance of MockPruneStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPruneStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPruneStore {
+	mock := &MockPruneStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
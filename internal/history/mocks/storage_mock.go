@@ -0,0 +1,675 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	history "github.com/shaharia-lab/echoy/internal/history"
+	goai "github.com/shaharia-lab/goai"
+
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockStorage is an autogenerated mock type for the Storage type
+type MockStorage struct {
+	mock.Mock
+}
+
+type MockStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockStorage) EXPECT() *MockStorage_Expecter {
+	return &MockStorage_Expecter{mock: &_m.Mock}
+}
+
+// AddMessage provides a mock function with given fields: ctx, _a1, message
+func (_m *MockStorage) AddMessage(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage) error {
+	ret := _m.Called(ctx, _a1, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error); ok {
+		r0 = rf(ctx, _a1, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_AddMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddMessage'
+type MockStorage_AddMessage_Call struct {
+	*mock.Call
+}
+
+// AddMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+//   - message goai.ChatHistoryMessage
+func (_e *MockStorage_Expecter) AddMessage(ctx interface{}, _a1 interface{}, message interface{}) *MockStorage_AddMessage_Call {
+	return &MockStorage_AddMessage_Call{Call: _e.mock.On("AddMessage", ctx, _a1, message)}
+}
+
+func (_c *MockStorage_AddMessage_Call) Run(run func(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage)) *MockStorage_AddMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(goai.ChatHistoryMessage))
+	})
+	return _c
+}
+
+func (_c *MockStorage_AddMessage_Call) Return(_a0 error) *MockStorage_AddMessage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_AddMessage_Call) RunAndReturn(run func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error) *MockStorage_AddMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddPartialMessage provides a mock function with given fields: ctx, _a1, message
+func (_m *MockStorage) AddPartialMessage(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage) error {
+	ret := _m.Called(ctx, _a1, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddPartialMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error); ok {
+		r0 = rf(ctx, _a1, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_AddPartialMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddPartialMessage'
+type MockStorage_AddPartialMessage_Call struct {
+	*mock.Call
+}
+
+// AddPartialMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+//   - message goai.ChatHistoryMessage
+func (_e *MockStorage_Expecter) AddPartialMessage(ctx interface{}, _a1 interface{}, message interface{}) *MockStorage_AddPartialMessage_Call {
+	return &MockStorage_AddPartialMessage_Call{Call: _e.mock.On("AddPartialMessage", ctx, _a1, message)}
+}
+
+func (_c *MockStorage_AddPartialMessage_Call) Run(run func(ctx context.Context, _a1 uuid.UUID, message goai.ChatHistoryMessage)) *MockStorage_AddPartialMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(goai.ChatHistoryMessage))
+	})
+	return _c
+}
+
+func (_c *MockStorage_AddPartialMessage_Call) Return(_a0 error) *MockStorage_AddPartialMessage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_AddPartialMessage_Call) RunAndReturn(run func(context.Context, uuid.UUID, goai.ChatHistoryMessage) error) *MockStorage_AddPartialMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Close provides a mock function with no fields
+func (_m *MockStorage) Close() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockStorage_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *MockStorage_Expecter) Close() *MockStorage_Close_Call {
+	return &MockStorage_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *MockStorage_Close_Call) Run(run func()) *MockStorage_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockStorage_Close_Call) Return(_a0 error) *MockStorage_Close_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_Close_Call) RunAndReturn(run func() error) *MockStorage_Close_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateChat provides a mock function with given fields: ctx
+func (_m *MockStorage) CreateChat(ctx context.Context) (*goai.ChatHistory, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChat")
+	}
+
+	var r0 *goai.ChatHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*goai.ChatHistory, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *goai.ChatHistory); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*goai.ChatHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_CreateChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateChat'
+type MockStorage_CreateChat_Call struct {
+	*mock.Call
+}
+
+// CreateChat is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockStorage_Expecter) CreateChat(ctx interface{}) *MockStorage_CreateChat_Call {
+	return &MockStorage_CreateChat_Call{Call: _e.mock.On("CreateChat", ctx)}
+}
+
+func (_c *MockStorage_CreateChat_Call) Run(run func(ctx context.Context)) *MockStorage_CreateChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStorage_CreateChat_Call) Return(_a0 *goai.ChatHistory, _a1 error) *MockStorage_CreateChat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_CreateChat_Call) RunAndReturn(run func(context.Context) (*goai.ChatHistory, error)) *MockStorage_CreateChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteChat provides a mock function with given fields: ctx, _a1
+func (_m *MockStorage) DeleteChat(ctx context.Context, _a1 uuid.UUID) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_DeleteChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteChat'
+type MockStorage_DeleteChat_Call struct {
+	*mock.Call
+}
+
+// DeleteChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockStorage_Expecter) DeleteChat(ctx interface{}, _a1 interface{}) *MockStorage_DeleteChat_Call {
+	return &MockStorage_DeleteChat_Call{Call: _e.mock.On("DeleteChat", ctx, _a1)}
+}
+
+func (_c *MockStorage_DeleteChat_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockStorage_DeleteChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockStorage_DeleteChat_Call) Return(_a0 error) *MockStorage_DeleteChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_DeleteChat_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockStorage_DeleteChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetChat provides a mock function with given fields: ctx, _a1
+func (_m *MockStorage) GetChat(ctx context.Context, _a1 uuid.UUID) (*goai.ChatHistory, error) {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChat")
+	}
+
+	var r0 *goai.ChatHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*goai.ChatHistory, error)); ok {
+		return rf(ctx, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *goai.ChatHistory); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*goai.ChatHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_GetChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChat'
+type MockStorage_GetChat_Call struct {
+	*mock.Call
+}
+
+// GetChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockStorage_Expecter) GetChat(ctx interface{}, _a1 interface{}) *MockStorage_GetChat_Call {
+	return &MockStorage_GetChat_Call{Call: _e.mock.On("GetChat", ctx, _a1)}
+}
+
+func (_c *MockStorage_GetChat_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockStorage_GetChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetChat_Call) Return(_a0 *goai.ChatHistory, _a1 error) *MockStorage_GetChat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_GetChat_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*goai.ChatHistory, error)) *MockStorage_GetChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetChatDetails provides a mock function with given fields: ctx, _a1
+func (_m *MockStorage) GetChatDetails(ctx context.Context, _a1 uuid.UUID) (*history.Chat, error) {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChatDetails")
+	}
+
+	var r0 *history.Chat
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*history.Chat, error)); ok {
+		return rf(ctx, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *history.Chat); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*history.Chat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_GetChatDetails_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChatDetails'
+type MockStorage_GetChatDetails_Call struct {
+	*mock.Call
+}
+
+// GetChatDetails is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+func (_e *MockStorage_Expecter) GetChatDetails(ctx interface{}, _a1 interface{}) *MockStorage_GetChatDetails_Call {
+	return &MockStorage_GetChatDetails_Call{Call: _e.mock.On("GetChatDetails", ctx, _a1)}
+}
+
+func (_c *MockStorage_GetChatDetails_Call) Run(run func(ctx context.Context, _a1 uuid.UUID)) *MockStorage_GetChatDetails_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetChatDetails_Call) Return(_a0 *history.Chat, _a1 error) *MockStorage_GetChatDetails_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_GetChatDetails_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*history.Chat, error)) *MockStorage_GetChatDetails_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportChat provides a mock function with given fields: ctx, chat
+func (_m *MockStorage) ImportChat(ctx context.Context, chat history.Chat) error {
+	ret := _m.Called(ctx, chat)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportChat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, history.Chat) error); ok {
+		r0 = rf(ctx, chat)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_ImportChat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportChat'
+type MockStorage_ImportChat_Call struct {
+	*mock.Call
+}
+
+// ImportChat is a helper method to define mock.On call
+//   - ctx context.Context
+//   - chat history.Chat
+func (_e *MockStorage_Expecter) ImportChat(ctx interface{}, chat interface{}) *MockStorage_ImportChat_Call {
+	return &MockStorage_ImportChat_Call{Call: _e.mock.On("ImportChat", ctx, chat)}
+}
+
+func (_c *MockStorage_ImportChat_Call) Run(run func(ctx context.Context, chat history.Chat)) *MockStorage_ImportChat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(history.Chat))
+	})
+	return _c
+}
+
+func (_c *MockStorage_ImportChat_Call) Return(_a0 error) *MockStorage_ImportChat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_ImportChat_Call) RunAndReturn(run func(context.Context, history.Chat) error) *MockStorage_ImportChat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListChatHistories provides a mock function with given fields: ctx
+func (_m *MockStorage) ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChatHistories")
+	}
+
+	var r0 []goai.ChatHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]goai.ChatHistory, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []goai.ChatHistory); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]goai.ChatHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_ListChatHistories_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListChatHistories'
+type MockStorage_ListChatHistories_Call struct {
+	*mock.Call
+}
+
+// ListChatHistories is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockStorage_Expecter) ListChatHistories(ctx interface{}) *MockStorage_ListChatHistories_Call {
+	return &MockStorage_ListChatHistories_Call{Call: _e.mock.On("ListChatHistories", ctx)}
+}
+
+func (_c *MockStorage_ListChatHistories_Call) Run(run func(ctx context.Context)) *MockStorage_ListChatHistories_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStorage_ListChatHistories_Call) Return(_a0 []goai.ChatHistory, _a1 error) *MockStorage_ListChatHistories_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_ListChatHistories_Call) RunAndReturn(run func(context.Context) ([]goai.ChatHistory, error)) *MockStorage_ListChatHistories_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListChats provides a mock function with given fields: ctx
+func (_m *MockStorage) ListChats(ctx context.Context) ([]history.Chat, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChats")
+	}
+
+	var r0 []history.Chat
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]history.Chat, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []history.Chat); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]history.Chat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_ListChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListChats'
+type MockStorage_ListChats_Call struct {
+	*mock.Call
+}
+
+// ListChats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockStorage_Expecter) ListChats(ctx interface{}) *MockStorage_ListChats_Call {
+	return &MockStorage_ListChats_Call{Call: _e.mock.On("ListChats", ctx)}
+}
+
+func (_c *MockStorage_ListChats_Call) Run(run func(ctx context.Context)) *MockStorage_ListChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStorage_ListChats_Call) Return(_a0 []history.Chat, _a1 error) *MockStorage_ListChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_ListChats_Call) RunAndReturn(run func(context.Context) ([]history.Chat, error)) *MockStorage_ListChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SearchChats provides a mock function with given fields: ctx, query
+func (_m *MockStorage) SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchChats")
+	}
+
+	var r0 []goai.ChatHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]goai.ChatHistory, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []goai.ChatHistory); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]goai.ChatHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStorage_SearchChats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchChats'
+type MockStorage_SearchChats_Call struct {
+	*mock.Call
+}
+
+// SearchChats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+func (_e *MockStorage_Expecter) SearchChats(ctx interface{}, query interface{}) *MockStorage_SearchChats_Call {
+	return &MockStorage_SearchChats_Call{Call: _e.mock.On("SearchChats", ctx, query)}
+}
+
+func (_c *MockStorage_SearchChats_Call) Run(run func(ctx context.Context, query string)) *MockStorage_SearchChats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_SearchChats_Call) Return(_a0 []goai.ChatHistory, _a1 error) *MockStorage_SearchChats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockStorage_SearchChats_Call) RunAndReturn(run func(context.Context, string) ([]goai.ChatHistory, error)) *MockStorage_SearchChats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetChatTitle provides a mock function with given fields: ctx, _a1, title
+func (_m *MockStorage) SetChatTitle(ctx context.Context, _a1 uuid.UUID, title string) error {
+	ret := _m.Called(ctx, _a1, title)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetChatTitle")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, _a1, title)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStorage_SetChatTitle_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetChatTitle'
+type MockStorage_SetChatTitle_Call struct {
+	*mock.Call
+}
+
+// SetChatTitle is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 uuid.UUID
+//   - title string
+func (_e *MockStorage_Expecter) SetChatTitle(ctx interface{}, _a1 interface{}, title interface{}) *MockStorage_SetChatTitle_Call {
+	return &MockStorage_SetChatTitle_Call{Call: _e.mock.On("SetChatTitle", ctx, _a1, title)}
+}
+
+func (_c *MockStorage_SetChatTitle_Call) Run(run func(ctx context.Context, _a1 uuid.UUID, title string)) *MockStorage_SetChatTitle_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_SetChatTitle_Call) Return(_a0 error) *MockStorage_SetChatTitle_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockStorage_SetChatTitle_Call) RunAndReturn(run func(context.Context, uuid.UUID, string) error) *MockStorage_SetChatTitle_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockStorage creates a new instance of MockStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStorage {
+	mock := &MockStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/goai"
+)
+
+// NoopStorage doesn't store anything. Chats get a UUID so a conversation can go on, but
+// their messages are dropped and they can never be found again.
+type NoopStorage struct{}
+
+// Close implements Storage
+func (NoopStorage) Close() error {
+	return nil
+}
+
+// CreateChat returns a new chat without storing it
+func (NoopStorage) CreateChat(ctx context.Context) (*goai.ChatHistory, error) {
+	return &goai.ChatHistory{
+		UUID:      uuid.New(),
+		Messages:  []goai.ChatHistoryMessage{},
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// AddMessage drops the message
+func (NoopStorage) AddMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	return nil
+}
+
+// AddPartialMessage drops the message
+func (NoopStorage) AddPartialMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	return nil
+}
+
+// SetChatTitle always fails, there are no chats to rename
+func (NoopStorage) SetChatTitle(ctx context.Context, chatUUID uuid.UUID, title string) error {
+	return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+}
+
+// GetChat always fails, no chat is ever stored
+func (NoopStorage) GetChat(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error) {
+	return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+}
+
+// GetChatDetails always fails, no chat is ever stored
+func (NoopStorage) GetChatDetails(ctx context.Context, chatUUID uuid.UUID) (*Chat, error) {
+	return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+}
+
+// ImportChat drops the chat
+func (NoopStorage) ImportChat(ctx context.Context, chat Chat) error {
+	return nil
+}
+
+// ListChatHistories returns no chats
+func (NoopStorage) ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error) {
+	return []goai.ChatHistory{}, nil
+}
+
+// ListChats returns no chats
+func (NoopStorage) ListChats(ctx context.Context) ([]Chat, error) {
+	return nil, nil
+}
+
+// DeleteChat always fails, there are no chats to delete
+func (NoopStorage) DeleteChat(ctx context.Context, chatUUID uuid.UUID) error {
+	return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+}
+
+// SearchChats returns no chats
+func (NoopStorage) SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error) {
+	return []goai.ChatHistory{}, nil
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+)
+
+// DefaultPruneInterval is how often Pruner.Run applies the retention policy
+const DefaultPruneInterval = time.Hour
+
+// RetentionPolicy limits how much chat history is kept. Zero values mean "keep forever".
+type RetentionPolicy struct {
+	// RetentionDays deletes chats created more than this many days ago
+	RetentionDays int
+	// MaxChats keeps only this many of the most recent chats
+	MaxChats int
+}
+
+// Enabled reports whether the policy would ever delete anything
+func (p RetentionPolicy) Enabled() bool {
+	return p.RetentionDays > 0 || p.MaxChats > 0
+}
+
+// PruneStore is the part of the history storage needed for pruning
+type PruneStore interface {
+	ListChatTimestamps(ctx context.Context) ([]ChatTimestamp, error)
+	DeleteChat(ctx context.Context, uuid uuid.UUID) error
+}
+
+// Pruner deletes chats that fall outside a RetentionPolicy
+type Pruner struct {
+	store  PruneStore
+	policy RetentionPolicy
+	logger logger.Logger
+	now    func() time.Time
+}
+
+// NewPruner creates a Pruner applying policy to store
+func NewPruner(store PruneStore, policy RetentionPolicy, log logger.Logger) *Pruner {
+	return &Pruner{
+		store:  store,
+		policy: policy,
+		logger: log,
+		now:    time.Now,
+	}
+}
+
+// Prune deletes the chats older than the retention window and those beyond the maximum
+// count, oldest first, and returns how many were deleted
+func (p *Pruner) Prune(ctx context.Context) (int, error) {
+	if !p.policy.Enabled() {
+		return 0, nil
+	}
+
+	chats, err := p.store.ListChatTimestamps(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chats for pruning: %w", err)
+	}
+
+	cutoff := p.now().AddDate(0, 0, -p.policy.RetentionDays)
+
+	pruned := 0
+	for i, chat := range chats {
+		expired := p.policy.RetentionDays > 0 && chat.CreatedAt.Before(cutoff)
+		overLimit := p.policy.MaxChats > 0 && i >= p.policy.MaxChats
+		if !expired && !overLimit {
+			continue
+		}
+
+		if err := p.store.DeleteChat(ctx, chat.UUID); err != nil {
+			return pruned, fmt.Errorf("failed to prune chat %s: %w", chat.UUID, err)
+		}
+		pruned++
+	}
+
+	return pruned, nil
+}
+
+// Run prunes immediately and then every interval until ctx is cancelled
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	if !p.policy.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pruned, err := p.Prune(ctx)
+		if err != nil {
+			p.logger.WithField(logger.ErrorKey, err).Error("failed to prune chat history")
+		} else {
+			p.logger.WithFields(map[string]interface{}{
+				"pruned":         pruned,
+				"retention_days": p.policy.RetentionDays,
+				"max_chats":      p.policy.MaxChats,
+			}).Info("pruned chat history")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartPruner applies the retention settings of chatConfig to the chat history database
+// at dbPath every DefaultPruneInterval, until ctx is cancelled or the returned function is
+// called. That function stops the pruner and closes the storage it opened.
+//
+// The pruner opens its own storage, which only reaches the chats of other processes when
+// they are stored in the database, so nothing is started for the other backends or without
+// a retention policy.
+func StartPruner(ctx context.Context, chatConfig config.ChatConfig, dbPath string, log logger.Logger) (func(), error) {
+	policy := RetentionPolicy{
+		RetentionDays: chatConfig.RetentionDays,
+		MaxChats:      chatConfig.MaxChats,
+	}
+	if !policy.Enabled() || chatConfig.ResolvedHistoryBackend() != config.HistoryBackendSQLite {
+		return func() {}, nil
+	}
+
+	storage, err := NewSQLiteStorage(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat history storage: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewPruner(storage, policy, log).Run(ctx, DefaultPruneInterval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		storage.Close()
+	}, nil
+}
@@ -0,0 +1,98 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruner_Prune(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		policy    RetentionPolicy
+		ageInDays []int
+		remaining []int
+	}{
+		{name: "disabled keeps everything", policy: RetentionPolicy{}, ageInDays: []int{1, 100, 1000}, remaining: []int{1, 100, 1000}},
+		{name: "retention window", policy: RetentionPolicy{RetentionDays: 30}, ageInDays: []int{1, 29, 31, 365}, remaining: []int{1, 29}},
+		{name: "max chats keeps newest", policy: RetentionPolicy{MaxChats: 2}, ageInDays: []int{10, 1, 5}, remaining: []int{1, 5}},
+		{name: "both limits", policy: RetentionPolicy{RetentionDays: 7, MaxChats: 3}, ageInDays: []int{1, 2, 3, 4, 8}, remaining: []int{1, 2, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := newTestStorage(t)
+			ctx := context.Background()
+
+			ages := make(map[uuid.UUID]int)
+			for _, age := range tc.ageInDays {
+				chat := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: now.AddDate(0, 0, -age)}}
+				require.NoError(t, storage.ImportChat(ctx, chat))
+				ages[chat.UUID] = age
+			}
+
+			pruner := NewPruner(storage, tc.policy, logger.NewNoopLogger())
+			pruner.now = func() time.Time { return now }
+
+			pruned, err := pruner.Prune(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.ageInDays)-len(tc.remaining), pruned)
+
+			chats, err := storage.ListChatHistories(ctx)
+			require.NoError(t, err)
+
+			var remaining []int
+			for _, chat := range chats {
+				remaining = append(remaining, ages[chat.UUID])
+			}
+			assert.Equal(t, tc.remaining, remaining)
+		})
+	}
+}
+
+func TestStartPruner(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "chat_history.db")
+
+	storage, err := NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	for _, age := range []int{3, 1, 2} {
+		chat := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: time.Now().AddDate(0, 0, -age)}}
+		require.NoError(t, storage.ImportChat(ctx, chat))
+	}
+	defer storage.Close()
+
+	countChats := func() int {
+		chats, err := storage.ListChatTimestamps(ctx)
+		require.NoError(t, err)
+		return len(chats)
+	}
+
+	for _, chatConfig := range []config.ChatConfig{
+		{},
+		{MaxChats: 1, HistoryBackend: config.HistoryBackendMemory},
+	} {
+		stop, err := StartPruner(ctx, chatConfig, dbPath, logger.NewNoopLogger())
+		require.NoError(t, err)
+		stop()
+		assert.Equal(t, 3, countChats(), "nothing should be pruned without a policy or with another backend")
+	}
+
+	stop, err := StartPruner(ctx, config.ChatConfig{MaxChats: 1}, dbPath, logger.NewNoopLogger())
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return countChats() == 1 }, 5*time.Second, 10*time.Millisecond)
+	stop()
+
+	_, err = StartPruner(ctx, config.ChatConfig{MaxChats: 1}, filepath.Join(t.TempDir(), "missing", "chat_history.db"), logger.NewNoopLogger())
+	assert.ErrorContains(t, err, "failed to open chat history storage")
+}
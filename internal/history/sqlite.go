@@ -0,0 +1,442 @@
+// Package history provides persistent storage for chat histories
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/shaharia-lab/goai"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS chats (
+	uuid       TEXT PRIMARY KEY,
+	title      TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	chat_uuid    TEXT NOT NULL REFERENCES chats(uuid) ON DELETE CASCADE,
+	role         TEXT NOT NULL,
+	text         TEXT NOT NULL,
+	generated_at TIMESTAMP NOT NULL,
+	partial      INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_chat_uuid ON chat_messages(chat_uuid);
+`
+
+// MaxTitleLength is the maximum number of characters of a title generated from a message
+const MaxTitleLength = 60
+
+var (
+	// ErrChatExists is returned by ImportChat when a chat with the same UUID is already stored
+	ErrChatExists = errors.New("chat already exists")
+	// ErrChatNotFound is returned when the requested chat is not stored
+	ErrChatNotFound = errors.New("chat not found")
+)
+
+// Chat is a stored chat history together with its metadata
+type Chat struct {
+	goai.ChatHistory
+	Title string `json:"title"`
+	// PartialMessages are the indexes in Messages of the answers whose stream ended before
+	// they were complete. The text of these messages is only what was delivered.
+	PartialMessages []int `json:"partial_messages,omitempty"`
+}
+
+// ChatTimestamp identifies a stored chat and when it was created, without loading its
+// messages
+type ChatTimestamp struct {
+	UUID      uuid.UUID
+	CreatedAt time.Time
+}
+
+// IsPartial reports whether the message at index was an interrupted answer
+func (c Chat) IsPartial(index int) bool {
+	return slices.Contains(c.PartialMessages, index)
+}
+
+// SQLiteStorage stores chat histories in a SQLite database
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens the SQLite database at dbPath and makes sure the schema exists
+func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", dbPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat history database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate chat history database: %w", err)
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// migrate creates the schema and upgrades databases created by older versions
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+
+	var hasTitle bool
+	if err := db.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info('chats') WHERE name = 'title'").Scan(&hasTitle); err != nil {
+		return err
+	}
+
+	if !hasTitle {
+		if _, err := db.Exec("ALTER TABLE chats ADD COLUMN title TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	var hasPartial bool
+	if err := db.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info('chat_messages') WHERE name = 'partial'").Scan(&hasPartial); err != nil {
+		return err
+	}
+
+	if !hasPartial {
+		if _, err := db.Exec("ALTER TABLE chat_messages ADD COLUMN partial INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// CreateChat initializes a new chat conversation
+func (s *SQLiteStorage) CreateChat(ctx context.Context) (*goai.ChatHistory, error) {
+	chat := &goai.ChatHistory{
+		UUID:      uuid.New(),
+		Messages:  []goai.ChatHistoryMessage{},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if _, err := s.db.ExecContext(ctx, "INSERT INTO chats (uuid, created_at) VALUES (?, ?)", chat.UUID.String(), chat.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to create chat: %w", err)
+	}
+
+	return chat, nil
+}
+
+// AddMessage adds a new message to an existing conversation. The first user message
+// also becomes the chat's title unless one was already set.
+func (s *SQLiteStorage) AddMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	return s.addMessage(ctx, chatUUID, message, false)
+}
+
+// AddPartialMessage adds an answer whose stream ended before it was complete, which is
+// listed in Chat.PartialMessages
+func (s *SQLiteStorage) AddPartialMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage) error {
+	return s.addMessage(ctx, chatUUID, message, true)
+}
+
+func (s *SQLiteStorage) addMessage(ctx context.Context, chatUUID uuid.UUID, message goai.ChatHistoryMessage, partial bool) error {
+	if err := s.chatExists(ctx, chatUUID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add message to chat %s: %w", chatUUID, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO chat_messages (chat_uuid, role, text, generated_at, partial) VALUES (?, ?, ?, ?, ?)",
+		chatUUID.String(), string(message.Role), message.Text, message.GeneratedAt, partial,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add message to chat %s: %w", chatUUID, err)
+	}
+
+	if title := TitleFromMessage(message.Text); message.Role == goai.UserRole && title != "" {
+		if _, err := tx.ExecContext(ctx, "UPDATE chats SET title = ? WHERE uuid = ? AND title = ''", title, chatUUID.String()); err != nil {
+			return fmt.Errorf("failed to set title of chat %s: %w", chatUUID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add message to chat %s: %w", chatUUID, err)
+	}
+
+	return nil
+}
+
+// SetChatTitle renames a conversation
+func (s *SQLiteStorage) SetChatTitle(ctx context.Context, chatUUID uuid.UUID, title string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE chats SET title = ? WHERE uuid = ?", title, chatUUID.String())
+	if err != nil {
+		return fmt.Errorf("failed to set title of chat %s: %w", chatUUID, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	return nil
+}
+
+// GetChat retrieves a conversation with all of its messages
+func (s *SQLiteStorage) GetChat(ctx context.Context, chatUUID uuid.UUID) (*goai.ChatHistory, error) {
+	chat, err := s.GetChatDetails(ctx, chatUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chat.ChatHistory, nil
+}
+
+// GetChatDetails retrieves a conversation with all of its messages and its metadata
+func (s *SQLiteStorage) GetChatDetails(ctx context.Context, chatUUID uuid.UUID) (*Chat, error) {
+	chat := &Chat{ChatHistory: goai.ChatHistory{
+		UUID:     chatUUID,
+		Messages: []goai.ChatHistoryMessage{},
+	}}
+
+	err := s.db.QueryRowContext(ctx, "SELECT title, created_at FROM chats WHERE uuid = ?", chatUUID.String()).Scan(&chat.Title, &chat.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat %s: %w", chatUUID, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT role, text, generated_at, partial FROM chat_messages WHERE chat_uuid = ? ORDER BY id", chatUUID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages for chat %s: %w", chatUUID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var message goai.ChatHistoryMessage
+		var role string
+		var partial bool
+		if err := rows.Scan(&role, &message.Text, &message.GeneratedAt, &partial); err != nil {
+			return nil, fmt.Errorf("failed to read message for chat %s: %w", chatUUID, err)
+		}
+
+		message.Role = goai.LLMMessageRole(role)
+		if partial {
+			chat.PartialMessages = append(chat.PartialMessages, len(chat.Messages))
+		}
+		chat.Messages = append(chat.Messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages for chat %s: %w", chatUUID, err)
+	}
+
+	return chat, nil
+}
+
+// ImportChat stores a complete chat, keeping its UUID, title, creation time and messages.
+// It returns ErrChatExists if a chat with the same UUID is already stored.
+func (s *SQLiteStorage) ImportChat(ctx context.Context, chat Chat) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to import chat %s: %w", chat.UUID, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO chats (uuid, title, created_at) VALUES (?, ?, ?)", chat.UUID.String(), chat.Title, chat.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to import chat %s: %w", chat.UUID, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("failed to import chat %s: %w", chat.UUID, ErrChatExists)
+	}
+
+	for i, message := range chat.Messages {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO chat_messages (chat_uuid, role, text, generated_at, partial) VALUES (?, ?, ?, ?, ?)",
+			chat.UUID.String(), string(message.Role), message.Text, message.GeneratedAt.UTC(), chat.IsPartial(i),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to import messages for chat %s: %w", chat.UUID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to import chat %s: %w", chat.UUID, err)
+	}
+
+	return nil
+}
+
+// ListChatHistories returns all stored conversations, newest first
+func (s *SQLiteStorage) ListChatHistories(ctx context.Context) ([]goai.ChatHistory, error) {
+	chats, err := s.ListChats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return chatHistories(chats), nil
+}
+
+// ListChats returns all stored conversations with their metadata, newest first
+func (s *SQLiteStorage) ListChats(ctx context.Context) ([]Chat, error) {
+	return s.queryChats(ctx, "SELECT uuid, title FROM chats ORDER BY created_at DESC")
+}
+
+// ListChatTimestamps returns when each stored conversation was created, newest first
+func (s *SQLiteStorage) ListChatTimestamps(ctx context.Context) ([]ChatTimestamp, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT uuid, created_at FROM chats ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chats: %w", err)
+	}
+	defer rows.Close()
+
+	var timestamps []ChatTimestamp
+	for rows.Next() {
+		var id string
+		var timestamp ChatTimestamp
+		if err := rows.Scan(&id, &timestamp.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read chat: %w", err)
+		}
+
+		if timestamp.UUID, err = uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid chat ID %q in database: %w", id, err)
+		}
+
+		timestamps = append(timestamps, timestamp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query chats: %w", err)
+	}
+
+	return timestamps, nil
+}
+
+// DeleteChat removes a conversation and its messages
+func (s *SQLiteStorage) DeleteChat(ctx context.Context, chatUUID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM chats WHERE uuid = ?", chatUUID.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete chat %s: %w", chatUUID, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	return nil
+}
+
+// SearchChats returns the conversations with at least one message containing query,
+// newest first. Matching is case-insensitive for ASCII text.
+func (s *SQLiteStorage) SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error) {
+	chats, err := s.queryChats(
+		ctx,
+		`SELECT c.uuid, c.title FROM chats c
+		WHERE EXISTS (SELECT 1 FROM chat_messages m WHERE m.chat_uuid = c.uuid AND m.text LIKE ? ESCAPE '\')
+		ORDER BY c.created_at DESC`,
+		"%"+escapeLike(query)+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return chatHistories(chats), nil
+}
+
+// queryChats loads the full chats for the (uuid, title) rows returned by query
+func (s *SQLiteStorage) queryChats(ctx context.Context, query string, args ...any) ([]Chat, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chats: %w", err)
+	}
+
+	var chats []Chat
+	for rows.Next() {
+		var id string
+		var chat Chat
+		if err := rows.Scan(&id, &chat.Title); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read chat: %w", err)
+		}
+
+		if chat.UUID, err = uuid.Parse(id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("invalid chat ID %q in database: %w", id, err)
+		}
+
+		chats = append(chats, chat)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query chats: %w", err)
+	}
+
+	for i := range chats {
+		chat, err := s.GetChatDetails(ctx, chats[i].UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		chats[i] = *chat
+	}
+
+	return chats, nil
+}
+
+// chatHistories strips the metadata from chats
+func chatHistories(chats []Chat) []goai.ChatHistory {
+	histories := make([]goai.ChatHistory, 0, len(chats))
+	for _, chat := range chats {
+		histories = append(histories, chat.ChatHistory)
+	}
+
+	return histories
+}
+
+func (s *SQLiteStorage) chatExists(ctx context.Context, chatUUID uuid.UUID) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM chats WHERE uuid = ?)", chatUUID.String()).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up chat %s: %w", chatUUID, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrChatNotFound, chatUUID)
+	}
+
+	return nil
+}
+
+// TitleFromMessage derives a chat title from a message: whitespace is collapsed and
+// the text is truncated to MaxTitleLength characters
+func TitleFromMessage(text string) string {
+	title := []rune(strings.Join(strings.Fields(text), " "))
+	if len(title) <= MaxTitleLength {
+		return string(title)
+	}
+
+	return strings.TrimSpace(string(title[:MaxTitleLength-3])) + "..."
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
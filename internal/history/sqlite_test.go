@@ -0,0 +1,277 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "chat_history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+
+	return storage
+}
+
+func addMessage(t *testing.T, storage Storage, chatUUID uuid.UUID, role goai.LLMMessageRole, text string) {
+	t.Helper()
+
+	err := storage.AddMessage(context.Background(), chatUUID, goai.ChatHistoryMessage{
+		LLMMessage:  goai.LLMMessage{Role: role, Text: text},
+		GeneratedAt: time.Now().UTC(),
+	})
+	require.NoError(t, err)
+}
+
+func TestSQLiteStorage_CreateAndGetChat(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	chat, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+
+	addMessage(t, storage, chat.UUID, goai.UserRole, "Hello")
+	addMessage(t, storage, chat.UUID, goai.AssistantRole, "Hi there")
+
+	got, err := storage.GetChat(ctx, chat.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, chat.UUID, got.UUID)
+	require.Len(t, got.Messages, 2)
+	assert.Equal(t, goai.UserRole, got.Messages[0].Role)
+	assert.Equal(t, "Hello", got.Messages[0].Text)
+	assert.Equal(t, goai.AssistantRole, got.Messages[1].Role)
+	assert.Equal(t, "Hi there", got.Messages[1].Text)
+}
+
+func TestSQLiteStorage_PersistsAcrossReopen(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "chat_history.db")
+	ctx := context.Background()
+
+	storage, err := NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	chat, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, chat.UUID, goai.UserRole, "remember me")
+	require.NoError(t, storage.Close())
+
+	reopened, err := NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.GetChat(ctx, chat.UUID)
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "remember me", got.Messages[0].Text)
+}
+
+func TestSQLiteStorage_UnknownChat(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	unknown := uuid.New()
+
+	_, err := storage.GetChat(ctx, unknown)
+
+	assert.ErrorIs(t, err, ErrChatNotFound)
+
+	err = storage.AddMessage(ctx, unknown, goai.ChatHistoryMessage{})
+	assert.ErrorIs(t, err, ErrChatNotFound)
+
+	err = storage.DeleteChat(ctx, unknown)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+}
+
+func TestSQLiteStorage_ListAndDelete(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	first, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	second, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, first.UUID, goai.UserRole, "first")
+
+	chats, err := storage.ListChatHistories(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chats, 2)
+
+	require.NoError(t, storage.DeleteChat(ctx, first.UUID))
+
+	chats, err = storage.ListChatHistories(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, second.UUID, chats[0].UUID)
+}
+
+func TestSQLiteStorage_ListChatTimestamps(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	older := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: now.AddDate(0, 0, -3)}}
+	newer := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: now}}
+	require.NoError(t, storage.ImportChat(ctx, older))
+	require.NoError(t, storage.ImportChat(ctx, newer))
+	addMessage(t, storage, older.UUID, goai.UserRole, "hello")
+
+	timestamps, err := storage.ListChatTimestamps(ctx)
+	require.NoError(t, err)
+	require.Len(t, timestamps, 2)
+	assert.Equal(t, newer.UUID, timestamps[0].UUID, "the newest chat should come first")
+	assert.True(t, newer.CreatedAt.Equal(timestamps[0].CreatedAt))
+	assert.Equal(t, older.UUID, timestamps[1].UUID)
+	assert.True(t, older.CreatedAt.Equal(timestamps[1].CreatedAt))
+}
+
+func TestSQLiteStorage_SearchChats(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	golang, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, golang.UUID, goai.UserRole, "How do goroutines work?")
+	addMessage(t, storage, golang.UUID, goai.AssistantRole, "Goroutines are lightweight threads.")
+
+	cooking, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, cooking.UUID, goai.UserRole, "Give me a recipe with 100% cocoa_butter")
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []uuid.UUID
+	}{
+		{name: "matches message text", query: "goroutines", expected: []uuid.UUID{golang.UUID}},
+		{name: "case insensitive", query: "RECIPE", expected: []uuid.UUID{cooking.UUID}},
+		{name: "percent is literal", query: "100%", expected: []uuid.UUID{cooking.UUID}},
+		{name: "underscore is literal", query: "cocoa_butter", expected: []uuid.UUID{cooking.UUID}},
+		{name: "wildcard is not expanded", query: "a%b", expected: nil},
+		{name: "no match", query: "kubernetes", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chats, err := storage.SearchChats(ctx, tc.query)
+			require.NoError(t, err)
+
+			var got []uuid.UUID
+			for _, chat := range chats {
+				got = append(got, chat.UUID)
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestSQLiteStorage_ImportChat(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	chat := Chat{
+		ChatHistory: goai.ChatHistory{
+			UUID:      uuid.New(),
+			CreatedAt: createdAt,
+			Messages: []goai.ChatHistoryMessage{
+				{LLMMessage: goai.LLMMessage{Role: goai.UserRole, Text: "question"}, GeneratedAt: createdAt},
+				{LLMMessage: goai.LLMMessage{Role: goai.AssistantRole, Text: "answer"}, GeneratedAt: createdAt.Add(time.Second)},
+			},
+		},
+		Title: "Imported title",
+	}
+
+	require.NoError(t, storage.ImportChat(ctx, chat))
+
+	got, err := storage.GetChat(ctx, chat.UUID)
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(got.CreatedAt))
+	require.Len(t, got.Messages, 2)
+	assert.Equal(t, "answer", got.Messages[1].Text)
+
+	err = storage.ImportChat(ctx, chat)
+	assert.ErrorIs(t, err, ErrChatExists)
+
+	got, err = storage.GetChat(ctx, chat.UUID)
+	require.NoError(t, err)
+	assert.Len(t, got.Messages, 2, "a rejected import must not add messages")
+
+	chats, err := storage.ListChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, "Imported title", chats[0].Title)
+}
+
+func TestSQLiteStorage_Titles(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	chat, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+
+	addMessage(t, storage, chat.UUID, goai.AssistantRole, "Welcome!")
+	addMessage(t, storage, chat.UUID, goai.UserRole, "  How do I\nreverse a slice?  ")
+	addMessage(t, storage, chat.UUID, goai.UserRole, "And sort it?")
+
+	chats, err := storage.ListChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, "How do I reverse a slice?", chats[0].Title, "the first user message becomes the title")
+	assert.Len(t, chats[0].Messages, 3)
+
+	require.NoError(t, storage.SetChatTitle(ctx, chat.UUID, "Slices"))
+	chats, err = storage.ListChats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Slices", chats[0].Title)
+
+	err = storage.SetChatTitle(ctx, uuid.New(), "missing")
+	assert.ErrorIs(t, err, ErrChatNotFound)
+}
+
+func TestNewSQLiteStorage_MigratesOldSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "chat_history.db")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE chats (uuid TEXT PRIMARY KEY, created_at TIMESTAMP NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE chat_messages (id INTEGER PRIMARY KEY AUTOINCREMENT, chat_uuid TEXT NOT NULL, role TEXT NOT NULL, text TEXT NOT NULL, generated_at TIMESTAMP NOT NULL)`)
+	require.NoError(t, err)
+	chatUUID := uuid.New()
+	_, err = db.Exec(`INSERT INTO chats (uuid, created_at) VALUES (?, ?)`, chatUUID.String(), time.Now().UTC())
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO chat_messages (chat_uuid, role, text, generated_at) VALUES (?, 'user', 'Hi', ?)`, chatUUID.String(), time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	storage, err := NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	chats, err := storage.ListChats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Empty(t, chats[0].Title)
+	assert.Len(t, chats[0].Messages, 1)
+	assert.Empty(t, chats[0].PartialMessages, "messages of older versions are complete")
+}
+
+func TestTitleFromMessage(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+
+	assert.Equal(t, "", TitleFromMessage("   "))
+	assert.Equal(t, "short question", TitleFromMessage("short\tquestion\n"))
+
+	title := TitleFromMessage(long)
+	assert.LessOrEqual(t, len([]rune(title)), MaxTitleLength)
+	assert.True(t, strings.HasSuffix(title, "..."))
+}
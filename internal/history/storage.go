@@ -0,0 +1,41 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/goai"
+)
+
+// Storage is implemented by all chat history backends
+type Storage interface {
+	goai.ChatHistoryStorage
+	AddPartialMessage(ctx context.Context, uuid uuid.UUID, message goai.ChatHistoryMessage) error
+	GetChatDetails(ctx context.Context, uuid uuid.UUID) (*Chat, error)
+	SearchChats(ctx context.Context, query string) ([]goai.ChatHistory, error)
+	ListChats(ctx context.Context) ([]Chat, error)
+	SetChatTitle(ctx context.Context, uuid uuid.UUID, title string) error
+	ImportChat(ctx context.Context, chat Chat) error
+	Close() error
+}
+
+// Open returns the storage for the history backend of chatConfig. dbPath is only used by
+// the sqlite backend.
+func Open(chatConfig config.ChatConfig, dbPath string, log logger.Logger) (Storage, error) {
+	switch backend := chatConfig.ResolvedHistoryBackend(); backend {
+	case "", config.HistoryBackendSQLite:
+		return NewSQLiteStorage(dbPath)
+	case config.HistoryBackendMemory:
+		return NewMemoryStorage().SetLogger(log).SetMaxChats(chatConfig.ResolvedMemoryMaxChats()), nil
+	case config.HistoryBackendNone:
+		return NoopStorage{}, nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown chat history backend '%s', must be one of: %s, %s, %s",
+			backend, config.HistoryBackendSQLite, config.HistoryBackendMemory, config.HistoryBackendNone,
+		)
+	}
+}
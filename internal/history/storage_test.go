@@ -0,0 +1,200 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "chat_history.db")
+
+	tests := []struct {
+		backend string
+		want    Storage
+		wantErr bool
+	}{
+		{backend: "", want: &SQLiteStorage{}},
+		{backend: "sqlite", want: &SQLiteStorage{}},
+		{backend: "memory", want: &MemoryStorage{}},
+		{backend: "none", want: NoopStorage{}},
+		{backend: "redis", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			storage, err := Open(config.ChatConfig{HistoryBackend: tt.backend}, dbPath, logger.NewNoopLogger())
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "unknown chat history backend 'redis'")
+				return
+			}
+
+			require.NoError(t, err)
+			defer storage.Close()
+			assert.IsType(t, tt.want, storage)
+		})
+	}
+}
+
+func TestStorage_PartialMessages(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		"sqlite": func(t *testing.T) Storage { return newTestStorage(t) },
+		"memory": func(t *testing.T) Storage { return NewMemoryStorage() },
+	}
+
+	for name, newStorage := range backends {
+		t.Run(name, func(t *testing.T) {
+			storage := newStorage(t)
+			ctx := context.Background()
+
+			chat, err := storage.CreateChat(ctx)
+			require.NoError(t, err)
+			addMessage(t, storage, chat.UUID, goai.UserRole, "Tell me a story")
+			require.NoError(t, storage.AddPartialMessage(ctx, chat.UUID, goai.ChatHistoryMessage{
+				LLMMessage:  goai.LLMMessage{Role: goai.AssistantRole, Text: "Once upon"},
+				GeneratedAt: time.Now().UTC(),
+			}))
+			addMessage(t, storage, chat.UUID, goai.UserRole, "Go on")
+
+			details, err := storage.GetChatDetails(ctx, chat.UUID)
+			require.NoError(t, err)
+			assert.Equal(t, []int{1}, details.PartialMessages)
+			assert.Equal(t, "Once upon", details.Messages[1].Text, "the text should only be what was delivered")
+
+			chats, err := storage.ListChats(ctx)
+			require.NoError(t, err)
+			require.Len(t, chats, 1)
+			assert.Equal(t, []int{1}, chats[0].PartialMessages)
+
+			imported := *details
+			imported.UUID = uuid.New()
+			require.NoError(t, storage.ImportChat(ctx, imported))
+			details, err = storage.GetChatDetails(ctx, imported.UUID)
+			require.NoError(t, err)
+			assert.Equal(t, []int{1}, details.PartialMessages, "an import should keep the flags")
+
+			_, err = storage.GetChatDetails(ctx, uuid.New())
+			assert.ErrorIs(t, err, ErrChatNotFound)
+		})
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	first, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, first.UUID, goai.UserRole, "Tell me about Go")
+
+	second, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	addMessage(t, storage, second.UUID, goai.UserRole, "Hello")
+	addMessage(t, storage, second.UUID, goai.AssistantRole, "Hi there")
+
+	got, err := storage.GetChat(ctx, second.UUID)
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 2)
+	assert.Equal(t, "Hi there", got.Messages[1].Text)
+
+	got.Messages[0].Text = "changed"
+	got, err = storage.GetChat(ctx, second.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", got.Messages[0].Text, "stored messages must not be shared with callers")
+
+	require.NoError(t, storage.SetChatTitle(ctx, first.UUID, "Go"))
+
+	chats, err := storage.ListChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 2)
+
+	results, err := storage.SearchChats(ctx, "GO")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, first.UUID, results[0].UUID)
+
+	require.NoError(t, storage.DeleteChat(ctx, first.UUID))
+	_, err = storage.GetChat(ctx, first.UUID)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+	assert.ErrorIs(t, storage.DeleteChat(ctx, first.UUID), ErrChatNotFound)
+	assert.ErrorIs(t, storage.AddMessage(ctx, first.UUID, goai.ChatHistoryMessage{}), ErrChatNotFound)
+}
+
+func TestMemoryStorage_ListNewestFirst(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	older := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: now.Add(-time.Hour)}, Title: "older"}
+	newer := Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New(), CreatedAt: now}, Title: "newer"}
+	require.NoError(t, storage.ImportChat(ctx, older))
+	require.NoError(t, storage.ImportChat(ctx, newer))
+	assert.ErrorIs(t, storage.ImportChat(ctx, older), ErrChatExists)
+
+	chats, err := storage.ListChats(ctx)
+	require.NoError(t, err)
+	require.Len(t, chats, 2)
+	assert.Equal(t, "newer", chats[0].Title)
+	assert.Equal(t, "older", chats[1].Title)
+}
+
+func TestMemoryStorage_EvictsLeastRecentlyUsed(t *testing.T) {
+	storage := NewMemoryStorage().SetMaxChats(2)
+	ctx := context.Background()
+
+	first, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	second, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+
+	// Reading the first chat makes the second one the least recently used
+	_, err = storage.GetChat(ctx, first.UUID)
+	require.NoError(t, err)
+
+	third, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+
+	_, err = storage.GetChat(ctx, second.UUID)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+	_, err = storage.GetChat(ctx, first.UUID)
+	assert.NoError(t, err)
+	_, err = storage.GetChat(ctx, third.UUID)
+	assert.NoError(t, err)
+
+	require.NoError(t, storage.DeleteChat(ctx, third.UUID))
+	require.NoError(t, storage.ImportChat(ctx, Chat{ChatHistory: goai.ChatHistory{UUID: uuid.New()}}))
+	chats, err := storage.ListChats(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chats, 2, "deleted chats should free their slot")
+
+	storage.SetMaxChats(1)
+	chats, err = storage.ListChats(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chats, 1, "lowering the limit should evict right away")
+}
+
+func TestNoopStorage(t *testing.T) {
+	storage := NoopStorage{}
+	ctx := context.Background()
+
+	chat, err := storage.CreateChat(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, chat.UUID)
+
+	addMessage(t, storage, chat.UUID, goai.UserRole, "Hello")
+
+	_, err = storage.GetChat(ctx, chat.UUID)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+
+	chats, err := storage.ListChatHistories(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, chats)
+}
@@ -0,0 +1,137 @@
+// Package httpx builds the HTTP clients of outbound calls, so timeouts, connection
+// pooling, proxies, trusted CAs and the User-Agent are configured the same everywhere.
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Defaults applied by NewClient to options that aren't set
+const (
+	DefaultTimeout               = 30 * time.Second
+	DefaultDialTimeout           = 10 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConnsPerHost   = 10
+)
+
+// Options configure a client created by NewClient
+type Options struct {
+	// Version is the Echoy version sent in the User-Agent
+	Version string
+	// UserAgent replaces the default User-Agent, UserAgent(Version)
+	UserAgent string
+	// Timeout bounds a whole request, including reading the response body. It defaults
+	// to DefaultTimeout, a negative value means no limit.
+	Timeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for the response headers once the request is
+	// sent. It defaults to DefaultResponseHeaderTimeout, a negative value means no limit.
+	ResponseHeaderTimeout time.Duration
+	// CAFile is a PEM file of certificate authorities trusted in addition to the system ones
+	CAFile string
+	// CheckRedirect is the redirect policy of the client, see http.Client
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// NewClient creates an HTTP client configured by opts. Requests are sent through the proxy
+// of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func NewClient(opts Options) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   DefaultDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	switch {
+	case opts.ResponseHeaderTimeout > 0:
+		transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	case opts.ResponseHeaderTimeout < 0:
+		transport.ResponseHeaderTimeout = 0
+	}
+
+	if opts.CAFile != "" {
+		rootCAs, err := loadCAs(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	timeout := opts.Timeout
+	switch {
+	case timeout == 0:
+		timeout = DefaultTimeout
+	case timeout < 0:
+		timeout = 0
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = UserAgent(opts.Version)
+	}
+
+	return &http.Client{
+		Transport:     &userAgentTransport{base: transport, userAgent: userAgent},
+		Timeout:       timeout,
+		CheckRedirect: opts.CheckRedirect,
+	}, nil
+}
+
+// UserAgent returns the User-Agent of Echoy's requests, e.g. echoy/1.2.0
+func UserAgent(version string) string {
+	if version == "" {
+		version = "dev"
+	}
+
+	return "echoy/" + version
+}
+
+// loadCAs returns the system certificate pool with the certificates in path added
+func loadCAs(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+
+	return pool, nil
+}
+
+// userAgentTransport sets the User-Agent of requests that don't have one
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	return t.base.RoundTrip(req)
+}
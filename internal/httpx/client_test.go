@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_UserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Options{Version: "1.2.0"})
+	require.NoError(t, err)
+
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	request.Header.Set("User-Agent", "custom")
+	response, err = client.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, []string{"echoy/1.2.0", "custom"}, userAgents, "a User-Agent set by the caller should be kept")
+}
+
+func TestNewClient_CustomUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Options{Version: "1.2.0", UserAgent: "echoy/1.2.0 (+https://github.com/shaharia-lab/echoy)"})
+	require.NoError(t, err)
+
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, "echoy/1.2.0 (+https://github.com/shaharia-lab/echoy)", userAgent)
+}
+
+func TestNewClient_Timeout(t *testing.T) {
+	client, err := NewClient(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTimeout, client.Timeout)
+
+	client, err = NewClient(Options{Timeout: -1})
+	require.NoError(t, err)
+	assert.Zero(t, client.Timeout, "a negative timeout should mean no limit")
+
+	client, err = NewClient(Options{Timeout: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, client.Timeout)
+}
+
+func TestNewClient_ResponseHeaderTimeout(t *testing.T) {
+	transport := func(client *http.Client) *http.Transport {
+		return client.Transport.(*userAgentTransport).base.(*http.Transport)
+	}
+
+	client, err := NewClient(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultResponseHeaderTimeout, transport(client).ResponseHeaderTimeout)
+
+	client, err = NewClient(Options{ResponseHeaderTimeout: -1})
+	require.NoError(t, err)
+	assert.Zero(t, transport(client).ResponseHeaderTimeout, "a negative timeout should mean no limit")
+
+	client, err = NewClient(Options{ResponseHeaderTimeout: 2 * time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, transport(client).ResponseHeaderTimeout)
+}
+
+func TestNewClient_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certificate, 0600))
+
+	client, err := NewClient(Options{CAFile: caFile})
+	require.NoError(t, err)
+
+	response, err := client.Get(server.URL)
+	require.NoError(t, err, "the server's certificate should be trusted")
+	response.Body.Close()
+
+	_, err = NewClient(Options{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read CA file")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0600))
+	_, err = NewClient(Options{CAFile: empty})
+	assert.ErrorContains(t, err, "no certificates found")
+}
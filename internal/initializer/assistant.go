@@ -5,7 +5,7 @@ import (
 )
 
 // ConfigureAssistant configures assistant details
-func (i *Initializer) ConfigureAssistant(assistantName string) error {
+func (i *Initializer) ConfigureAssistant(defaultName string) error {
 	i.cliTheme.GetCurrentTheme().Primary().Println("📝 Assistant Details")
 
 	promptAssistantName := &survey.Input{
@@ -13,13 +13,14 @@ func (i *Initializer) ConfigureAssistant(assistantName string) error {
 		Help:    "Give your AI assistant a friendly name",
 		Default: i.Config.Assistant.Name,
 	}
+	var assistantName string
 	err := survey.AskOne(promptAssistantName, &assistantName)
 	if err != nil {
 		return err
 	}
 
 	if assistantName == "" {
-		assistantName = assistantName
+		assistantName = defaultName
 	}
 
 	i.Config.Assistant.Name = assistantName
@@ -1,43 +1,41 @@
 package initializer
 
 import (
-	"context"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
-	"github.com/shaharia-lab/telemetry-collector"
 	"github.com/spf13/cobra"
 )
 
 // NewCmd creates an interactive init command
-func NewCmd(config config.Config, appConfig *config.AppConfig, logger logger.Logger, themeManager *theme.Manager, initializer *Initializer) *cobra.Command {
+func NewCmd(events *telemetryEvent.Batch, appConfig *config.AppConfig, logger logger.Logger, themeManager *theme.Manager, initializer *Initializer) *cobra.Command {
+	var dryRun bool
+
 	cmd := &cobra.Command{
 		Version: appConfig.Version.VersionText(),
 		Use:     "init",
 		Short:   "Initialize the Echoy with a guided setup",
 		Long:    `Start an interactive wizard to configure Echoy with a series of questions.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if config.UsageTracking.Enabled {
-				telemetryEvent.SendTelemetryEvent(
-					context.Background(),
-					appConfig,
-					"cmd.init",
-					telemetry.SeverityInfo, "Starting initialization",
-					nil,
-				)
-			}
+			events.Add(telemetryEvent.InitStarted())
 
 			logger.Info("Starting initialization...")
 			defer logger.Flush()
 
-			if err := initializer.Run(); err != nil {
+			if err := initializer.WithDryRun(dryRun).Run(); err != nil {
 				logger.Errorf("Initialization failed: %v", err)
 				themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Initialization failed: %v", err))
 				return err
 			}
 
+			if dryRun {
+				logger.Info("Dry run complete, the configuration was not saved.")
+				themeManager.GetCurrentTheme().Info().Println("Run 'echoy init' without --dry-run to save it.")
+				return nil
+			}
+
 			logger.Info("Initialization complete. You can now run 'echoy' to start using Echoy.")
 
 			themeManager.GetCurrentTheme().Info().Println("\nRun 'echoy chat' to start an interactive chat session.")
@@ -47,5 +45,7 @@ func NewCmd(config config.Config, appConfig *config.AppConfig, logger logger.Log
 		},
 	}
 
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Walk through the setup and print the resulting configuration without saving it")
+
 	return cmd
 }
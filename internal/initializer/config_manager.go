@@ -1,16 +1,45 @@
 package initializer
 
 import (
+	"errors"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"gopkg.in/yaml.v3"
+	"io/fs"
+	"maps"
 	"os"
+	"strings"
 )
 
-// LoadConfig loads the existing configuration
-// LoadConfig loads the existing configuration or creates and loads default config if not found
+// LoadConfig loads the configuration in two layers: the system-wide configuration, see
+// config.SystemConfigPath, and the configuration of the user on top of it. The settings of
+// the user take precedence, those it leaves out keep their system value. Lists are
+// replaced as a whole, maps are merged key by key. Finally the ECHOY_ environment
+// variables named by config.EnvName override single settings, so the precedence is
+// environment, then files, then defaults.
+//
+// If neither file holds a configuration, e.g. because the user's is missing or blank like
+// the empty file created along with the application directories, it returns the default
+// configuration, with the environment overrides, together with
+// config.ErrConfigNotInitialized.
 func (cm *DefaultConfigManager) LoadConfig() (config.Config, error) {
-	// Initialize with default configuration values
+	cfg, err := cm.loadConfigFiles()
+	if err != nil && !errors.Is(err, config.ErrConfigNotInitialized) {
+		return cfg, err
+	}
+
+	loaded := cfg
+	overridden, envErr := config.ApplyEnv(&loaded, os.LookupEnv)
+	if envErr != nil {
+		return cfg, envErr
+	}
+
+	cm.fileConfig, cm.loadedConfig, cm.envOverrides = cfg, loaded, overridden
+	return loaded, err
+}
+
+// loadConfigFiles loads the system-wide and the user's configuration, see LoadConfig
+func (cm *DefaultConfigManager) loadConfigFiles() (config.Config, error) {
 	c := config.Config{}
 	defaultConfig := c.Default()
 
@@ -18,54 +47,118 @@ func (cm *DefaultConfigManager) LoadConfig() (config.Config, error) {
 		return defaultConfig, fmt.Errorf("config file path not set")
 	}
 
-	// If config file doesn't exist, save the default config
-	if !cm.configFileExists() {
-		err := cm.SaveConfig(defaultConfig)
-		if err != nil {
-			return config.Config{}, fmt.Errorf("failed to save default config: %w", err)
-		}
-		return defaultConfig, nil
+	var cfg config.Config
+	systemLoaded, err := decodeConfigFile(cm.systemConfigPath, &cfg)
+	if err != nil {
+		return defaultConfig, fmt.Errorf("system config file %s: %w", cm.systemConfigPath, err)
 	}
 
-	// Config file exists, read it
-	configFile, err := os.ReadFile(cm.configFilePath)
+	userLoaded, err := decodeConfigFile(cm.configFilePath, &cfg)
 	if err != nil {
-		return defaultConfig, fmt.Errorf("failed to read config file: %w", err)
+		return defaultConfig, err
 	}
 
-	// Check if the file is empty
-	if len(configFile) == 0 {
-		err := cm.SaveConfig(defaultConfig)
-		if err != nil {
-			return config.Config{}, fmt.Errorf("failed to save default config to empty file: %w", err)
-		}
-		return defaultConfig, nil
+	if !systemLoaded && !userLoaded {
+		return defaultConfig, config.ErrConfigNotInitialized
 	}
 
-	var cfg config.Config
-	if err := yaml.Unmarshal(configFile, &cfg); err != nil {
-		return defaultConfig, fmt.Errorf("failed to parse config file: %w", err)
+	return cfg, nil
+}
+
+// decodeConfigFile decodes the configuration at path onto cfg, the values the file doesn't
+// set are kept. It reports false if there is no file at path or it holds no YAML document.
+func decodeConfigFile(path string, cfg *config.Config) (bool, error) {
+	if path == "" {
+		return false, nil
 	}
 
-	return cfg, nil
+	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if strings.TrimSpace(string(configFile)) == "" {
+		return false, nil
+	}
+
+	// A file with nothing but comments has no YAML document
+	var document yaml.Node
+	if err := yaml.Unmarshal(configFile, &document); err != nil {
+		return false, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return false, nil
+	}
+
+	if err := document.Decode(cfg); err != nil {
+		return false, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return true, nil
 }
 
-// SaveConfig saves the configuration to disk
+// SaveConfig saves the configuration to the user's file: what RenderConfig returns, with
+// the secrets instead of their redacted values
 func (cm *DefaultConfigManager) SaveConfig(cfg config.Config) error {
 	if cm.configFilePath == "" {
 		return fmt.Errorf("config file path not set")
 	}
 
-	yamlData, err := yaml.Marshal(&cfg)
+	yamlData, err := cm.render(cfg, false)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
 
-	return os.WriteFile(cm.configFilePath, yamlData, 0644)
+	// WriteFile only sets the mode of a new file, an existing one may be readable by others.
+	// It is restricted before the tokens are written.
+	if err := os.Chmod(cm.configFilePath, 0600); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to restrict the permissions of the config file: %w", err)
+	}
+
+	return os.WriteFile(cm.configFilePath, yamlData, 0600)
 }
 
-// ConfigExists checks if a configuration file already exists
-func (cm *DefaultConfigManager) configFileExists() bool {
-	_, err := os.Stat(cm.configFilePath)
-	return !os.IsNotExist(err)
+// RenderConfig returns the content SaveConfig writes for cfg, with the secrets redacted so
+// it can be shown. Only the settings that differ from the system-wide configuration are
+// written, so the others keep following it. Overrides of the environment aren't saved, and
+// the comments of the current file are kept.
+func (cm *DefaultConfigManager) RenderConfig(cfg config.Config) ([]byte, error) {
+	return cm.render(cfg, true)
+}
+
+// render encodes cfg for the user's file, see RenderConfig
+func (cm *DefaultConfigManager) render(cfg config.Config, redact bool) ([]byte, error) {
+	// Settings overridden by the environment keep their value from the files, unless they
+	// were changed since
+	config.RevertEnv(&cfg, cm.fileConfig, cm.loadedConfig, cm.envOverrides)
+
+	// Tokens read from a file or the keychain must not end up in the config file, the
+	// others are kept with the credentials of their provider
+	if cfg.LLM.StoresToken() && cfg.LLM.RequiresToken() {
+		cfg.LLM.Credentials = maps.Clone(cfg.LLM.Credentials)
+		cfg.LLM.SetProviderToken(cfg.LLM.Provider, cfg.LLM.Token)
+	}
+	cfg.LLM.Token = ""
+
+	var system config.Config
+	systemLoaded, err := decodeConfigFile(cm.systemConfigPath, &system)
+	if err != nil {
+		return nil, fmt.Errorf("system config file %s: %w", cm.systemConfigPath, err)
+	}
+
+	if redact {
+		cfg = config.Redact(cfg)
+		system = config.Redact(system)
+	}
+
+	// The current file is only read to keep its comments, so failing to read it isn't fatal
+	previous, _ := os.ReadFile(cm.configFilePath)
+
+	if !systemLoaded {
+		return config.Marshal(cfg, previous)
+	}
+	return config.MarshalOverrides(cfg, system, previous)
 }
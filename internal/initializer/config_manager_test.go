@@ -0,0 +1,198 @@
+package initializer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfigManager_LoadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  *string
+		wantErr  error
+		wantName string
+	}{
+		{name: "missing file", content: nil, wantErr: config.ErrConfigNotInitialized},
+		{name: "empty file", content: ptr(""), wantErr: config.ErrConfigNotInitialized},
+		{name: "blank file", content: ptr("  \n\n\t\n"), wantErr: config.ErrConfigNotInitialized},
+		{name: "only comments", content: ptr("# filled in by echoy init\n"), wantErr: config.ErrConfigNotInitialized},
+		{name: "configured", content: ptr("Assistant:\n  name: Echo\nllm:\n  provider: echo\n"), wantName: "Echo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if tt.content != nil {
+				require.NoError(t, os.WriteFile(configPath, []byte(*tt.content), 0600))
+			}
+
+			cfg, err := NewDefaultConfigManager(configPath).LoadConfig()
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, new(config.Config).Default(), cfg)
+				if tt.content == nil {
+					assert.NoFileExists(t, configPath, "init writes the configuration, not loading it")
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, cfg.Assistant.Name)
+		})
+	}
+}
+
+func TestDefaultConfigManager_LoadConfig_Invalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("llm: [unclosed\n"), 0600))
+
+	_, err := NewDefaultConfigManager(configPath).LoadConfig()
+
+	assert.ErrorContains(t, err, "failed to parse config file")
+	assert.NotErrorIs(t, err, config.ErrConfigNotInitialized)
+}
+
+func TestDefaultConfigManager_SaveConfig_KeepsComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("llm:\n    # my provider\n    provider: echo\n"), 0600))
+
+	cm := NewDefaultConfigManager(configPath)
+	cfg, err := cm.LoadConfig()
+	require.NoError(t, err)
+
+	cfg.LLM.Model = "echo"
+	require.NoError(t, cm.SaveConfig(cfg))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "llm:\n    # my provider\n    provider: echo\n")
+
+	reloaded, err := cm.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "echo", reloaded.LLM.Model)
+}
+
+func TestDefaultConfigManager_SaveConfig_RestrictsPermissions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("llm:\n    provider: echo\n"), 0644))
+	require.NoError(t, os.Chmod(configPath, 0644))
+
+	cm := NewDefaultConfigManager(configPath)
+	cfg, err := cm.LoadConfig()
+	require.NoError(t, err)
+	require.NoError(t, cm.SaveConfig(cfg))
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "an existing config file should only be readable by its owner")
+}
+
+func TestDefaultConfigManager_EnvOverrides(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("llm:\n    provider: echo\n    model: echo\n    streaming: false\n"), 0600))
+	t.Setenv("ECHOY_LLM_MODEL", "echo-large")
+	t.Setenv("ECHOY_LLM_STREAMING", "true")
+
+	cm := NewDefaultConfigManager(configPath).WithSystemConfigPath("")
+	cfg, err := cm.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "echo-large", cfg.LLM.Model, "the environment takes precedence over the file")
+	assert.True(t, cfg.LLM.Streaming)
+
+	cfg.LLM.Streaming = false
+	require.NoError(t, cm.SaveConfig(cfg))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "model: echo\n", "overrides of the environment should not be saved")
+	assert.NotContains(t, string(data), "echo-large")
+
+	t.Setenv("ECHOY_LLM_MAX_TOKENS", "many")
+	_, err = cm.LoadConfig()
+	assert.ErrorContains(t, err, "ECHOY_LLM_MAX_TOKENS")
+}
+
+func TestDefaultConfigManager_SaveConfig_KeepsTokensPerProvider(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("llm:\n    provider: openai\n    token: sk-openai\n"), 0600))
+
+	cm := NewDefaultConfigManager(configPath)
+	cfg, err := cm.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", cfg.LLM.ProviderToken("openai"), "a token saved before credentials were kept per provider should be read")
+
+	cfg.LLM.Provider = "anthropic"
+	cfg.LLM.Token = "sk-anthropic"
+	cfg.LLM.SetProviderToken("openai", "sk-openai")
+	require.NoError(t, cm.SaveConfig(cfg))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "\n    token: sk-", "tokens should only be saved with the credentials of their provider")
+
+	reloaded, err := cm.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-anthropic", reloaded.LLM.ProviderToken("anthropic"))
+	assert.Equal(t, "sk-openai", reloaded.LLM.ProviderToken("openai"), "switching providers should keep the token of the previous one")
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestDefaultConfigManager_LoadConfig_SystemConfig(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := filepath.Join(dir, "system.yaml")
+	userPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(systemPath, []byte("Assistant:\n  name: Corp\nllm:\n  provider: echo\n  model: echo-1\n"), 0644))
+
+	manager := NewDefaultConfigManager(userPath).WithSystemConfigPath(systemPath)
+
+	cfg, err := manager.LoadConfig()
+	require.NoError(t, err, "the system configuration alone should be enough")
+	assert.Equal(t, "Corp", cfg.Assistant.Name)
+	assert.Equal(t, "echo-1", cfg.LLM.Model)
+
+	require.NoError(t, os.WriteFile(userPath, []byte("llm:\n  model: echo-2\n"), 0600))
+	cfg, err = manager.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Corp", cfg.Assistant.Name, "settings the user leaves out keep their system value")
+	assert.Equal(t, "echo", cfg.LLM.Provider)
+	assert.Equal(t, "echo-2", cfg.LLM.Model, "the user's settings take precedence")
+
+	require.NoError(t, os.WriteFile(systemPath, []byte("llm: [unclosed\n"), 0644))
+	_, err = manager.LoadConfig()
+	assert.ErrorContains(t, err, "system config file "+systemPath)
+}
+
+func TestDefaultConfigManager_SaveConfig_SystemConfig(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := filepath.Join(dir, "system.yaml")
+	userPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(systemPath, []byte("Assistant:\n  name: Corp\nllm:\n  provider: echo\n  model: echo-1\n"), 0644))
+
+	manager := NewDefaultConfigManager(userPath).WithSystemConfigPath(systemPath)
+	cfg, err := manager.LoadConfig()
+	require.NoError(t, err)
+
+	cfg.LLM.Model = "echo-2"
+	require.NoError(t, manager.SaveConfig(cfg))
+
+	saved, err := os.ReadFile(userPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "model: echo-2")
+	assert.NotContains(t, string(saved), "Corp", "settings equal to the system ones should not be saved")
+	assert.NotContains(t, string(saved), "provider:")
+
+	require.NoError(t, os.WriteFile(systemPath, []byte("Assistant:\n  name: Corp Assistant\nllm:\n  provider: echo\n  model: echo-1\n"), 0644))
+	cfg, err = manager.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Corp Assistant", cfg.Assistant.Name, "settings the user didn't change should keep following the system ones")
+	assert.Equal(t, "echo-2", cfg.LLM.Model)
+}
@@ -1,37 +1,65 @@
 package initializer
 
 import (
+	"errors"
 	"fmt"
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/llm"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	"github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
+	"io"
+	"os"
 )
 
 // Initializer handles the interactive setup process
 type Initializer struct {
-	Config        config.Config
-	IsUpdateMode  bool
+	Config       config.Config
+	IsUpdateMode bool
+	// DryRun prints the resulting configuration instead of saving it
+	DryRun        bool
 	configManager ConfigManager
 	log           logger.Logger
 	appConfig     *config.AppConfig
 	cliTheme      *theme.Manager
+	// stdout receives the configuration printed by a dry run
+	stdout io.Writer
+	// confirm asks the user a yes or no question
+	confirm func(message string) (bool, error)
 }
 
 // ConfigManager interface for loading/saving configuration
 type ConfigManager interface {
 	LoadConfig() (config.Config, error)
 	SaveConfig(config.Config) error
+	// RenderConfig returns what SaveConfig would write, with the secrets redacted
+	RenderConfig(config.Config) ([]byte, error)
 }
 
 // DefaultConfigManager implements ConfigManager with real file operations
 type DefaultConfigManager struct {
 	configFilePath string
+	// systemConfigPath is the system-wide configuration the user's is layered on
+	systemConfigPath string
+
+	// fileConfig is the last configuration loaded before the environment overrides were
+	// applied, loadedConfig the one returned with them and envOverrides their paths.
+	// SaveConfig reverts them so they don't end up in the file.
+	fileConfig   config.Config
+	loadedConfig config.Config
+	envOverrides []string
 }
 
 func NewDefaultConfigManager(configFilePath string) *DefaultConfigManager {
-	return &DefaultConfigManager{configFilePath: configFilePath}
+	return &DefaultConfigManager{configFilePath: configFilePath, systemConfigPath: config.SystemConfigPath()}
+}
+
+// WithSystemConfigPath sets the system-wide configuration the user's is layered on. An
+// empty path only loads the configuration of the user.
+func (cm *DefaultConfigManager) WithSystemConfigPath(path string) *DefaultConfigManager {
+	cm.systemConfigPath = path
+	return cm
 }
 
 // NewInitializer creates a new initializer with default dependencies
@@ -41,6 +69,8 @@ func NewInitializer(log logger.Logger, appCfg *config.AppConfig, theme *theme.Ma
 		appConfig:     appCfg,
 		configManager: configManager,
 		cliTheme:      theme,
+		stdout:        os.Stdout,
+		confirm:       askConfirm,
 	}
 }
 
@@ -50,25 +80,37 @@ func (i *Initializer) WithConfigManager(cm ConfigManager) *Initializer {
 	return i
 }
 
+// WithDryRun makes Run print the resulting configuration instead of saving it
+func (i *Initializer) WithDryRun(dryRun bool) *Initializer {
+	i.DryRun = dryRun
+	return i
+}
+
 // Run starts the interactive configuration process
 func (i *Initializer) Run() error {
 	i.log.Debug("Starting configuration process", nil)
 
 	var err error
-	i.IsUpdateMode = true
+	var previous config.Config
+
+	i.Config, err = i.configManager.LoadConfig()
+	switch {
+	case errors.Is(err, config.ErrConfigNotInitialized):
+		i.IsUpdateMode = false
+	case err != nil:
+		i.log.Errorf("error loading configuration: %v", err)
+		return fmt.Errorf("error loading configuration: %v", err)
+	default:
+		i.IsUpdateMode = true
+	}
 	i.log.Debugf("Update mode: %v", i.IsUpdateMode)
 
 	if i.IsUpdateMode {
-		i.Config, err = i.configManager.LoadConfig()
-		if err != nil {
-			i.log.Errorf("error loading configuration: %v", err)
-			return fmt.Errorf("error loading configuration: %v", err)
-		}
+		previous = i.Config
 
 		i.cliTheme.GetCurrentTheme().Primary().Println("🔄 Configuration Update Mode")
 		i.cliTheme.GetCurrentTheme().Warning().Println("You are about to update your existing configuration. Press Enter to keep current values, or provide new ones.")
 	} else {
-		i.Config = config.Config{}
 		i.cliTheme.GetCurrentTheme().Primary().Println("🔧 Initial Configuration")
 		i.cliTheme.GetCurrentTheme().Info().Println("Please configure your assistant for the first time. You can always change the configuration later.")
 	}
@@ -99,6 +141,26 @@ func (i *Initializer) Run() error {
 		return fmt.Errorf("error configuring telemetry: %v", err)
 	}
 
+	if i.DryRun {
+		return i.printDryRun(previous)
+	}
+
+	return i.save(previous)
+}
+
+// save writes the configuration. In update mode the user confirms the changes to previous
+// first, and nothing is written without changes.
+func (i *Initializer) save(previous config.Config) error {
+	if i.IsUpdateMode {
+		save, err := i.confirmChanges(config.Diff(previous, i.Config))
+		if err != nil {
+			return fmt.Errorf("error confirming configuration changes: %v", err)
+		}
+		if !save {
+			return nil
+		}
+	}
+
 	i.log.Debugf("Saving configuration: %v", i.Config)
 	if err := i.configManager.SaveConfig(i.Config); err != nil {
 		i.log.Errorf("error saving configuration: %v", err)
@@ -109,3 +171,79 @@ func (i *Initializer) Run() error {
 	i.cliTheme.GetCurrentTheme().Success().Println("\n✅ Configuration updated successfully!")
 	return nil
 }
+
+// printDryRun prints the configuration file Run would save, with secrets redacted, and in
+// update mode the changes to previous
+func (i *Initializer) printDryRun(previous config.Config) error {
+	t := i.cliTheme.GetCurrentTheme()
+
+	yamlData, err := i.configManager.RenderConfig(i.Config)
+	if err != nil {
+		return fmt.Errorf("error encoding configuration: %v", err)
+	}
+
+	if i.IsUpdateMode {
+		fmt.Fprintln(i.stdout)
+		changes := config.Diff(previous, i.Config)
+		if len(changes) == 0 {
+			t.Info().Println("No changes, your configuration would stay the same.")
+		} else {
+			printChanges(t, changes)
+		}
+	}
+
+	fmt.Fprintln(i.stdout)
+	t.Primary().Println("📄 Resulting configuration")
+	fmt.Fprint(i.stdout, string(yamlData))
+	fmt.Fprintln(i.stdout)
+	t.Warning().Println("Dry run: the configuration was not saved.")
+
+	return nil
+}
+
+// confirmChanges shows what is about to be overwritten and asks the user to confirm. It
+// returns false without asking if nothing changed.
+func (i *Initializer) confirmChanges(changes []config.FieldChange) (bool, error) {
+	t := i.cliTheme.GetCurrentTheme()
+
+	fmt.Fprintln(i.stdout)
+	if len(changes) == 0 {
+		t.Info().Println("No changes to save, your configuration is unchanged.")
+		return false, nil
+	}
+
+	printChanges(t, changes)
+	fmt.Fprintln(i.stdout)
+
+	save, err := i.confirm("Save these changes?")
+	if err != nil {
+		return false, err
+	}
+
+	if !save {
+		t.Warning().Println("Changes discarded, your configuration is unchanged.")
+	}
+
+	return save, nil
+}
+
+// askConfirm asks a yes or no question in the terminal, yes being the default
+func askConfirm(message string) (bool, error) {
+	answer := true
+	prompt := &survey.Confirm{
+		Message: message,
+		Default: true,
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return false, err
+	}
+
+	return answer, nil
+}
+
+func printChanges(t theme.Theme, changes []config.FieldChange) {
+	t.Primary().Println("📋 Configuration changes")
+	for _, change := range changes {
+		t.Info().Println("  " + change.String())
+	}
+}
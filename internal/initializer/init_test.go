@@ -0,0 +1,144 @@
+package initializer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitializer_PrintDryRun(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "llm:\n    # my provider\n    provider: openai\n    token: sk-secret\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0600))
+
+	var output bytes.Buffer
+	themeManager := theme.NewManager(theme.NewDefaultTheme(), &config.AppConfig{}, nil).SetOutput(&output)
+	cm := NewDefaultConfigManager(configPath).WithSystemConfigPath("")
+	initializer := NewInitializer(logger.NewNoopLogger(), &config.AppConfig{}, themeManager, cm).WithDryRun(true)
+	initializer.stdout = &output
+
+	previous, err := cm.LoadConfig()
+	require.NoError(t, err)
+	initializer.Config = previous
+	initializer.IsUpdateMode = true
+	initializer.Config.LLM.Model = "gpt-4o"
+
+	require.NoError(t, initializer.printDryRun(previous))
+
+	printed := output.String()
+	assert.Contains(t, printed, "llm.model", "the changes should be listed")
+	assert.Contains(t, printed, "# my provider\n    provider: openai\n", "the file should be printed as saved, with its comments")
+	assert.Contains(t, printed, "model: gpt-4o")
+	assert.Contains(t, printed, "credentials:", "the token should be printed where it is saved")
+	assert.NotContains(t, printed, "sk-secret", "secrets should be redacted")
+	assert.Contains(t, printed, "Dry run: the configuration was not saved.")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data), "a dry run shouldn't touch the file")
+}
+
+func TestInitializer_Save(t *testing.T) {
+	showWelcome, hideWelcome := true, false
+	previous := (&config.Config{}).Default()
+	previous.Chat.ShowWelcome = &showWelcome
+
+	tests := []struct {
+		name       string
+		updateMode bool
+		modify     func(c *config.Config)
+		answer     bool
+		answerErr  error
+		wantAsked  bool
+		wantSaved  bool
+		wantErr    bool
+		wantOutput []string
+	}{
+		{
+			name:       "first run saves without asking",
+			modify:     func(c *config.Config) {},
+			wantSaved:  true,
+			wantOutput: []string{"Configuration updated successfully"},
+		},
+		{
+			name:       "update without changes is skipped",
+			updateMode: true,
+			modify:     func(c *config.Config) {},
+			wantOutput: []string{"No changes to save"},
+		},
+		{
+			name:       "confirmed changes are saved",
+			updateMode: true,
+			modify:     func(c *config.Config) { c.Chat.ShowWelcome = &hideWelcome },
+			answer:     true,
+			wantAsked:  true,
+			wantSaved:  true,
+			wantOutput: []string{"chat.show_welcome: true → false", "Configuration updated successfully"},
+		},
+		{
+			name:       "declined changes are discarded",
+			updateMode: true,
+			modify:     func(c *config.Config) { c.LLM.Model = "gpt-4o" },
+			answer:     false,
+			wantAsked:  true,
+			wantOutput: []string{`llm.model: "gpt-3.5-turbo" → "gpt-4o"`, "Changes discarded"},
+		},
+		{
+			name:       "failed prompt",
+			updateMode: true,
+			modify:     func(c *config.Config) { c.LLM.Model = "gpt-4o" },
+			answerErr:  errors.New("interrupt"),
+			wantAsked:  true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			themeManager := theme.NewManager(theme.NewDefaultTheme(), &config.AppConfig{}, nil).SetOutput(&output)
+			cm := &MockConfigManager{}
+			if tt.wantSaved {
+				cm.On("SaveConfig", mock.Anything).Return(nil).Once()
+			}
+
+			initializer := NewInitializer(logger.NewNoopLogger(), &config.AppConfig{}, themeManager, cm)
+			initializer.stdout = &output
+			asked := false
+			initializer.confirm = func(message string) (bool, error) {
+				asked = true
+				assert.Equal(t, "Save these changes?", message)
+				return tt.answer, tt.answerErr
+			}
+
+			initializer.IsUpdateMode = tt.updateMode
+			initializer.Config = previous
+			tt.modify(&initializer.Config)
+
+			err := initializer.save(previous)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantAsked, asked)
+			cm.AssertExpectations(t)
+			if !tt.wantSaved {
+				cm.AssertNotCalled(t, "SaveConfig", mock.Anything)
+			}
+			for _, want := range tt.wantOutput {
+				assert.Contains(t, output.String(), want)
+			}
+		})
+	}
+}
@@ -24,6 +24,11 @@ func (m *MockConfigManager) SaveConfig(cfg config.Config) error {
 	return args.Error(0)
 }
 
+func (m *MockConfigManager) RenderConfig(cfg config.Config) ([]byte, error) {
+	args := m.Called(cfg)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
 func (m *MockConfigManager) ConfigExists() bool {
 	args := m.Called()
 	return args.Bool(0)
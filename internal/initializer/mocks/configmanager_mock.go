@@ -76,6 +76,64 @@ func (_c *MockConfigManager_LoadConfig_Call) RunAndReturn(run func() (config.Con
 	return _c
 }
 
+// RenderConfig provides a mock function with given fields: _a0
+func (_m *MockConfigManager) RenderConfig(_a0 config.Config) ([]byte, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenderConfig")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(config.Config) ([]byte, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(config.Config) []byte); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(config.Config) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConfigManager_RenderConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenderConfig'
+type MockConfigManager_RenderConfig_Call struct {
+	*mock.Call
+}
+
+// RenderConfig is a helper method to define mock.On call
+//   - _a0 config.Config
+func (_e *MockConfigManager_Expecter) RenderConfig(_a0 interface{}) *MockConfigManager_RenderConfig_Call {
+	return &MockConfigManager_RenderConfig_Call{Call: _e.mock.On("RenderConfig", _a0)}
+}
+
+func (_c *MockConfigManager_RenderConfig_Call) Run(run func(_a0 config.Config)) *MockConfigManager_RenderConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(config.Config))
+	})
+	return _c
+}
+
+func (_c *MockConfigManager_RenderConfig_Call) Return(_a0 []byte, _a1 error) *MockConfigManager_RenderConfig_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConfigManager_RenderConfig_Call) RunAndReturn(run func(config.Config) ([]byte, error)) *MockConfigManager_RenderConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SaveConfig provides a mock function with given fields: _a0
 func (_m *MockConfigManager) SaveConfig(_a0 config.Config) error {
 	ret := _m.Called(_a0)
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/packages/ssestream"
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/goai"
+)
+
+// anthropicClient implements goai.AnthropicClientProvider like goai.AnthropicClient, with
+// the base URL and the extra headers of the configuration
+type anthropicClient struct {
+	messages *anthropic.MessageService
+}
+
+var _ goai.AnthropicClientProvider = (*anthropicClient)(nil)
+
+// newAnthropicClient creates the client of the anthropic provider, calling the API with
+// httpClient unless it is nil. The base URL of llmConfig must have been validated, see
+// validateEndpoint.
+func newAnthropicClient(token string, llmConfig config.LLMConfig, httpClient *http.Client) *anthropicClient {
+	opts := []option.RequestOption{option.WithAPIKey(token)}
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+
+	if llmConfig.BaseURL != "" {
+		// The API paths are resolved against the base URL, which drops its last segment
+		// unless it ends with a slash
+		baseURL := llmConfig.BaseURL
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+
+	for name, value := range llmConfig.ExtraHeaders {
+		opts = append(opts, option.WithHeader(name, value))
+	}
+
+	client := anthropic.NewClient(opts...)
+	return &anthropicClient{messages: client.Messages}
+}
+
+// CreateMessage implements goai.AnthropicClientProvider
+func (c *anthropicClient) CreateMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	return c.messages.New(ctx, params)
+}
+
+// CreateStreamingMessage implements goai.AnthropicClientProvider
+func (c *anthropicClient) CreateStreamingMessage(ctx context.Context, params anthropic.MessageNewParams) *ssestream.Stream[anthropic.MessageStreamEvent] {
+	return c.messages.NewStreaming(ctx, params)
+}
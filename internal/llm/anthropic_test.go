@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLLMService_AnthropicGateway(t *testing.T) {
+	var got *http.Request
+	gateway := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer gateway.Close()
+
+	// The gateway's certificate is only trusted through http.ca_file
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gateway.Certificate().Raw}), 0600))
+
+	llmConfig := config.LLMConfig{
+		Provider:     "anthropic",
+		Model:        "claude-3",
+		Token:        "test-token",
+		BaseURL:      gateway.URL + "/anthropic",
+		ExtraHeaders: map[string]string{"Helicone-Auth": "Bearer gateway-key"},
+	}
+	httpClient, err := NewHTTPClient(llmConfig, config.HTTPConfig{CAFile: caFile}, "1.2.0")
+	require.NoError(t, err)
+
+	service, err := NewLLMService(llmConfig, httpClient)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Ping(context.Background()))
+	require.NotNil(t, got)
+	assert.Equal(t, "/anthropic/v1/messages", got.URL.Path)
+	assert.Equal(t, "Bearer gateway-key", got.Header.Get("Helicone-Auth"))
+	assert.Equal(t, "test-token", got.Header.Get("X-Api-Key"))
+}
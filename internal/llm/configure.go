@@ -90,26 +90,20 @@ func ConfigureLLM(themeManager *theme.Manager, config *config.Config) error {
 		return err
 	}
 
-	var apiToken string
-	promptToken := &survey.Password{
-		Message: "Enter your API token:",
-		Help:    "This will be used to authenticate with the LLM provider",
-	}
-
-	if config.LLM.Token != "" {
-		color.Yellow("API token is already set. Press Enter to keep the existing token or enter a new one.")
-	}
-
-	err = survey.AskOne(promptToken, &apiToken)
-	if err != nil {
-		return err
-	}
-
-	if apiToken == "" {
-		if config.LLM.Token == "" {
-			return fmt.Errorf("API token is required")
+	rememberToken(&config.LLM)
+
+	apiToken := config.LLM.ProviderToken(providerID)
+	if !requiresToken(providerID) {
+		apiToken = ""
+		color.Yellow("The %s provider doesn't call any API, skipping the token prompt.", providerID)
+	} else if config.LLM.StoresToken() {
+		apiToken, err = askToken(apiToken)
+		if err != nil {
+			return err
 		}
-		apiToken = config.LLM.Token
+		config.LLM.SetProviderToken(providerID, apiToken)
+	} else {
+		color.Yellow("API token is read from the %s, skipping the token prompt.", tokenSourceLabel(config.LLM))
 	}
 
 	config.LLM.Provider = providerID
@@ -210,3 +204,51 @@ func ConfigureLLM(themeManager *theme.Manager, config *config.Config) error {
 
 	return nil
 }
+
+// askToken prompts for the API token. An empty answer keeps current.
+func askToken(current string) (string, error) {
+	var apiToken string
+	promptToken := &survey.Password{
+		Message: "Enter your API token:",
+		Help:    "This will be used to authenticate with the LLM provider",
+	}
+
+	if current != "" {
+		color.Yellow("API token is already set. Press Enter to keep the existing token or enter a new one.")
+	}
+
+	if err := survey.AskOne(promptToken, &apiToken); err != nil {
+		return "", err
+	}
+
+	if apiToken == "" {
+		if current == "" {
+			return "", fmt.Errorf("API token is required")
+		}
+		apiToken = current
+	}
+
+	return apiToken, nil
+}
+
+// rememberToken moves the token of the current provider to its credentials, so switching
+// back doesn't ask again. Without a token, e.g. one already kept in the credentials, they
+// are left alone.
+func rememberToken(llmConfig *config.LLMConfig) {
+	if llmConfig.Token != "" && llmConfig.StoresToken() && requiresToken(llmConfig.Provider) {
+		llmConfig.SetProviderToken(llmConfig.Provider, llmConfig.Token)
+	}
+}
+
+// tokenSourceLabel describes where a token that isn't stored in the config file comes from
+func tokenSourceLabel(llmConfig config.LLMConfig) string {
+	if llmConfig.ResolvedTokenSource() == config.TokenSourceFile {
+		return "file " + llmConfig.TokenFile
+	}
+	return llmConfig.ResolvedTokenSource()
+}
+
+// requiresToken reports whether the provider with providerID needs an API token
+func requiresToken(providerID string) bool {
+	return config.LLMConfig{Provider: providerID}.RequiresToken()
+}
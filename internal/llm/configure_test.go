@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRememberToken(t *testing.T) {
+	t.Run("keeps a token already in the credentials", func(t *testing.T) {
+		llmConfig := config.LLMConfig{
+			Provider:    "openai",
+			Credentials: map[string]config.ProviderCreds{"openai": {Token: "sk-stored"}},
+		}
+
+		rememberToken(&llmConfig)
+		assert.Equal(t, "sk-stored", llmConfig.ProviderToken("openai"), "re-running init should not ask for the token again")
+	})
+
+	t.Run("moves the token of the current provider to its credentials", func(t *testing.T) {
+		llmConfig := config.LLMConfig{Provider: "openai", Token: "sk-legacy"}
+
+		rememberToken(&llmConfig)
+		assert.Equal(t, "sk-legacy", llmConfig.Credentials["openai"].Token)
+	})
+}
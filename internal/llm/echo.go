@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shaharia-lab/goai"
+)
+
+// echoWordPattern matches a word together with the whitespace before it
+var echoWordPattern = regexp.MustCompile(`\s*\S+`)
+
+// echoPrefix marks every answer of the echo provider, so it is never mistaken for a model's
+const echoPrefix = "[echo, not a real model]"
+
+// EchoProvider is a goai.LLMProvider for trying Echoy without an API token, e.g. for demos
+// or web UI development. It answers with the last user message and streams the answer
+// word by word, waiting latency before each word.
+type EchoProvider struct {
+	latency time.Duration
+}
+
+// NewEchoProvider creates an EchoProvider
+func NewEchoProvider(latency time.Duration) *EchoProvider {
+	return &EchoProvider{latency: latency}
+}
+
+// GetResponse implements goai.LLMProvider
+func (p *EchoProvider) GetResponse(ctx context.Context, messages []goai.LLMMessage, config goai.LLMRequestConfig) (goai.LLMResponse, error) {
+	started := time.Now()
+	words := p.answer(messages)
+
+	for range words {
+		if err := p.wait(ctx); err != nil {
+			return goai.LLMResponse{}, err
+		}
+	}
+
+	return goai.LLMResponse{
+		Text:             strings.Join(words, ""),
+		TotalInputToken:  countWords(messages),
+		TotalOutputToken: len(words),
+		CompletionTime:   time.Since(started).Seconds(),
+	}, nil
+}
+
+// GetStreamingResponse implements goai.LLMProvider
+func (p *EchoProvider) GetStreamingResponse(ctx context.Context, messages []goai.LLMMessage, config goai.LLMRequestConfig) (<-chan goai.StreamingLLMResponse, error) {
+	words := p.answer(messages)
+	responses := make(chan goai.StreamingLLMResponse)
+
+	go func() {
+		defer close(responses)
+
+		for _, word := range words {
+			response := goai.StreamingLLMResponse{Text: word, TokenCount: 1}
+			if err := p.wait(ctx); err != nil {
+				response = goai.StreamingLLMResponse{Error: err, Done: true}
+			}
+
+			select {
+			case responses <- response:
+			case <-ctx.Done():
+				return
+			}
+			if response.Error != nil {
+				return
+			}
+		}
+
+		select {
+		case responses <- goai.StreamingLLMResponse{Done: true}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return responses, nil
+}
+
+// answer returns the words of the answer to messages, each with the whitespace before it
+func (p *EchoProvider) answer(messages []goai.LLMMessage) []string {
+	question := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == goai.UserRole {
+			question = messages[i].Text
+			break
+		}
+	}
+
+	text := echoPrefix + " You said: " + question
+	if question == "" {
+		text = echoPrefix + " There was no question to echo."
+	}
+
+	return echoWordPattern.FindAllString(text, -1)
+}
+
+func (p *EchoProvider) wait(ctx context.Context) error {
+	if p.latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(p.latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("echo provider: %w", ctx.Err())
+	}
+}
+
+// countWords approximates the input tokens by the number of words
+func countWords(messages []goai.LLMMessage) int {
+	count := 0
+	for _, message := range messages {
+		count += len(strings.Fields(message.Text))
+	}
+
+	return count
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEchoProvider_GetResponse(t *testing.T) {
+	provider := NewEchoProvider(0)
+
+	response, err := provider.GetResponse(context.Background(), []goai.LLMMessage{
+		{Role: goai.UserRole, Text: "first question"},
+		{Role: goai.AssistantRole, Text: "an answer"},
+		{Role: goai.UserRole, Text: "what is  Go?"},
+	}, goai.LLMRequestConfig{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "[echo, not a real model] You said: what is  Go?", response.Text)
+	assert.Equal(t, 7, response.TotalInputToken)
+	assert.Equal(t, 10, response.TotalOutputToken)
+}
+
+func TestEchoProvider_GetStreamingResponse(t *testing.T) {
+	provider := NewEchoProvider(time.Millisecond)
+
+	responses, err := provider.GetStreamingResponse(context.Background(), []goai.LLMMessage{
+		{Role: goai.UserRole, Text: "hello there"},
+	}, goai.LLMRequestConfig{})
+	require.NoError(t, err)
+
+	var text strings.Builder
+	var chunks int
+	var done bool
+	for response := range responses {
+		require.NoError(t, response.Error)
+		text.WriteString(response.Text)
+		chunks++
+		done = response.Done
+	}
+
+	assert.Equal(t, "[echo, not a real model] You said: hello there", text.String())
+	assert.Equal(t, 10, chunks, "one chunk per word and the final one")
+	assert.True(t, done)
+}
+
+func TestEchoProvider_Cancelled(t *testing.T) {
+	provider := NewEchoProvider(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := provider.GetResponse(ctx, []goai.LLMMessage{{Role: goai.UserRole, Text: "hello"}}, goai.LLMRequestConfig{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrorKind classifies why the LLM provider can't be used
+type ErrorKind string
+
+const (
+	// ErrorKindAuth means the token was rejected
+	ErrorKindAuth ErrorKind = "auth"
+	// ErrorKindNetwork means the provider couldn't be reached
+	ErrorKindNetwork ErrorKind = "network"
+	// ErrorKindRateLimit means the provider is throttling requests or the quota is used up
+	ErrorKindRateLimit ErrorKind = "rate_limit"
+	// ErrorKindUnknown is any other failure
+	ErrorKindUnknown ErrorKind = "unknown"
+)
+
+// ProviderError is a failed request to the LLM provider together with its classification
+type ProviderError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	switch e.Kind {
+	case ErrorKindAuth:
+		return fmt.Sprintf("the LLM provider rejected the token: %v", e.Err)
+	case ErrorKindNetwork:
+		return fmt.Sprintf("the LLM provider is not reachable: %v", e.Err)
+	case ErrorKindRateLimit:
+		return fmt.Sprintf("the LLM provider is rate limiting requests: %v", e.Err)
+	default:
+		return fmt.Sprintf("the LLM provider request failed: %v", e.Err)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError wraps err in a ProviderError. Errors carrying an HTTP status are
+// classified by it, other errors by their type or, as a last resort, their message.
+func ClassifyError(err error) *ProviderError {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr
+	}
+
+	return &ProviderError{Kind: classify(err), Err: err}
+}
+
+func classify(err error) ErrorKind {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		if kind, ok := kindForStatus(apiErr.StatusCode); ok {
+			return kind
+		}
+		return ErrorKindUnknown
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindNetwork
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case containsAny(message, "401", "403", "unauthorized", "unauthenticated", "permission denied", "invalid api key", "api key not valid"):
+		return ErrorKindAuth
+	case containsAny(message, "429", "rate limit", "quota", "resource exhausted", "resource_exhausted"):
+		return ErrorKindRateLimit
+	case containsAny(message, "connection refused", "no such host", "i/o timeout", "network is unreachable"):
+		return ErrorKindNetwork
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+func kindForStatus(status int) (ErrorKind, bool) {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorKindAuth, true
+	case http.StatusTooManyRequests:
+		return ErrorKindRateLimit, true
+	default:
+		return "", false
+	}
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
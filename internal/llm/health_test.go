@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{
+			name: "anthropic unauthorized",
+			err:  fmt.Errorf("request failed: %w", &anthropic.Error{StatusCode: http.StatusUnauthorized}),
+			want: ErrorKindAuth,
+		},
+		{
+			name: "anthropic rate limited",
+			err:  &anthropic.Error{StatusCode: http.StatusTooManyRequests},
+			want: ErrorKindRateLimit,
+		},
+		{
+			name: "anthropic server error",
+			err:  &anthropic.Error{StatusCode: http.StatusInternalServerError},
+			want: ErrorKindUnknown,
+		},
+		{
+			name: "network error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: ErrorKindNetwork,
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("generate: %w", context.DeadlineExceeded),
+			want: ErrorKindNetwork,
+		},
+		{
+			name: "invalid api key message",
+			err:  errors.New("googleapi: Error 400: API key not valid. Please pass a valid API key."),
+			want: ErrorKindAuth,
+		},
+		{
+			name: "quota message",
+			err:  errors.New("googleapi: Error 429: Resource has been exhausted (e.g. check quota)."),
+			want: ErrorKindRateLimit,
+		},
+		{
+			name: "anything else",
+			err:  errors.New("model not found"),
+			want: ErrorKindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyError(tt.err)
+			assert.Equal(t, tt.want, got.Kind)
+			assert.ErrorIs(t, got, tt.err)
+		})
+	}
+
+	t.Run("already classified", func(t *testing.T) {
+		classified := &ProviderError{Kind: ErrorKindAuth, Err: errors.New("bad token")}
+		assert.Same(t, classified, ClassifyError(fmt.Errorf("ping: %w", classified)))
+	})
+}
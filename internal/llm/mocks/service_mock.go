@@ -139,6 +139,52 @@ func (_c *MockService_GenerateStream_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// Ping provides a mock function with given fields: ctx
+func (_m *MockService) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockService_Ping_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ping'
+type MockService_Ping_Call struct {
+	*mock.Call
+}
+
+// Ping is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockService_Expecter) Ping(ctx interface{}) *MockService_Ping_Call {
+	return &MockService_Ping_Call{Call: _e.mock.On("Ping", ctx)}
+}
+
+func (_c *MockService_Ping_Call) Run(run func(ctx context.Context)) *MockService_Ping_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockService_Ping_Call) Return(_a0 error) *MockService_Ping_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockService_Ping_Call) RunAndReturn(run func(context.Context) error) *MockService_Ping_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockService creates a new instance of MockService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockService(t interface {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/httpx"
 	"github.com/shaharia-lab/goai"
 	"github.com/shaharia-lab/goai/observability"
 	"log"
+	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -14,6 +17,9 @@ import (
 type Service interface {
 	Generate(ctx context.Context, messages []goai.LLMMessage) (goai.LLMResponse, error)
 	GenerateStream(ctx context.Context, messages []goai.LLMMessage) (<-chan goai.StreamingLLMResponse, error)
+	// Ping checks that the provider is reachable and accepts the token. Failures are
+	// returned as a *ProviderError.
+	Ping(ctx context.Context) error
 }
 
 // ServiceImpl implements the Service interface
@@ -22,9 +28,10 @@ type ServiceImpl struct {
 	config   goai.LLMRequestConfig
 }
 
-// NewLLMService creates a new LLM service
-func NewLLMService(llmConfig config.LLMConfig) (*ServiceImpl, error) {
-	provider, err := buildLLMProvider(llmConfig)
+// NewLLMService creates a new LLM service. The provider is called with httpClient, see
+// NewHTTPClient, or the default client of its SDK if it is nil.
+func NewLLMService(llmConfig config.LLMConfig, httpClient *http.Client) (*ServiceImpl, error) {
+	provider, err := buildLLMProvider(llmConfig, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -57,24 +64,68 @@ func (s *ServiceImpl) GenerateStream(ctx context.Context, messages []goai.LLMMes
 	return llm.GenerateStream(ctx, messages)
 }
 
+// Ping implements the Service interface. It requests a single token completion, which is
+// the cheapest call that exercises both the connection and the token.
+func (s *ServiceImpl) Ping(ctx context.Context) error {
+	cfg := goai.NewRequestConfig(
+		goai.WithMaxToken(1),
+		goai.UseToolsProvider(goai.NewToolsProvider()),
+	)
+
+	_, err := goai.NewLLMRequest(cfg, s.provider).Generate(ctx, []goai.LLMMessage{
+		{Role: goai.UserRole, Text: "ping"},
+	})
+	if err != nil {
+		return ClassifyError(err)
+	}
+
+	return nil
+}
+
+// NewHTTPClient creates the HTTP client of the calls to the provider of llmConfig, trusting
+// the CAs of httpConfig. The wait for an answer, or for a streamed one to start, is bounded
+// by the request timeout of llmConfig; reading a streamed answer isn't.
+func NewHTTPClient(llmConfig config.LLMConfig, httpConfig config.HTTPConfig, version string) (*http.Client, error) {
+	return httpx.NewClient(httpx.Options{
+		Version:               version,
+		Timeout:               -1,
+		ResponseHeaderTimeout: llmConfig.ResolvedRequestTimeout(),
+		CAFile:                httpConfig.CAFile,
+	})
+}
+
 // buildLLMProvider creates the appropriate LLM provider based on config
-func buildLLMProvider(llmConfig config.LLMConfig) (goai.LLMProvider, error) {
+func buildLLMProvider(llmConfig config.LLMConfig, httpClient *http.Client) (goai.LLMProvider, error) {
 	if llmConfig.Provider == "" {
 		return nil, fmt.Errorf("llm provider not specified")
 	}
 
-	if llmConfig.Token == "" {
+	if !llmConfig.RequiresToken() {
+		return NewEchoProvider(llmConfig.EchoLatency), nil
+	}
+
+	token := llmConfig.ProviderToken(llmConfig.Provider)
+	if token == "" {
 		return nil, fmt.Errorf("token for LLM provider not specified")
 	}
 
+	if err := validateEndpoint(llmConfig); err != nil {
+		return nil, err
+	}
+
 	switch strings.ToLower(llmConfig.Provider) {
 	case "anthropic":
 		return goai.NewAnthropicLLMProvider(goai.AnthropicProviderConfig{
-			Client: goai.NewAnthropicClient(llmConfig.Token),
+			Client: newAnthropicClient(token, llmConfig, httpClient),
 			Model:  llmConfig.Model,
 		}), nil
 	case "gemini":
-		googleGeminiService, err := goai.NewGoogleGeminiService(llmConfig.Token, llmConfig.Model)
+		// The Gemini client talks gRPC, it can't be routed through an HTTP gateway
+		if llmConfig.BaseURL != "" || len(llmConfig.ExtraHeaders) > 0 {
+			return nil, fmt.Errorf("llm.base_url and llm.extra_headers aren't supported by the gemini provider")
+		}
+
+		googleGeminiService, err := goai.NewGoogleGeminiService(token, llmConfig.Model)
 		if err != nil {
 			log.Fatalf("Error creating Google Gemini Service: %v", err)
 		}
@@ -84,3 +135,25 @@ func buildLLMProvider(llmConfig config.LLMConfig) (goai.LLMProvider, error) {
 		return nil, fmt.Errorf("unsupported LLM provider: %s", llmConfig.Provider)
 	}
 }
+
+// validateEndpoint checks the base URL and the extra headers of llmConfig, which route the
+// calls to the provider through a gateway
+func validateEndpoint(llmConfig config.LLMConfig) error {
+	if llmConfig.BaseURL != "" {
+		baseURL, err := url.Parse(llmConfig.BaseURL)
+		if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return fmt.Errorf("invalid llm.base_url %q, expected an http or https URL such as https://gateway.example.com/anthropic", llmConfig.BaseURL)
+		}
+	}
+
+	for name, value := range llmConfig.ExtraHeaders {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q in llm.extra_headers", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value of the %s header in llm.extra_headers, it can't span lines", name)
+		}
+	}
+
+	return nil
+}
@@ -22,6 +22,14 @@ func Test_buildLLMProvider(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "echo provider without token",
+			config: config.LLMConfig{
+				Provider: "echo",
+				Model:    "echo",
+			},
+			wantErr: false,
+		},
 		{
 			name: "empty provider",
 			config: config.LLMConfig{
@@ -40,6 +48,36 @@ func Test_buildLLMProvider(t *testing.T) {
 			wantErr:    true,
 			errMessage: "token for LLM provider not specified",
 		},
+		{
+			name: "invalid base URL",
+			config: config.LLMConfig{
+				Provider: "anthropic",
+				Token:    "test-token",
+				BaseURL:  "gateway.example.com",
+			},
+			wantErr:    true,
+			errMessage: `invalid llm.base_url "gateway.example.com", expected an http or https URL such as https://gateway.example.com/anthropic`,
+		},
+		{
+			name: "invalid extra header",
+			config: config.LLMConfig{
+				Provider:     "anthropic",
+				Token:        "test-token",
+				ExtraHeaders: map[string]string{"Helicone Auth": "Bearer key"},
+			},
+			wantErr:    true,
+			errMessage: `invalid header name "Helicone Auth" in llm.extra_headers`,
+		},
+		{
+			name: "gemini through a gateway",
+			config: config.LLMConfig{
+				Provider: "gemini",
+				Token:    "test-token",
+				BaseURL:  "https://gateway.example.com/gemini",
+			},
+			wantErr:    true,
+			errMessage: "llm.base_url and llm.extra_headers aren't supported by the gemini provider",
+		},
 		{
 			name: "unsupported provider",
 			config: config.LLMConfig{
@@ -53,7 +91,7 @@ func Test_buildLLMProvider(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			provider, err := buildLLMProvider(tt.config)
+			provider, err := buildLLMProvider(tt.config, nil)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -6,6 +6,8 @@ type Model struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	ModelID     string `json:"modelId"`
+	// ContextWindow is the number of tokens the model accepts, prompt and answer together
+	ContextWindow int `json:"contextWindow,omitempty"`
 }
 
 // Provider represents a provider of large language models
@@ -0,0 +1,66 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockPanicReporter is an autogenerated mock type for the PanicReporter type
+type MockPanicReporter struct {
+	mock.Mock
+}
+
+type MockPanicReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockPanicReporter) EXPECT() *MockPanicReporter_Expecter {
+	return &MockPanicReporter_Expecter{mock: &_m.Mock}
+}
+
+// Execute provides a mock function with given fields: recovered, stack
+func (_m *MockPanicReporter) Execute(recovered interface{}, stack []byte) {
+	_m.Called(recovered, stack)
+}
+
+// MockPanicReporter_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type MockPanicReporter_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+//   - recovered interface{}
+//   - stack []byte
+func (_e *MockPanicReporter_Expecter) Execute(recovered interface{}, stack interface{}) *MockPanicReporter_Execute_Call {
+	return &MockPanicReporter_Execute_Call{Call: _e.mock.On("Execute", recovered, stack)}
+}
+
+func (_c *MockPanicReporter_Execute_Call) Run(run func(recovered interface{}, stack []byte)) *MockPanicReporter_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(interface{}), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockPanicReporter_Execute_Call) Return() *MockPanicReporter_Execute_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockPanicReporter_Execute_Call) RunAndReturn(run func(interface{}, []byte)) *MockPanicReporter_Execute_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockPanicReporter creates a new instance of MockPanicReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPanicReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPanicReporter {
+	mock := &MockPanicReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
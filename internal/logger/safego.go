@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicReporter is told about every panic recovered by SafeGo, e.g. to send it as telemetry
+type PanicReporter func(recovered interface{}, stack []byte)
+
+var (
+	panicReporterMu sync.RWMutex
+	panicReporter   PanicReporter
+)
+
+// SetPanicReporter sets the reporter told about recovered panics, nil disables reporting
+func SetPanicReporter(reporter PanicReporter) {
+	panicReporterMu.Lock()
+	defer panicReporterMu.Unlock()
+
+	panicReporter = reporter
+}
+
+// SafeGo runs fn in a new goroutine. A panic in fn is recovered and logged with its stack
+// trace instead of crashing the process, and passed on to the panic reporter if one is set.
+// Deferred calls of fn still run, so it can release what it holds.
+func SafeGo(fn func(), log Logger) {
+	go func() {
+		defer RecoverPanic(log)
+		fn()
+	}()
+}
+
+// RecoverPanic logs and reports a panic of the calling goroutine. It must be deferred.
+func RecoverPanic(log Logger) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	log.WithFields(Fields{
+		ErrorKey: fmt.Sprintf("%v", recovered),
+		"stack":  string(stack),
+	}).Error("Recovered from panic in goroutine")
+
+	panicReporterMu.RLock()
+	reporter := panicReporter
+	panicReporterMu.RUnlock()
+
+	if reporter != nil {
+		reporter(recovered, stack)
+	}
+}
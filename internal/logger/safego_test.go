@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeGo_RecoversAndReportsPanic(t *testing.T) {
+	type report struct {
+		recovered interface{}
+		stack     string
+	}
+	reports := make(chan report, 1)
+	SetPanicReporter(func(recovered interface{}, stack []byte) {
+		reports <- report{recovered: recovered, stack: string(stack)}
+	})
+	t.Cleanup(func() { SetPanicReporter(nil) })
+
+	released := make(chan struct{})
+	SafeGo(func() {
+		defer close(released)
+		panic("boom")
+	}, NewNoopLogger())
+
+	select {
+	case r := <-reports:
+		assert.Equal(t, "boom", r.recovered)
+		assert.True(t, strings.Contains(r.stack, "safego_test.go"), "stack should point at the panicking function")
+	case <-time.After(time.Second):
+		t.Fatal("panic was not reported")
+	}
+
+	select {
+	case <-released:
+	default:
+		t.Error("deferred calls of the goroutine should have run")
+	}
+}
+
+func TestSafeGo_RunsWithoutReporter(t *testing.T) {
+	done := make(chan struct{})
+	SafeGo(func() {
+		defer close(done)
+		panic("no reporter")
+	}, NewNoopLogger())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("goroutine did not run")
+	}
+}
@@ -18,9 +18,13 @@ import (
 type Config struct {
 	LogLevel    Level
 	LogFilePath string
-	MaxSizeMB   int
-	MaxBackups  int
-	MaxAgeDays  int
+	// DisableFileLog writes the JSON logs meant for LogFilePath to stdout instead, for
+	// environments like containers where the output of the process is collected
+	DisableFileLog bool
+	MaxSizeMB      int
+	MaxBackups     int
+	MaxAgeDays     int
+	// UseConsole also writes logs to stderr, keeping stdout for command output
 	UseConsole  bool
 	Development bool
 }
@@ -153,22 +157,26 @@ func buildZapLogger(config Config) (*zap.Logger, error) {
 		}), nil
 	}
 
-	if config.LogFilePath != "" {
+	infoPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.DebugLevel && lvl <= zapcore.FatalLevel
+	})
+
+	switch {
+	case config.LogFilePath != "" && config.DisableFileLog:
+		cores = append(cores, zapcore.NewCore(jsonEncoder, zapcore.Lock(os.Stdout), infoPriority))
+	case config.LogFilePath != "":
 		writer, err := newLumberjackWriter(config.LogFilePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed creating info log writer: %w", err)
 		}
 
-		infoPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			return lvl >= zapcore.DebugLevel && lvl <= zapcore.FatalLevel
-		})
 		cores = append(cores, zapcore.NewCore(jsonEncoder, writer, infoPriority))
 	}
 
 	if config.UseConsole {
 		cores = append(cores, zapcore.NewCore(
 			consoleEncoder,
-			zapcore.AddSync(os.Stdout),
+			zapcore.AddSync(os.Stderr),
 			minLogLevel,
 		))
 	}
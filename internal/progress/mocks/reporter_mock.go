@@ -0,0 +1,78 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockReporter is an autogenerated mock type for the Reporter type
+type MockReporter struct {
+	mock.Mock
+}
+
+type MockReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockReporter) EXPECT() *MockReporter_Expecter {
+	return &MockReporter_Expecter{mock: &_m.Mock}
+}
+
+// Execute provides a mock function with given fields: message
+func (_m *MockReporter) Execute(message string) error {
+	ret := _m.Called(message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockReporter_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type MockReporter_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+//   - message string
+func (_e *MockReporter_Expecter) Execute(message interface{}) *MockReporter_Execute_Call {
+	return &MockReporter_Execute_Call{Call: _e.mock.On("Execute", message)}
+}
+
+func (_c *MockReporter_Execute_Call) Run(run func(message string)) *MockReporter_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockReporter_Execute_Call) Return(_a0 error) *MockReporter_Execute_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockReporter_Execute_Call) RunAndReturn(run func(string) error) *MockReporter_Execute_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockReporter creates a new instance of MockReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockReporter {
+	mock := &MockReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,27 @@
+// Package progress carries a reporter for the progress of slow operations in a context, so
+// code deep down a call, such as the download of the web UI, can tell the user what it's
+// doing without knowing who's listening, e.g. the client of a daemon command.
+package progress
+
+import "context"
+
+// Reporter receives a progress message. It returns an error once the messages can't be
+// delivered anymore.
+type Reporter func(message string) error
+
+type reporterKey struct{}
+
+// WithReporter returns a copy of ctx whose progress is reported to report
+func WithReporter(ctx context.Context, report Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, report)
+}
+
+// Report passes message to the reporter of ctx. It does nothing if ctx has none.
+func Report(ctx context.Context, message string) error {
+	report, ok := ctx.Value(reporterKey{}).(Reporter)
+	if !ok {
+		return nil
+	}
+
+	return report(message)
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+// +build darwin
+
+package secret
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// readKeychain looks the token up in the login keychain. It can be stored with
+// security add-generic-password -s echoy -a <provider> -w
+func readKeychain(service, account string) (string, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the keychain item for service '%s' and account '%s': %w", service, account, err)
+	}
+
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
@@ -0,0 +1,27 @@
+//go:build linux
+// +build linux
+
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// readKeychain looks the token up in the Secret Service (GNOME Keyring, KWallet) using
+// secret-tool. It can be stored with
+// secret-tool store --label=echoy service echoy account <provider>
+func readKeychain(service, account string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", errors.New("secret-tool is not installed, install libsecret-tools to use the keychain")
+	}
+
+	output, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the secret for service '%s' and account '%s': %w", service, account, err)
+	}
+
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package secret
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func readKeychain(service, account string) (string, error) {
+	return "", fmt.Errorf("the keychain is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package secret
+
+import (
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const credTypeGeneric = 1
+
+var (
+	advapi32  = syscall.NewLazyDLL("advapi32.dll")
+	credReadW = advapi32.NewProc("CredReadW")
+	credFree  = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// readKeychain reads the generic credential named service:account from the Windows
+// Credential Manager. It can be stored with
+// cmdkey /generic:echoy:<provider> /user:<provider> /pass:<token>
+func readKeychain(service, account string) (string, error) {
+	target := service + ":" + account
+	targetPtr, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	ret, _, err := credReadW.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return "", fmt.Errorf("failed to read credential '%s': %w", target, err)
+	}
+	defer credFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+
+	// Credential Manager stores passwords as UTF-16
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	chars := make([]uint16, len(blob)/2)
+	for i := range chars {
+		chars[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+	}
+
+	return string(utf16.Decode(chars)), nil
+}
@@ -0,0 +1,91 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	config "github.com/shaharia-lab/echoy/internal/config"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockSource is an autogenerated mock type for the Source type
+type MockSource struct {
+	mock.Mock
+}
+
+type MockSource_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSource) EXPECT() *MockSource_Expecter {
+	return &MockSource_Expecter{mock: &_m.Mock}
+}
+
+// Execute provides a mock function with given fields: cfg
+func (_m *MockSource) Execute(cfg config.LLMConfig) (string, error) {
+	ret := _m.Called(cfg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(config.LLMConfig) (string, error)); ok {
+		return rf(cfg)
+	}
+	if rf, ok := ret.Get(0).(func(config.LLMConfig) string); ok {
+		r0 = rf(cfg)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(config.LLMConfig) error); ok {
+		r1 = rf(cfg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSource_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type MockSource_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+//   - cfg config.LLMConfig
+func (_e *MockSource_Expecter) Execute(cfg interface{}) *MockSource_Execute_Call {
+	return &MockSource_Execute_Call{Call: _e.mock.On("Execute", cfg)}
+}
+
+func (_c *MockSource_Execute_Call) Run(run func(cfg config.LLMConfig)) *MockSource_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(config.LLMConfig))
+	})
+	return _c
+}
+
+func (_c *MockSource_Execute_Call) Return(_a0 string, _a1 error) *MockSource_Execute_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSource_Execute_Call) RunAndReturn(run func(config.LLMConfig) (string, error)) *MockSource_Execute_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockSource creates a new instance of MockSource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSource(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSource {
+	mock := &MockSource{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
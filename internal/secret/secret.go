@@ -0,0 +1,132 @@
+// Package secret resolves the LLM token from the source the configuration names, so it
+// doesn't have to be stored in plain text in config.yaml.
+package secret
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+)
+
+// KeychainService is the service name the token is stored under in the OS keychain. The
+// account is the LLM provider, e.g. openai.
+const KeychainService = "echoy"
+
+// Source reads the token for an LLM configuration
+type Source func(cfg config.LLMConfig) (string, error)
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{
+		config.TokenSourceConfig:   configSource,
+		config.TokenSourceFile:     fileSource,
+		config.TokenSourceKeychain: keychainSource,
+	}
+)
+
+// Register adds a token source, or replaces the one registered under name
+func Register(name string, source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	sources[strings.ToLower(name)] = source
+}
+
+// Resolve returns the token for cfg from the source it selects
+func Resolve(cfg config.LLMConfig) (string, error) {
+	name := cfg.ResolvedTokenSource()
+
+	sourcesMu.RLock()
+	source, ok := sources[name]
+	sourcesMu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown token source '%s', must be one of: %s", name, strings.Join(sourceNames(), ", "))
+	}
+
+	token, err := source(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the LLM token from %s: %w", name, err)
+	}
+
+	return token, nil
+}
+
+func sourceNames() []string {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func configSource(cfg config.LLMConfig) (string, error) {
+	return cfg.ProviderToken(cfg.Provider), nil
+}
+
+// fileSource reads the token from LLM.TokenFile. Surrounding whitespace, such as the
+// trailing newline most editors add, is ignored.
+func fileSource(cfg config.LLMConfig) (string, error) {
+	if cfg.TokenFile == "" {
+		return "", fmt.Errorf("token_file is not set")
+	}
+
+	path, err := expandHome(cfg.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+
+	return token, nil
+}
+
+// keychainSource reads the token stored in the OS keychain for the configured provider
+func keychainSource(cfg config.LLMConfig) (string, error) {
+	if cfg.Provider == "" {
+		return "", fmt.Errorf("the LLM provider is required to look up its token")
+	}
+
+	token, err := readKeychain(KeychainService, strings.ToLower(cfg.Provider))
+	if err != nil {
+		return "", err
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("no token stored for service '%s' and account '%s'", KeychainService, strings.ToLower(cfg.Provider))
+	}
+
+	return token, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,98 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("sk-from-file\n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0600))
+
+	Register("test", func(cfg config.LLMConfig) (string, error) {
+		return "sk-from-test-" + cfg.Provider, nil
+	})
+	Register("failing", func(cfg config.LLMConfig) (string, error) {
+		return "", errors.New("vault sealed")
+	})
+
+	tests := []struct {
+		name        string
+		cfg         config.LLMConfig
+		want        string
+		errContains string
+	}{
+		{
+			name: "token from config",
+			cfg:  config.LLMConfig{Token: "sk-inline"},
+			want: "sk-inline",
+		},
+		{
+			name: "token file is used by default when set",
+			cfg:  config.LLMConfig{Token: "sk-inline", TokenFile: tokenFile},
+			want: "sk-from-file",
+		},
+		{
+			name: "explicit config source wins over the token file",
+			cfg:  config.LLMConfig{Token: "sk-inline", TokenFile: tokenFile, TokenSource: "config"},
+			want: "sk-inline",
+		},
+		{
+			name:        "missing token file",
+			cfg:         config.LLMConfig{TokenFile: filepath.Join(dir, "missing")},
+			errContains: "failed to read the LLM token from file",
+		},
+		{
+			name:        "empty token file",
+			cfg:         config.LLMConfig{TokenFile: emptyFile},
+			errContains: "is empty",
+		},
+		{
+			name:        "file source without a token file",
+			cfg:         config.LLMConfig{TokenSource: "file"},
+			errContains: "token_file is not set",
+		},
+		{
+			name:        "keychain needs a provider",
+			cfg:         config.LLMConfig{TokenSource: "keychain"},
+			errContains: "provider is required",
+		},
+		{
+			name: "registered source",
+			cfg:  config.LLMConfig{Provider: "openai", TokenSource: "TEST"},
+			want: "sk-from-test-openai",
+		},
+		{
+			name:        "failing source",
+			cfg:         config.LLMConfig{TokenSource: "failing"},
+			errContains: "vault sealed",
+		},
+		{
+			name:        "unknown source",
+			cfg:         config.LLMConfig{TokenSource: "vault"},
+			errContains: "unknown token source 'vault'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.cfg)
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
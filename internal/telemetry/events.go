@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shaharia-lab/telemetry-collector"
+)
+
+// Property keys of events. Events only carry the properties their constructor sets, so
+// nothing but these keys is ever sent.
+const (
+	// PropertyErrorType is the Go type of the error a command failed with, its message may
+	// contain paths or other user data and is never sent
+	PropertyErrorType = "error.type"
+	// PropertyPanicType is the Go type of the value a recovered panic was raised with
+	PropertyPanicType = "panic.type"
+)
+
+// Event is a telemetry event. Events are created with the constructors below, which fix
+// their name, severity, message and properties.
+type Event struct {
+	name       string
+	severity   telemetry.Severity
+	message    string
+	properties map[string]string
+	time       time.Time
+}
+
+// Name returns the name of the event
+func (e Event) Name() string {
+	return e.name
+}
+
+// Properties returns a copy of the properties of the event
+func (e Event) Properties() map[string]string {
+	properties := make(map[string]string, len(e.properties))
+	for key, value := range e.properties {
+		properties[key] = value
+	}
+
+	return properties
+}
+
+func newEvent(name string, severity telemetry.Severity, message string, properties map[string]string) Event {
+	return Event{name: name, severity: severity, message: message, properties: properties, time: time.Now()}
+}
+
+// CLIStarted is recorded when the CLI starts
+func CLIStarted() Event {
+	return newEvent("start", telemetry.SeverityInfo, "CLI starting", nil)
+}
+
+// CommandFailed is recorded when a command returns err
+func CommandFailed(err error) Event {
+	return newEvent("root.cmd.error", telemetry.SeverityError, "Error executing command", map[string]string{
+		PropertyErrorType: errorType(err),
+	})
+}
+
+// RootCommandExecuted is recorded when echoy runs without a subcommand
+func RootCommandExecuted() Event {
+	return newEvent("cmd.root.execute", telemetry.SeverityInfo, "Root command executed", nil)
+}
+
+// InitStarted is recorded when the init wizard starts
+func InitStarted() Event {
+	return newEvent("cmd.init", telemetry.SeverityInfo, "Starting initialization", nil)
+}
+
+// UpdateStarted is recorded when the CLI starts updating itself
+func UpdateStarted() Event {
+	return newEvent("cmd.update", telemetry.SeverityInfo, "Start updating the CLI", nil)
+}
+
+// ChatStarted is recorded when an interactive chat session starts
+func ChatStarted() Event {
+	return newEvent("cmd.chat", telemetry.SeverityInfo, "Starting chat session", nil)
+}
+
+// QuestionAsked is recorded when a single question is asked with chat --prompt
+func QuestionAsked() Event {
+	return newEvent("cmd.chat.ask", telemetry.SeverityInfo, "Asking a single question", nil)
+}
+
+// DaemonStartAttempted is recorded when echoy start runs
+func DaemonStartAttempted() Event {
+	return newEvent("daemon.start.attempt", telemetry.SeverityInfo, "Attempting to start daemon", nil)
+}
+
+// DaemonStarted is recorded when the daemon is up, in the background or in the foreground
+func DaemonStarted(background bool) Event {
+	if background {
+		return newEvent("daemon.start.background.success", telemetry.SeverityInfo, "Daemon started in background", nil)
+	}
+
+	return newEvent("daemon.start.foreground.success", telemetry.SeverityInfo, "Daemon started in foreground", nil)
+}
+
+// DaemonPanicked is recorded when the daemon recovers from a panic raised with recovered
+func DaemonPanicked(recovered interface{}) Event {
+	return newEvent("daemon.panic", telemetry.SeverityError, "Recovered from panic", map[string]string{
+		PropertyPanicType: fmt.Sprintf("%T", recovered),
+	})
+}
+
+// DaemonStatusChecked is recorded when echoy status runs
+func DaemonStatusChecked() Event {
+	return newEvent("daemon.status", telemetry.SeverityInfo, "Checking daemon status", nil)
+}
+
+// DaemonStopAttempted is recorded when echoy stop runs
+func DaemonStopAttempted() Event {
+	return newEvent("daemon.stop.attempt", telemetry.SeverityInfo, "Attempting to stop daemon", nil)
+}
+
+// DaemonStopped is recorded when echoy stop has stopped a running daemon
+func DaemonStopped() Event {
+	return newEvent("daemon.stop.success", telemetry.SeverityInfo, "Daemon stopped", nil)
+}
+
+// errorType returns the type of the innermost error wrapped by err
+func errorType(err error) string {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = unwrapped
+	}
+}
@@ -2,64 +2,170 @@ package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/telemetry-collector"
-	"runtime"
-	"runtime/debug"
 )
 
 const (
 	telemetryEndpoint = "https://telemetry-pub.shaharialab.com/telemetry/event"
 )
 
-// SendTelemetryEvent sends a telemetry event to the specified endpoint
-func SendTelemetryEvent(ctx context.Context, appCfg *config.AppConfig, eventName string, severityText telemetry.Severity, message string, attributes map[string]interface{}) {
-	collector := telemetry.NewCollector(
-		telemetryEndpoint,
-		fmt.Sprintf("%s-cli", appCfg.Name),
-	)
+const (
+	// CLIFlushTimeout bounds how long a command waits for its events to be sent before the
+	// CLI exits. Every command waits for it, so it is short; events not sent by then are lost.
+	CLIFlushTimeout = 500 * time.Millisecond
+	// FlushTimeout bounds the flushes of the daemon, which run in the background
+	FlushTimeout = 3 * time.Second
+)
+
+// Batch collects telemetry events and sends them together when it is flushed. A disabled
+// batch drops every event, so callers don't have to check whether usage tracking is on.
+type Batch struct {
+	appCfg  *config.AppConfig
+	enabled bool
+	// send delivers the events of a flush, it is replaced in tests
+	send func(events []*telemetry.Event) error
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewBatch creates a batch for the application described by appCfg. Events are only
+// collected if enabled is true.
+func NewBatch(appCfg *config.AppConfig, enabled bool) *Batch {
+	b := &Batch{appCfg: appCfg, enabled: enabled}
+	b.send = b.sendToCollector
+
+	return b
+}
+
+// Add queues events to be sent with the next flush
+func (b *Batch) Add(events ...Event) {
+	if b == nil || !b.enabled {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, events...)
+}
+
+// Len returns the number of events waiting to be flushed
+func (b *Batch) Len() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.events)
+}
+
+// Flush sends the queued events. It returns when they are sent or ctx is done, whatever
+// comes first; events still being sent then are delivered in the background.
+func (b *Batch) Flush(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	events := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	collectorEvents := make([]*telemetry.Event, 0, len(events))
+	for _, event := range events {
+		collectorEvents = append(collectorEvents, b.collectorEvent(event))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.send(collectorEvents)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendToCollector sends events one after another through a single collector
+func (b *Batch) sendToCollector(events []*telemetry.Event) error {
+	collector := telemetry.NewCollector(telemetryEndpoint, b.serviceName())
 	defer collector.Close()
 
+	var errs []error
+	for _, event := range events {
+		if err := collector.SendSync(context.Background(), event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send telemetry event %s: %w", event.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *Batch) serviceName() string {
+	return fmt.Sprintf("%s-cli", b.appCfg.Name)
+}
+
+// collectorEvent adds the attributes describing the installation and build to event
+func (b *Batch) collectorEvent(event Event) *telemetry.Event {
 	var systemUUID string
-	if appCfg.SystemConfig != nil {
-		systemUUID = appCfg.SystemConfig.UUID
+	if b.appCfg.SystemConfig != nil {
+		systemUUID = b.appCfg.SystemConfig.UUID
 	}
 
-	telemetryEvent := telemetry.Event{
-		Name:         eventName,
+	collectorEvent := &telemetry.Event{
+		Name:         event.name,
+		TimeUnixNano: event.time.UnixNano(),
 		TraceID:      uuid.New().String(),
 		SpanID:       uuid.New().String(),
-		SeverityText: severityText,
-		Body:         message,
+		SeverityText: event.severity,
+		Body:         event.message,
 		Attributes: map[string]interface{}{
 			"system.uuid":        systemUUID,
-			"cli_version.code":   appCfg.Version.Version,
-			"cli_version.commit": appCfg.Version.Commit,
-			"cli_version.date":   appCfg.Version.Date,
+			"cli_version.code":   b.appCfg.Version.Version,
+			"cli_version.commit": b.appCfg.Version.Commit,
+			"cli_version.date":   b.appCfg.Version.Date,
 			"os.name":            runtime.GOOS,
 			"os.arch":            runtime.GOARCH,
 			"go.runtime_version": runtime.Version(),
 		},
 		Resource: map[string]interface{}{
-			"service.name":    fmt.Sprintf("%s-cli", appCfg.Name),
-			"service.version": appCfg.Version.Version,
+			"service.name":    b.serviceName(),
+			"service.version": b.appCfg.Version.Version,
 		},
 	}
 
-	buildInfo, _ := debug.ReadBuildInfo()
-	for _, setting := range buildInfo.Settings {
-		key := fmt.Sprintf("build_settings.%s", setting.Key)
-		telemetryEvent.Attributes[key] = setting.Value
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			key := fmt.Sprintf("build_settings.%s", setting.Key)
+			collectorEvent.Attributes[key] = setting.Value
+		}
 	}
 
-	for k, v := range attributes {
-		if _, exists := telemetryEvent.Attributes[k]; exists {
+	for key, value := range event.properties {
+		if _, exists := collectorEvent.Attributes[key]; exists {
 			continue
 		}
-		telemetryEvent.Attributes[k] = fmt.Sprintf("%v", v)
+		collectorEvent.Attributes[key] = value
 	}
 
-	collector.SendAsync(ctx, &telemetryEvent)
+	return collectorEvent
 }
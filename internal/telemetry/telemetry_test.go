@@ -0,0 +1,131 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/telemetry-collector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAppConfig() *config.AppConfig {
+	return &config.AppConfig{
+		Name:         "Echoy",
+		Version:      config.Version{Version: "1.2.3", Commit: "abc", Date: "today"},
+		SystemConfig: &config.SystemConfig{UUID: "system-uuid"},
+	}
+}
+
+func TestBatch_FlushSendsAllEventsTogether(t *testing.T) {
+	batch := NewBatch(testAppConfig(), true)
+
+	var flushes [][]*telemetry.Event
+	batch.send = func(events []*telemetry.Event) error {
+		flushes = append(flushes, events)
+		return nil
+	}
+
+	batch.Add(CLIStarted(), DaemonStopAttempted())
+	batch.Add(CommandFailed(fmt.Errorf("failed to open %s: %w", "/home/user/secret", os.ErrPermission)))
+	assert.Equal(t, 3, batch.Len())
+
+	require.NoError(t, batch.Flush(context.Background()))
+	assert.Equal(t, 0, batch.Len())
+	require.Len(t, flushes, 1, "all events should be sent in one flush")
+
+	events := flushes[0]
+	require.Len(t, events, 3)
+	assert.Equal(t, "start", events[0].Name)
+	assert.Equal(t, "daemon.stop.attempt", events[1].Name)
+	assert.Equal(t, "root.cmd.error", events[2].Name)
+	assert.Equal(t, telemetry.SeverityError, events[2].SeverityText)
+
+	assert.Equal(t, "system-uuid", events[0].Attributes["system.uuid"])
+	assert.Equal(t, "1.2.3", events[0].Attributes["cli_version.code"])
+	assert.Equal(t, "Echoy-cli", events[0].Resource["service.name"])
+
+	assert.Equal(t, "*errors.errorString", events[2].Attributes[PropertyErrorType])
+	for key, value := range events[2].Attributes {
+		assert.NotContains(t, fmt.Sprint(value), "/home/user/secret", "the error message should not be sent as %s", key)
+	}
+
+	require.NoError(t, batch.Flush(context.Background()))
+	assert.Len(t, flushes, 1, "an empty batch should not be sent")
+}
+
+func TestBatch_DisabledDropsEvents(t *testing.T) {
+	batch := NewBatch(testAppConfig(), false)
+	batch.send = func(events []*telemetry.Event) error {
+		t.Fatal("a disabled batch should not send anything")
+		return nil
+	}
+
+	batch.Add(CLIStarted())
+
+	assert.Equal(t, 0, batch.Len())
+	assert.NoError(t, batch.Flush(context.Background()))
+}
+
+func TestBatch_Nil(t *testing.T) {
+	var batch *Batch
+
+	batch.Add(CLIStarted())
+
+	assert.Equal(t, 0, batch.Len())
+	assert.NoError(t, batch.Flush(context.Background()))
+}
+
+func TestBatch_FlushGivesUpWhenContextIsDone(t *testing.T) {
+	batch := NewBatch(testAppConfig(), true)
+
+	release := make(chan struct{})
+	defer close(release)
+	batch.send = func(events []*telemetry.Event) error {
+		<-release
+		return nil
+	}
+
+	batch.Add(CLIStarted())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, batch.Flush(ctx), context.DeadlineExceeded)
+}
+
+func TestBatch_FlushReturnsSendError(t *testing.T) {
+	batch := NewBatch(testAppConfig(), true)
+	batch.send = func(events []*telemetry.Event) error {
+		return errors.New("endpoint unreachable")
+	}
+
+	batch.Add(CLIStarted())
+
+	assert.EqualError(t, batch.Flush(context.Background()), "endpoint unreachable")
+}
+
+func TestEvents_Schema(t *testing.T) {
+	tests := []struct {
+		event      Event
+		name       string
+		properties map[string]string
+	}{
+		{event: DaemonStarted(true), name: "daemon.start.background.success", properties: map[string]string{}},
+		{event: DaemonStarted(false), name: "daemon.start.foreground.success", properties: map[string]string{}},
+		{event: DaemonPanicked("boom"), name: "daemon.panic", properties: map[string]string{PropertyPanicType: "string"}},
+		{event: CommandFailed(errors.New("boom")), name: "root.cmd.error", properties: map[string]string{PropertyErrorType: "*errors.errorString"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.event.Name())
+			assert.Equal(t, tt.properties, tt.event.Properties())
+		})
+	}
+}
@@ -2,7 +2,9 @@ package theme
 
 import (
 	"fmt"
+	"github.com/mattn/go-runewidth"
 	"github.com/shaharia-lab/echoy/internal/config"
+	"io"
 	"strings"
 )
 
@@ -11,6 +13,7 @@ type Manager struct {
 	currentTheme Theme
 	appConfig    *config.AppConfig
 	writer       Writer
+	quiet        bool
 }
 
 // NewManager creates a new theme manager with default settings
@@ -32,63 +35,71 @@ func (m *Manager) WithWriter(w Writer) *Manager {
 	return m
 }
 
+// SetOutput redirects the themed output, including banners, to w instead of stdout
+func (m *Manager) SetOutput(w io.Writer) *Manager {
+	m.currentTheme.SetOutput(w)
+	m.writer = &IOWriter{Writer: w}
+	return m
+}
+
+// SetQuiet suppresses banners and all themed output except errors and warnings
+func (m *Manager) SetQuiet(quiet bool) *Manager {
+	m.currentTheme.SetQuiet(quiet)
+	m.quiet = quiet
+	return m
+}
+
 // GetCurrentTheme returns the currently active theme
 func (m *Manager) GetCurrentTheme() Theme {
 	return m.currentTheme
 }
 
-// DisplayBanner prints a styled banner with the app name and description
+// DisplayBanner prints a styled banner with the app name and description. Text is
+// measured in terminal cells, so wide characters such as CJK and emoji stay aligned,
+// and the box grows when the text doesn't fit into width.
 func (m *Manager) DisplayBanner(title string, width int, subtitle ...string) {
+	if m.quiet {
+		return
+	}
+
 	primary := m.currentTheme.Primary()
 	secondary := m.currentTheme.Secondary()
 
-	if width < len(title)+4 {
-		width = len(title) + 4
+	if width < runewidth.StringWidth(title)+4 {
+		width = runewidth.StringWidth(title) + 4
 	}
 
 	for _, sub := range subtitle {
-		if len(sub)+4 > width {
-			width = len(sub) + 4
+		if runewidth.StringWidth(sub)+4 > width {
+			width = runewidth.StringWidth(sub) + 4
 		}
 	}
 
-	titlePadding := (width - len(title) - 2) / 2
-	titleLeftPadding := titlePadding
-	titleRightPadding := titlePadding
-
-	if (width-len(title)-2)%2 != 0 {
-		titleRightPadding++
-	}
-
 	top := "╔" + strings.Repeat("═", width-2) + "╗"
 	bottom := "╚" + strings.Repeat("═", width-2) + "╝"
 
-	titleLeftSpaces := strings.Repeat(" ", titleLeftPadding)
-	titleRightSpaces := strings.Repeat(" ", titleRightPadding)
-
 	primary.Println(top)
 
-	primary.Println(fmt.Sprintf("║%s%s%s║", titleLeftSpaces, title, titleRightSpaces))
+	primary.Println(bannerLine(title, width))
 
 	if len(subtitle) > 0 {
 		separator := "║" + strings.Repeat("─", width-2) + "║"
 		primary.Println(separator)
 
 		for _, sub := range subtitle {
-			subPadding := (width - len(sub) - 2) / 2
-			subLeftPadding := subPadding
-			subRightPadding := subPadding
-
-			if (width-len(sub)-2)%2 != 0 {
-				subRightPadding++
-			}
-
-			subLeftSpaces := strings.Repeat(" ", subLeftPadding)
-			subRightSpaces := strings.Repeat(" ", subRightPadding)
-
-			secondary.Println(fmt.Sprintf("║%s%s%s║", subLeftSpaces, sub, subRightSpaces))
+			secondary.Println(bannerLine(sub, width))
 		}
 	}
 
 	primary.Println(bottom)
 }
+
+// bannerLine centers text between the banner's side borders, putting the odd
+// space of padding on the right
+func bannerLine(text string, width int) string {
+	padding := width - runewidth.StringWidth(text) - 2
+	leftPadding := padding / 2
+	rightPadding := padding - leftPadding
+
+	return fmt.Sprintf("║%s%s%s║", strings.Repeat(" ", leftPadding), text, strings.Repeat(" ", rightPadding))
+}
@@ -1,9 +1,14 @@
 package theme_test
 
 import (
+	"bytes"
+	"github.com/fatih/color"
 	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/theme"
 	"github.com/shaharia-lab/echoy/internal/theme/mocks"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -88,6 +93,50 @@ func TestManager_DisplayBanner(t *testing.T) {
 				primaryMock.On("Println", "╚══════════════════╝").Once()
 			},
 		},
+		{
+			name:     "Wide CJK title",
+			title:    "你好世界",
+			width:    20,
+			subtitle: []string{},
+			setupMocks: func(mockTheme *mocks.MockTheme, primaryMock *mocks.MockStylePrinter, secondaryMock *mocks.MockStylePrinter) {
+				mockTheme.On("Primary").Return(primaryMock)
+				mockTheme.On("Secondary").Return(secondaryMock)
+
+				primaryMock.On("Println", "╔══════════════════╗").Once()
+				primaryMock.On("Println", "║     你好世界     ║").Once()
+				primaryMock.On("Println", "╚══════════════════╝").Once()
+			},
+		},
+		{
+			name:     "Title with emoji and wide subtitle",
+			title:    "🚀 Echoy",
+			width:    20,
+			subtitle: []string{"日本語"},
+			setupMocks: func(mockTheme *mocks.MockTheme, primaryMock *mocks.MockStylePrinter, secondaryMock *mocks.MockStylePrinter) {
+				mockTheme.On("Primary").Return(primaryMock)
+				mockTheme.On("Secondary").Return(secondaryMock)
+
+				primaryMock.On("Println", "╔══════════════════╗").Once()
+				primaryMock.On("Println", "║     🚀 Echoy     ║").Once()
+				primaryMock.On("Println", "║──────────────────║").Once()
+				secondaryMock.On("Println", "║      日本語      ║").Once()
+				primaryMock.On("Println", "╚══════════════════╝").Once()
+			},
+		},
+		{
+			name:     "Title wider than requested width expands the box",
+			title:    "中文标题很长",
+			width:    8,
+			subtitle: []string{},
+			setupMocks: func(mockTheme *mocks.MockTheme, primaryMock *mocks.MockStylePrinter, secondaryMock *mocks.MockStylePrinter) {
+				mockTheme.On("Primary").Return(primaryMock)
+				mockTheme.On("Secondary").Return(secondaryMock)
+
+				primaryMock.On("Println", "╔══════════════╗").Once()
+				primaryMock.On("Println", "║ 中文标题很长 ║").Once()
+				primaryMock.On("Println", "╚══════════════╝").Once()
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,3 +162,51 @@ func TestManager_DisplayBanner(t *testing.T) {
 		})
 	}
 }
+
+func TestManager_SetOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	defaultTheme := theme.NewDefaultTheme()
+	defaultTheme.SetEnabled(false)
+	manager := theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&buf)
+
+	manager.GetCurrentTheme().Success().Println("daemon is running")
+	manager.GetCurrentTheme().Warning().Printf("%d warnings\n", 2)
+	manager.DisplayBanner("My App", 20)
+
+	defaultTheme.RegisterCustomStyle("highlight", theme.NewStyle(color.FgMagenta, 0))
+	manager.GetCurrentTheme().Custom("highlight").Print("custom")
+
+	output := stripANSI(buf.String())
+	assert.Contains(t, output, "daemon is running\n")
+	assert.Contains(t, output, "2 warnings\n")
+	assert.Contains(t, output, "║      My App      ║\n")
+	assert.True(t, strings.HasSuffix(output, "custom"))
+}
+
+func TestManager_SetQuiet(t *testing.T) {
+	var buf bytes.Buffer
+
+	defaultTheme := theme.NewDefaultTheme()
+	manager := theme.NewManager(defaultTheme, &config.AppConfig{}, nil).SetOutput(&buf).SetQuiet(true)
+
+	manager.DisplayBanner("My App", 20)
+	manager.GetCurrentTheme().Info().Println("starting")
+	manager.GetCurrentTheme().Success().Println("started")
+	defaultTheme.RegisterCustomStyle("highlight", theme.NewStyle(color.FgMagenta, 0))
+	manager.GetCurrentTheme().Custom("highlight").Print("custom")
+	manager.GetCurrentTheme().Warning().Println("disk almost full")
+	manager.GetCurrentTheme().Error().Println("failed")
+
+	assert.Equal(t, "disk almost full\nfailed\n", stripANSI(buf.String()))
+
+	buf.Reset()
+	manager.SetQuiet(false)
+	manager.GetCurrentTheme().Info().Println("starting")
+	assert.Equal(t, "starting\n", stripANSI(buf.String()))
+}
+
+// stripANSI removes color escape sequences so assertions don't depend on terminal detection
+func stripANSI(s string) string {
+	return regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(s, "")
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	io "io"
+
 	theme "github.com/shaharia-lab/echoy/internal/theme"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -381,6 +383,72 @@ func (_c *MockTheme_SetEnabled_Call) RunAndReturn(run func(bool)) *MockTheme_Set
 	return _c
 }
 
+// SetOutput provides a mock function with given fields: w
+func (_m *MockTheme) SetOutput(w io.Writer) {
+	_m.Called(w)
+}
+
+// MockTheme_SetOutput_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetOutput'
+type MockTheme_SetOutput_Call struct {
+	*mock.Call
+}
+
+// SetOutput is a helper method to define mock.On call
+//   - w io.Writer
+func (_e *MockTheme_Expecter) SetOutput(w interface{}) *MockTheme_SetOutput_Call {
+	return &MockTheme_SetOutput_Call{Call: _e.mock.On("SetOutput", w)}
+}
+
+func (_c *MockTheme_SetOutput_Call) Run(run func(w io.Writer)) *MockTheme_SetOutput_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(io.Writer))
+	})
+	return _c
+}
+
+func (_c *MockTheme_SetOutput_Call) Return() *MockTheme_SetOutput_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockTheme_SetOutput_Call) RunAndReturn(run func(io.Writer)) *MockTheme_SetOutput_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetQuiet provides a mock function with given fields: quiet
+func (_m *MockTheme) SetQuiet(quiet bool) {
+	_m.Called(quiet)
+}
+
+// MockTheme_SetQuiet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetQuiet'
+type MockTheme_SetQuiet_Call struct {
+	*mock.Call
+}
+
+// SetQuiet is a helper method to define mock.On call
+//   - quiet bool
+func (_e *MockTheme_Expecter) SetQuiet(quiet interface{}) *MockTheme_SetQuiet_Call {
+	return &MockTheme_SetQuiet_Call{Call: _e.mock.On("SetQuiet", quiet)}
+}
+
+func (_c *MockTheme_SetQuiet_Call) Run(run func(quiet bool)) *MockTheme_SetQuiet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *MockTheme_SetQuiet_Call) Return() *MockTheme_SetQuiet_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockTheme_SetQuiet_Call) RunAndReturn(run func(bool)) *MockTheme_SetQuiet_Call {
+	_c.Run(run)
+	return _c
+}
+
 // Subtle provides a mock function with no fields
 func (_m *MockTheme) Subtle() theme.StylePrinter {
 	ret := _m.Called()
@@ -0,0 +1,144 @@
+package theme
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// clearLine moves the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner renders an animated status line with the theme's styles. A disabled spinner
+// doesn't animate and only prints the final Success and Fail messages, which keeps
+// output that isn't going to a terminal free of control sequences.
+type Spinner struct {
+	theme    Theme
+	enabled  bool
+	interval time.Duration
+
+	// output is held while the spinner writes, so callers sharing it can keep their
+	// own writes from interleaving with the animation
+	output sync.Locker
+
+	mu      sync.Mutex
+	message string
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewSpinner creates a spinner rendering with t. The animation is only shown if enabled is true.
+func NewSpinner(t Theme, enabled bool) *Spinner {
+	return &Spinner{
+		theme:    t,
+		enabled:  enabled,
+		interval: 100 * time.Millisecond,
+	}
+}
+
+// NewTerminalSpinner creates a spinner that animates only when stdout is a terminal
+func NewTerminalSpinner(t Theme) *Spinner {
+	fd := os.Stdout.Fd()
+	return NewSpinner(t, isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd))
+}
+
+// WithOutputLock makes the spinner hold l for every write to the terminal
+func (s *Spinner) WithOutputLock(l sync.Locker) *Spinner {
+	s.output = l
+	return s
+}
+
+// Start shows the spinner with message. Calling Start on a running spinner only updates the message.
+func (s *Spinner) Start(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.message = message
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(s.stop, s.done)
+}
+
+// Update changes the message shown next to the spinner
+func (s *Spinner) Update(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.message = message
+}
+
+// Stop halts the animation and clears the spinner line. It is safe to call more than once.
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
+	s.write(func() {
+		s.theme.Info().Print(clearLine)
+	})
+}
+
+// Success stops the spinner and prints message as a success
+func (s *Spinner) Success(message string) {
+	s.Stop()
+	s.write(func() {
+		s.theme.Success().Println("✓ " + message)
+	})
+}
+
+// Fail stops the spinner and prints message as an error
+func (s *Spinner) Fail(message string) {
+	s.Stop()
+	s.write(func() {
+		s.theme.Error().Println("✗ " + message)
+	})
+}
+
+func (s *Spinner) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		s.mu.Lock()
+		message := s.message
+		s.mu.Unlock()
+
+		s.write(func() {
+			s.theme.Primary().Print(clearLine + spinnerFrames[frame%len(spinnerFrames)] + " ")
+			s.theme.Subtle().Print(message)
+		})
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// write runs fn while holding the output lock, if one is set
+func (s *Spinner) write(fn func()) {
+	if s.output != nil {
+		s.output.Lock()
+		defer s.output.Unlock()
+	}
+
+	fn()
+}
@@ -0,0 +1,93 @@
+package theme
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBufferedTheme() (*DefaultTheme, *bytes.Buffer) {
+	var buf bytes.Buffer
+
+	t := NewDefaultTheme()
+	t.SetOutput(&buf)
+
+	return t, &buf
+}
+
+func TestSpinner_Disabled(t *testing.T) {
+	th, buf := newBufferedTheme()
+	spinner := NewSpinner(th, false)
+
+	spinner.Start("Downloading")
+	spinner.Update("Still downloading")
+	spinner.Stop()
+	assert.Empty(t, buf.String(), "a disabled spinner must not render the animation")
+
+	spinner.Success("Downloaded")
+	spinner.Fail("Verification failed")
+
+	output := buf.String()
+	assert.Contains(t, output, "✓ Downloaded\n")
+	assert.Contains(t, output, "✗ Verification failed\n")
+	assert.NotContains(t, output, clearLine)
+}
+
+func TestSpinner_Enabled(t *testing.T) {
+	th, buf := newBufferedTheme()
+	spinner := NewSpinner(th, true)
+	spinner.interval = 5 * time.Millisecond
+
+	spinner.Start("Starting daemon")
+	time.Sleep(20 * time.Millisecond)
+	spinner.Update("Waiting for socket")
+	time.Sleep(20 * time.Millisecond)
+	spinner.Success("Daemon started")
+
+	output := buf.String()
+	assert.Contains(t, output, "Starting daemon")
+	assert.Contains(t, output, "Waiting for socket")
+	assert.True(t, strings.HasSuffix(output, "✓ Daemon started\n"), "got %q", output)
+
+	// Stop after Success is a no-op
+	spinner.Stop()
+	assert.True(t, strings.HasSuffix(buf.String(), "✓ Daemon started\n"))
+}
+
+func TestSpinner_StopClearsLine(t *testing.T) {
+	th, buf := newBufferedTheme()
+	spinner := NewSpinner(th, true)
+	spinner.interval = 5 * time.Millisecond
+
+	spinner.Start("Thinking")
+	spinner.Stop()
+
+	assert.True(t, strings.HasSuffix(buf.String(), clearLine), "got %q", buf.String())
+}
+
+func TestSpinner_OutputLock(t *testing.T) {
+	th, buf := newBufferedTheme()
+	var output sync.Mutex
+	spinner := NewSpinner(th, true).WithOutputLock(&output)
+	spinner.interval = 5 * time.Millisecond
+
+	output.Lock()
+	spinner.Start("Thinking")
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, buf.String(), "the spinner must not write while the output lock is held")
+	output.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	output.Lock()
+	assert.Contains(t, buf.String(), "Thinking")
+	buf.Reset()
+	buf.WriteString("token")
+	output.Unlock()
+
+	spinner.Stop()
+	assert.True(t, strings.HasSuffix(buf.String(), clearLine), "got %q", buf.String())
+}
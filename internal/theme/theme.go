@@ -2,6 +2,8 @@ package theme
 
 import (
 	"github.com/fatih/color"
+	"io"
+	"os"
 	"sync"
 )
 
@@ -39,6 +41,12 @@ type Theme interface {
 
 	// SetEnabled enables or disables color output
 	SetEnabled(enabled bool)
+
+	// SetOutput redirects all styles to w
+	SetOutput(w io.Writer)
+
+	// SetQuiet discards everything but errors and warnings when quiet is true
+	SetQuiet(quiet bool)
 }
 
 // DefaultTheme represents the default theme implementation
@@ -53,6 +61,8 @@ type DefaultTheme struct {
 	disabled  *Style
 	custom    map[string]*Style
 	enabled   bool
+	output    io.Writer
+	quiet     bool
 	mu        sync.RWMutex
 }
 
@@ -228,6 +238,10 @@ func (t *DefaultTheme) RegisterCustomStyle(name string, style *Style) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if t.output != nil || t.quiet {
+		style.WithWriter(t.writer(false))
+	}
+
 	t.custom[name] = style
 }
 
@@ -240,3 +254,48 @@ func (t *DefaultTheme) IsEnabled() bool {
 func (t *DefaultTheme) SetEnabled(enabled bool) {
 	t.enabled = enabled
 }
+
+// SetOutput redirects all styles, including custom styles registered later, to w
+func (t *DefaultTheme) SetOutput(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.output = w
+	t.applyOutput()
+}
+
+// SetQuiet discards the output of all styles except errors and warnings when quiet is true
+func (t *DefaultTheme) SetQuiet(quiet bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.quiet = quiet
+	t.applyOutput()
+}
+
+// applyOutput points every style at the writer it should use. t.mu must be held.
+func (t *DefaultTheme) applyOutput() {
+	for _, style := range []*Style{t.error, t.warning} {
+		style.WithWriter(t.writer(true))
+	}
+
+	for _, style := range []*Style{t.primary, t.secondary, t.success, t.info, t.subtle, t.disabled} {
+		style.WithWriter(t.writer(false))
+	}
+
+	for _, style := range t.custom {
+		style.WithWriter(t.writer(false))
+	}
+}
+
+// writer returns where a style prints to. Non-essential styles are discarded in quiet mode.
+func (t *DefaultTheme) writer(essential bool) io.Writer {
+	switch {
+	case t.quiet && !essential:
+		return io.Discard
+	case t.output != nil:
+		return t.output
+	default:
+		return os.Stdout
+	}
+}
@@ -0,0 +1,132 @@
+// Package update verifies release assets before the running executable is replaced by them
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// PublicKey is the minisign public key releases are signed with, as in the .pub file
+// written by 'minisign -G'. It is set when a release is built:
+//
+//	-ldflags "-X github.com/shaharia-lab/echoy/internal/update.PublicKey=RWQ..."
+var PublicKey string
+
+// SignatureSuffix is appended to the name of a release asset to get its signature's name
+const SignatureSuffix = ".minisig"
+
+// Signature algorithms of minisign. Pure signs the file, prehashed its BLAKE2b-512 hash.
+const (
+	algorithmPure      = "Ed"
+	algorithmPrehashed = "ED"
+)
+
+const (
+	keyIDLength          = 8
+	trustedCommentPrefix = "trusted comment: "
+)
+
+var (
+	// ErrNoPublicKey is returned when the executable was built without a public key, so
+	// updates can't be verified
+	ErrNoPublicKey = errors.New("this build has no public key to verify updates with")
+
+	// ErrInvalidSignature is returned when a release asset doesn't match its signature
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// MinisignValidator verifies release assets against their detached minisign signature. It
+// implements selfupdate.Validator.
+type MinisignValidator struct {
+	keyID [keyIDLength]byte
+	key   ed25519.PublicKey
+}
+
+// NewMinisignValidator parses a minisign public key, either the whole .pub file or only
+// its base64 line
+func NewMinisignValidator(publicKey string) (*MinisignValidator, error) {
+	encoded := lastLine(publicKey, "untrusted comment:")
+	if encoded == "" {
+		return nil, ErrNoPublicKey
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != 2+keyIDLength+ed25519.PublicKeySize || string(decoded[:2]) != algorithmPure {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+
+	v := &MinisignValidator{key: ed25519.PublicKey(decoded[2+keyIDLength:])}
+	copy(v.keyID[:], decoded[2:2+keyIDLength])
+
+	return v, nil
+}
+
+// Suffix implements selfupdate.Validator
+func (v *MinisignValidator) Suffix() string {
+	return SignatureSuffix
+}
+
+// Validate checks that release is signed by the public key, including the trusted comment
+// of the signature. It implements selfupdate.Validator.
+func (v *MinisignValidator) Validate(release, signature []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return fmt.Errorf("%w: not a minisign signature", ErrInvalidSignature)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+keyIDLength+ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	message := release
+	switch string(sig[:2]) {
+	case algorithmPure:
+	case algorithmPrehashed:
+		hash := blake2b.Sum512(release)
+		message = hash[:]
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, sig[:2])
+	}
+
+	if !bytes.Equal(sig[2:2+keyIDLength], v.keyID[:]) {
+		return fmt.Errorf("%w: signed with a different key", ErrInvalidSignature)
+	}
+
+	fileSignature := sig[2+keyIDLength:]
+	if !ed25519.Verify(v.key, message, fileSignature) {
+		return fmt.Errorf("%w: the file doesn't match its signature", ErrInvalidSignature)
+	}
+
+	// The global signature covers the trusted comment, so it can't be swapped either
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed trusted comment signature", ErrInvalidSignature)
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	if !ed25519.Verify(v.key, append(append([]byte{}, fileSignature...), trustedComment...), globalSignature) {
+		return fmt.Errorf("%w: the trusted comment doesn't match its signature", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// lastLine returns the last non-blank line of s that doesn't start with skipPrefix
+func lastLine(s, skipPrefix string) string {
+	lines := strings.Split(s, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, skipPrefix) {
+			return line
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,136 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+type testKey struct {
+	id      [keyIDLength]byte
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T) testKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key := testKey{public: public, private: private}
+	_, err = rand.Read(key.id[:])
+	require.NoError(t, err)
+
+	return key
+}
+
+// publicKeyFile returns the key as written to a .pub file by 'minisign -G'
+func (k testKey) publicKeyFile() string {
+	encoded := base64.StdEncoding.EncodeToString(append(append([]byte(algorithmPure), k.id[:]...), k.public...))
+	return "untrusted comment: minisign public key\n" + encoded + "\n"
+}
+
+// sign returns a minisign signature of data with the given algorithm and trusted comment
+func (k testKey) sign(data []byte, algorithm, trustedComment string) string {
+	message := data
+	if algorithm == algorithmPrehashed {
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	}
+
+	fileSignature := ed25519.Sign(k.private, message)
+	globalSignature := ed25519.Sign(k.private, append(append([]byte{}, fileSignature...), trustedComment...))
+
+	return strings.Join([]string{
+		"untrusted comment: signature from minisign secret key",
+		base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), k.id[:]...), fileSignature...)),
+		trustedCommentPrefix + trustedComment,
+		base64.StdEncoding.EncodeToString(globalSignature),
+	}, "\n") + "\n"
+}
+
+func TestNewMinisignValidator(t *testing.T) {
+	key := newTestKey(t)
+
+	_, err := NewMinisignValidator(key.publicKeyFile())
+	assert.NoError(t, err, "the whole .pub file should be accepted")
+
+	_, err = NewMinisignValidator(strings.Split(key.publicKeyFile(), "\n")[1])
+	assert.NoError(t, err, "the base64 line alone should be accepted")
+
+	_, err = NewMinisignValidator("  ")
+	assert.ErrorIs(t, err, ErrNoPublicKey)
+
+	_, err = NewMinisignValidator("not base64!")
+	assert.Error(t, err)
+}
+
+func TestMinisignValidator_Validate(t *testing.T) {
+	key := newTestKey(t)
+	release := []byte("echoy release archive")
+
+	validator, err := NewMinisignValidator(key.publicKeyFile())
+	require.NoError(t, err)
+	assert.Equal(t, ".minisig", validator.Suffix())
+
+	tests := []struct {
+		name      string
+		release   []byte
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "prehashed signature",
+			release:   release,
+			signature: key.sign(release, algorithmPrehashed, "timestamp:1700000000\tfile:echoy.tar.gz"),
+		},
+		{
+			name:      "legacy signature",
+			release:   release,
+			signature: key.sign(release, algorithmPure, "timestamp:1700000000"),
+		},
+		{
+			name:      "tampered release",
+			release:   []byte("echoy release archive with a backdoor"),
+			signature: key.sign(release, algorithmPrehashed, "timestamp:1700000000"),
+			wantErr:   true,
+		},
+		{
+			name:      "signed by another key",
+			release:   release,
+			signature: newTestKey(t).sign(release, algorithmPrehashed, "timestamp:1700000000"),
+			wantErr:   true,
+		},
+		{
+			name:    "tampered trusted comment",
+			release: release,
+			signature: strings.Replace(key.sign(release, algorithmPrehashed, "timestamp:1700000000"),
+				"timestamp:1700000000", "timestamp:1800000000", 1),
+			wantErr: true,
+		},
+		{
+			name:      "checksum instead of signature",
+			release:   release,
+			signature: "4f2b8c1d  echoy.tar.gz\n",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.release, []byte(tt.signature))
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrInvalidSignature), "error should wrap ErrInvalidSignature, got %v", err)
+		})
+	}
+}
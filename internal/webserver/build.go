@@ -4,59 +4,111 @@ import (
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/chat"
 	"github.com/shaharia-lab/echoy/internal/config"
+	"github.com/shaharia-lab/echoy/internal/history"
+	"github.com/shaharia-lab/echoy/internal/httpx"
 	"github.com/shaharia-lab/echoy/internal/llm"
 	"github.com/shaharia-lab/echoy/internal/logger"
 	"github.com/shaharia-lab/echoy/internal/theme"
 	"github.com/shaharia-lab/echoy/internal/tools"
 	"github.com/shaharia-lab/echoy/internal/webui"
-	"github.com/shaharia-lab/goai"
 	"github.com/shaharia-lab/goai/mcp"
 	mcpTools "github.com/shaharia-lab/mcp-tools"
 	"net/http"
+	"time"
 )
 
-// BuildWebserver initializes the web server with the provided configuration and dependencies
-func BuildWebserver(config config.Config, themeManager *theme.Manager, webUIStaticDirectory string, logDirectory string) (*WebServer, error) {
-	serverLogger, err := logger.NewZapLogger(logger.Config{
-		LogLevel:    logger.DebugLevel,
-		LogFilePath: fmt.Sprintf("%s/webserver.log", logDirectory),
+// webUIDownloadTimeout bounds downloading a web UI release, which is bigger than the
+// responses the default client timeout is meant for
+const webUIDownloadTimeout = 5 * time.Minute
 
-		MaxSizeMB:  50,
-		MaxAgeDays: 14,
-		MaxBackups: 5,
-	})
+// BuildWebserver initializes the web server with the provided configuration and dependencies.
+// The version and repository of appConfig identify Echoy in outbound requests, and the
+// server logs with logConfig.
+func BuildWebserver(config config.Config, appConfig *config.AppConfig, themeManager *theme.Manager, webUIStaticDirectory string, logConfig logger.Config, chatHistoryDBPath string) (*WebServer, error) {
+	serverLogger, err := logger.NewZapLogger(logConfig)
 	if err != nil {
 		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to initialize webserver logger: %v", err))
 		return nil, fmt.Errorf("failed to initialize webserver logger: %w", err)
 	}
 
+	// A bad port is reported before anything is built, rather than when the server starts
+	if _, err := ListenAddress(config.WebServer.ResolvedAPIPort()); err != nil {
+		serverLogger.Errorf("Invalid webserver.api_port: %v", err)
+		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Invalid webserver.api_port: %v", err))
+		return nil, fmt.Errorf("webserver.api_port: %w", err)
+	}
+
+	location, err := config.UI.ResolvedLocation()
+	if err != nil {
+		serverLogger.Errorf("Invalid ui.timezone: %v", err)
+		themeManager.GetCurrentTheme().Error().Println(err.Error())
+		return nil, err
+	}
+
 	ts := []mcp.Tool{
 		mcpTools.GetWeather,
 	}
 
-	llmService, err := llm.NewLLMService(config.LLM)
+	llmHTTPClient, err := llm.NewHTTPClient(config.LLM, config.HTTP, appConfig.Version.Version)
+	if err != nil {
+		serverLogger.Errorf("Failed to create the HTTP client of the LLM provider: %v", err)
+		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to create the HTTP client of the LLM provider: %v", err))
+		return nil, err
+	}
+
+	llmService, err := llm.NewLLMService(config.LLM, llmHTTPClient)
 	if err != nil {
 		serverLogger.Errorf("Failed to create LLM service: %v", err)
 		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to create LLM service: %v", err))
 		return nil, err
 	}
 
-	historyService := goai.NewInMemoryChatHistoryStorage()
+	historyService, err := history.Open(config.Chat, chatHistoryDBPath, serverLogger)
+	if err != nil {
+		serverLogger.Errorf("Failed to open chat history storage: %v", err)
+		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to open chat history storage: %v", err))
+		return nil, err
+	}
 
-	chatService := chat.NewChatService(llmService, historyService)
+	chatService := chat.NewChatService(llmService, historyService, serverLogger).
+		SetRequestTimeout(config.LLM.ResolvedRequestTimeout()).
+		SetMaxTokens(config.LLM.MaxTokens).
+		SetModel(config.LLM.Provider, config.LLM.Model).
+		SetLogContent(config.LLM.LogContent)
 	chatHandler := chat.NewChatHandler(chatService)
-	webUIDownloaderHttpClient := &http.Client{
+	chatHandler.DefaultStreaming = config.APIStreamingEnabled()
+	chatHandler.MaxTokens = config.LLM.MaxTokens
+	chatHandler.Location = location
+	userAgent := fmt.Sprintf("%s (+%s)", httpx.UserAgent(appConfig.Version.Version), appConfig.Repository.URL())
+	webUIDownloaderHttpClient, err := httpx.NewClient(httpx.Options{
+		UserAgent: userAgent,
+		Timeout:   webUIDownloadTimeout,
+		CAFile:    config.HTTP.CAFile,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return nil
 		},
+	})
+	if err != nil {
+		serverLogger.Errorf("Failed to create the HTTP client of the web UI downloader: %v", err)
+		themeManager.GetCurrentTheme().Error().Println(fmt.Sprintf("Failed to create the HTTP client of the web UI downloader: %v", err))
+		return nil, err
 	}
 
-	return NewWebServer(
-		"10222",
+	server := NewWebServer(
+		config.WebServer.ResolvedAPIPort(),
 		webUIStaticDirectory,
 		tools.NewProvider(ts),
 		llm.NewLLMHandler(llm.GetSupportedLLMProviders()),
 		chatHandler,
-		webui.NewFrontendGitHubReleaseDownloader(webUIStaticDirectory, webUIDownloaderHttpClient, serverLogger),
-	), nil
+		webui.NewFrontendGitHubReleaseDownloader(webUIStaticDirectory, webUIDownloaderHttpClient, serverLogger).
+			SetGitHubAPIURL(config.Frontend.GitHubAPIURL).
+			SetDownloadURL(config.Frontend.DownloadURL).
+			SetUserAgent(userAgent),
+	)
+	server.EnableCompression = config.WebServer.CompressionEnabled()
+	server.MaxStreamDuration = config.WebServer.ResolvedMaxStreamDuration()
+	server.IdleShutdown = config.WebServer.IdleShutdown
+	server.EnablePprof = config.WebServer.EnablePprof
+
+	return server, nil
 }
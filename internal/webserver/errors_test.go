@@ -0,0 +1,72 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandlers(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.NotFound(notFoundHandler())
+	r.MethodNotAllowed(methodNotAllowedHandler(r))
+	r.Get("/api/v1/chats", ok)
+	r.Post("/api/v1/chats", ok)
+	r.Get("/api/v1/chats/{chatId}", ok)
+	r.Patch("/api/v1/chats/{chatId}", ok)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantAllow string
+		wantError string
+	}{
+		{
+			name:      "unknown path",
+			method:    http.MethodGet,
+			path:      "/api/v1/unknown",
+			wantCode:  http.StatusNotFound,
+			wantError: "no route for /api/v1/unknown",
+		},
+		{
+			name:      "wrong method",
+			method:    http.MethodDelete,
+			path:      "/api/v1/chats",
+			wantCode:  http.StatusMethodNotAllowed,
+			wantAllow: "GET, POST",
+			wantError: "method DELETE is not allowed for /api/v1/chats",
+		},
+		{
+			name:      "wrong method with a path parameter",
+			method:    http.MethodPost,
+			path:      "/api/v1/chats/123",
+			wantCode:  http.StatusMethodNotAllowed,
+			wantAllow: "GET, PATCH",
+			wantError: "method POST is not allowed for /api/v1/chats/123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantAllow, rec.Header().Get("Allow"))
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantError, body.Error)
+		})
+	}
+}
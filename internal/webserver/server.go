@@ -6,15 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"github.com/shaharia-lab/echoy/internal/chat"
+	"github.com/shaharia-lab/echoy/internal/config"
 	"github.com/shaharia-lab/echoy/internal/llm"
 	"github.com/shaharia-lab/echoy/internal/tools"
-	"github.com/shaharia-lab/echoy/internal/types"
 	"github.com/shaharia-lab/echoy/internal/webui"
 	"log"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -26,10 +31,69 @@ import (
 const ShutdownTimeout = 10 * time.Second
 const frontendBuildDirectoryName = "dist"
 
+// Default timeouts applied to the underlying http.Server
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 2 * time.Minute
+	DefaultIdleTimeout       = 120 * time.Second
+)
+
+// compressionLevel is the gzip level of compressed responses, a balance of size and CPU
+const compressionLevel = 5
+
 // WebServer represents a simple HTTP server
 type WebServer struct {
-	APIPort            string
-	server             *http.Server
+	// APIPort is where the server listens, a bare port such as 8080 on all interfaces or
+	// a host:port such as 127.0.0.1:8080, see ListenAddress. It is applied on Start.
+	APIPort string
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are applied to the
+	// http.Server on Start. Streaming routes manage their own write deadlines.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// EnableCompression gzips responses for clients that accept it. It is applied on
+	// Start and defaults to true.
+	EnableCompression bool
+
+	// MaxStreamDuration caps how long an answer is streamed before it is ended with a
+	// terminal event and its LLM call is cancelled, whether or not it is still producing
+	// tokens. Zero means no cap. It is applied on Start.
+	MaxStreamDuration time.Duration
+
+	// IdleShutdown stops the server once no request was served for this long, it can be
+	// started again. Requests still in flight keep it running. Zero disables it. It is
+	// applied on Start.
+	IdleShutdown time.Duration
+
+	// EnablePprof serves the handlers of net/http/pprof under /debug/pprof/. It is
+	// applied on Start.
+	EnablePprof bool
+
+	// activeRequests and lastRequest, the end of the last request in Unix nanoseconds,
+	// tell the idle shutdown whether the server is in use
+	activeRequests atomic.Int64
+	lastRequest    atomic.Int64
+
+	// mu guards server, served, addr and started, Start and Stop are called from daemon
+	// commands and from the daemon's shutdown concurrently
+	mu     sync.Mutex
+	server *http.Server
+	// served is closed once Serve returned and the listener is released
+	served chan struct{}
+	// addr is the address the running server listens on, the port is chosen by the
+	// system when APIPort is 0
+	addr net.Addr
+	// exited receives the error of a server that stopped on its own
+	exited chan error
+	// started is set by the first Start, the router is in use from then on
+	started bool
+	// mounts are the handlers added with Mount, attached to the router by the first Start
+	mounts []mount
+
 	router             *chi.Mux
 	webStaticDirectory string
 	toolsProvider      *tools.Provider
@@ -54,8 +118,8 @@ func NewWebServer(
 	r := chi.NewRouter()
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID"},
 		ExposedHeaders:   []string{"Link", "X-MKit-Chat-UUID"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -63,19 +127,112 @@ func NewWebServer(
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
-	return &WebServer{
+	ws := &WebServer{
 		APIPort:            apiPort,
+		ReadHeaderTimeout:  DefaultReadHeaderTimeout,
+		ReadTimeout:        DefaultReadTimeout,
+		WriteTimeout:       DefaultWriteTimeout,
+		IdleTimeout:        DefaultIdleTimeout,
+		EnableCompression:  true,
+		MaxStreamDuration:  config.DefaultMaxStreamDuration,
 		router:             r,
+		exited:             make(chan error, 1),
 		webStaticDirectory: webStaticDirectory,
 		toolsProvider:      toolsProvider,
 		llmHandler:         llmHandler,
 		chatHandler:        chatHandler,
 		frontendDownloader: frontendDownloader,
 	}
+	r.Use(ws.trackActivity)
+
+	return ws
+}
+
+// trackActivity records the requests in flight and when the last one ended, for the idle
+// shutdown
+func (ws *WebServer) trackActivity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.activeRequests.Add(1)
+		defer func() {
+			ws.lastRequest.Store(time.Now().UnixNano())
+			ws.activeRequests.Add(-1)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Mount attaches handler to the router of the server at pattern, for embedders adding
+// their own endpoints next to the built-in ones. Like chi's Mount, handler serves pattern
+// and every path below it, and a chi.Router handler matches its routes relative to
+// pattern. The routes go through the middleware of the server: CORS, request logging,
+// panic recovery and the idle tracking.
+//
+// Mount must be called before the first Start, it panics otherwise. The handlers are
+// attached by Start, which fails if pattern clashes with a built-in route, e.g. /api or
+// /web/plugin, rather than let one of them silently replace the other.
+func (ws *WebServer) Mount(pattern string, handler http.Handler) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.started {
+		panic(fmt.Sprintf("webserver: Mount of %s after the server was started", pattern))
+	}
+
+	ws.mounts = append(ws.mounts, mount{pattern: pattern, handler: handler})
+}
+
+// mount is a handler added with Mount
+type mount struct {
+	pattern string
+	handler http.Handler
+}
+
+// mountRoutes attaches the handlers added with Mount to the router, once the built-in
+// routes are set up
+func (ws *WebServer) mountRoutes() error {
+	var builtIn []string
+	err := chi.Walk(ws.router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		builtIn = append(builtIn, route)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list the routes of the server: %w", err)
+	}
+
+	for _, m := range ws.mounts {
+		for _, route := range builtIn {
+			if routesClash(m.pattern, route) {
+				return fmt.Errorf("can't mount %s, it clashes with the built-in route %s", m.pattern, route)
+			}
+		}
+	}
+
+	for _, m := range ws.mounts {
+		ws.router.Mount(m.pattern, m.handler)
+	}
+
+	return nil
+}
+
+// routesClash reports whether a handler mounted at pattern, which serves pattern and
+// every path below it, would take requests from route or lose them to it
+func routesClash(pattern, route string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" || route == pattern || strings.HasPrefix(route, pattern+"/") {
+		return true
+	}
+
+	// A wildcard route such as /web/* serves everything below its prefix
+	prefix, wildcard := strings.CutSuffix(route, "/*")
+	return wildcard && strings.HasPrefix(pattern+"/", prefix+"/")
 }
 
 // setupRoutes configures the default routes
 func (ws *WebServer) setupRoutes() {
+	ws.router.NotFound(notFoundHandler())
+	ws.router.MethodNotAllowed(methodNotAllowedHandler(ws.router))
+
 	ws.router.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("pong"))
 	})
@@ -89,54 +246,213 @@ func (ws *WebServer) setupRoutes() {
 		w.Write([]byte("pong"))
 	})
 
+	// Liveness of the web server itself, cheap enough to be probed often
+	ws.router.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	// Readiness of the configured LLM, every check is a billed completion
+	ws.router.Get("/readyz", ws.chatHandler.HandleReadinessRequest())
+
+	if ws.EnablePprof {
+		ws.router.HandleFunc("/debug/pprof/*", pprof.Index)
+		ws.router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		ws.router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		ws.router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		ws.router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
 	// Serve static files from the dist directory
 	fileServer := http.FileServer(http.Dir(filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName)))
 	ws.router.Handle("/web", http.StripPrefix("/web", fileServer))
 	ws.router.Handle("/web/*", http.StripPrefix("/web", fileServer))
 
-	// tools related routes
-	ws.router.Get("/api/v1/tools", ws.toolsProvider.ListToolsHTTPHandler())
-	ws.router.Get("/api/v1/tools/{name}", ws.toolsProvider.GetToolByNameHTTPHandler())
+	ws.router.Group(func(r chi.Router) {
+		// Only the types chi compresses by default are gzipped, so already compressed
+		// content like images is left alone, and so are event streams, which POST
+		// /api/v1/chats answers with when streaming
+		if ws.EnableCompression {
+			r.Use(middleware.Compress(compressionLevel))
+		}
+		r.Use(limitStreamDuration(ws.MaxStreamDuration))
+
+		// tools related routes
+		r.Get("/api/v1/tools", ws.toolsProvider.ListToolsHTTPHandler())
+		r.Get("/api/v1/tools/{name}", ws.toolsProvider.GetToolByNameHTTPHandler())
+
+		// LLM related routes
+		r.Get("/api/v1/llm/providers", ws.llmHandler.ListProvidersHTTPHandler())
+		r.Get("/api/v1/llm/providers/{id}", ws.llmHandler.GetProviderByIDHTTPHandler())
+
+		// Chat related routes
+		r.Post("/api/v1/chats", ws.chatHandler.HandleChatRequest())
+		r.Post("/api/v1/chats/new", ws.chatHandler.HandleNewChatRequest())
+		r.Get("/api/v1/chats", ws.chatHandler.HandleChatHistoryRequest())
+		r.Get("/api/v1/chats/search", ws.chatHandler.HandleChatSearchRequest())
+		r.Get("/api/v1/chats/export", ws.chatHandler.HandleChatExportRequest())
+		r.Get("/api/v1/chats/{chatId}", ws.chatHandler.HandleChatByIDRequest())
+		r.Patch("/api/v1/chats/{chatId}", ws.chatHandler.HandleChatRenameRequest())
+		r.Delete("/api/v1/chats/{chatId}", ws.chatHandler.HandleChatDeleteRequest())
+		r.Post("/api/v1/chats/{chatId}/continue", ws.chatHandler.HandleChatContinueRequest())
+		r.Post("/api/v1/chats/{chatId}/cancel", ws.chatHandler.HandleChatCancelRequest())
+		r.Post("/api/v1/chats/{chatId}/fork", ws.chatHandler.HandleChatForkRequest())
+	})
+
+	// The stream is never compressed, gzip would hold events back until its buffer fills
+	ws.router.With(limitStreamDuration(ws.MaxStreamDuration)).
+		Post("/api/v1/chats/stream", ws.chatHandler.HandleChatStreamRequest())
+}
 
-	// LLM related routes
-	ws.router.Get("/api/v1/llm/providers", ws.llmHandler.ListProvidersHTTPHandler())
-	ws.router.Get("/api/v1/llm/providers/{id}", ws.llmHandler.GetProviderByIDHTTPHandler())
+// limitStreamDuration caps how long the chat handlers stream an answer to d, see
+// chat.WithMaxStreamDuration
+func limitStreamDuration(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(chat.WithMaxStreamDuration(r.Context(), d)))
+		})
+	}
+}
 
-	// Chat related routes
-	ws.router.Post("/api/v1/chats", ws.chatHandler.HandleChatRequest())
-	ws.router.Get("/api/v1/chats", ws.chatHandler.HandleChatHistoryRequest())
-	ws.router.Get("/api/v1/chats/{chatId}", ws.chatHandler.HandleChatByIDRequest())
-	ws.router.Post("/api/v1/chats/stream", ws.chatHandler.HandleChatStreamRequest())
+// ListenAddress validates apiPort, a bare port or a host:port, and returns the address
+// to listen on: ":8080" for 8080, and host:port unchanged. The port must be a number from
+// 0 to 65535, 0 letting the system choose one.
+func ListenAddress(apiPort string) (string, error) {
+	apiPort = strings.TrimSpace(apiPort)
+	if apiPort == "" {
+		return "", errors.New("invalid API port: it is empty")
+	}
+
+	host, port := "", apiPort
+	if strings.Contains(apiPort, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(apiPort); err != nil {
+			return "", fmt.Errorf("invalid API address %q, expected a port such as 8080 or a host:port such as 127.0.0.1:8080: %w", apiPort, err)
+		}
+	}
+
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 0 || number > 65535 {
+		return "", fmt.Errorf("invalid API port %q, expected a number from 0 to 65535", port)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(number)), nil
 }
 
-// Start initializes and starts the HTTP server
-func (ws *WebServer) Start() error {
-	err := ws.prepareWebUIFrontendDirectory()
+// Start initializes and starts the HTTP server. The port is bound before Start returns,
+// so an address already in use is reported to the caller, and so is an invalid APIPort.
+// ctx bounds the preparation of the web UI, whose download progress is reported with the
+// progress package; the server keeps running after ctx is done.
+func (ws *WebServer) Start(ctx context.Context) error {
+	addr, err := ListenAddress(ws.APIPort)
+	if err != nil {
+		return err
+	}
+
+	err = ws.prepareWebUIFrontendDirectory(ctx)
 	if err != nil {
 		return err
 	}
 
-	if ws.server != nil {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.runningLocked() {
 		return errors.New("server already running")
 	}
 
+	// Drop the exit of a previous run, this one is started anyway
+	select {
+	case <-ws.exited:
+	default:
+	}
+
 	ws.setupRoutes()
+	if !ws.started {
+		if err := ws.mountRoutes(); err != nil {
+			return err
+		}
+	}
+	ws.started = true
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           ws.router,
+		ReadHeaderTimeout: ws.ReadHeaderTimeout,
+		ReadTimeout:       ws.ReadTimeout,
+		WriteTimeout:      ws.WriteTimeout,
+		IdleTimeout:       ws.IdleTimeout,
+	}
 
-	ws.server = &http.Server{
-		Addr:    ":" + ws.APIPort,
-		Handler: ws.router,
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
 	}
 
+	served := make(chan struct{})
 	go func() {
-		if err := ws.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("HTTP server ListenAndServe error: %v", err)
+		defer close(served)
+		if err := serve(server, listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP server Serve error: %v", err)
+			select {
+			case ws.exited <- err:
+			default:
+			}
 		}
 	}()
 
+	ws.server = server
+	ws.served = served
+	ws.addr = listener.Addr()
+
+	if ws.IdleShutdown > 0 {
+		ws.lastRequest.Store(time.Now().UnixNano())
+		go ws.watchIdle(served, ws.IdleShutdown)
+	}
+
 	return nil
 }
 
-func (ws *WebServer) prepareWebUIFrontendDirectory() error {
+// serve runs server on listener and turns a panic into an error, so a crash doesn't take
+// the daemon down with it
+func serve(server *http.Server, listener net.Listener) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("web server panicked: %v", r)
+		}
+	}()
+
+	return server.Serve(listener)
+}
+
+// Running reports whether the server is started and hasn't exited
+func (ws *WebServer) Running() bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.runningLocked()
+}
+
+func (ws *WebServer) runningLocked() bool {
+	if ws.server == nil {
+		return false
+	}
+
+	select {
+	case <-ws.served:
+		return false
+	default:
+		return true
+	}
+}
+
+// Exited receives the error the server exited with when it stops on its own, e.g. because
+// it panicked. It isn't signalled by Stop.
+func (ws *WebServer) Exited() <-chan error {
+	return ws.exited
+}
+
+func (ws *WebServer) prepareWebUIFrontendDirectory(ctx context.Context) error {
 	distDirPath := filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName)
 
 	if info, err := os.Stat(distDirPath); err == nil && info.IsDir() {
@@ -155,7 +471,12 @@ func (ws *WebServer) prepareWebUIFrontendDirectory() error {
 	}
 
 	log.Printf("Downloading frontend files...")
-	if err := ws.frontendDownloader.DownloadFrontend("latest"); err != nil {
+	if err := ws.frontendDownloader.DownloadFrontend(ctx, "latest"); err != nil {
+		if errors.Is(err, webui.ErrInsufficientDiskSpace) {
+			log.Printf("Not enough disk space for frontend files, nothing was downloaded: %v", err)
+			return fmt.Errorf("not enough disk space to download the web UI, free up some space and try again: %w", err)
+		}
+
 		log.Printf("Failed to download frontend files: %v", err)
 		return fmt.Errorf("failed to download frontend files: %w", err)
 	}
@@ -164,8 +485,53 @@ func (ws *WebServer) prepareWebUIFrontendDirectory() error {
 	return nil
 }
 
-// Stop gracefully shuts down the server and blocks until shutdown is complete or timeout occurs
+// Stop gracefully shuts down the server and blocks until shutdown is complete or timeout occurs.
+// In-flight requests are given until then to finish, after which their connections are
+// closed. The listener is released when Stop returns.
 func (ws *WebServer) Stop(ctx context.Context) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	return ws.stopLocked(ctx)
+}
+
+// watchIdle stops the run of the server that closes served once it served no request for
+// idle. It returns when that run ends.
+func (ws *WebServer) watchIdle(served chan struct{}, idle time.Duration) {
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-served:
+			return
+		case <-timer.C:
+		}
+
+		remaining := idle - time.Since(time.Unix(0, ws.lastRequest.Load()))
+		if ws.activeRequests.Load() > 0 {
+			remaining = idle
+		}
+		if remaining > 0 {
+			timer.Reset(remaining)
+			continue
+		}
+
+		ws.mu.Lock()
+		// The server may have been stopped and started again in the meantime
+		if ws.served == served {
+			log.Printf("No request was served for %s, stopping the web server", idle)
+			if err := ws.stopLocked(context.Background()); err != nil {
+				log.Printf("Failed to stop the idle web server: %v", err)
+			}
+		}
+		ws.mu.Unlock()
+		return
+	}
+}
+
+// stopLocked gracefully shuts the server down. mu must be held.
+func (ws *WebServer) stopLocked(ctx context.Context) error {
 	if ws.server == nil {
 		return nil
 	}
@@ -174,36 +540,31 @@ func (ws *WebServer) Stop(ctx context.Context) error {
 	defer cancel()
 
 	err := ws.server.Shutdown(shutdownCtx)
+	if err != nil {
+		// Requests that didn't drain in time are cut off
+		ws.server.Close()
+	}
+	<-ws.served
 
 	ws.server = nil
+	ws.served = nil
+	ws.addr = nil
 
 	return err
 }
 
-// DaemonCommandHandler returns a CommandFunc that starts the web server
-func (ws *WebServer) DaemonCommandHandler() types.CommandFunc {
-	return func(ctx context.Context, args []string) (string, error) {
-		if len(args) == 0 {
-			return "", fmt.Errorf("missing subcommand: please specify 'start' or 'stop'")
-		}
+// Status describes whether the web server is running and the port it listens on
+func (ws *WebServer) Status() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 
-		subcommand := strings.ToLower(args[0])
-
-		switch subcommand {
-		case "start":
-			if err := ws.Start(); err != nil {
-				return "", fmt.Errorf("failed to start web server: %w", err)
-			}
-			return fmt.Sprintf("Web server started successfully on port %s", ws.APIPort), nil
-
-		case "stop":
-			if err := ws.Stop(ctx); err != nil {
-				return "", fmt.Errorf("failed to stop web server: %w", err)
-			}
-			return "Web server stopped successfully", nil
+	if !ws.runningLocked() {
+		return "stopped"
+	}
 
-		default:
-			return "", fmt.Errorf("unknown subcommand '%s': valid subcommands are 'start', 'stop', and 'status'", subcommand)
-		}
+	port := ws.APIPort
+	if addr, ok := ws.addr.(*net.TCPAddr); ok {
+		port = strconv.Itoa(addr.Port)
 	}
+	return fmt.Sprintf("running on port %s", port)
 }
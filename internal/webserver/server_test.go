@@ -0,0 +1,301 @@
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shaharia-lab/echoy/internal/chat"
+	chatMocks "github.com/shaharia-lab/echoy/internal/chat/mocks"
+	"github.com/shaharia-lab/echoy/internal/llm"
+	"github.com/shaharia-lab/echoy/internal/tools"
+	"github.com/shaharia-lab/goai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebServer(t *testing.T, chatService chat.Service) *WebServer {
+	t.Helper()
+
+	return NewWebServer(
+		"0",
+		t.TempDir(),
+		tools.NewProvider(nil),
+		llm.NewLLMHandler(llm.GetSupportedLLMProviders()),
+		chat.NewChatHandler(chatService),
+		nil,
+	)
+}
+
+func TestWebServer_Compression(t *testing.T) {
+	chatService := chatMocks.NewMockService(t)
+	ws := newTestWebServer(t, chatService)
+	ws.setupRoutes()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/llm/providers", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	ws.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"), "JSON responses should be compressed")
+
+	stream := make(chan goai.StreamingLLMResponse, 1)
+	stream <- goai.StreamingLLMResponse{Text: "Hello", Done: true}
+	close(stream)
+	chatService.EXPECT().ChatStreaming(mock.Anything, uuid.Nil, "Hi").Return(stream, nil).Once()
+
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/chats/stream", strings.NewReader(`{"question":"Hi"}`))
+	request.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	ws.router.ServeHTTP(rec, request)
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"), "event streams should never be compressed")
+	assert.Contains(t, rec.Body.String(), "Hello")
+}
+
+func TestWebServer_Pprof(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			ws := newTestWebServer(t, chatMocks.NewMockService(t))
+			ws.EnablePprof = enabled
+			ws.setupRoutes()
+
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+				rec := httptest.NewRecorder()
+				ws.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+				if enabled {
+					assert.Equal(t, http.StatusOK, rec.Code, path)
+				} else {
+					assert.Equal(t, http.StatusNotFound, rec.Code, path)
+				}
+			}
+		})
+	}
+}
+
+func TestWebServer_CompressionDisabled(t *testing.T) {
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	ws.EnableCompression = false
+	ws.setupRoutes()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/llm/providers", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	ws.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+}
+
+func TestWebServer_Healthz(t *testing.T) {
+	// The mock fails the test if the LLM is checked
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	ws.setupRoutes()
+
+	rec := httptest.NewRecorder()
+	ws.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestWebServer_Status(t *testing.T) {
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	require.NoError(t, os.MkdirAll(filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName), 0755))
+
+	assert.Equal(t, "stopped", ws.Status())
+
+	require.NoError(t, ws.Start(context.Background()))
+	status := ws.Status()
+	assert.Regexp(t, `^running on port \d+$`, status)
+	assert.NotEqual(t, "running on port 0", status, "the port chosen by the system should be reported")
+
+	require.NoError(t, ws.Stop(context.Background()))
+	assert.Equal(t, "stopped", ws.Status())
+}
+
+func TestListenAddress(t *testing.T) {
+	tests := []struct {
+		apiPort string
+		want    string
+		wantErr string
+	}{
+		{apiPort: "8080", want: ":8080"},
+		{apiPort: " 8080 ", want: ":8080"},
+		{apiPort: "0", want: ":0"},
+		{apiPort: ":8080", want: ":8080"},
+		{apiPort: "127.0.0.1:8080", want: "127.0.0.1:8080"},
+		{apiPort: "localhost:8080", want: "localhost:8080"},
+		{apiPort: "[::1]:8080", want: "[::1]:8080"},
+		{apiPort: "", wantErr: "it is empty"},
+		{apiPort: "http", wantErr: `invalid API port "http"`},
+		{apiPort: "65536", wantErr: "from 0 to 65535"},
+		{apiPort: "-1", wantErr: "from 0 to 65535"},
+		{apiPort: "localhost:", wantErr: `invalid API port ""`},
+		{apiPort: "::1:8080", wantErr: `invalid API address "::1:8080"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.apiPort, func(t *testing.T) {
+			addr, err := ListenAddress(tt.apiPort)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, addr)
+		})
+	}
+}
+
+func TestWebServer_Start_InvalidPort(t *testing.T) {
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	ws.APIPort = "web"
+
+	assert.ErrorContains(t, ws.Start(context.Background()), `invalid API port "web"`)
+	assert.False(t, ws.Running())
+}
+
+func TestWebServer_Mount(t *testing.T) {
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	require.NoError(t, os.MkdirAll(filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName), 0755))
+
+	plugin := chi.NewRouter()
+	plugin.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello from " + r.URL.Path))
+	})
+	ws.Mount("/plugin", plugin)
+	ws.Mount("/panics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("broken plugin")
+	}))
+
+	require.NoError(t, ws.Start(context.Background()))
+	t.Cleanup(func() { ws.Stop(context.Background()) })
+
+	response, err := http.Get("http://" + ws.addr.String() + "/plugin/hello")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "hello from /plugin/hello", string(body), "the routes should be relative to the pattern")
+
+	response, err = http.Get("http://" + ws.addr.String() + "/panics")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "mounted handlers should go through the panic recovery")
+
+	response, err = http.Get("http://" + ws.addr.String() + "/ping")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode, "the built-in routes should be kept")
+
+	assert.Panics(t, func() { ws.Mount("/late", plugin) }, "routes can't be added once the server started")
+
+	require.NoError(t, ws.Stop(context.Background()))
+	require.NoError(t, ws.Start(context.Background()), "mounted routes should survive a restart")
+}
+
+func TestWebServer_Mount_Clash(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		wantClash string
+	}{
+		{pattern: "/healthz", wantClash: "/healthz"},
+		{pattern: "/api", wantClash: "/api/v1/"},
+		{pattern: "/web/plugin", wantClash: "/web/*"},
+		{pattern: "/", wantClash: "/"},
+		{pattern: "/api/v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			ws := newTestWebServer(t, chatMocks.NewMockService(t))
+			require.NoError(t, os.MkdirAll(filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName), 0755))
+
+			ws.Mount(tt.pattern, http.NotFoundHandler())
+			err := ws.Start(context.Background())
+			t.Cleanup(func() { ws.Stop(context.Background()) })
+
+			if tt.wantClash == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, "can't mount "+tt.pattern+", it clashes with the built-in route "+tt.wantClash)
+			assert.False(t, ws.Running())
+		})
+	}
+}
+
+func TestWebServer_IdleShutdown(t *testing.T) {
+	ws := newTestWebServer(t, chatMocks.NewMockService(t))
+	require.NoError(t, os.MkdirAll(filepath.Join(ws.webStaticDirectory, frontendBuildDirectoryName), 0755))
+	ws.IdleShutdown = 200 * time.Millisecond
+
+	require.NoError(t, ws.Start(context.Background()))
+	t.Cleanup(func() { ws.Stop(context.Background()) })
+
+	// Requests keep the server running past the idle period
+	url := "http://" + ws.addr.String() + "/ping"
+	for i := 0; i < 4; i++ {
+		response, err := http.Get(url)
+		require.NoError(t, err)
+		response.Body.Close()
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.True(t, ws.Running(), "the server should keep running while it is used")
+
+	assert.Eventually(t, func() bool { return !ws.Running() }, 2*time.Second, 20*time.Millisecond, "the idle server should stop")
+
+	require.NoError(t, ws.Start(context.Background()), "a server stopped for being idle should start again")
+	assert.True(t, ws.Running())
+}
+
+func TestWebServer_MaxStreamDuration(t *testing.T) {
+	var generationCtx context.Context
+	chatService := chatMocks.NewMockService(t)
+	chatService.EXPECT().ChatStreaming(mock.Anything, uuid.Nil, "Hi").RunAndReturn(
+		func(ctx context.Context, _ uuid.UUID, _ string) (<-chan goai.StreamingLLMResponse, error) {
+			generationCtx = ctx
+
+			// A runaway model, producing tokens until it is cancelled
+			stream := make(chan goai.StreamingLLMResponse)
+			go func() {
+				defer close(stream)
+				for {
+					select {
+					case stream <- goai.StreamingLLMResponse{Text: "more "}:
+						time.Sleep(5 * time.Millisecond)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return stream, nil
+		})
+
+	ws := newTestWebServer(t, chatService)
+	ws.MaxStreamDuration = 50 * time.Millisecond
+	ws.setupRoutes()
+
+	started := time.Now()
+	rec := httptest.NewRecorder()
+	ws.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/chats/stream", strings.NewReader(`{"question":"Hi"}`)))
+
+	assert.Less(t, time.Since(started), time.Second, "the stream should end once the maximum duration elapsed")
+	assert.Contains(t, rec.Body.String(), "more ")
+	assert.True(t, strings.HasSuffix(rec.Body.String(), `data: {"error":"the answer took longer than the maximum stream duration","done":true,"finish_reason":"max_duration"}`+"\n\n"))
+	assert.Eventually(t, func() bool { return generationCtx.Err() != nil }, time.Second, 10*time.Millisecond, "the LLM call should be cancelled")
+}
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package webui
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// availableDiskSpace returns the number of bytes available to the current user on the volume holding path
+func availableDiskSpace(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytesAvailable uint64
+	ret, _, err := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&freeBytesAvailable)), 0, 0)
+	if ret == 0 {
+		return 0, err
+	}
+
+	return freeBytesAvailable, nil
+}
@@ -5,12 +5,18 @@ import (
 	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/shaharia-lab/echoy/internal/httpx"
 	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/progress"
 	"io"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,8 +27,48 @@ const (
 	githubAPIBaseURL = "https://api.github.com"
 	assetFileName    = "dist.zip"
 	downloadTimeout  = 60 * time.Second
+
+	// extractionSizeFactor estimates how much larger the extracted assets are than dist.zip
+	extractionSizeFactor = 3
+
+	// releaseMarkerFileName is the file in the destination directory describing the installed release
+	releaseMarkerFileName = ".echoy-webui-release.json"
+)
+
+// Environment variables overriding where releases are looked up and downloaded from. They
+// take precedence over the configured URLs.
+const (
+	GitHubAPIURLEnv = "ECHOY_GITHUB_API_URL"
+	DownloadURLEnv  = "ECHOY_DOWNLOAD_URL"
 )
 
+// githubTokenEnv names the environment variable holding an optional GitHub token, which
+// raises the API rate limit for release lookups
+const githubTokenEnv = "GITHUB_TOKEN"
+
+// RateLimitError is returned when GitHub rejects a request because the API rate limit is exceeded
+type RateLimitError struct {
+	// ResetAt is when requests are allowed again, zero if GitHub didn't say
+	ResetAt time.Time
+	// Authenticated reports whether the request was made with GITHUB_TOKEN
+	Authenticated bool
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	if !e.ResetAt.IsZero() {
+		msg += fmt.Sprintf(", the limit resets at %s", e.ResetAt.Local().Format(time.RFC1123))
+	}
+	if !e.Authenticated {
+		msg += fmt.Sprintf("; set %s to a GitHub token to raise the limit", githubTokenEnv)
+	}
+	return msg
+}
+
+// ErrInsufficientDiskSpace is returned when there isn't enough free space to download and extract the assets
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
 // HTTPClient is an interface that wraps the Do method, allowing for custom HTTP clients.
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -30,7 +76,9 @@ type HTTPClient interface {
 
 // FrontendDownloader is an interface for downloading the frontend assets.
 type FrontendDownloader interface {
-	DownloadFrontend(version string) error
+	// DownloadFrontend downloads and extracts the assets of version. Its progress is
+	// reported with the progress package.
+	DownloadFrontend(ctx context.Context, version string) error
 }
 
 type release struct {
@@ -38,6 +86,23 @@ type release struct {
 	Assets  []asset `json:"assets"`
 }
 
+// releaseLookup is the result of looking up a release. notModified is set if the release
+// metadata didn't change since the ETag that was sent along.
+type releaseLookup struct {
+	asset       asset
+	tagName     string
+	etag        string
+	notModified bool
+}
+
+// releaseMarker records which release is extracted in the destination directory, so
+// unchanged releases aren't downloaded again
+type releaseMarker struct {
+	Version string `json:"version"`
+	TagName string `json:"tag_name"`
+	ETag    string `json:"etag,omitempty"`
+}
+
 type asset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
@@ -50,6 +115,11 @@ type FrontendGitHubReleaseDownloader struct {
 	DestinationDirectory string
 	httpClient           HTTPClient
 	logger               logger.Logger
+	freeSpace            func(path string) (uint64, error)
+
+	apiBaseURL      string
+	downloadBaseURL string
+	userAgent       string
 }
 
 // NewFrontendGitHubReleaseDownloader creates a new instance of FrontendGitHubReleaseDownloader.
@@ -58,20 +128,108 @@ func NewFrontendGitHubReleaseDownloader(destinationDirectory string, httpClient
 		DestinationDirectory: destinationDirectory,
 		httpClient:           httpClient,
 		logger:               logger,
+		freeSpace:            availableDiskSpace,
+		userAgent:            httpx.UserAgent(""),
+	}
+}
+
+// SetUserAgent sets the User-Agent of the downloader's requests. GitHub's API rejects
+// requests without one.
+func (d *FrontendGitHubReleaseDownloader) SetUserAgent(userAgent string) *FrontendGitHubReleaseDownloader {
+	if userAgent != "" {
+		d.userAgent = userAgent
+	}
+	return d
+}
+
+// SetGitHubAPIURL sets the GitHub API releases are looked up in, e.g. the /api/v3
+// endpoint of GitHub Enterprise. Empty keeps the public GitHub API.
+func (d *FrontendGitHubReleaseDownloader) SetGitHubAPIURL(apiBaseURL string) *FrontendGitHubReleaseDownloader {
+	d.apiBaseURL = apiBaseURL
+	return d
+}
+
+// SetDownloadURL sets a base URL replacing the scheme and host of release asset URLs, for
+// mirrors serving the assets under GitHub's paths. Empty downloads from where the release points.
+func (d *FrontendGitHubReleaseDownloader) SetDownloadURL(downloadBaseURL string) *FrontendGitHubReleaseDownloader {
+	d.downloadBaseURL = downloadBaseURL
+	return d
+}
+
+// githubAPIURL returns the API base URL from GitHubAPIURLEnv, the configuration or the default
+func (d *FrontendGitHubReleaseDownloader) githubAPIURL() string {
+	for _, candidate := range []string{os.Getenv(GitHubAPIURLEnv), d.apiBaseURL} {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			return strings.TrimSuffix(candidate, "/")
+		}
+	}
+
+	return githubAPIBaseURL
+}
+
+// assetDownloadURL moves assetURL to the mirror from DownloadURLEnv or the configuration,
+// keeping its path and query. Without a mirror assetURL is returned as it is.
+func (d *FrontendGitHubReleaseDownloader) assetDownloadURL(assetURL string) (string, error) {
+	mirror := strings.TrimSpace(os.Getenv(DownloadURLEnv))
+	if mirror == "" {
+		mirror = strings.TrimSpace(d.downloadBaseURL)
+	}
+	if mirror == "" {
+		return assetURL, nil
+	}
+
+	base, err := url.Parse(mirror)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return "", fmt.Errorf("invalid download URL '%s', it must be an absolute URL", mirror)
 	}
+
+	asset, err := url.Parse(assetURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid asset URL '%s': %w", assetURL, err)
+	}
+
+	base.Path = strings.TrimSuffix(base.Path, "/") + asset.Path
+	base.RawQuery = asset.RawQuery
+
+	return base.String(), nil
 }
 
 // DownloadFrontend downloads the frontend assets from a GitHub release and extracts them to the specified directory.
-func (d *FrontendGitHubReleaseDownloader) DownloadFrontend(version string) error {
+// Progress is reported to the reporter of ctx, see progress.Report.
+func (d *FrontendGitHubReleaseDownloader) DownloadFrontend(ctx context.Context, version string) error {
 	d.logger.WithField("version", version).Info("Downloading frontend assets...")
-	downloadURL, err := d.getDownloadURL(version)
+	installed, isInstalled := d.installedRelease(version)
+
+	_ = progress.Report(ctx, "Looking up the web UI release")
+	lookup, err := d.getReleaseForVersion(ctx, version, installed.ETag)
+	if err != nil {
+		d.logger.WithField("error", err).Error("Failed to get download URL")
+		return fmt.Errorf("failed to get download URL: %w", err)
+	}
+
+	if isInstalled && (lookup.notModified || lookup.tagName == installed.TagName) {
+		d.logger.WithFields(map[string]interface{}{
+			"version":      version,
+			"tag_name":     installed.TagName,
+			"not_modified": lookup.notModified,
+		}).Info("Frontend assets are up to date")
+		return nil
+	}
+
+	distAsset := lookup.asset
+	downloadURL, err := d.assetDownloadURL(distAsset.BrowserDownloadURL)
 	if err != nil {
 		d.logger.WithField("error", err).Error("Failed to get download URL")
 		return fmt.Errorf("failed to get download URL: %w", err)
 	}
 
+	if err := d.checkDiskSpace(distAsset); err != nil {
+		d.logger.WithFields(map[string]interface{}{"error": err, "asset_size": distAsset.Size}).Error("Not enough disk space for frontend assets")
+		return err
+	}
+
 	d.logger.WithFields(map[string]interface{}{"version": version, "download_url": downloadURL}).Info("Downloading frontend asset...")
-	zipPath, err := d.downloadAsset(downloadURL)
+	zipPath, err := d.downloadAsset(ctx, downloadURL, int64(distAsset.Size))
 	if err != nil {
 		d.logger.WithField("error", err).Error("Failed to download frontend asset")
 		return fmt.Errorf("failed to download frontend asset: %w", err)
@@ -79,12 +237,17 @@ func (d *FrontendGitHubReleaseDownloader) DownloadFrontend(version string) error
 	defer os.Remove(zipPath)
 
 	d.logger.WithField("zip_path", zipPath).Info("Extracting frontend asset...")
+	_ = progress.Report(ctx, "Extracting the web UI")
 
 	if err := d.extractZip(zipPath); err != nil {
 		d.logger.WithField("error", err).Error("Failed to extract frontend asset")
 		return fmt.Errorf("failed to extract frontend: %w", err)
 	}
 
+	if err := d.writeReleaseMarker(releaseMarker{Version: version, TagName: lookup.tagName, ETag: lookup.etag}); err != nil {
+		d.logger.WithField("error", err).Warn("Failed to record the installed frontend release")
+	}
+
 	d.logger.WithFields(map[string]interface{}{
 		"zip_path":              zipPath,
 		"destination_directory": d.DestinationDirectory,
@@ -95,64 +258,179 @@ func (d *FrontendGitHubReleaseDownloader) DownloadFrontend(version string) error
 	return nil
 }
 
-func (d *FrontendGitHubReleaseDownloader) getReleaseURL(releasePath string, releaseIdentifier string) (string, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/%s",
-		githubAPIBaseURL,
+func (d *FrontendGitHubReleaseDownloader) getRelease(ctx context.Context, releasePath string, releaseIdentifier string, etag string) (releaseLookup, error) {
+	releaseURL := fmt.Sprintf("%s/repos/%s/%s/%s",
+		d.githubAPIURL(),
 		webUIRepoOwner,
 		webUIRepoName,
 		releasePath,
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releaseURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return releaseLookup{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", d.userAgent)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	token := os.Getenv(githubTokenEnv)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get release %s: %w", releaseIdentifier, err)
+		return releaseLookup{}, fmt.Errorf("failed to get release %s: %w", releaseIdentifier, err)
 	}
 	defer resp.Body.Close()
 
+	if rateLimitErr := rateLimitError(resp, token != ""); rateLimitErr != nil {
+		return releaseLookup{}, fmt.Errorf("failed to get release %s: %w", releaseIdentifier, rateLimitErr)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return releaseLookup{etag: etag, notModified: true}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to get release %s, status code: %d", releaseIdentifier, resp.StatusCode)
+		return releaseLookup{}, fmt.Errorf("failed to get release %s, status code: %d", releaseIdentifier, resp.StatusCode)
 	}
 
 	var rel release
 	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
-		return "", fmt.Errorf("failed to decode release info: %w", err)
+		return releaseLookup{}, fmt.Errorf("failed to decode release info: %w", err)
 	}
 
-	for _, asset := range rel.Assets {
-		if asset.Name == assetFileName {
-			return asset.BrowserDownloadURL, nil
+	for _, a := range rel.Assets {
+		if a.Name == assetFileName {
+			return releaseLookup{asset: a, tagName: rel.TagName, etag: resp.Header.Get("ETag")}, nil
 		}
 	}
 
-	return "", fmt.Errorf("dist.zip asset not found in release %s", releaseIdentifier)
+	return releaseLookup{}, fmt.Errorf("dist.zip asset not found in release %s", releaseIdentifier)
+}
+
+// rateLimitError returns a RateLimitError if resp is GitHub's rate limit response, which is
+// a 403 or 429 with either no requests remaining or a Retry-After header
+func rateLimitError(resp *http.Response, authenticated bool) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" && retryAfter == "" {
+		return nil
+	}
+
+	rateLimitErr := &RateLimitError{Authenticated: authenticated}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		rateLimitErr.ResetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimitErr.ResetAt = time.Unix(reset, 0)
+	}
+
+	return rateLimitErr
 }
 
-func (d *FrontendGitHubReleaseDownloader) getDownloadURL(version string) (string, error) {
+func (d *FrontendGitHubReleaseDownloader) getReleaseForVersion(ctx context.Context, version string, etag string) (releaseLookup, error) {
 	if version == "latest" {
-		return d.getReleaseURL("releases/latest", "latest")
+		return d.getRelease(ctx, "releases/latest", "latest", etag)
 	} else {
-		return d.getReleaseURL(fmt.Sprintf("releases/tags/%s", version), version)
+		return d.getRelease(ctx, fmt.Sprintf("releases/tags/%s", version), version, etag)
+	}
+}
+
+// installedRelease returns the marker of the release extracted into the destination
+// directory for version. It reports false if there is none or the assets are missing.
+func (d *FrontendGitHubReleaseDownloader) installedRelease(version string) (releaseMarker, bool) {
+	data, err := os.ReadFile(filepath.Join(d.DestinationDirectory, releaseMarkerFileName))
+	if err != nil {
+		return releaseMarker{}, false
+	}
+
+	var marker releaseMarker
+	if err := json.Unmarshal(data, &marker); err != nil || marker.Version != version {
+		return releaseMarker{}, false
+	}
+
+	entries, err := os.ReadDir(d.DestinationDirectory)
+	if err != nil || len(entries) < 2 {
+		return releaseMarker{}, false
+	}
+
+	return marker, true
+}
+
+func (d *FrontendGitHubReleaseDownloader) writeReleaseMarker(marker releaseMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(d.DestinationDirectory, releaseMarkerFileName), data, 0644)
+}
+
+// checkDiskSpace makes sure the download directory can hold the asset and the destination
+// directory its extracted contents, before anything is written. Assets of unknown size
+// and filesystems whose free space can't be determined are not checked.
+func (d *FrontendGitHubReleaseDownloader) checkDiskSpace(a asset) error {
+	if a.Size <= 0 {
+		return nil
+	}
+
+	required := map[string]uint64{}
+	required[existingParent(os.TempDir())] += uint64(a.Size)
+	required[existingParent(d.DestinationDirectory)] += uint64(a.Size) * extractionSizeFactor
+
+	for dir, needed := range required {
+		available, err := d.freeSpace(dir)
+		if err != nil {
+			d.logger.WithFields(map[string]interface{}{"error": err, "directory": dir}).Warn("Failed to determine free disk space, skipping check")
+			continue
+		}
+
+		if available < needed {
+			return fmt.Errorf("%w: %s needs %d bytes free in %s, but only %d bytes are available", ErrInsufficientDiskSpace, assetFileName, needed, dir, available)
+		}
+	}
+
+	return nil
+}
+
+// existingParent returns path or its closest ancestor that exists
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
 	}
 }
 
-func (d *FrontendGitHubReleaseDownloader) downloadAsset(url string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+// downloadAsset saves the asset at url to a temporary file and returns its path. The share
+// of size, the expected size of the asset, downloaded so far is reported to ctx.
+func (d *FrontendGitHubReleaseDownloader) downloadAsset(ctx context.Context, url string, size int64) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create download request: %w", err)
 	}
+	req.Header.Set("User-Agent", d.userAgent)
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
@@ -172,7 +450,7 @@ func (d *FrontendGitHubReleaseDownloader) downloadAsset(url string) (string, err
 	defer tempFile.Close()
 
 	// Copy the response body to the temporary file
-	_, err = io.Copy(tempFile, resp.Body)
+	_, err = io.Copy(tempFile, &progressReader{ctx: ctx, reader: resp.Body, size: size})
 	if err != nil {
 		os.Remove(tempFile.Name())
 		return "", fmt.Errorf("failed to save downloaded asset: %w", err)
@@ -181,25 +459,100 @@ func (d *FrontendGitHubReleaseDownloader) downloadAsset(url string) (string, err
 	return tempFile.Name(), nil
 }
 
-func (d *FrontendGitHubReleaseDownloader) cleanDestinationDirectory() error {
+// progressReader reports how much of size, the expected size of the download, was read, in
+// steps of progressStep percent. With an unknown size, the bytes read are reported every
+// progressStepBytes.
+type progressReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	size     int64
+	read     int64
+	reported int64
+}
+
+const (
+	progressStep      = 10
+	progressStepBytes = 5 << 20
+)
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if r.size > 0 {
+		if percent := min(r.read*100/r.size, 100); percent >= r.reported+progressStep {
+			r.reported = percent - percent%progressStep
+			_ = progress.Report(r.ctx, fmt.Sprintf("Downloading the web UI: %d%% of %s", r.reported, formatMegabytes(r.size)))
+		}
+	} else if r.read >= r.reported+progressStepBytes {
+		r.reported = r.read - r.read%progressStepBytes
+		_ = progress.Report(r.ctx, fmt.Sprintf("Downloading the web UI: %s", formatMegabytes(r.reported)))
+	}
+
+	return n, err
+}
+
+func formatMegabytes(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
+
+// ClearResult describes what Clear removed
+type ClearResult struct {
+	// Removed are the paths removed from the destination directory
+	Removed []string `json:"removed"`
+	// Bytes is the size of the removed files
+	Bytes int64 `json:"bytes"`
+}
+
+// Clear removes the extracted assets and the release marker from the destination
+// directory, so the next start of the web server downloads the web UI again
+func (d *FrontendGitHubReleaseDownloader) Clear() (ClearResult, error) {
+	return d.cleanDestinationDirectory()
+}
+
+func (d *FrontendGitHubReleaseDownloader) cleanDestinationDirectory() (ClearResult, error) {
+	result := ClearResult{Removed: []string{}}
+
 	if _, err := os.Stat(d.DestinationDirectory); os.IsNotExist(err) {
-		return nil
+		return result, nil
 	}
 
 	entries, err := os.ReadDir(d.DestinationDirectory)
 	if err != nil {
-		return fmt.Errorf("failed to read destination directory: %w", err)
+		return result, fmt.Errorf("failed to read destination directory: %w", err)
 	}
 
 	for _, entry := range entries {
 		path := filepath.Join(d.DestinationDirectory, entry.Name())
+		size := directorySize(path)
+
 		err := os.RemoveAll(path)
 		if err != nil {
-			return fmt.Errorf("failed to remove item %s: %w", path, err)
+			return result, fmt.Errorf("failed to remove item %s: %w", path, err)
 		}
+
+		result.Removed = append(result.Removed, path)
+		result.Bytes += size
 	}
 
-	return nil
+	return result, nil
+}
+
+// directorySize returns the size of the regular files below path, or of path itself if it
+// is a file. Entries that can't be read are not counted.
+func directorySize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size
 }
 
 func (d *FrontendGitHubReleaseDownloader) extractZip(zipPath string) error {
@@ -209,7 +562,7 @@ func (d *FrontendGitHubReleaseDownloader) extractZip(zipPath string) error {
 	}
 	defer reader.Close()
 
-	if err := d.cleanDestinationDirectory(); err != nil {
+	if _, err := d.cleanDestinationDirectory(); err != nil {
 		return err
 	}
 
@@ -225,6 +578,13 @@ func (d *FrontendGitHubReleaseDownloader) extractZip(zipPath string) error {
 			return fmt.Errorf("illegal file path: %s", path)
 		}
 
+		// A symlink could point outside the destination and have later entries written
+		// through it. The web UI archive has none, so they are skipped.
+		if file.Mode()&os.ModeSymlink != 0 {
+			d.logger.WithField("entry", file.Name).Warn("Skipping symlink in frontend asset")
+			continue
+		}
+
 		if file.FileInfo().IsDir() {
 			if err := os.MkdirAll(path, file.Mode()); err != nil {
 				return fmt.Errorf("failed to create directory %s: %w", path, err)
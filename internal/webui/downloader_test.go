@@ -3,14 +3,19 @@ package webui
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"errors"
 	"github.com/shaharia-lab/echoy/internal/logger"
+	"github.com/shaharia-lab/echoy/internal/progress"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -208,7 +213,7 @@ func TestDownloadFrontend(t *testing.T) {
 			noOpLogger := logger.NewNoopLogger()
 
 			downloader := NewFrontendGitHubReleaseDownloader(testDir, mockClient, noOpLogger)
-			err = downloader.DownloadFrontend(tt.version)
+			err = downloader.DownloadFrontend(context.Background(), tt.version)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DownloadFrontend() error = %v, wantErr %v", err, tt.wantErr)
@@ -226,6 +231,49 @@ func TestDownloadFrontend(t *testing.T) {
 	}
 }
 
+func TestExtractZip_SkipsSymlinks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+
+	fileWriter, err := zipWriter.Create("index.html")
+	if err != nil {
+		t.Fatalf("Failed to create file in zip: %v", err)
+	}
+	if _, err := fileWriter.Write([]byte("<html><body>Test</body></html>")); err != nil {
+		t.Fatalf("Failed to write to file in zip: %v", err)
+	}
+
+	header := &zip.FileHeader{Name: "escape"}
+	header.SetMode(os.ModeSymlink | 0777)
+	linkWriter, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		t.Fatalf("Failed to create symlink in zip: %v", err)
+	}
+	if _, err := linkWriter.Write([]byte("../../etc")); err != nil {
+		t.Fatalf("Failed to write symlink target in zip: %v", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "dist.zip")
+	if err := os.WriteFile(zipPath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write zip file: %v", err)
+	}
+
+	testDir := filepath.Join(t.TempDir(), "webui")
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, nil, logger.NewNoopLogger())
+	if err := downloader.extractZip(zipPath); err != nil {
+		t.Fatalf("extractZip() error = %v", err)
+	}
+
+	verifyFilesInDirectory(t, testDir, []string{"index.html"})
+	if _, err := os.Lstat(filepath.Join(testDir, "escape")); !os.IsNotExist(err) {
+		t.Errorf("symlink entry was extracted, Lstat() error = %v", err)
+	}
+}
+
 func verifyFilesInDirectory(t *testing.T, dir string, expectedFiles []string) {
 	t.Helper()
 
@@ -245,3 +293,330 @@ type MockResponse struct {
 	Body       string
 	Err        error
 }
+
+func TestDownloadFrontend_InsufficientDiskSpace(t *testing.T) {
+	mockClient := mocks.NewMockHTTPClient(t)
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == "https://api.github.com/repos/shaharia-lab/echoy-webui/releases/latest"
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"tag_name":"v1.0.0","assets":[{"name":"dist.zip","browser_download_url":"https://github.com/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip","size":1048576}]}`)),
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	testDir := filepath.Join(t.TempDir(), "webui")
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, mockClient, logger.NewNoopLogger())
+	downloader.freeSpace = func(path string) (uint64, error) {
+		return 2 * 1048576, nil
+	}
+
+	err := downloader.DownloadFrontend(context.Background(), "latest")
+	if !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("DownloadFrontend() error = %v, want ErrInsufficientDiskSpace", err)
+	}
+
+	if _, err := os.Stat(testDir); !os.IsNotExist(err) {
+		t.Errorf("destination directory must not be created when there isn't enough space, stat error: %v", err)
+	}
+}
+
+func TestDownloadFrontend_RateLimited(t *testing.T) {
+	resetAt := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name          string
+		token         string
+		headers       map[string]string
+		wantResetAt   time.Time
+		authenticated bool
+	}{
+		{
+			name:        "unauthenticated limit with reset time",
+			headers:     map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(resetAt.Unix(), 10)},
+			wantResetAt: resetAt,
+		},
+		{
+			name:          "authenticated limit",
+			token:         "ghp_test",
+			headers:       map[string]string{"X-RateLimit-Remaining": "0"},
+			authenticated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(githubTokenEnv, tt.token)
+
+			header := make(http.Header)
+			for key, value := range tt.headers {
+				header.Set(key, value)
+			}
+
+			mockClient := mocks.NewMockHTTPClient(t)
+			mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				wantAuthorization := ""
+				if tt.token != "" {
+					wantAuthorization = "Bearer " + tt.token
+				}
+				return req.Header.Get("Authorization") == wantAuthorization
+			})).Return(&http.Response{
+				StatusCode: http.StatusForbidden,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"message": "API rate limit exceeded"}`)),
+				Header:     header,
+			}, nil).Once()
+
+			downloader := NewFrontendGitHubReleaseDownloader(t.TempDir(), mockClient, logger.NewNoopLogger())
+			err := downloader.DownloadFrontend(context.Background(), "latest")
+
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Fatalf("DownloadFrontend() error = %v, want a RateLimitError", err)
+			}
+
+			if !rateLimitErr.ResetAt.Equal(tt.wantResetAt) {
+				t.Errorf("ResetAt = %v, want %v", rateLimitErr.ResetAt, tt.wantResetAt)
+			}
+
+			if rateLimitErr.Authenticated != tt.authenticated {
+				t.Errorf("Authenticated = %v, want %v", rateLimitErr.Authenticated, tt.authenticated)
+			}
+
+			if !tt.authenticated && !strings.Contains(err.Error(), githubTokenEnv) {
+				t.Errorf("error %q should mention %s", err, githubTokenEnv)
+			}
+		})
+	}
+}
+
+func TestDownloadFrontend_ETagCaching(t *testing.T) {
+	const (
+		releaseURL = "https://api.github.com/repos/shaharia-lab/echoy-webui/releases/latest"
+		assetURL   = "https://github.com/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip"
+		etag       = `W/"abc123"`
+	)
+
+	testDir := t.TempDir()
+	mockClient := mocks.NewMockHTTPClient(t)
+
+	releaseHeader := make(http.Header)
+	releaseHeader.Set("ETag", etag)
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == releaseURL && req.Header.Get("If-None-Match") == ""
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"tag_name":"v1.0.0","assets":[{"name":"dist.zip","browser_download_url":"` + assetURL + `","size":1024}]}`)),
+		Header:     releaseHeader,
+	}, nil).Once()
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == assetURL
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(createTestZip(t))),
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, mockClient, logger.NewNoopLogger())
+	if err := downloader.DownloadFrontend(context.Background(), "latest"); err != nil {
+		t.Fatalf("first DownloadFrontend() error = %v", err)
+	}
+
+	marker, ok := downloader.installedRelease("latest")
+	if !ok || marker.ETag != etag || marker.TagName != "v1.0.0" {
+		t.Fatalf("installed release = %+v (found: %v), want tag v1.0.0 with ETag %s", marker, ok, etag)
+	}
+
+	// The second check sends the ETag and GitHub answers 304, so nothing is downloaded again
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == releaseURL && req.Header.Get("If-None-Match") == etag
+	})).Return(&http.Response{
+		StatusCode: http.StatusNotModified,
+		Body:       http.NoBody,
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	if err := downloader.DownloadFrontend(context.Background(), "latest"); err != nil {
+		t.Fatalf("second DownloadFrontend() error = %v", err)
+	}
+
+	verifyFilesInDirectory(t, testDir, []string{"index.html", releaseMarkerFileName})
+}
+
+func TestDownloadFrontend_EnterpriseURLs(t *testing.T) {
+	const (
+		releaseURL = "https://github.example.com/api/v3/repos/shaharia-lab/echoy-webui/releases/latest"
+		assetURL   = "https://github.example.com/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip"
+		mirrorURL  = "https://mirror.example.com/github/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip"
+	)
+
+	testDir := t.TempDir()
+	mockClient := mocks.NewMockHTTPClient(t)
+
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == releaseURL
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"tag_name":"v1.0.0","assets":[{"name":"dist.zip","browser_download_url":"` + assetURL + `","size":1024}]}`)),
+		Header:     make(http.Header),
+	}, nil).Once()
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == mirrorURL
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(createTestZip(t))),
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	// The environment takes precedence over the configured mirror
+	t.Setenv(DownloadURLEnv, "https://mirror.example.com/github/")
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, mockClient, logger.NewNoopLogger()).
+		SetGitHubAPIURL("https://github.example.com/api/v3/").
+		SetDownloadURL("https://other-mirror.example.com")
+
+	if err := downloader.DownloadFrontend(context.Background(), "latest"); err != nil {
+		t.Fatalf("DownloadFrontend() error = %v", err)
+	}
+
+	verifyFilesInDirectory(t, testDir, []string{"index.html", releaseMarkerFileName})
+}
+
+func TestDownloadFrontend_UserAgent(t *testing.T) {
+	const userAgent = "echoy/1.2.0 (+https://github.com/shaharia-lab/echoy)"
+
+	testDir := t.TempDir()
+	mockClient := mocks.NewMockHTTPClient(t)
+
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.Contains(req.URL.Path, "/releases/latest") && req.UserAgent() == userAgent
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"tag_name":"v1.0.0","assets":[{"name":"dist.zip","browser_download_url":"https://github.com/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip","size":1024}]}`)),
+		Header:     make(http.Header),
+	}, nil).Once()
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/dist.zip") && req.UserAgent() == userAgent
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(createTestZip(t))),
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, mockClient, logger.NewNoopLogger()).SetUserAgent(userAgent)
+	if err := downloader.DownloadFrontend(context.Background(), "latest"); err != nil {
+		t.Fatalf("DownloadFrontend() error = %v", err)
+	}
+}
+
+func TestDownloadFrontend_Progress(t *testing.T) {
+	zipData := createTestZip(t)
+	mockClient := mocks.NewMockHTTPClient(t)
+
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.Contains(req.URL.Path, "/releases/latest")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"tag_name":"v1.0.0","assets":[{"name":"dist.zip","browser_download_url":"https://github.com/shaharia-lab/echoy-webui/releases/download/v1.0.0/dist.zip","size":` + strconv.Itoa(len(zipData)) + `}]}`)),
+		Header:     make(http.Header),
+	}, nil).Once()
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/dist.zip")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(zipData)),
+		Header:     make(http.Header),
+	}, nil).Once()
+
+	var messages []string
+	ctx := progress.WithReporter(context.Background(), func(message string) error {
+		messages = append(messages, message)
+		return nil
+	})
+
+	downloader := NewFrontendGitHubReleaseDownloader(t.TempDir(), mockClient, logger.NewNoopLogger())
+	if err := downloader.DownloadFrontend(ctx, "latest"); err != nil {
+		t.Fatalf("DownloadFrontend() error = %v", err)
+	}
+
+	want := []string{
+		"Looking up the web UI release",
+		"Downloading the web UI: 100% of " + formatMegabytes(int64(len(zipData))),
+		"Extracting the web UI",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DownloadFrontend() reported %q, want %q", messages, want)
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var messages []string
+	ctx := progress.WithReporter(context.Background(), func(message string) error {
+		messages = append(messages, message)
+		return nil
+	})
+
+	// Read a byte at a time, each step of 10% is reported once
+	reader := &progressReader{ctx: ctx, reader: iotest.OneByteReader(bytes.NewReader(make([]byte, 4096))), size: 4096}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("io.Copy() error = %v", err)
+	}
+
+	if len(messages) != 10 {
+		t.Fatalf("progressReader reported %d messages, want 10: %q", len(messages), messages)
+	}
+	if messages[0] != "Downloading the web UI: 10% of 0.0 MB" || messages[9] != "Downloading the web UI: 100% of 0.0 MB" {
+		t.Errorf("progressReader reported %q", messages)
+	}
+}
+
+func TestAssetDownloadURL_InvalidMirror(t *testing.T) {
+	downloader := NewFrontendGitHubReleaseDownloader(t.TempDir(), nil, logger.NewNoopLogger()).SetDownloadURL("mirror.example.com")
+
+	if _, err := downloader.assetDownloadURL("https://github.com/a/b/releases/download/v1/dist.zip"); err == nil {
+		t.Error("assetDownloadURL() should reject a download URL without scheme")
+	}
+}
+
+func TestClear(t *testing.T) {
+	testDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(testDir, "dist", "assets"), 0755); err != nil {
+		t.Fatalf("Failed to create dist directory: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join("dist", "index.html"):       "<html></html>",
+		filepath.Join("dist", "assets", "app.js"): "console.log(1)",
+		releaseMarkerFileName:                     `{"version":"latest","tag_name":"v1.0.0"}`,
+	}
+	var wantBytes int64
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(testDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		wantBytes += int64(len(content))
+	}
+
+	downloader := NewFrontendGitHubReleaseDownloader(testDir, mocks.NewMockHTTPClient(t), logger.NewNoopLogger())
+
+	result, err := downloader.Clear()
+	if err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	if len(result.Removed) != 2 || result.Bytes != wantBytes {
+		t.Errorf("Clear() = %+v, want the dist directory and the release marker with %d bytes", result, wantBytes)
+	}
+	if _, ok := downloader.installedRelease("latest"); ok {
+		t.Error("no release should be installed after Clear()")
+	}
+	if entries, err := os.ReadDir(testDir); err != nil || len(entries) != 0 {
+		t.Errorf("destination directory should be empty after Clear(), has %d entries (error: %v)", len(entries), err)
+	}
+
+	result, err = downloader.Clear()
+	if err != nil || len(result.Removed) != 0 {
+		t.Errorf("Clear() of an empty directory = %+v, %v, want nothing removed", result, err)
+	}
+
+	result, err = NewFrontendGitHubReleaseDownloader(filepath.Join(testDir, "missing"), nil, logger.NewNoopLogger()).Clear()
+	if err != nil || len(result.Removed) != 0 {
+		t.Errorf("Clear() of a missing directory = %+v, %v, want nothing removed", result, err)
+	}
+}
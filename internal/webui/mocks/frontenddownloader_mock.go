@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockFrontendDownloader is an autogenerated mock type for the FrontendDownloader type
 type MockFrontendDownloader struct {
@@ -17,17 +21,17 @@ func (_m *MockFrontendDownloader) EXPECT() *MockFrontendDownloader_Expecter {
 	return &MockFrontendDownloader_Expecter{mock: &_m.Mock}
 }
 
-// DownloadFrontend provides a mock function with given fields: version
-func (_m *MockFrontendDownloader) DownloadFrontend(version string) error {
-	ret := _m.Called(version)
+// DownloadFrontend provides a mock function with given fields: ctx, version
+func (_m *MockFrontendDownloader) DownloadFrontend(ctx context.Context, version string) error {
+	ret := _m.Called(ctx, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DownloadFrontend")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(version)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,14 +45,15 @@ type MockFrontendDownloader_DownloadFrontend_Call struct {
 }
 
 // DownloadFrontend is a helper method to define mock.On call
+//   - ctx context.Context
 //   - version string
-func (_e *MockFrontendDownloader_Expecter) DownloadFrontend(version interface{}) *MockFrontendDownloader_DownloadFrontend_Call {
-	return &MockFrontendDownloader_DownloadFrontend_Call{Call: _e.mock.On("DownloadFrontend", version)}
+func (_e *MockFrontendDownloader_Expecter) DownloadFrontend(ctx interface{}, version interface{}) *MockFrontendDownloader_DownloadFrontend_Call {
+	return &MockFrontendDownloader_DownloadFrontend_Call{Call: _e.mock.On("DownloadFrontend", ctx, version)}
 }
 
-func (_c *MockFrontendDownloader_DownloadFrontend_Call) Run(run func(version string)) *MockFrontendDownloader_DownloadFrontend_Call {
+func (_c *MockFrontendDownloader_DownloadFrontend_Call) Run(run func(ctx context.Context, version string)) *MockFrontendDownloader_DownloadFrontend_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -58,7 +63,7 @@ func (_c *MockFrontendDownloader_DownloadFrontend_Call) Return(_a0 error) *MockF
 	return _c
 }
 
-func (_c *MockFrontendDownloader_DownloadFrontend_Call) RunAndReturn(run func(string) error) *MockFrontendDownloader_DownloadFrontend_Call {
+func (_c *MockFrontendDownloader_DownloadFrontend_Call) RunAndReturn(run func(context.Context, string) error) *MockFrontendDownloader_DownloadFrontend_Call {
 	_c.Call.Return(run)
 	return _c
 }
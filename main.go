@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/shaharia-lab/echoy/cmd"
 	"github.com/shaharia-lab/echoy/internal/chat"
@@ -9,10 +10,8 @@ import (
 	"github.com/shaharia-lab/echoy/internal/daemon"
 	"github.com/shaharia-lab/echoy/internal/filesystem"
 	"github.com/shaharia-lab/echoy/internal/initializer"
-	"github.com/shaharia-lab/echoy/internal/logger"
 	telemetryEvent "github.com/shaharia-lab/echoy/internal/telemetry"
 	"github.com/shaharia-lab/echoy/internal/theme"
-	"github.com/shaharia-lab/telemetry-collector"
 	"log/slog"
 	"os"
 )
@@ -23,42 +22,65 @@ var date = "unknown"
 
 func main() {
 	ctx := context.Background()
+	verbosity := cli.VerbosityFromArgs(os.Args[1:])
 
-	cliContainer, err := cli.NewContainer(cli.InitOptions{
+	cliContainer, err := cli.NewContainerWithContext(ctx, cli.InitOptions{
 		Version:  version,
 		Commit:   commit,
 		Date:     date,
-		LogLevel: logger.InfoLevel,
+		LogLevel: verbosity.LogLevel(),
 		Theme:    theme.NewProfessionalTheme(),
+		Quiet:    verbosity.Quiet(),
+
+		DisableFileLog: cli.DisableFileLogFromEnv(os.LookupEnv),
 	})
 	if err != nil {
 		fmt.Println("Error initializing cliContainer:", err)
 		os.Exit(1)
 	}
 
-	if cliContainer.ConfigFromFile.UsageTracking.Enabled {
-		telemetryEvent.SendTelemetryEvent(ctx, cliContainer.Config, "start", telemetry.SeverityInfo, "CLI starting", nil)
-	}
+	cliContainer.Telemetry.Add(telemetryEvent.CLIStarted())
 	slogger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
 
 	// setup commands
 	rootCmd := cmd.NewRootCmd(cliContainer)
 	rootCmd.AddCommand(
-		initializer.NewCmd(cliContainer.ConfigFromFile, cliContainer.Config, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.Initializer),
+		initializer.NewCmd(cliContainer.Telemetry, cliContainer.Config, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.Initializer),
 		chat.NewChatCmd(cliContainer),
-		cmd.NewUpdateCmd(cliContainer.ConfigFromFile, cliContainer.Config, cliContainer.ThemeMgr),
-		daemon.NewStartCmd(cliContainer, cliContainer.ConfigFromFile, cliContainer.Config, cliContainer.ThemeMgr, cliContainer.SocketFilePath, cliContainer.Paths[filesystem.CacheWebuiBuild], slogger),
-		daemon.NewStopCmd(cliContainer.ConfigFromFile, cliContainer.Config, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.SocketFilePath),
-		daemon.NewStatusCmd(cliContainer.ConfigFromFile, cliContainer.Config, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.SocketFilePath),
+		chat.NewChatsCmd(cliContainer),
+		cmd.NewUpdateCmd(cliContainer.Telemetry, cliContainer.Config, cliContainer.ThemeMgr),
+		daemon.NewStartCmd(cliContainer, cliContainer.ThemeMgr, cliContainer.SocketFilePath, cliContainer.Paths[filesystem.CacheWebuiBuild], slogger),
+		daemon.NewStopCmd(cliContainer.Telemetry, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.SocketFilePath),
+		daemon.NewStatusCmd(cliContainer.Telemetry, cliContainer.Logger, cliContainer.ThemeMgr, cliContainer.SocketFilePath),
 		cmd.NewWebserverCmd(cliContainer),
+		cmd.NewWebUICmd(cliContainer),
+		cmd.NewDoctorCmd(cliContainer),
+		cmd.NewSelftestCmd(cliContainer),
+		cmd.NewPathsCmd(cliContainer),
 	)
 
 	// execute the command
-	if err := rootCmd.Execute(); err != nil {
-		if cliContainer.ConfigFromFile.UsageTracking.Enabled {
-			telemetryEvent.SendTelemetryEvent(ctx, cliContainer.Config, "root.cmd.error", telemetry.SeverityError, "Error executing command", map[string]interface{}{"error": err})
+	err = rootCmd.Execute()
+	if err != nil {
+		cliContainer.Telemetry.Add(telemetryEvent.CommandFailed(err))
+	}
+	flushTelemetry(ctx, cliContainer.Telemetry)
+
+	if err != nil {
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
 		}
+
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// flushTelemetry sends the events recorded during the run, giving up after telemetryEvent.CLIFlushTimeout
+func flushTelemetry(ctx context.Context, events *telemetryEvent.Batch) {
+	ctx, cancel := context.WithTimeout(ctx, telemetryEvent.CLIFlushTimeout)
+	defer cancel()
+
+	_ = events.Flush(ctx)
+}